@@ -96,8 +96,8 @@ func initialGlobalData(ctx context.Context, cfg *common.Config, boltStore *store
 	return nil
 }
 
-func initJobs(jobManager *jobs.JobManager, store *store.BoltStore) error {
-	if err := jobManager.Init(store); err != nil {
+func initJobs(jobManager *jobs.JobManager, store *store.BoltStore, db *gorm.DB, engines map[string]dataengine.QueryEngine, s3Client *clients.SimpleS3Cliet) error {
+	if err := jobManager.Init(store, db, engines, s3Client); err != nil {
 		return err
 	}
 
@@ -159,6 +159,78 @@ func initDB(cfg *common.Config) (*gorm.DB, error) {
 		return nil, err
 	}
 
+	if err := db.AutoMigrate(&datamodel.ShareTokenModel{}); err != nil {
+		return nil, err
+	}
+
+	if err := db.AutoMigrate(&datamodel.UserSessionModel{}); err != nil {
+		return nil, err
+	}
+
+	if err := db.AutoMigrate(&datamodel.ApiKeyModel{}); err != nil {
+		return nil, err
+	}
+
+	if err := db.AutoMigrate(&datamodel.WorkspaceModel{}); err != nil {
+		return nil, err
+	}
+
+	if err := db.AutoMigrate(&datamodel.WorkspaceMemberModel{}); err != nil {
+		return nil, err
+	}
+
+	if err := db.AutoMigrate(&datamodel.WorkspaceStatistics{}); err != nil {
+		return nil, err
+	}
+
+	if err := db.AutoMigrate(&datamodel.ContentPermissionModel{}); err != nil {
+		return nil, err
+	}
+
+	if err := db.AutoMigrate(&datamodel.AuditLogModel{}); err != nil {
+		return nil, err
+	}
+
+	if err := db.AutoMigrate(&datamodel.UserTwoFactorModel{}); err != nil {
+		return nil, err
+	}
+
+	if err := db.AutoMigrate(&datamodel.UserRecoveryCodeModel{}); err != nil {
+		return nil, err
+	}
+
+	if err := db.AutoMigrate(&datamodel.UserFollowModel{}); err != nil {
+		return nil, err
+	}
+
+	if err := db.AutoMigrate(&datamodel.CommentModel{}); err != nil {
+		return nil, err
+	}
+
+	if err := db.AutoMigrate(&datamodel.CommentMentionModel{}); err != nil {
+		return nil, err
+	}
+
+	if err := db.AutoMigrate(&datamodel.NotificationModel{}); err != nil {
+		return nil, err
+	}
+
+	if err := db.AutoMigrate(&datamodel.WebhookModel{}); err != nil {
+		return nil, err
+	}
+
+	if err := db.AutoMigrate(&datamodel.WebhookDeliveryModel{}); err != nil {
+		return nil, err
+	}
+
+	if err := db.AutoMigrate(&datamodel.AlertModel{}); err != nil {
+		return nil, err
+	}
+
+	if err := datamodel.MigrateZeroDeletedAt(db, &datamodel.UserModel{}, &datamodel.DashboardModel{}); err != nil {
+		return nil, err
+	}
+
 	if err := datamodel.HackAutoMigrate(db); err != nil {
 		return nil, err
 	}
@@ -204,12 +276,6 @@ func main() {
 		log.Fatalf("Error initial global data: %v", err)
 	}
 
-	jobManager := jobs.NewJobManager(cfg)
-
-	if err := initJobs(jobManager, boltStore); err != nil {
-		log.Fatalf("Error initial jobs: %v", err)
-	}
-
 	db, err := initDB(cfg)
 	if err != nil {
 		log.Fatalf("Error initial database: %v", err)
@@ -225,7 +291,13 @@ func main() {
 		log.Fatalf("Error initial s3 client: %v", err)
 	}
 
-	apiserver, err := apis.NewApiServer(boltStore, cfg, db, engines, s3Client)
+	jobManager := jobs.NewJobManager(cfg)
+
+	if err := initJobs(jobManager, boltStore, db, engines, s3Client); err != nil {
+		log.Fatalf("Error initial jobs: %v", err)
+	}
+
+	apiserver, err := apis.NewApiServer(boltStore, cfg, db, engines, s3Client, jobManager)
 	if err != nil {
 		log.Fatalf("Error creating apiserver: %v", err)
 	}
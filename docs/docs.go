@@ -15,9 +15,14 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/apis/v1/dashboard": {
+        "/admin/audit": {
             "get": {
-                "description": "List dashboard",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List the audit logs of logins, account changes and content changes, the latest logs are listed first.",
                 "consumes": [
                     "application/json"
                 ],
@@ -25,10 +30,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Dashboard apis"
+                    "admin apis"
                 ],
-                "summary": "List dashboard",
+                "summary": "list audit logs",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
                     {
                         "type": "integer",
                         "description": "page",
@@ -37,37 +49,65 @@ const docTemplate = `{
                     },
                     {
                         "type": "integer",
-                        "description": "page size",
+                        "description": "page_size",
                         "name": "page_size",
                         "in": "query"
                     },
                     {
                         "type": "integer",
-                        "description": "user id",
-                        "name": "user_id",
+                        "description": "the user who takes the action",
+                        "name": "actor_id",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "time range",
-                        "name": "time_range",
+                        "description": "action, e.g. dashboard.delete",
+                        "name": "action",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "order",
-                        "name": "order",
+                        "description": "target type, one of user, query, dashboard and panel",
+                        "name": "target_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "target id",
+                        "name": "target_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC3339 time, the logs created at or after it",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC3339 time, the logs created before it",
+                        "name": "to",
                         "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/base.ResponseAuditLogs"
+                        }
                     }
                 }
-            },
-            "put": {
-                "description": "Update dashboard",
+            }
+        },
+        "/admin/dashboard/{id}/unpublish": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Make the public dashboard private, e.g. the spam or abusive dashboard.",
                 "consumes": [
                     "application/json"
                 ],
@@ -75,31 +115,43 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Dashboard apis"
+                    "admin apis"
                 ],
-                "summary": "Update dashboard",
+                "summary": "unpublish dashboard",
                 "parameters": [
                     {
-                        "description": "dashboard",
-                        "name": "dashboard",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/datamodel.DashboardModel"
-                        }
+                        "type": "string",
+                        "description": "token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "dashboard id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dashboard.Response"
+                            "$ref": "#/definitions/base.BaseResponse"
                         }
                     }
                 }
-            },
+            }
+        },
+        "/admin/query/{id}/unpublish": {
             "post": {
-                "description": "Create dashboard",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Make the public query private, e.g. the spam or abusive query.",
                 "consumes": [
                     "application/json"
                 ],
@@ -107,33 +159,43 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Dashboard apis"
+                    "admin apis"
                 ],
-                "summary": "Create dashboard",
+                "summary": "unpublish query",
                 "parameters": [
                     {
-                        "description": "dashboard",
-                        "name": "dashboard",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/datamodel.DashboardModel"
-                        }
+                        "type": "string",
+                        "description": "token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "query id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dashboard.Response"
+                            "$ref": "#/definitions/base.BaseResponse"
                         }
                     }
                 }
             }
         },
-        "/apis/v1/dashboard/browse": {
-            "get": {
-                "description": "List browse user dashboard",
+        "/admin/statistics/reconcile": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Start to recount the queries, dashboards, stars, followers and followings of all users in background,\nit fixes the statistics drifted from the contents. The statistics are also reconciled daily.",
                 "consumes": [
                     "application/json"
                 ],
@@ -141,51 +203,73 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Dashboard apis"
+                    "admin apis"
                 ],
-                "summary": "List browse user dashboard",
+                "summary": "reconcile user statistics",
                 "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "page",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "page size",
-                        "name": "page_size",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "user id",
-                        "name": "user_id",
-                        "in": "query"
-                    },
                     {
                         "type": "string",
-                        "description": "time range",
-                        "name": "time_range",
-                        "in": "query"
-                    },
+                        "description": "token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/base.BaseResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/sync/metadata": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Start to sync the chains and tables metadata of query engines in background.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin apis"
+                ],
+                "summary": "sync metadata",
+                "parameters": [
                     {
                         "type": "string",
-                        "description": "order",
-                        "name": "order",
-                        "in": "query"
+                        "description": "token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/base.BaseResponse"
+                        }
                     }
                 }
             }
         },
-        "/apis/v1/dashboard/favorite": {
+        "/admin/users": {
             "get": {
-                "description": "List favorite dashboard",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List users for admin, filtered by keyword of username or email, role and disabled.",
                 "consumes": [
                     "application/json"
                 ],
@@ -193,10 +277,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Dashboard apis"
+                    "admin apis"
                 ],
-                "summary": "List favorite dashboard",
+                "summary": "list users",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
                     {
                         "type": "integer",
                         "description": "page",
@@ -205,37 +296,47 @@ const docTemplate = `{
                     },
                     {
                         "type": "integer",
-                        "description": "page size",
+                        "description": "page_size",
                         "name": "page_size",
                         "in": "query"
                     },
                     {
-                        "type": "integer",
-                        "description": "user id",
-                        "name": "user_id",
+                        "type": "string",
+                        "description": "keyword of username or email",
+                        "name": "keyword",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "time range",
-                        "name": "time_range",
+                        "description": "role",
+                        "name": "role",
                         "in": "query"
                     },
                     {
-                        "type": "string",
-                        "description": "order",
-                        "name": "order",
+                        "type": "boolean",
+                        "description": "only list the disabled users",
+                        "name": "disabled",
                         "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/admin.ResponseListUsers"
+                        }
                     }
                 }
-            },
-            "put": {
-                "description": "Dashboard favorite",
+            }
+        },
+        "/admin/users/{id}/disable": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Disable the user, the user can not login and the sessions and api keys of user are rejected.",
                 "consumes": [
                     "application/json"
                 ],
@@ -243,30 +344,43 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Dashboard apis"
+                    "admin apis"
                 ],
-                "summary": "Dashboard favorite",
+                "summary": "disable user",
                 "parameters": [
                     {
-                        "description": "dashboard",
-                        "name": "dashboard",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/datamodel.UserDashboardFavorites"
-                        }
+                        "type": "string",
+                        "description": "token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/admin.ResponseUser"
+                        }
                     }
                 }
             }
         },
-        "/apis/v1/dashboard/panel/{panelId}": {
-            "delete": {
-                "description": "Remove dashboard panel",
+        "/admin/users/{id}/enable": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Enable the disabled user.",
                 "consumes": [
                     "application/json"
                 ],
@@ -274,28 +388,43 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Dashboard apis"
+                    "admin apis"
                 ],
-                "summary": "Remove dashboard panel",
+                "summary": "enable user",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
                     {
                         "type": "integer",
-                        "description": "panel id",
-                        "name": "panelId",
+                        "description": "user id",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/admin.ResponseUser"
+                        }
                     }
                 }
             }
         },
-        "/apis/v1/dashboard/popular": {
-            "get": {
-                "description": "List dashboard",
+        "/admin/users/{id}/role": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Update the role of user, the sessions of user are revoked so the new role takes effect after login again.",
                 "consumes": [
                     "application/json"
                 ],
@@ -303,51 +432,47 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Dashboard apis"
+                    "admin apis"
                 ],
-                "summary": "List popular dashboard",
+                "summary": "update user role",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "page",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "page size",
-                        "name": "page_size",
-                        "in": "query"
+                        "type": "string",
+                        "description": "token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
                     },
                     {
                         "type": "integer",
                         "description": "user id",
-                        "name": "user_id",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "time range",
-                        "name": "time_range",
-                        "in": "query"
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "order",
-                        "name": "order",
-                        "in": "query"
+                        "description": "update role request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/admin.RequestUpdateRole"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/admin.ResponseUser"
+                        }
                     }
                 }
             }
         },
-        "/apis/v1/dashboard/unfavorite": {
-            "put": {
-                "description": "Dashboard unfavorite",
+        "/alert": {
+            "get": {
+                "description": "List the alerts of current user.",
                 "consumes": [
                     "application/json"
                 ],
@@ -355,30 +480,62 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Dashboard apis"
+                    "alert apis"
                 ],
-                "summary": "Dashboard unfavorite",
+                "summary": "list alerts",
                 "parameters": [
                     {
-                        "description": "dashboard",
-                        "name": "dashboard",
+                        "type": "integer",
+                        "description": "list the alerts of query",
+                        "name": "query_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/alert.ResponseListAlerts"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create an alert on a saved query which current user can view. The query is executed every interval minutes,\nand the column of rows reduced by first, min, max, sum or count is compared with the threshold.\nThe user is notified by the channels when the alert is triggered or resolved, or when the query fails.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "alert apis"
+                ],
+                "summary": "create alert",
+                "parameters": [
+                    {
+                        "description": "alert",
+                        "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/datamodel.UserDashboardFavorites"
+                            "$ref": "#/definitions/alert.RequestCreateAlert"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/alert.ResponseAlert"
+                        }
                     }
                 }
             }
         },
-        "/apis/v1/dashboard/{id}": {
+        "/alert/{id}": {
             "get": {
-                "description": "Get dashboard",
+                "description": "Get the alert of current user with its state.",
                 "consumes": [
                     "application/json"
                 ],
@@ -386,13 +543,13 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Dashboard apis"
+                    "alert apis"
                 ],
-                "summary": "Get dashboard",
+                "summary": "get alert",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "dashboard id",
+                        "description": "alert id",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -402,13 +559,13 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dashboard.Response"
+                            "$ref": "#/definitions/alert.ResponseAlert"
                         }
                     }
                 }
             },
-            "delete": {
-                "description": "Delete dashboard",
+            "put": {
+                "description": "Update the alert of current user, its state is reset to ok and it is evaluated again soon.",
                 "consumes": [
                     "application/json"
                 ],
@@ -416,60 +573,70 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Dashboard apis"
+                    "alert apis"
                 ],
-                "summary": "Delete dashboard",
+                "summary": "update alert",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "dashboard id",
+                        "description": "alert id",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "alert",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/alert.RequestUpdateAlert"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/base.BaseResponse"
+                            "$ref": "#/definitions/alert.ResponseAlert"
                         }
                     }
                 }
-            }
-        },
-        "/file": {
-            "get": {
-                "description": "Get file",
+            },
+            "delete": {
+                "description": "Delete the alert of current user.",
                 "consumes": [
                     "application/json"
                 ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "File apis"
+                    "alert apis"
                 ],
-                "summary": "Get file",
+                "summary": "delete alert",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "file name",
-                        "name": "file",
-                        "in": "query",
+                        "type": "integer",
+                        "description": "alert id",
+                        "name": "id",
+                        "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "ok",
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/base.BaseResponse"
                         }
                     }
                 }
             }
         },
-        "/query": {
+        "/apikey": {
             "get": {
-                "description": "list query",
+                "description": "List the api keys of current user, include the revoked and expired keys.",
                 "consumes": [
                     "application/json"
                 ],
@@ -477,49 +644,20 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "query apis"
-                ],
-                "summary": "list query",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "page",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "page_size",
-                        "name": "page_size",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "user_id",
-                        "name": "user_id",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "time_range",
-                        "name": "time_range",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "order",
-                        "name": "order",
-                        "in": "query"
-                    }
+                    "apikey apis"
                 ],
+                "summary": "List api keys",
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/apikey.ResponseListApiKeys"
+                        }
                     }
                 }
             },
-            "put": {
-                "description": "update query",
+            "post": {
+                "description": "Create a personal api key, the key is sent in X-API-Key header to access the apis of its scopes.\nThe key is only responded once, and all scopes are granted if scopes is empty.",
                 "consumes": [
                     "application/json"
                 ],
@@ -527,17 +665,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "query apis"
+                    "apikey apis"
                 ],
-                "summary": "update query",
+                "summary": "Create api key",
                 "parameters": [
                     {
-                        "description": "body",
+                        "description": "create api key request",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/datamodel.QueryModel"
+                            "$ref": "#/definitions/apikey.RequestCreateApiKey"
                         }
                     }
                 ],
@@ -545,15 +683,15 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/query.Response"
+                            "$ref": "#/definitions/apikey.ResponseCreateApiKey"
                         }
                     }
                 }
             }
         },
-        "/query/:id": {
-            "get": {
-                "description": "get query",
+        "/apikey/{id}": {
+            "delete": {
+                "description": "Revoke the api key of current user.",
                 "consumes": [
                     "application/json"
                 ],
@@ -561,13 +699,13 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "query apis"
+                    "apikey apis"
                 ],
-                "summary": "get query",
+                "summary": "Revoke api key",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "query id",
+                        "description": "api key id",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -577,42 +715,15 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/query.Response"
+                            "$ref": "#/definitions/base.BaseResponse"
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "delete query",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "query apis"
-                ],
-                "summary": "delete query",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "query id",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK"
-                    }
-                }
             }
         },
-        "/query/browse": {
+        "/apis/v1/dashboard": {
             "get": {
-                "description": "list browse query",
+                "description": "List dashboard",
                 "consumes": [
                     "application/json"
                 ],
@@ -620,9 +731,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "query apis"
+                    "Dashboard apis"
                 ],
-                "summary": "list browse query",
+                "summary": "List dashboard",
                 "parameters": [
                     {
                         "type": "integer",
@@ -632,19 +743,19 @@ const docTemplate = `{
                     },
                     {
                         "type": "integer",
-                        "description": "page_size",
+                        "description": "page size",
                         "name": "page_size",
                         "in": "query"
                     },
                     {
                         "type": "integer",
-                        "description": "user_id",
+                        "description": "user id",
                         "name": "user_id",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "time_range",
+                        "description": "time range",
                         "name": "time_range",
                         "in": "query"
                     },
@@ -653,6 +764,12 @@ const docTemplate = `{
                         "description": "order",
                         "name": "order",
                         "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "list the dashboards of workspace, including the private ones",
+                        "name": "workspace_id",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -660,11 +777,9 @@ const docTemplate = `{
                         "description": "OK"
                     }
                 }
-            }
-        },
-        "/query/chart": {
-            "post": {
-                "description": "create query chart",
+            },
+            "put": {
+                "description": "Update dashboard, it requires the edit access of dashboard. The dashboard can only be moved and published by its owner or the editors of its workspace.\nThe dashboard is moved to another workspace by workspace_id, 0 means the personal dashboard of its creator.\nThe panels not on the dashboard are created as new panels, and the queries and charts of panels must be viewable by the current user.",
                 "consumes": [
                     "application/json"
                 ],
@@ -672,17 +787,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "query apis"
+                    "Dashboard apis"
                 ],
-                "summary": "create query chart",
+                "summary": "Update dashboard",
                 "parameters": [
                     {
-                        "description": "body",
-                        "name": "body",
+                        "description": "dashboard",
+                        "name": "dashboard",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/datamodel.QueryModel"
+                            "$ref": "#/definitions/datamodel.DashboardModel"
                         }
                     }
                 ],
@@ -690,71 +805,13 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/query.Response"
+                            "$ref": "#/definitions/dashboard.Response"
                         }
                     }
                 }
-            }
-        },
-        "/query/chart/:id": {
-            "get": {
-                "description": "get current logined user query chart",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "query apis"
-                ],
-                "summary": "get current logined user query chart",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "chart id",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK"
-                    }
-                }
             },
-            "delete": {
-                "description": "delete query chart",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "query apis"
-                ],
-                "summary": "delete query chart",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "chart id",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK"
-                    }
-                }
-            }
-        },
-        "/query/chart/:id/user/:userId": {
-            "get": {
-                "description": "get user query chart",
+            "post": {
+                "description": "Create dashboard",
                 "consumes": [
                     "application/json"
                 ],
@@ -762,35 +819,33 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "query apis"
+                    "Dashboard apis"
                 ],
-                "summary": "get user query chart",
+                "summary": "Create dashboard",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "chart id",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "user id",
-                        "name": "userId",
-                        "in": "path",
-                        "required": true
+                        "description": "dashboard",
+                        "name": "dashboard",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/datamodel.DashboardModel"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dashboard.Response"
+                        }
                     }
                 }
             }
         },
-        "/query/charts": {
+        "/apis/v1/dashboard/browse": {
             "get": {
-                "description": "list current logined user charts",
+                "description": "List browse user dashboard",
                 "consumes": [
                     "application/json"
                 ],
@@ -798,9 +853,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "query apis"
+                    "Dashboard apis"
                 ],
-                "summary": "list current logined user charts",
+                "summary": "List browse user dashboard",
                 "parameters": [
                     {
                         "type": "integer",
@@ -810,66 +865,19 @@ const docTemplate = `{
                     },
                     {
                         "type": "integer",
-                        "description": "page_size",
+                        "description": "page size",
                         "name": "page_size",
                         "in": "query"
                     },
-                    {
-                        "type": "string",
-                        "description": "time_range",
-                        "name": "time_range",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "order",
-                        "name": "order",
-                        "in": "query"
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK"
-                    }
-                }
-            }
-        },
-        "/query/charts/user/:userId": {
-            "get": {
-                "description": "list user charts",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "query apis"
-                ],
-                "summary": "list user charts",
-                "parameters": [
                     {
                         "type": "integer",
                         "description": "user id",
-                        "name": "userId",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "page",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "page_size",
-                        "name": "page_size",
+                        "name": "user_id",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "time_range",
+                        "description": "time range",
                         "name": "time_range",
                         "in": "query"
                     },
@@ -887,9 +895,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/query/favorite": {
+        "/apis/v1/dashboard/favorite": {
             "get": {
-                "description": "list favorite query",
+                "description": "List favorite dashboard",
                 "consumes": [
                     "application/json"
                 ],
@@ -897,9 +905,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "query apis"
+                    "Dashboard apis"
                 ],
-                "summary": "list favorite query",
+                "summary": "List favorite dashboard",
                 "parameters": [
                     {
                         "type": "integer",
@@ -909,19 +917,19 @@ const docTemplate = `{
                     },
                     {
                         "type": "integer",
-                        "description": "page_size",
+                        "description": "page size",
                         "name": "page_size",
                         "in": "query"
                     },
                     {
                         "type": "integer",
-                        "description": "user_id",
+                        "description": "user id",
                         "name": "user_id",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "time_range",
+                        "description": "time range",
                         "name": "time_range",
                         "in": "query"
                     },
@@ -939,7 +947,7 @@ const docTemplate = `{
                 }
             },
             "put": {
-                "description": "user favorite query",
+                "description": "Dashboard favorite",
                 "consumes": [
                     "application/json"
                 ],
@@ -947,17 +955,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "query apis"
+                    "Dashboard apis"
                 ],
-                "summary": "user favorite query",
+                "summary": "Dashboard favorite",
                 "parameters": [
                     {
-                        "description": "body",
-                        "name": "body",
+                        "description": "dashboard",
+                        "name": "dashboard",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/datamodel.UserQueryFavorites"
+                            "$ref": "#/definitions/datamodel.UserDashboardFavorites"
                         }
                     }
                 ],
@@ -968,9 +976,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/query/run": {
-            "get": {
-                "description": "run query",
+        "/apis/v1/dashboard/import": {
+            "post": {
+                "description": "Import dashboard from a bundle exported by hyperdot node. The ids are remapped and\nall contents are owned by the current user.",
                 "consumes": [
                     "application/json"
                 ],
@@ -978,38 +986,33 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "query apis"
+                    "Dashboard apis"
                 ],
-                "summary": "run query",
+                "summary": "Import dashboard",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "q",
-                        "name": "query",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "engine",
-                        "name": "engine",
-                        "in": "query",
-                        "required": true
+                        "description": "dashboard bundle",
+                        "name": "bundle",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dashboard.DashboardBundle"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/query.ResponseRun"
+                            "$ref": "#/definitions/dashboard.Response"
                         }
                     }
                 }
             }
         },
-        "/query/unfavorite": {
-            "put": {
-                "description": "user unfavorite query",
+        "/apis/v1/dashboard/panel/{panelId}": {
+            "delete": {
+                "description": "Remove dashboard panel, it requires the edit access of dashboard.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1017,40 +1020,18 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "query apis"
+                    "Dashboard apis"
                 ],
-                "summary": "user unfavorite query",
+                "summary": "Remove dashboard panel",
                 "parameters": [
                     {
-                        "description": "body",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/datamodel.UserQueryFavorites"
-                        }
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK"
+                        "type": "integer",
+                        "description": "panel id",
+                        "name": "panelId",
+                        "in": "path",
+                        "required": true
                     }
-                }
-            }
-        },
-        "/system/engines": {
-            "get": {
-                "description": "List query engines",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "System apis"
                 ],
-                "summary": "List query engines",
                 "responses": {
                     "200": {
                         "description": "OK"
@@ -1058,9 +1039,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/system/engines/{engineId}": {
+        "/apis/v1/dashboard/popular": {
             "get": {
-                "description": "Get query engine dataset",
+                "description": "List dashboard",
                 "consumes": [
                     "application/json"
                 ],
@@ -1068,16 +1049,39 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "System apis"
+                    "Dashboard apis"
                 ],
-                "summary": "Get query engine dataset",
+                "summary": "List popular dashboard",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "engineId",
-                        "name": "engineId",
-                        "in": "path",
-                        "required": true
+                        "type": "integer",
+                        "description": "page",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page size",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "time range",
+                        "name": "time_range",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "order",
+                        "name": "order",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -1087,14 +1091,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/user": {
+        "/apis/v1/dashboard/trash": {
             "get": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
-                ],
-                "description": "Get the current logined user.",
+                "description": "List the trashed personal dashboards of current user, or the trashed dashboards of workspace\nif workspace_id is given, it requires an editor of the workspace. The latest trashed are listed first.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1102,25 +1101,42 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user apis"
+                    "Dashboard apis"
+                ],
+                "summary": "List trashed dashboards",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "workspace id",
+                        "name": "workspace_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page_size",
+                        "name": "page_size",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get the current logined user.",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/user.ResponseGetUser"
+                            "$ref": "#/definitions/dashboard.ResponseTrash"
                         }
                     }
                 }
-            },
+            }
+        },
+        "/apis/v1/dashboard/unfavorite": {
             "put": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
-                ],
-                "description": "Update user info.",
+                "description": "Dashboard unfavorite",
                 "consumes": [
                     "application/json"
                 ],
@@ -1128,40 +1144,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user apis"
+                    "Dashboard apis"
                 ],
-                "summary": "Update user info.",
+                "summary": "Dashboard unfavorite",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "token",
-                        "name": "Authorization",
-                        "in": "header",
-                        "required": true
-                    },
-                    {
-                        "description": "update user request",
-                        "name": "body",
+                        "description": "dashboard",
+                        "name": "dashboard",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/datamodel.UserModel"
+                            "$ref": "#/definitions/datamodel.UserDashboardFavorites"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/user.ResponseUpdateUser"
-                        }
+                        "description": "OK"
                     }
                 }
             }
         },
-        "/user/auth/createAccount": {
-            "post": {
-                "description": "Create account by username and password.",
+        "/apis/v1/dashboard/{id}": {
+            "get": {
+                "description": "Get dashboard, the private dashboard can be accessed by its owner, the members of its workspace and the granted users.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1169,33 +1175,61 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user apis"
+                    "Dashboard apis"
                 ],
-                "summary": "Create account by username and password.",
+                "summary": "Get dashboard",
                 "parameters": [
                     {
-                        "description": "create account request",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
+                        "type": "integer",
+                        "description": "dashboard id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/user.RequestCreateAccount"
+                            "$ref": "#/definitions/dashboard.Response"
                         }
                     }
+                }
+            },
+            "delete": {
+                "description": "Delete dashboard, it requires the owner or an editor of the workspace of dashboard.\nThe dashboard is moved to trash, it can be restored before it is purged after the retention period.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Dashboard apis"
+                ],
+                "summary": "Delete dashboard",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "dashboard id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/user.ResponseCreateAccount"
+                            "$ref": "#/definitions/base.BaseResponse"
                         }
                     }
                 }
             }
         },
-        "/user/auth/login": {
+        "/apis/v1/dashboard/{id}/duplicate": {
             "post": {
-                "description": "Login by username and password.",
+                "description": "Duplicate dashboard with all panels, optionally cloning the referenced queries and charts.\nIf the dashboard is a template, the queries are always cloned and the placeholders are filled by params.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1203,17 +1237,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user apis"
+                    "Dashboard apis"
                 ],
-                "summary": "Login by username and password.",
+                "summary": "Duplicate dashboard",
                 "parameters": [
                     {
-                        "description": "login request",
+                        "type": "integer",
+                        "description": "dashboard id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "duplicate request",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/user.RequestLogin"
+                            "$ref": "#/definitions/dashboard.RequestDuplicateDashboard"
                         }
                     }
                 ],
@@ -1221,73 +1262,63 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/user.ResponseLogin"
+                            "$ref": "#/definitions/dashboard.Response"
                         }
                     }
                 }
             }
         },
-        "/user/avatar": {
+        "/apis/v1/dashboard/{id}/export": {
             "get": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
-                ],
-                "description": "Get user avatar.",
+                "description": "Export dashboard with its panels and all referenced queries and charts as a portable bundle.",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "image/jpeg"
+                    "application/json"
                 ],
                 "tags": [
-                    "user apis"
+                    "Dashboard apis"
                 ],
-                "summary": "Get user avatar.",
+                "summary": "Export dashboard",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "token",
-                        "name": "Authorization",
-                        "in": "header",
+                        "type": "integer",
+                        "description": "dashboard id",
+                        "name": "id",
+                        "in": "path",
                         "required": true
                     }
                 ],
-                "responses": {}
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dashboard.DashboardBundle"
+                        }
+                    }
+                }
             }
         },
-        "/user/avatar/upload": {
+        "/apis/v1/dashboard/{id}/restore": {
             "post": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
-                ],
-                "description": "Upload user avatar.",
+                "description": "Restore the trashed dashboard with its panels, it requires the owner or an editor of the workspace of dashboard.",
                 "consumes": [
-                    "multipart/form-data"
+                    "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user apis"
+                    "Dashboard apis"
                 ],
-                "summary": "Upload user avatar.",
+                "summary": "Restore dashboard",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "token",
-                        "name": "Authorization",
-                        "in": "header",
-                        "required": true
-                    },
-                    {
-                        "type": "file",
-                        "description": "avatar file",
-                        "name": "avatar",
-                        "in": "formData",
+                        "type": "integer",
+                        "description": "dashboard id",
+                        "name": "id",
+                        "in": "path",
                         "required": true
                     }
                 ],
@@ -1295,20 +1326,15 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/user.ResponseUploadAvatar"
+                            "$ref": "#/definitions/dashboard.Response"
                         }
                     }
                 }
             }
         },
-        "/user/email": {
+        "/comment/{id}": {
             "put": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
-                ],
-                "description": "Update user email.",
+                "description": "Update the body of comment, only the author can update it.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1316,24 +1342,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user apis"
+                    "comment apis"
                 ],
-                "summary": "Update user email.",
+                "summary": "update comment",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "token",
-                        "name": "Authorization",
-                        "in": "header",
+                        "type": "integer",
+                        "description": "comment id",
+                        "name": "id",
+                        "in": "path",
                         "required": true
                     },
                     {
-                        "description": "update email request",
+                        "description": "comment",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/user.RequestUpdateEmail"
+                            "$ref": "#/definitions/comment.RequestUpdateComment"
                         }
                     }
                 ],
@@ -1341,20 +1367,13 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/user.ResponseUpdateUser"
+                            "$ref": "#/definitions/comment.ResponseComment"
                         }
                     }
                 }
-            }
-        },
-        "/user/password": {
-            "put": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
-                ],
-                "description": "Update user password.",
+            },
+            "delete": {
+                "description": "Delete the comment by its author, the manager of content or a moderator.\nThe comment with replies is kept as a placeholder without body and author.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1362,24 +1381,142 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user apis"
+                    "comment apis"
                 ],
-                "summary": "Update user password.",
+                "summary": "delete comment",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "token",
-                        "name": "Authorization",
-                        "in": "header",
+                        "type": "integer",
+                        "description": "comment id",
+                        "name": "id",
+                        "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "update password request",
-                        "name": "body",
-                        "in": "body",
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/base.BaseResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/comment/{id}/replies": {
+            "get": {
+                "description": "List the replies of comment, the oldest are listed first.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comment apis"
+                ],
+                "summary": "list replies",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "comment id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page_size",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/comment.ResponseListComments"
+                        }
+                    }
+                }
+            }
+        },
+        "/dashboard/panel/{panelId}/comments": {
+            "get": {
+                "description": "List the threads of comments on query, dashboard or dashboard panel, the oldest are listed first.\nThe replies of a thread are listed by /comment/{id}/replies.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comment apis"
+                ],
+                "summary": "list comments",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "dashboard panel id",
+                        "name": "panelId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page_size",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/comment.ResponseListComments"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Comment on query, dashboard or dashboard panel which current user can view, or reply to a comment by parent_id.\nThe reply to a reply is added to the same thread. The mentioned users who can view the content are saved in mentions.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comment apis"
+                ],
+                "summary": "create comment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "dashboard panel id",
+                        "name": "panelId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "comment",
+                        "name": "body",
+                        "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/user.RequestUpdatePassword"
+                            "$ref": "#/definitions/comment.RequestCreateComment"
                         }
                     }
                 ],
@@ -1387,20 +1524,57 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/user.ResponseUpdateUser"
+                            "$ref": "#/definitions/comment.ResponseComment"
                         }
                     }
                 }
             }
         },
-        "/user/{id}": {
+        "/dashboard/{id}/comments": {
             "get": {
-                "security": [
+                "description": "List the threads of comments on query, dashboard or dashboard panel, the oldest are listed first.\nThe replies of a thread are listed by /comment/{id}/replies.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comment apis"
+                ],
+                "summary": "list comments",
+                "parameters": [
                     {
-                        "ApiKeyAuth": []
+                        "type": "integer",
+                        "description": "query or dashboard id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page_size",
+                        "name": "page_size",
+                        "in": "query"
                     }
                 ],
-                "description": "Get user by id.",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/comment.ResponseListComments"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Comment on query, dashboard or dashboard panel which current user can view, or reply to a comment by parent_id.\nThe reply to a reply is added to the same thread. The mentioned users who can view the content are saved in mentions.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1408,49 +1582,5438 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user apis"
+                    "comment apis"
                 ],
-                "summary": "Get user by id.",
+                "summary": "create comment",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "user id",
+                        "description": "query or dashboard id",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "comment",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/comment.RequestCreateComment"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/user.ResponseGetUser"
+                            "$ref": "#/definitions/comment.ResponseComment"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "base.BaseResponse": {
-            "type": "object",
-            "properties": {
-                "errorCode": {
-                    "type": "integer"
-                },
-                "errorMessage": {
-                    "type": "string"
-                },
-                "success": {
-                    "type": "boolean"
+        },
+        "/dashboard/{id}/permissions": {
+            "get": {
+                "description": "List the users granted the permissions of query or dashboard, it requires the edit access.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "permission apis"
+                ],
+                "summary": "list permissions",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "query or dashboard id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/permission.ResponseListPermissions"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Grant a user the viewer or editor permission of query or dashboard, the permission is updated if it has been granted.\nIt requires the owner or an editor of the workspace of content.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "permission apis"
+                ],
+                "summary": "grant permission",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "query or dashboard id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "permission",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/permission.RequestGrantPermission"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/permission.ResponsePermission"
+                        }
+                    }
                 }
             }
         },
-        "dashboard.Response": {
+        "/dashboard/{id}/permissions/{userId}": {
+            "delete": {
+                "description": "Revoke the permission of user, it requires the owner or an editor of the workspace of content unless the user revokes its own permission.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "permission apis"
+                ],
+                "summary": "revoke permission",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "query or dashboard id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/base.BaseResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/file": {
+            "get": {
+                "description": "Get file",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "File apis"
+                ],
+                "summary": "Get file",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "file name",
+                        "name": "file",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/query": {
+            "get": {
+                "description": "list query",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "query apis"
+                ],
+                "summary": "list query",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "page",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page_size",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "user_id",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "time_range",
+                        "name": "time_range",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "order",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "list the queries of workspace, including the private ones",
+                        "name": "workspace_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            },
+            "put": {
+                "description": "update query, it requires the edit access of query. The query can only be moved and published by its owner or the editors of its workspace.\nThe query is moved to another workspace by workspace_id, 0 means the personal query of its creator.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "query apis"
+                ],
+                "summary": "update query",
+                "parameters": [
+                    {
+                        "description": "body",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/datamodel.QueryModel"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/query.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/query/:id": {
+            "get": {
+                "description": "get query, the private query can be accessed by its owner, the members of its workspace and the granted users.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "query apis"
+                ],
+                "summary": "get query",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "query id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/query.Response"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "delete query, it requires the owner or an editor of the workspace of query.\nThe query is moved to trash, it can be restored before it is purged after the retention period.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "query apis"
+                ],
+                "summary": "delete query",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "query id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/query/browse": {
+            "get": {
+                "description": "list browse query",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "query apis"
+                ],
+                "summary": "list browse query",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "page",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page_size",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "user_id",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "time_range",
+                        "name": "time_range",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "order",
+                        "name": "order",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/query/chart": {
+            "post": {
+                "description": "create query chart",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "query apis"
+                ],
+                "summary": "create query chart",
+                "parameters": [
+                    {
+                        "description": "body",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/datamodel.QueryModel"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/query.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/query/chart/:id": {
+            "get": {
+                "description": "get current logined user query chart",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "query apis"
+                ],
+                "summary": "get current logined user query chart",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "chart id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            },
+            "delete": {
+                "description": "delete query chart",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "query apis"
+                ],
+                "summary": "delete query chart",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "chart id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/query/chart/:id/user/:userId": {
+            "get": {
+                "description": "get user query chart",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "query apis"
+                ],
+                "summary": "get user query chart",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "chart id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/query/charts": {
+            "get": {
+                "description": "list current logined user charts",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "query apis"
+                ],
+                "summary": "list current logined user charts",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "page",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page_size",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "time_range",
+                        "name": "time_range",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "order",
+                        "name": "order",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/query/charts/user/:userId": {
+            "get": {
+                "description": "list user charts",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "query apis"
+                ],
+                "summary": "list user charts",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page_size",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "time_range",
+                        "name": "time_range",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "order",
+                        "name": "order",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/query/favorite": {
+            "get": {
+                "description": "list favorite query",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "query apis"
+                ],
+                "summary": "list favorite query",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "page",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page_size",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "user_id",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "time_range",
+                        "name": "time_range",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "order",
+                        "name": "order",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            },
+            "put": {
+                "description": "user favorite query",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "query apis"
+                ],
+                "summary": "user favorite query",
+                "parameters": [
+                    {
+                        "description": "body",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/datamodel.UserQueryFavorites"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/query/run": {
+            "get": {
+                "description": "run query",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "query apis"
+                ],
+                "summary": "run query",
+                "parameters": [
+                    {
+                        "description": "query body",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/query.RequestRunQuery"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/query.ResponseRun"
+                        }
+                    }
+                }
+            }
+        },
+        "/query/trash": {
+            "get": {
+                "description": "List the trashed personal queries of current user, or the trashed queries of workspace\nif workspace_id is given, it requires an editor of the workspace. The latest trashed are listed first.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "query apis"
+                ],
+                "summary": "list trashed queries",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "workspace id",
+                        "name": "workspace_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page_size",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/query.ResponseTrash"
+                        }
+                    }
+                }
+            }
+        },
+        "/query/unfavorite": {
+            "put": {
+                "description": "user unfavorite query",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "query apis"
+                ],
+                "summary": "user unfavorite query",
+                "parameters": [
+                    {
+                        "description": "body",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/datamodel.UserQueryFavorites"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/query/{id}/comments": {
+            "get": {
+                "description": "List the threads of comments on query, dashboard or dashboard panel, the oldest are listed first.\nThe replies of a thread are listed by /comment/{id}/replies.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comment apis"
+                ],
+                "summary": "list comments",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "query or dashboard id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page_size",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/comment.ResponseListComments"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Comment on query, dashboard or dashboard panel which current user can view, or reply to a comment by parent_id.\nThe reply to a reply is added to the same thread. The mentioned users who can view the content are saved in mentions.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comment apis"
+                ],
+                "summary": "create comment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "query or dashboard id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "comment",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/comment.RequestCreateComment"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/comment.ResponseComment"
+                        }
+                    }
+                }
+            }
+        },
+        "/query/{id}/permissions": {
+            "get": {
+                "description": "List the users granted the permissions of query or dashboard, it requires the edit access.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "permission apis"
+                ],
+                "summary": "list permissions",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "query or dashboard id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/permission.ResponseListPermissions"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Grant a user the viewer or editor permission of query or dashboard, the permission is updated if it has been granted.\nIt requires the owner or an editor of the workspace of content.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "permission apis"
+                ],
+                "summary": "grant permission",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "query or dashboard id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "permission",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/permission.RequestGrantPermission"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/permission.ResponsePermission"
+                        }
+                    }
+                }
+            }
+        },
+        "/query/{id}/permissions/{userId}": {
+            "delete": {
+                "description": "Revoke the permission of user, it requires the owner or an editor of the workspace of content unless the user revokes its own permission.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "permission apis"
+                ],
+                "summary": "revoke permission",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "query or dashboard id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/base.BaseResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/query/{id}/restore": {
+            "post": {
+                "description": "Restore the trashed query with its charts, it requires the owner or an editor of the workspace of query.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "query apis"
+                ],
+                "summary": "restore query",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "query id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/query.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/query/{id}/results": {
+            "get": {
+                "description": "Get the latest result of a saved query. The cached result is served if it is fresh enough, otherwise the query is executed again.\nGuests can only read the cached results whatever max_age is, the query is executed by signed in users only.\nThe placeholders such as {{chain_id}} in query are filled by params[chain_id], the values can only be numbers, identifiers, dates or addresses.\nIt can be accessed by api key with scope query:run.",
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "tags": [
+                    "query apis"
+                ],
+                "summary": "get query results",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "query id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "max age of cached result in seconds, 0 executes the query again",
+                        "name": "max_age",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "json or csv, default is json",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "parameter values, e.g. params[chain_id]=2004",
+                        "name": "params",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/query.ResponseQueryResult"
+                        }
+                    }
+                }
+            }
+        },
+        "/share": {
+            "get": {
+                "description": "List share tokens of current user.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "share apis"
+                ],
+                "summary": "List share tokens",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "dashboard id",
+                        "name": "dashboard_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "chart id",
+                        "name": "chart_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a read-only share token of a dashboard or chart owned by current user, the token is only responded once.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "share apis"
+                ],
+                "summary": "Create share token",
+                "parameters": [
+                    {
+                        "description": "create share request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/share.RequestCreateShare"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/share.ResponseShare"
+                        }
+                    }
+                }
+            }
+        },
+        "/share/public/{token}": {
+            "get": {
+                "description": "Get the shared dashboard or chart with the referenced queries by share token, it can be accessed by guest.\nThe sql of queries is not shared.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "share apis"
+                ],
+                "summary": "Get shared content",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "share token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/share.ResponseShared"
+                        }
+                    }
+                }
+            }
+        },
+        "/share/public/{token}/results/{queryId}": {
+            "get": {
+                "description": "Get the cached result of a query referenced by the shared content, it can be accessed by guest.\nThe query is never executed by share token, it responds not found if the result is not cached.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "share apis"
+                ],
+                "summary": "Get shared query result",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "share token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "query id",
+                        "name": "queryId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/share.ResponseSharedResult"
+                        }
+                    }
+                }
+            }
+        },
+        "/share/{id}": {
+            "delete": {
+                "description": "Revoke share token of current user.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "share apis"
+                ],
+                "summary": "Revoke share token",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "share token id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/base.BaseResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/system/engines": {
+            "get": {
+                "description": "List query engines",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System apis"
+                ],
+                "summary": "List query engines",
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/system/engines/{engineId}": {
+            "get": {
+                "description": "Get query engine dataset",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System apis"
+                ],
+                "summary": "Get query engine dataset",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "engineId",
+                        "name": "engineId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/user": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get the current logined user.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user apis"
+                ],
+                "summary": "Get the current logined user.",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user.ResponseGetUser"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Update user info.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user apis"
+                ],
+                "summary": "Update user info.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "update user request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/datamodel.UserModel"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user.ResponseUpdateUser"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/2fa": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get the two-factor authentication status and the number of unused recovery codes of current user.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user apis"
+                ],
+                "summary": "Get the two-factor authentication status of current user.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user.ResponseTwoFactorStatus"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/2fa/disable": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Disable the two-factor authentication of current user, it requires the password and a TOTP code or recovery code.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user apis"
+                ],
+                "summary": "Disable the two-factor authentication.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "disable two-factor request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.RequestDisableTwoFactor"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/base.BaseResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/2fa/enroll": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Generate a new TOTP secret of current user, add it to the authenticator app by the QR code of provisioning uri.\nThe two-factor authentication is enabled after a code is verified by POST /user/2fa/verify.\nIt is only supported for the accounts signed up by password.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user apis"
+                ],
+                "summary": "Enroll the TOTP two-factor authentication.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user.ResponseTwoFactorEnroll"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/2fa/recoveryCodes": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Replace the recovery codes of current user by new ones, it requires a TOTP code of the authenticator.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user apis"
+                ],
+                "summary": "Regenerate the recovery codes.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "regenerate recovery codes request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.RequestTwoFactorCode"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user.ResponseRecoveryCodes"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/2fa/verify": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Verify a code of the secret enrolled by POST /user/2fa/enroll and enable two-factor authentication.\nThe recovery codes are responded only once, each of them can login once if the authenticator is lost.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user apis"
+                ],
+                "summary": "Verify the enrolled TOTP secret and enable two-factor authentication.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "verify two-factor request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.RequestTwoFactorCode"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user.ResponseRecoveryCodes"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/activity": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List the actions taken by current user, such as logins, account changes and content changes. The latest logs are listed first.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user apis"
+                ],
+                "summary": "List the audit logs of current user.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "page",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page_size",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "action, e.g. user.login",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "target type, one of user, query, dashboard and panel",
+                        "name": "target_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "target id",
+                        "name": "target_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC3339 time, the logs created at or after it",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC3339 time, the logs created before it",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/base.ResponseAuditLogs"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/auth/2fa/login": {
+            "post": {
+                "description": "Complete the login of user who enabled two-factor authentication, by the pending token responded by POST /user/auth/login\nand a TOTP code or recovery code. Each recovery code can be used only once.\nThe failures lock the account out like the failures of password.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user apis"
+                ],
+                "summary": "Complete the login by the second factor.",
+                "parameters": [
+                    {
+                        "description": "two-factor login request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.RequestTwoFactorLogin"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user.ResponseLogin"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/auth/createAccount": {
+            "post": {
+                "description": "Create account by username and password.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user apis"
+                ],
+                "summary": "Create account by username and password.",
+                "parameters": [
+                    {
+                        "description": "create account request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.RequestCreateAccount"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user.ResponseCreateAccount"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/auth/forgotPassword": {
+            "post": {
+                "description": "Send the password reset mail to the email of user signed up by password.\nIt always succeeds so that the registered emails are not exposed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user apis"
+                ],
+                "summary": "Send the password reset mail.",
+                "parameters": [
+                    {
+                        "description": "forgot password request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.RequestForgotPassword"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/base.BaseResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/auth/github": {
+            "get": {
+                "description": "Redirect to the github authorization page, github redirects back to the callback api after authorized.",
+                "tags": [
+                    "user apis"
+                ],
+                "summary": "Redirect to the github authorization page.",
+                "responses": {
+                    "302": {
+                        "description": "Found"
+                    }
+                }
+            }
+        },
+        "/user/auth/github/callback": {
+            "get": {
+                "description": "Login by the github authorization code. The github account is linked to the user of same confirmed email,\nor a new user is created. If the email is registered but not confirmed, it responses 409 and the user\nhas to link the github account by POST /user/auth/github/link. If the authorization is started by the\nlink api, the github account is linked to the user instead of login. It redirects to the configured\nlogin page, or responses the tokens if not configured. If the user enabled two-factor authentication,\nthe pending token is responsed, or passed to the login page by the two_factor_token query.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user apis"
+                ],
+                "summary": "Login by the github authorization code.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization code",
+                        "name": "code",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "state",
+                        "name": "state",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user.ResponseLogin"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/auth/github/link": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Start linking a github account to current user, it responses the url of github authorization page.\nThe github account is linked to current user when github redirects back to the callback api, then\nthe user can login by the github account.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user apis"
+                ],
+                "summary": "Link a github account to current user.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user.ResponseGithubLink"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/auth/jwks": {
+            "get": {
+                "description": "Get the public keys to verify tokens signed by RS256 or EdDSA, the HS256 secrets are never exposed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user apis"
+                ],
+                "summary": "Get the json web key set to verify tokens.",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/base.Jwks"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/auth/login": {
+            "post": {
+                "description": "Login by username and password.\nIf the user enabled two-factor authentication, the session tokens are not responded but a pending token\nwith two_factor_required, complete the login by POST /user/auth/2fa/login with it.\nThe account is locked out progressively after repeated failures, 429 is responded with Retry-After header during the lockout.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user apis"
+                ],
+                "summary": "Login by username and password.",
+                "parameters": [
+                    {
+                        "description": "login request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.RequestLogin"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user.ResponseLogin"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/auth/logout": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Revoke the session of current token, the access token and refresh token of it are rejected.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user apis"
+                ],
+                "summary": "Logout the current session.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/base.BaseResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/auth/logoutAll": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Revoke all sessions of the current user, include the current session.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user apis"
+                ],
+                "summary": "Logout all sessions of the current user.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/base.BaseResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/auth/refresh": {
+            "post": {
+                "description": "Renew the access token by refresh token. The refresh token is rotated and the old one can not be used again.\nThe refresh token is read from the refresh_token cookie if it is absent in body.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user apis"
+                ],
+                "summary": "Renew the access token by refresh token.",
+                "parameters": [
+                    {
+                        "description": "refresh token request",
+                        "name": "body",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/user.RequestRefreshToken"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user.ResponseLogin"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/auth/resetPassword": {
+            "post": {
+                "description": "Reset password by the token in password reset mail, all sessions of user are logged out.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user apis"
+                ],
+                "summary": "Reset password by the token in password reset mail.",
+                "parameters": [
+                    {
+                        "description": "reset password request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.RequestResetPassword"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/base.BaseResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/auth/substrate/nonce": {
+            "post": {
+                "description": "Issue a one-time message with nonce, the wallet signs the message by sr25519 or ed25519\nand login by POST /user/auth/login with provider substrate in 5 minutes.\nIf chain_id is given, the address must be encoded by the prefix of chain or the generic prefix 42.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user apis"
+                ],
+                "summary": "Issue a nonce for the substrate account to sign.",
+                "parameters": [
+                    {
+                        "description": "substrate nonce request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.RequestSubstrateNonce"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user.ResponseSubstrateNonce"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/auth/verifyEmail": {
+            "post": {
+                "description": "Verify email by the token in verification mail. The email of user is changed if the token is sent to a new email.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user apis"
+                ],
+                "summary": "Verify email by the token in verification mail.",
+                "parameters": [
+                    {
+                        "description": "verify email request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.RequestVerifyEmail"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user.ResponseUpdateUser"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/avatar": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get user avatar.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "image/jpeg"
+                ],
+                "tags": [
+                    "user apis"
+                ],
+                "summary": "Get user avatar.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/user/avatar/upload": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Upload user avatar.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user apis"
+                ],
+                "summary": "Upload user avatar.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "avatar file",
+                        "name": "avatar",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user.ResponseUploadAvatar"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/deletion": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Request to delete the account of current user, the account is purged after a grace period of 14 days\nand the deletion can be cancelled before it. The private queries and dashboards are deleted,\nand the public ones are kept with the anonymized user. The username confirms the account,\nand the password is required if the user has a password. The user without password provides\nthe two-factor code if it is enabled, or logins again within 10 minutes before the request.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user apis"
+                ],
+                "summary": "Request to delete account",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "delete account request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.RequestDeleteAccount"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user.ResponseAccountDeletion"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Cancel the requested deletion of the account of current user before it is purged.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user apis"
+                ],
+                "summary": "Cancel account deletion",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user.ResponseAccountDeletion"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/email": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Send a verification mail to the new email, the email is changed after it is verified by POST /user/auth/verifyEmail.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user apis"
+                ],
+                "summary": "Update user email.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "update email request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.RequestUpdateEmail"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user.ResponseUpdateUser"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/email/verify": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Send the verification mail of current email, the public contents can be published after verified.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user apis"
+                ],
+                "summary": "Send the verification mail of current email.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/base.BaseResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/export": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Download the personal data of current user as a zip archive, it contains the profile, queries, charts,\ndashboards with panels and favorites as json files.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/zip"
+                ],
+                "tags": [
+                    "user apis"
+                ],
+                "summary": "Export personal data",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/user/feed": {
+            "get": {
+                "description": "List the new public queries, dashboards and forks of the users followed by current user, the latest are listed first.\nPass the next_cursor of response as cursor to get the next page.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "follow apis"
+                ],
+                "summary": "get feed",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "cursor of next page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "limit, default 20 and at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/follow.ResponseFeed"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/notifications": {
+            "get": {
+                "description": "List the notifications of current user, the latest are listed first.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notification apis"
+                ],
+                "summary": "list notifications",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "list the unread only",
+                        "name": "unread",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page_size",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/notification.ResponseListNotifications"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/notifications/preferences": {
+            "get": {
+                "description": "Get whether current user receives each notification type.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notification apis"
+                ],
+                "summary": "get notification preferences",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/notification.ResponsePreferences"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Enable or disable the notification types of current user.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notification apis"
+                ],
+                "summary": "update notification preferences",
+                "parameters": [
+                    {
+                        "description": "preferences",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/notification.RequestUpdatePreferences"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/notification.ResponsePreferences"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/notifications/read": {
+            "put": {
+                "description": "Mark the notifications of current user as read, and response the unread count.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notification apis"
+                ],
+                "summary": "mark read",
+                "parameters": [
+                    {
+                        "description": "notifications",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/notification.RequestMarkRead"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/notification.ResponseUnread"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/notifications/unread": {
+            "get": {
+                "description": "Count the unread notifications of current user.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notification apis"
+                ],
+                "summary": "unread count",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/notification.ResponseUnread"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/password": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Update user password, the other sessions of user are logged out.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user apis"
+                ],
+                "summary": "Update user password.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "update password request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.RequestUpdatePassword"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user.ResponseUpdateUser"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/sessions": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List the active sessions of the current user.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user apis"
+                ],
+                "summary": "List the active sessions of the current user.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user.ResponseListSessions"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get user by id.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user apis"
+                ],
+                "summary": "Get user by id.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user.ResponseGetUser"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/{id}/follow": {
+            "post": {
+                "description": "Follow the user, the new public contents of the user are listed in the feed of current user.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "follow apis"
+                ],
+                "summary": "follow user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/follow.ResponseFollow"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Unfollow the user.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "follow apis"
+                ],
+                "summary": "unfollow user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/follow.ResponseFollow"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/{id}/followers": {
+            "get": {
+                "description": "List the followers of user, the latest followers are listed first.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "follow apis"
+                ],
+                "summary": "list followers",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page_size",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/follow.ResponseListFollowUsers"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/{id}/following": {
+            "get": {
+                "description": "List the users followed by user, the latest followed users are listed first.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "follow apis"
+                ],
+                "summary": "list following",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page_size",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/follow.ResponseListFollowUsers"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhook": {
+            "get": {
+                "description": "List the webhooks of current user.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhook apis"
+                ],
+                "summary": "list webhooks",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/webhook.ResponseListWebhooks"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a webhook, the subscribed events of current user's contents are posted to its url.\nThe payloads are signed by HMAC-SHA256 of the secret, the X-Hyperdot-Signature header is\n\"sha256=\" + hex(hmac(secret, X-Hyperdot-Timestamp + \".\" + body)). The secret is only responded once.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhook apis"
+                ],
+                "summary": "create webhook",
+                "parameters": [
+                    {
+                        "description": "webhook",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/webhook.RequestCreateWebhook"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/webhook.ResponseWebhook"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhook/{id}": {
+            "put": {
+                "description": "Update the webhook of current user, the secret is responded if it is rotated.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhook apis"
+                ],
+                "summary": "update webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "webhook id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "webhook",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/webhook.RequestUpdateWebhook"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/webhook.ResponseWebhook"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete the webhook of current user with its delivery log.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhook apis"
+                ],
+                "summary": "delete webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "webhook id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/base.BaseResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhook/{id}/deliveries": {
+            "get": {
+                "description": "List the deliveries of webhook, the latest are listed first.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhook apis"
+                ],
+                "summary": "list deliveries",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "webhook id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "pending, success or failed",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page_size",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/webhook.ResponseListDeliveries"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhook/{id}/ping": {
+            "post": {
+                "description": "Queue a ping event to the webhook to test it, the result is found in its deliveries.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhook apis"
+                ],
+                "summary": "ping webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "webhook id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/base.BaseResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/workspace": {
+            "get": {
+                "description": "List the workspaces which the current user is a member of.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workspace apis"
+                ],
+                "summary": "list workspaces",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/workspace.ResponseListWorkspaces"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a workspace, the current user is the owner of it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workspace apis"
+                ],
+                "summary": "create workspace",
+                "parameters": [
+                    {
+                        "description": "workspace",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/workspace.RequestWorkspace"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/workspace.ResponseWorkspace"
+                        }
+                    }
+                }
+            }
+        },
+        "/workspace/{id}": {
+            "get": {
+                "description": "Get the workspace with the role of current user, it can be accessed by members.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workspace apis"
+                ],
+                "summary": "get workspace",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "workspace id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/workspace.ResponseWorkspace"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Update the name and description of workspace, it requires owner role.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workspace apis"
+                ],
+                "summary": "update workspace",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "workspace id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "workspace",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/workspace.RequestWorkspace"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/workspace.ResponseWorkspace"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete the workspace, it requires owner role. The queries and dashboards of workspace are moved back to their creators.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workspace apis"
+                ],
+                "summary": "delete workspace",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "workspace id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/base.BaseResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/workspace/{id}/members": {
+            "get": {
+                "description": "List the members of workspace, it can be accessed by members.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workspace apis"
+                ],
+                "summary": "list workspace members",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "workspace id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/workspace.ResponseListMembers"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Add a user to workspace with role, it requires owner role.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workspace apis"
+                ],
+                "summary": "add workspace member",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "workspace id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "member",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/workspace.RequestAddMember"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/workspace.ResponseMember"
+                        }
+                    }
+                }
+            }
+        },
+        "/workspace/{id}/members/{userId}": {
+            "put": {
+                "description": "Update the role of member, it requires owner role. The last owner can not be demoted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workspace apis"
+                ],
+                "summary": "update workspace member",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "workspace id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "member",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/workspace.RequestUpdateMember"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/workspace.ResponseMember"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Remove the member from workspace, it requires owner role unless the member leaves by itself. The last owner can not be removed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workspace apis"
+                ],
+                "summary": "remove workspace member",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "workspace id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/base.BaseResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/workspace/{id}/statistics": {
+            "get": {
+                "description": "Get the number of members, queries and dashboards of workspace, it can be accessed by members.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workspace apis"
+                ],
+                "summary": "get workspace statistics",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "workspace id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/workspace.ResponseStatistics"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "admin.RequestUpdateRole": {
+            "type": "object",
+            "properties": {
+                "role": {
+                    "description": "Role is one of user, moderator and admin.",
+                    "type": "string"
+                }
+            }
+        },
+        "admin.ResponseListUsers": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/admin.ResponseListUsersData"
+                },
+                "errorCode": {
+                    "type": "integer"
+                },
+                "errorMessage": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "admin.ResponseListUsersData": {
+            "type": "object",
+            "properties": {
+                "total": {
+                    "type": "integer"
+                },
+                "users": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/datamodel.UserModel"
+                    }
+                }
+            }
+        },
+        "admin.ResponseUser": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/datamodel.UserModel"
+                },
+                "errorCode": {
+                    "type": "integer"
+                },
+                "errorMessage": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "alert.RequestCreateAlert": {
+            "type": "object",
+            "properties": {
+                "channels": {
+                    "description": "Channels are some of email, webhook and in_app, the default is in_app.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "column": {
+                    "description": "Column is the column of rows compared by alert, it is not required by count.",
+                    "type": "string"
+                },
+                "enabled": {
+                    "description": "Enabled is true by default.",
+                    "type": "boolean"
+                },
+                "interval": {
+                    "description": "Interval is the minutes between evaluations, the default is 60.",
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "operator": {
+                    "description": "Operator is one of gt, gte, lt, lte, eq and ne.",
+                    "type": "string"
+                },
+                "params": {
+                    "description": "Params are the values of the placeholders of query.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "query_id": {
+                    "description": "QueryID is the saved query executed by alert.",
+                    "type": "integer"
+                },
+                "reduce": {
+                    "description": "Reduce is one of first, min, max, sum and count, the default is first.",
+                    "type": "string"
+                },
+                "threshold": {
+                    "type": "number"
+                }
+            }
+        },
+        "alert.RequestUpdateAlert": {
+            "type": "object",
+            "properties": {
+                "channels": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "column": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "interval": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "operator": {
+                    "type": "string"
+                },
+                "params": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "reduce": {
+                    "type": "string"
+                },
+                "threshold": {
+                    "type": "number"
+                }
+            }
+        },
+        "alert.ResponseAlert": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/datamodel.AlertModel"
+                },
+                "errorCode": {
+                    "type": "integer"
+                },
+                "errorMessage": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "alert.ResponseListAlerts": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/datamodel.AlertModel"
+                    }
+                },
+                "errorCode": {
+                    "type": "integer"
+                },
+                "errorMessage": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "apikey.RequestCreateApiKey": {
+            "type": "object",
+            "properties": {
+                "expires_in": {
+                    "description": "ExpiresIn is the lifetime of key in seconds, 0 means never expires.",
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "description": "Scopes of the key, all scopes are granted if it is empty.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "apikey.ResponseCreateApiKey": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/apikey.ResponseCreateApiKeyData"
+                },
+                "errorCode": {
+                    "type": "integer"
+                },
+                "errorMessage": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "apikey.ResponseCreateApiKeyData": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "key": {
+                    "description": "Key is the api key, it is only responded once when it is created.",
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "description": "Prefix is the beginning of key to tell keys apart.",
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "apikey.ResponseListApiKeys": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/datamodel.ApiKeyModel"
+                    }
+                },
+                "errorCode": {
+                    "type": "integer"
+                },
+                "errorMessage": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "base.BaseResponse": {
+            "type": "object",
+            "properties": {
+                "errorCode": {
+                    "type": "integer"
+                },
+                "errorMessage": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "base.Jwk": {
+            "type": "object",
+            "properties": {
+                "alg": {
+                    "type": "string"
+                },
+                "crv": {
+                    "type": "string"
+                },
+                "e": {
+                    "type": "string"
+                },
+                "kid": {
+                    "type": "string"
+                },
+                "kty": {
+                    "type": "string"
+                },
+                "n": {
+                    "type": "string"
+                },
+                "use": {
+                    "type": "string"
+                },
+                "x": {
+                    "type": "string"
+                }
+            }
+        },
+        "base.Jwks": {
+            "type": "object",
+            "properties": {
+                "keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/base.Jwk"
+                    }
+                }
+            }
+        },
+        "base.ResponseAuditLogs": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/base.ResponseAuditLogsData"
+                },
+                "errorCode": {
+                    "type": "integer"
+                },
+                "errorMessage": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "base.ResponseAuditLogsData": {
+            "type": "object",
+            "properties": {
+                "logs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/datamodel.AuditLogModel"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "cache.QueryResult": {
+            "type": "object",
+            "properties": {
+                "params": {
+                    "description": "Params are the parameter values rendered into the query.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "query_id": {
+                    "type": "integer"
+                },
+                "rows": {
+                    "type": "array",
+                    "items": {
+                        "type": "object",
+                        "additionalProperties": true
+                    }
+                },
+                "schemas": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dataengine.FieldSchema"
+                    }
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "comment.RequestCreateComment": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "description": "Body is the markdown text, the users are mentioned by @username.",
+                    "type": "string"
+                },
+                "parent_id": {
+                    "description": "ParentID is the comment replied to, it is 0 for a new thread.",
+                    "type": "integer"
+                }
+            }
+        },
+        "comment.RequestUpdateComment": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "type": "string"
+                }
+            }
+        },
+        "comment.ResponseComment": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/comment.ResponseCommentData"
+                },
+                "errorCode": {
+                    "type": "integer"
+                },
+                "errorMessage": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "comment.ResponseCommentData": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "edited_at": {
+                    "type": "string"
+                },
+                "icon_url": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "mentions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/comment.ResponseMention"
+                    }
+                },
+                "parent_id": {
+                    "type": "integer"
+                },
+                "replies": {
+                    "description": "Replies is the number of replies of a thread.",
+                    "type": "integer"
+                },
+                "target_id": {
+                    "type": "integer"
+                },
+                "target_type": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "comment.ResponseListComments": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/comment.ResponseListCommentsData"
+                },
+                "errorCode": {
+                    "type": "integer"
+                },
+                "errorMessage": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "comment.ResponseListCommentsData": {
+            "type": "object",
+            "properties": {
+                "comments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/comment.ResponseCommentData"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "comment.ResponseMention": {
+            "type": "object",
+            "properties": {
+                "user_id": {
+                    "type": "integer"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "dashboard.DashboardBundle": {
+            "type": "object",
+            "properties": {
+                "charts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/datamodel.ChartModel"
+                    }
+                },
+                "dashboard": {
+                    "$ref": "#/definitions/datamodel.DashboardModel"
+                },
+                "exported_at": {
+                    "type": "string"
+                },
+                "queries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/datamodel.QueryModel"
+                    }
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dashboard.RequestDuplicateDashboard": {
+            "type": "object",
+            "properties": {
+                "clone_queries": {
+                    "description": "CloneQueries clones the queries and charts referenced by panels.\nIt is always true when the source dashboard is a template.",
+                    "type": "boolean"
+                },
+                "description": {
+                    "description": "Description of the new dashboard, default is the description of the source dashboard.",
+                    "type": "string"
+                },
+                "is_privacy": {
+                    "description": "IsPrivacy of the new dashboard.",
+                    "type": "boolean"
+                },
+                "name": {
+                    "description": "Name of the new dashboard, default is the name of the source dashboard.",
+                    "type": "string"
+                },
+                "params": {
+                    "description": "Params fill the template placeholders, e.g. {\"chain_id\": \"2004\"}.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "dashboard.Response": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/datamodel.DashboardModel"
+                },
+                "errorCode": {
+                    "type": "integer"
+                },
+                "errorMessage": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "dashboard.ResponseTrash": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/dashboard.ResponseTrashData"
+                },
+                "errorCode": {
+                    "type": "integer"
+                },
+                "errorMessage": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "dashboard.ResponseTrashData": {
+            "type": "object",
+            "properties": {
+                "dashboards": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/datamodel.DashboardModel"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dataengine.FieldSchema": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "description": "A description of the field. The maximum length is 16,384 characters.",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "The field name.\nMust contain only letters (a-z, A-Z), numbers (0-9), or underscores (_),\nand must start with a letter or underscore.\nThe maximum length is 128 characters.",
+                    "type": "string"
+                },
+                "repeated": {
+                    "description": "Whether the field may contain multiple values.",
+                    "type": "boolean"
+                },
+                "required": {
+                    "description": "Whether the field is required.  Ignored if Repeated is true.",
+                    "type": "boolean"
+                },
+                "type": {
+                    "description": "The field data type.  If Type is Record, then this field contains a nested schema,\nwhich is described by Schema.",
+                    "type": "string"
+                }
+            }
+        },
+        "datamodel.AlertModel": {
+            "type": "object",
+            "properties": {
+                "channels": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "column": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "interval": {
+                    "description": "Interval is the minutes between evaluations.",
+                    "type": "integer"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "last_run_at": {
+                    "type": "string"
+                },
+                "last_value": {
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "next_run_at": {
+                    "type": "string"
+                },
+                "operator": {
+                    "type": "string"
+                },
+                "params": {
+                    "description": "Params are the values of placeholders of query.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/datamodel.JSON"
+                        }
+                    ]
+                },
+                "query_id": {
+                    "type": "integer"
+                },
+                "reduce": {
+                    "type": "string"
+                },
+                "state": {
+                    "type": "string"
+                },
+                "threshold": {
+                    "type": "number"
+                },
+                "triggered_at": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "datamodel.ApiKeyModel": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "description": "Prefix is the beginning of key to tell keys apart.",
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "datamodel.AuditLogModel": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "actor_id": {
+                    "type": "integer"
+                },
+                "after": {
+                    "$ref": "#/definitions/datamodel.JSON"
+                },
+                "before": {
+                    "$ref": "#/definitions/datamodel.JSON"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "target_id": {
+                    "type": "integer"
+                },
+                "target_type": {
+                    "type": "string"
+                },
+                "user_agent": {
+                    "type": "string"
+                }
+            }
+        },
+        "datamodel.ChartModel": {
+            "type": "object",
+            "properties": {
+                "closeable": {
+                    "type": "boolean"
+                },
+                "config": {
+                    "$ref": "#/definitions/datamodel.JSON"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "query_id": {
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "datamodel.ContentPermissionModel": {
+            "type": "object",
+            "properties": {
+                "content_id": {
+                    "type": "integer"
+                },
+                "content_type": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "granted_by": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "permission": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "datamodel.DashboardModel": {
+            "type": "object",
+            "properties": {
+                "created_At": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "description": "DeletedAt is the time the dashboard is moved to trash, it is purged after the retention period.",
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "forked_from_id": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "is_privacy": {
+                    "type": "boolean"
+                },
+                "is_template": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "panels": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/datamodel.DashboardPanelModel"
+                    }
+                },
+                "tags": {
+                    "type": "string"
+                },
+                "template_params": {
+                    "$ref": "#/definitions/datamodel.JSON"
+                },
+                "updated_At": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "workspace_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "datamodel.DashboardPanelModel": {
+            "type": "object",
+            "properties": {
+                "chart_id": {
+                    "type": "integer"
+                },
+                "created_At": {
+                    "type": "string"
+                },
+                "dashboard_id": {
+                    "type": "integer"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "height": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "query_id": {
+                    "type": "integer"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "type": {
+                    "description": "0: Text, 1: Visualization",
+                    "type": "integer"
+                },
+                "updated_At": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "width": {
+                    "type": "string"
+                },
+                "x_pos": {
+                    "type": "number"
+                },
+                "y_pos": {
+                    "type": "number"
+                }
+            }
+        },
+        "datamodel.JSON": {
+            "type": "object",
+            "additionalProperties": true
+        },
+        "datamodel.QueryModel": {
+            "type": "object",
+            "properties": {
+                "charts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/datamodel.ChartModel"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "description": "DeletedAt is the time the query is moved to trash, it is purged after the retention period.",
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "is_privacy": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "query": {
+                    "type": "string"
+                },
+                "query_engine": {
+                    "type": "string"
+                },
+                "stars": {
+                    "type": "integer"
+                },
+                "unsaved": {
+                    "type": "boolean"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "workspace_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "datamodel.ShareTokenModel": {
+            "type": "object",
+            "properties": {
+                "chart_id": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "dashboard_id": {
+                    "type": "integer"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "prefix": {
+                    "description": "Prefix is the beginning of token to tell tokens apart.",
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "token": {
+                    "description": "Token is only responded when it is created.",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "datamodel.UserDashboardFavorites": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "dashboard_id": {
+                    "type": "integer"
+                },
+                "dashboard_user_id": {
+                    "type": "integer"
+                },
+                "stared": {
+                    "type": "boolean"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "datamodel.UserModel": {
+            "type": "object",
+            "properties": {
+                "bio": {
+                    "type": "string"
+                },
+                "confirmed_at": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "description": "DeletedAt is the time the account is purged, the deleted user is anonymized and\nexcluded from queries.",
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "deletion_scheduled_at": {
+                    "description": "DeletionScheduledAt is the time the account requested to be deleted is purged,\nthe user can cancel the deletion before it.",
+                    "type": "string"
+                },
+                "disabled_at": {
+                    "description": "DisabledAt is the time the user is disabled by admin, the disabled user can not login.",
+                    "type": "string"
+                },
+                "discord": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "github": {
+                    "type": "string"
+                },
+                "icon_url": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "location": {
+                    "type": "string"
+                },
+                "log": {
+                    "type": "string"
+                },
+                "logs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/datamodel.UserSignLog"
+                    }
+                },
+                "provider": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "sign_in_count": {
+                    "type": "integer"
+                },
+                "telgram": {
+                    "type": "string"
+                },
+                "twitter": {
+                    "type": "string"
+                },
+                "uid": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "datamodel.UserQueryFavorites": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "query_id": {
+                    "type": "integer"
+                },
+                "query_user_id": {
+                    "type": "integer"
+                },
+                "stared": {
+                    "type": "boolean"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "datamodel.UserSignLog": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "type": "string"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "user_agent": {
+                    "type": "string"
+                }
+            }
+        },
+        "datamodel.WebhookDeliveryModel": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "delivered_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "event": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "next_attempt_at": {
+                    "type": "string"
+                },
+                "payload": {
+                    "type": "string"
+                },
+                "response_code": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "webhook_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "datamodel.WebhookModel": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "datamodel.WorkspaceMemberModel": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "workspace_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "datamodel.WorkspaceStatistics": {
+            "type": "object",
+            "properties": {
+                "dashboards": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "members": {
+                    "type": "integer"
+                },
+                "queries": {
+                    "type": "integer"
+                },
+                "workspace_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "follow.ResponseFeed": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/follow.ResponseFeedData"
+                },
+                "errorCode": {
+                    "type": "integer"
+                },
+                "errorMessage": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "follow.ResponseFeedData": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/follow.ResponseFeedItem"
+                    }
+                },
+                "next_cursor": {
+                    "description": "NextCursor is the cursor of next page, it is empty if there is no more items.",
+                    "type": "string"
+                }
+            }
+        },
+        "follow.ResponseFeedItem": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "forked_from_id": {
+                    "type": "integer"
+                },
+                "icon_url": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "follow.ResponseFollow": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/follow.ResponseFollowData"
+                },
+                "errorCode": {
+                    "type": "integer"
+                },
+                "errorMessage": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "follow.ResponseFollowData": {
+            "type": "object",
+            "properties": {
+                "followed": {
+                    "type": "boolean"
+                },
+                "followers": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "follow.ResponseFollowUserData": {
+            "type": "object",
+            "properties": {
+                "bio": {
+                    "type": "string"
+                },
+                "followed_at": {
+                    "type": "string"
+                },
+                "icon_url": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "follow.ResponseListFollowUsers": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/follow.ResponseListFollowUsersData"
+                },
+                "errorCode": {
+                    "type": "integer"
+                },
+                "errorMessage": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "follow.ResponseListFollowUsersData": {
+            "type": "object",
+            "properties": {
+                "total": {
+                    "type": "integer"
+                },
+                "users": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/follow.ResponseFollowUserData"
+                    }
+                }
+            }
+        },
+        "notification.RequestMarkRead": {
+            "type": "object",
+            "properties": {
+                "all": {
+                    "description": "All marks all notifications of current user as read, the IDs are ignored.",
+                    "type": "boolean"
+                },
+                "ids": {
+                    "description": "IDs are the notifications to mark as read.",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "notification.RequestUpdatePreferences": {
+            "type": "object",
+            "properties": {
+                "preferences": {
+                    "description": "Preferences enables or disables the notification types, the types not in\nit are unchanged.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "boolean"
+                    }
+                }
+            }
+        },
+        "notification.ResponseListNotifications": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/notification.ResponseListNotificationsData"
+                },
+                "errorCode": {
+                    "type": "integer"
+                },
+                "errorMessage": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "notification.ResponseListNotificationsData": {
+            "type": "object",
+            "properties": {
+                "notifications": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/notification.ResponseNotificationData"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "unread": {
+                    "type": "integer"
+                }
+            }
+        },
+        "notification.ResponseNotificationData": {
+            "type": "object",
+            "properties": {
+                "actor_icon_url": {
+                    "type": "string"
+                },
+                "actor_id": {
+                    "type": "integer"
+                },
+                "actor_username": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "data": {
+                    "$ref": "#/definitions/datamodel.JSON"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "read_at": {
+                    "type": "string"
+                },
+                "target_id": {
+                    "type": "integer"
+                },
+                "target_type": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "notification.ResponsePreferences": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "boolean"
+                    }
+                },
+                "errorCode": {
+                    "type": "integer"
+                },
+                "errorMessage": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "notification.ResponseUnread": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/notification.ResponseUnreadData"
+                },
+                "errorCode": {
+                    "type": "integer"
+                },
+                "errorMessage": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "notification.ResponseUnreadData": {
+            "type": "object",
+            "properties": {
+                "unread": {
+                    "type": "integer"
+                }
+            }
+        },
+        "permission.RequestGrantPermission": {
+            "type": "object",
+            "properties": {
+                "permission": {
+                    "description": "Permission is one of viewer and editor.",
+                    "type": "string"
+                },
+                "user_id": {
+                    "description": "UserID is the user to grant, the user is found by Username if it is 0.",
+                    "type": "integer"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "permission.ResponseListPermissions": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/permission.ResponsePermissionData"
+                    }
+                },
+                "errorCode": {
+                    "type": "integer"
+                },
+                "errorMessage": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "permission.ResponsePermission": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/datamodel.ContentPermissionModel"
+                },
+                "errorCode": {
+                    "type": "integer"
+                },
+                "errorMessage": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "permission.ResponsePermissionData": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "granted_by": {
+                    "type": "integer"
+                },
+                "icon_url": {
+                    "type": "string"
+                },
+                "permission": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "query.RequestRunQuery": {
+            "type": "object",
+            "properties": {
+                "engine": {
+                    "type": "string"
+                },
+                "query": {
+                    "type": "string"
+                }
+            }
+        },
+        "query.Response": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/datamodel.QueryModel"
+                },
+                "errorCode": {
+                    "type": "integer"
+                },
+                "errorMessage": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "query.ResponseQueryResult": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/cache.QueryResult"
+                },
+                "errorCode": {
+                    "type": "integer"
+                },
+                "errorMessage": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "query.ResponseRun": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/query.ResponseRunData"
+                },
+                "errorCode": {
+                    "type": "integer"
+                },
+                "errorMessage": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "query.ResponseRunData": {
+            "type": "object",
+            "properties": {
+                "rows": {
+                    "type": "array",
+                    "items": {
+                        "type": "object",
+                        "additionalProperties": true
+                    }
+                },
+                "schemas": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dataengine.FieldSchema"
+                    }
+                }
+            }
+        },
+        "query.ResponseTrash": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/query.ResponseTrashData"
+                },
+                "errorCode": {
+                    "type": "integer"
+                },
+                "errorMessage": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "query.ResponseTrashData": {
+            "type": "object",
+            "properties": {
+                "queries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/datamodel.QueryModel"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "share.RequestCreateShare": {
+            "type": "object",
+            "properties": {
+                "chart_id": {
+                    "description": "ChartID is the shared chart, one of DashboardID and ChartID is required.",
+                    "type": "integer"
+                },
+                "dashboard_id": {
+                    "description": "DashboardID is the shared dashboard, one of DashboardID and ChartID is required.",
+                    "type": "integer"
+                },
+                "expires_in": {
+                    "description": "ExpiresIn is the lifetime of token in seconds, 0 means never expires.",
+                    "type": "integer"
+                }
+            }
+        },
+        "share.ResponseShare": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/datamodel.ShareTokenModel"
+                },
+                "errorCode": {
+                    "type": "integer"
+                },
+                "errorMessage": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "share.ResponseShared": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/share.ResponseSharedData"
+                },
+                "errorCode": {
+                    "type": "integer"
+                },
+                "errorMessage": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "share.ResponseSharedData": {
+            "type": "object",
+            "properties": {
+                "chart": {
+                    "$ref": "#/definitions/datamodel.ChartModel"
+                },
+                "dashboard": {
+                    "$ref": "#/definitions/datamodel.DashboardModel"
+                },
+                "queries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/datamodel.QueryModel"
+                    }
+                }
+            }
+        },
+        "share.ResponseSharedResult": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/cache.QueryResult"
+                },
+                "errorCode": {
+                    "type": "integer"
+                },
+                "errorMessage": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "user.RequestCreateAccount": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                },
+                "provider": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "user.RequestDeleteAccount": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "Code is the two-factor code or a recovery code, it is required if the user has no\npassword but enabled two-factor authentication.",
+                    "type": "string"
+                },
+                "password": {
+                    "description": "Password is required if the user has a password.",
+                    "type": "string"
+                },
+                "username": {
+                    "description": "Username confirms the account to be deleted.",
+                    "type": "string"
+                }
+            }
+        },
+        "user.RequestDisableTwoFactor": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "Code is the TOTP code of authenticator or a recovery code",
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "user.RequestForgotPassword": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                }
+            }
+        },
+        "user.RequestLogin": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "description": "Address is the SS58 address of substrate account",
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                },
+                "provider": {
+                    "type": "string"
+                },
+                "signature": {
+                    "description": "Signature is the hex encoded signature of the nonce message by substrate account",
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "string"
+                }
+            }
+        },
+        "user.RequestRefreshToken": {
+            "type": "object",
+            "properties": {
+                "refresh_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "user.RequestResetPassword": {
+            "type": "object",
+            "properties": {
+                "new_password": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "user.RequestSubstrateNonce": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "chain_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "user.RequestTwoFactorCode": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                }
+            }
+        },
+        "user.RequestTwoFactorLogin": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "Code is the TOTP code of authenticator or a recovery code",
+                    "type": "string"
+                },
+                "token": {
+                    "description": "Token is the pending token responded by POST /user/auth/login",
+                    "type": "string"
+                }
+            }
+        },
+        "user.RequestUpdateEmail": {
+            "type": "object",
+            "properties": {
+                "new_email": {
+                    "type": "string"
+                }
+            }
+        },
+        "user.RequestUpdatePassword": {
+            "type": "object",
+            "properties": {
+                "current_password": {
+                    "type": "string"
+                },
+                "new_password": {
+                    "type": "string"
+                }
+            }
+        },
+        "user.RequestVerifyEmail": {
+            "type": "object",
+            "properties": {
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "user.ResponseAccountDeletion": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/user.ResponseAccountDeletionData"
+                },
+                "errorCode": {
+                    "type": "integer"
+                },
+                "errorMessage": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "user.ResponseAccountDeletionData": {
+            "type": "object",
+            "properties": {
+                "deletion_scheduled_at": {
+                    "description": "DeletionScheduledAt is the time the account is purged, it is null if the deletion is cancelled.",
+                    "type": "string"
+                }
+            }
+        },
+        "user.ResponseCreateAccount": {
+            "type": "object"
+        },
+        "user.ResponseGetUser": {
             "type": "object",
             "properties": {
                 "data": {
-                    "$ref": "#/definitions/datamodel.DashboardModel"
+                    "$ref": "#/definitions/user.ResponseGetUserData"
                 },
                 "errorCode": {
                     "type": "integer"
@@ -1463,327 +7026,309 @@ const docTemplate = `{
                 }
             }
         },
-        "dataengine.FieldSchema": {
+        "user.ResponseGetUserData": {
             "type": "object",
             "properties": {
-                "description": {
-                    "description": "A description of the field. The maximum length is 16,384 characters.",
-                    "type": "string"
-                },
-                "name": {
-                    "description": "The field name.\nMust contain only letters (a-z, A-Z), numbers (0-9), or underscores (_),\nand must start with a letter or underscore.\nThe maximum length is 128 characters.",
+                "bio": {
                     "type": "string"
                 },
-                "repeated": {
-                    "description": "Whether the field may contain multiple values.",
-                    "type": "boolean"
-                },
-                "required": {
-                    "description": "Whether the field is required.  Ignored if Repeated is true.",
-                    "type": "boolean"
-                },
-                "type": {
-                    "description": "The field data type.  If Type is Record, then this field contains a nested schema,\nwhich is described by Schema.",
+                "confirmed_at": {
                     "type": "string"
-                }
-            }
-        },
-        "datamodel.ChartModel": {
-            "type": "object",
-            "properties": {
-                "closeable": {
-                    "type": "boolean"
-                },
-                "config": {
-                    "$ref": "#/definitions/datamodel.JSON"
                 },
-                "id": {
-                    "type": "integer"
-                },
-                "name": {
+                "created_at": {
                     "type": "string"
                 },
-                "query_id": {
+                "dashboards": {
                     "type": "integer"
                 },
-                "type": {
+                "discord": {
                     "type": "string"
                 },
-                "user_id": {
-                    "type": "integer"
-                }
-            }
-        },
-        "datamodel.DashboardModel": {
-            "type": "object",
-            "properties": {
-                "created_At": {
+                "email": {
                     "type": "string"
                 },
-                "deleted_at": {
+                "encrypted_password": {
                     "type": "string"
                 },
-                "description": {
-                    "type": "string"
+                "followed": {
+                    "description": "Followed reports whether the current user follows the user.",
+                    "type": "boolean"
                 },
-                "id": {
+                "followers": {
                     "type": "integer"
                 },
-                "is_privacy": {
-                    "type": "boolean"
+                "following": {
+                    "type": "integer"
                 },
-                "name": {
+                "github": {
                     "type": "string"
                 },
-                "panels": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/datamodel.DashboardPanelModel"
-                    }
+                "icon_url": {
+                    "type": "string"
                 },
-                "tags": {
+                "id": {
                     "type": "string"
                 },
-                "updated_At": {
+                "location": {
                     "type": "string"
                 },
-                "user_id": {
-                    "type": "integer"
-                }
-            }
-        },
-        "datamodel.DashboardPanelModel": {
-            "type": "object",
-            "properties": {
-                "chart_id": {
+                "queries": {
                     "type": "integer"
                 },
-                "created_At": {
+                "role": {
                     "type": "string"
                 },
-                "dashboard_id": {
+                "stars": {
                     "type": "integer"
                 },
-                "description": {
+                "telgram": {
                     "type": "string"
                 },
-                "height": {
+                "twitter": {
                     "type": "string"
                 },
-                "id": {
-                    "type": "integer"
-                },
-                "name": {
+                "uid": {
                     "type": "string"
                 },
-                "query_id": {
-                    "type": "integer"
-                },
-                "text": {
+                "updated_at": {
                     "type": "string"
                 },
-                "type": {
-                    "description": "0: Text, 1: Visualization",
-                    "type": "integer"
-                },
-                "updated_At": {
+                "username": {
                     "type": "string"
+                }
+            }
+        },
+        "user.ResponseGithubLink": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/user.ResponseGithubLinkData"
                 },
-                "user_id": {
+                "errorCode": {
                     "type": "integer"
                 },
-                "width": {
+                "errorMessage": {
                     "type": "string"
                 },
-                "x_pos": {
-                    "type": "number"
-                },
-                "y_pos": {
-                    "type": "number"
+                "success": {
+                    "type": "boolean"
                 }
             }
         },
-        "datamodel.JSON": {
+        "user.ResponseGithubLinkData": {
             "type": "object",
-            "additionalProperties": true
+            "properties": {
+                "url": {
+                    "description": "Url is the url of github authorization page.",
+                    "type": "string"
+                }
+            }
         },
-        "datamodel.QueryModel": {
+        "user.ResponseListSessions": {
             "type": "object",
             "properties": {
-                "charts": {
+                "data": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/datamodel.ChartModel"
+                        "$ref": "#/definitions/user.ResponseSession"
                     }
                 },
-                "created_At": {
-                    "type": "string"
+                "errorCode": {
+                    "type": "integer"
                 },
-                "description": {
+                "errorMessage": {
                     "type": "string"
                 },
-                "id": {
-                    "type": "integer"
-                },
-                "is_privacy": {
+                "success": {
                     "type": "boolean"
-                },
-                "name": {
+                }
+            }
+        },
+        "user.ResponseLogin": {
+            "type": "object",
+            "properties": {
+                "algorithm": {
                     "type": "string"
                 },
-                "query": {
+                "expires_at": {
                     "type": "string"
                 },
-                "query_engine": {
+                "refresh_token": {
                     "type": "string"
                 },
-                "stars": {
-                    "type": "integer"
+                "token": {
+                    "type": "string"
                 },
-                "unsaved": {
+                "two_factor_required": {
+                    "description": "TwoFactorRequired is true if the password is verified but the second factor\nis required, the login is completed by POST /user/auth/2fa/login with TwoFactorToken.",
                     "type": "boolean"
                 },
-                "updated_At": {
+                "two_factor_token": {
                     "type": "string"
-                },
-                "user_id": {
-                    "type": "integer"
                 }
             }
         },
-        "datamodel.UserDashboardFavorites": {
+        "user.ResponseRecoveryCodes": {
             "type": "object",
             "properties": {
-                "created_at": {
-                    "type": "string"
-                },
-                "dashboard_id": {
-                    "type": "integer"
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "dashboard_user_id": {
+                "errorCode": {
                     "type": "integer"
                 },
-                "stared": {
-                    "type": "boolean"
-                },
-                "updated_at": {
+                "errorMessage": {
                     "type": "string"
                 },
-                "user_id": {
-                    "type": "integer"
+                "success": {
+                    "type": "boolean"
                 }
             }
         },
-        "datamodel.UserModel": {
+        "user.ResponseSession": {
             "type": "object",
             "properties": {
-                "bio": {
+                "created_at": {
                     "type": "string"
                 },
-                "confirmed_at": {
-                    "type": "string"
+                "current": {
+                    "type": "boolean"
                 },
-                "created_at": {
+                "expires_at": {
                     "type": "string"
                 },
-                "deleted_at": {
+                "id": {
+                    "type": "integer"
+                },
+                "ip": {
                     "type": "string"
                 },
-                "discord": {
+                "refreshed_at": {
                     "type": "string"
                 },
-                "email": {
+                "revoked_at": {
                     "type": "string"
                 },
-                "github": {
+                "updated_at": {
                     "type": "string"
                 },
-                "icon_url": {
+                "user_agent": {
                     "type": "string"
                 },
-                "id": {
+                "user_id": {
                     "type": "integer"
+                }
+            }
+        },
+        "user.ResponseSubstrateNonce": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/user.ResponseSubstrateNonceData"
                 },
-                "location": {
+                "errorCode": {
+                    "type": "integer"
+                },
+                "errorMessage": {
                     "type": "string"
                 },
-                "log": {
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "user.ResponseSubstrateNonceData": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
                     "type": "string"
                 },
-                "logs": {
+                "message": {
+                    "description": "Message is the message to sign, it includes the nonce.",
+                    "type": "string"
+                },
+                "networks": {
+                    "description": "Networks is the chain names of the address prefix.",
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/datamodel.UserSignLog"
+                        "type": "string"
                     }
                 },
-                "provider": {
-                    "type": "string"
-                },
-                "sign_in_count": {
+                "prefix": {
+                    "description": "Prefix is the SS58 prefix of address.",
                     "type": "integer"
+                }
+            }
+        },
+        "user.ResponseTwoFactorEnroll": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/user.ResponseTwoFactorEnrollData"
                 },
-                "telgram": {
-                    "type": "string"
-                },
-                "twitter": {
-                    "type": "string"
+                "errorCode": {
+                    "type": "integer"
                 },
-                "uid": {
+                "errorMessage": {
                     "type": "string"
                 },
-                "updated_at": {
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "user.ResponseTwoFactorEnrollData": {
+            "type": "object",
+            "properties": {
+                "secret": {
                     "type": "string"
                 },
-                "username": {
+                "uri": {
+                    "description": "URI is the otpauth provisioning uri, the authenticator apps scan the QR code of it.",
                     "type": "string"
                 }
             }
         },
-        "datamodel.UserQueryFavorites": {
+        "user.ResponseTwoFactorStatus": {
             "type": "object",
             "properties": {
-                "created_at": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "integer"
-                },
-                "query_id": {
-                    "type": "integer"
+                "data": {
+                    "$ref": "#/definitions/user.ResponseTwoFactorStatusData"
                 },
-                "query_user_id": {
+                "errorCode": {
                     "type": "integer"
                 },
-                "stared": {
-                    "type": "boolean"
-                },
-                "updated_at": {
+                "errorMessage": {
                     "type": "string"
                 },
-                "user_id": {
-                    "type": "integer"
+                "success": {
+                    "type": "boolean"
                 }
             }
         },
-        "datamodel.UserSignLog": {
+        "user.ResponseTwoFactorStatusData": {
             "type": "object",
             "properties": {
-                "at": {
-                    "type": "string"
+                "enabled": {
+                    "type": "boolean"
                 },
-                "ip": {
+                "enabled_at": {
                     "type": "string"
                 },
-                "user_agent": {
-                    "type": "string"
+                "recovery_codes": {
+                    "description": "RecoveryCodes is the number of unused recovery codes",
+                    "type": "integer"
                 }
             }
         },
-        "query.Response": {
+        "user.ResponseUpdateUser": {
             "type": "object",
             "properties": {
                 "data": {
-                    "$ref": "#/definitions/datamodel.QueryModel"
+                    "$ref": "#/definitions/datamodel.UserModel"
                 },
                 "errorCode": {
                     "type": "integer"
@@ -1796,11 +7341,11 @@ const docTemplate = `{
                 }
             }
         },
-        "query.ResponseRun": {
+        "user.ResponseUploadAvatar": {
             "type": "object",
             "properties": {
                 "data": {
-                    "$ref": "#/definitions/query.ResponseRunData"
+                    "$ref": "#/definitions/user.ResponseUploadAvatarData"
                 },
                 "errorCode": {
                     "type": "integer"
@@ -1813,85 +7358,103 @@ const docTemplate = `{
                 }
             }
         },
-        "query.ResponseRunData": {
+        "user.ResponseUploadAvatarData": {
             "type": "object",
             "properties": {
-                "rows": {
-                    "type": "array",
-                    "items": {
-                        "type": "object",
-                        "additionalProperties": true
-                    }
+                "filesize": {
+                    "type": "integer"
                 },
-                "schemas": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/dataengine.FieldSchema"
-                    }
+                "key": {
+                    "type": "string"
                 }
             }
         },
-        "user.RequestCreateAccount": {
+        "webhook.RequestCreateWebhook": {
             "type": "object",
             "properties": {
-                "email": {
-                    "type": "string"
+                "enabled": {
+                    "description": "Enabled is true by default.",
+                    "type": "boolean"
                 },
-                "password": {
-                    "type": "string"
+                "events": {
+                    "description": "Events are the subscribed events, e.g. query.finished and dashboard.published.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "provider": {
+                "name": {
                     "type": "string"
                 },
-                "username": {
+                "url": {
+                    "description": "URL is the http or https url which the events are posted to.",
                     "type": "string"
                 }
             }
         },
-        "user.RequestLogin": {
+        "webhook.RequestUpdateWebhook": {
             "type": "object",
             "properties": {
-                "email": {
-                    "type": "string"
+                "enabled": {
+                    "type": "boolean"
                 },
-                "password": {
-                    "type": "string"
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "provider": {
+                "name": {
                     "type": "string"
                 },
-                "userId": {
+                "rotate_secret": {
+                    "description": "RotateSecret generates a new secret, it is responded once.",
+                    "type": "boolean"
+                },
+                "url": {
                     "type": "string"
                 }
             }
         },
-        "user.RequestUpdateEmail": {
+        "webhook.ResponseListDeliveries": {
             "type": "object",
             "properties": {
-                "new_email": {
+                "data": {
+                    "$ref": "#/definitions/webhook.ResponseListDeliveriesData"
+                },
+                "errorCode": {
+                    "type": "integer"
+                },
+                "errorMessage": {
                     "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
                 }
             }
         },
-        "user.RequestUpdatePassword": {
+        "webhook.ResponseListDeliveriesData": {
             "type": "object",
             "properties": {
-                "current_password": {
-                    "type": "string"
+                "deliveries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/datamodel.WebhookDeliveryModel"
+                    }
                 },
-                "new_password": {
-                    "type": "string"
+                "total": {
+                    "type": "integer"
                 }
             }
         },
-        "user.ResponseCreateAccount": {
-            "type": "object"
-        },
-        "user.ResponseGetUser": {
+        "webhook.ResponseListWebhooks": {
             "type": "object",
             "properties": {
                 "data": {
-                    "$ref": "#/definitions/user.ResponseGetUserData"
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/datamodel.WebhookModel"
+                    }
                 },
                 "errorCode": {
                     "type": "integer"
@@ -1904,81 +7467,177 @@ const docTemplate = `{
                 }
             }
         },
-        "user.ResponseGetUserData": {
+        "webhook.ResponseWebhook": {
             "type": "object",
             "properties": {
-                "bio": {
-                    "type": "string"
+                "data": {
+                    "$ref": "#/definitions/webhook.ResponseWebhookData"
                 },
-                "confirmed_at": {
+                "errorCode": {
+                    "type": "integer"
+                },
+                "errorMessage": {
                     "type": "string"
                 },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "webhook.ResponseWebhookData": {
+            "type": "object",
+            "properties": {
                 "created_at": {
                     "type": "string"
                 },
-                "dashboards": {
+                "enabled": {
+                    "type": "boolean"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
                     "type": "integer"
                 },
-                "discord": {
+                "name": {
                     "type": "string"
                 },
-                "email": {
+                "secret": {
+                    "description": "Secret signs the payloads, it is only responded when it is created or rotated.",
                     "type": "string"
                 },
-                "encrypted_password": {
+                "updated_at": {
                     "type": "string"
                 },
-                "github": {
+                "url": {
                     "type": "string"
                 },
-                "icon_url": {
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "workspace.RequestAddMember": {
+            "type": "object",
+            "properties": {
+                "role": {
+                    "description": "Role is one of owner, editor and viewer.",
                     "type": "string"
                 },
-                "id": {
+                "user_id": {
+                    "description": "UserID is the user to add, the user is found by Username if it is 0.",
+                    "type": "integer"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "workspace.RequestUpdateMember": {
+            "type": "object",
+            "properties": {
+                "role": {
+                    "description": "Role is one of owner, editor and viewer.",
+                    "type": "string"
+                }
+            }
+        },
+        "workspace.RequestWorkspace": {
+            "type": "object",
+            "properties": {
+                "description": {
                     "type": "string"
                 },
-                "location": {
+                "name": {
                     "type": "string"
+                }
+            }
+        },
+        "workspace.ResponseListMembers": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/workspace.ResponseMemberData"
+                    }
                 },
-                "queries": {
+                "errorCode": {
                     "type": "integer"
                 },
-                "stars": {
+                "errorMessage": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "workspace.ResponseListWorkspaces": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/workspace.ResponseWorkspaceData"
+                    }
+                },
+                "errorCode": {
                     "type": "integer"
                 },
-                "telgram": {
+                "errorMessage": {
                     "type": "string"
                 },
-                "twitter": {
-                    "type": "string"
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "workspace.ResponseMember": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/datamodel.WorkspaceMemberModel"
                 },
-                "uid": {
-                    "type": "string"
+                "errorCode": {
+                    "type": "integer"
                 },
-                "updated_at": {
+                "errorMessage": {
                     "type": "string"
                 },
-                "username": {
-                    "type": "string"
+                "success": {
+                    "type": "boolean"
                 }
             }
         },
-        "user.ResponseLogin": {
+        "workspace.ResponseMemberData": {
             "type": "object",
             "properties": {
-                "algorithm": {
+                "created_at": {
                     "type": "string"
                 },
-                "token": {
+                "icon_url": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "username": {
                     "type": "string"
                 }
             }
         },
-        "user.ResponseUpdateUser": {
+        "workspace.ResponseStatistics": {
             "type": "object",
             "properties": {
                 "data": {
-                    "$ref": "#/definitions/datamodel.UserModel"
+                    "$ref": "#/definitions/datamodel.WorkspaceStatistics"
                 },
                 "errorCode": {
                     "type": "integer"
@@ -1991,11 +7650,11 @@ const docTemplate = `{
                 }
             }
         },
-        "user.ResponseUploadAvatar": {
+        "workspace.ResponseWorkspace": {
             "type": "object",
             "properties": {
                 "data": {
-                    "$ref": "#/definitions/user.ResponseUploadAvatarData"
+                    "$ref": "#/definitions/workspace.ResponseWorkspaceData"
                 },
                 "errorCode": {
                     "type": "integer"
@@ -2008,14 +7667,29 @@ const docTemplate = `{
                 }
             }
         },
-        "user.ResponseUploadAvatarData": {
+        "workspace.ResponseWorkspaceData": {
             "type": "object",
             "properties": {
-                "filesize": {
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
                     "type": "integer"
                 },
-                "key": {
+                "name": {
                     "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
                 }
             }
         }
@@ -0,0 +1,51 @@
+package base
+
+import (
+	"context"
+	"errors"
+)
+
+// The scopes of api key, a route accepts the api key which has its scope.
+const (
+	ScopeQueryRun      = "query:run"
+	ScopeDashboardRead = "dashboard:read"
+)
+
+// ApiKeyScopes is all scopes of api key.
+var ApiKeyScopes = []string{ScopeQueryRun, ScopeDashboardRead}
+
+// ApiKeyHeader is the header of api key.
+const ApiKeyHeader = "X-API-Key"
+
+// ErrInvalidApiKey is returned when the api key is unknown, revoked or expired.
+var ErrInvalidApiKey = errors.New("invalid api key")
+
+// ApiKey is the identity of a verified api key.
+type ApiKey struct {
+	ID     uint
+	UserID uint
+	Scopes []string
+}
+
+// HasScope reports whether the key has the scope.
+func (k *ApiKey) HasScope(scope string) bool {
+	for _, v := range k.Scopes {
+		if v == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// ApiKeyVerifier verifies the api key in request.
+type ApiKeyVerifier interface {
+	VerifyApiKey(ctx context.Context, key string) (*ApiKey, error)
+}
+
+var apiKeyVerifier ApiKeyVerifier
+
+// SetupApiKeyVerifier sets the verifier used by auth middlewares, the api
+// keys are rejected if the verifier is nil.
+func SetupApiKeyVerifier(verifier ApiKeyVerifier) {
+	apiKeyVerifier = verifier
+}
@@ -0,0 +1,227 @@
+package base
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+)
+
+// auditIgnoredFields are changed by every update, so they are not recorded. The
+// fields are matched case-insensitively as some models are tagged as updated_At.
+var auditIgnoredFields = []string{"updated_at"}
+
+func isAuditIgnoredField(field string) bool {
+	for _, ignored := range auditIgnoredFields {
+		if strings.EqualFold(field, ignored) {
+			return true
+		}
+	}
+	return false
+}
+
+// AuditRecord is an action to be recorded in audit log.
+type AuditRecord struct {
+	// ActorID is the user who takes the action, it is the current user if empty.
+	ActorID    uint
+	Action     string
+	TargetType string
+	TargetID   uint
+	// Before and After are the target before and after the action, either of them can
+	// be nil. Only the changed fields are recorded if both are set.
+	Before any
+	After  any
+}
+
+// AuditFilter filters audit logs, the empty fields are ignored.
+type AuditFilter struct {
+	ActorID    uint
+	Action     string
+	TargetType string
+	TargetID   uint
+	From       *time.Time
+	To         *time.Time
+	Page       uint
+	PageSize   uint
+}
+
+// ResponseAuditLogsData is data of response of GET /admin/audit and GET /user/activity
+type ResponseAuditLogsData struct {
+	Logs  []datamodel.AuditLogModel `json:"logs"`
+	Total int64                     `json:"total"`
+}
+
+// ResponseAuditLogs is response of GET /admin/audit and GET /user/activity
+type ResponseAuditLogs struct {
+	BaseResponse
+	Data ResponseAuditLogsData `json:"data"`
+}
+
+func toAuditJSON(v any) (datamodel.JSON, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields datamodel.JSON
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// AuditDiff converts before and after to json objects, and removes the unchanged
+// fields if both of them are set.
+func AuditDiff(before any, after any) (datamodel.JSON, datamodel.JSON, error) {
+	b, err := toAuditJSON(before)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	a, err := toAuditJSON(after)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if b == nil || a == nil {
+		return b, a, nil
+	}
+
+	for field, value := range b {
+		if other, ok := a[field]; isAuditIgnoredField(field) || ok && reflect.DeepEqual(value, other) {
+			delete(b, field)
+			delete(a, field)
+		}
+	}
+	for field := range a {
+		if isAuditIgnoredField(field) {
+			delete(a, field)
+		}
+	}
+
+	return b, a, nil
+}
+
+// Audit records the action with the client of request, the actor is the current
+// user if the record has none. It is a post-commit side effect.
+func Audit(ctx *gin.Context, db *gorm.DB, record *AuditRecord) {
+	actorId := record.ActorID
+	if actorId == 0 {
+		actorId = GetCurrentUserIdOrGuest(ctx)
+	}
+
+	before, after, err := AuditDiff(record.Before, record.After)
+	if err != nil {
+		log.Printf("Error diff audit log %s of %s %d: %v", record.Action, record.TargetType, record.TargetID, err)
+	}
+
+	if err := db.Create(&datamodel.AuditLogModel{
+		ActorID:    actorId,
+		Action:     record.Action,
+		TargetType: record.TargetType,
+		TargetID:   record.TargetID,
+		IP:         ctx.ClientIP(),
+		UserAgent:  ctx.Request.UserAgent(),
+		Before:     before,
+		After:      after,
+		CreatedAt:  time.Now(),
+	}).Error; err != nil {
+		log.Printf("Error record audit log %s of %s %d: %v", record.Action, record.TargetType, record.TargetID, err)
+	}
+}
+
+// GetAuditFilter parses the audit filter from queries, from and to are RFC3339 times.
+func GetAuditFilter(ctx *gin.Context) (*AuditFilter, error) {
+	filter := AuditFilter{
+		Action:     ctx.Query("action"),
+		TargetType: ctx.Query("target_type"),
+	}
+
+	uints := map[string]*uint{
+		"actor_id":  &filter.ActorID,
+		"target_id": &filter.TargetID,
+	}
+	for name, value := range uints {
+		v, err := GetUIntQuery(ctx, name)
+		if err != nil {
+			if err == ErrQueryNotFound {
+				continue
+			}
+			return nil, err
+		}
+		*value = v
+	}
+
+	page, pageSize, err := GetPage(ctx, 10)
+	if err != nil {
+		return nil, err
+	}
+	filter.Page, filter.PageSize = uint(page), uint(pageSize)
+
+	times := map[string]**time.Time{
+		"from": &filter.From,
+		"to":   &filter.To,
+	}
+	for name, value := range times {
+		if len(ctx.Query(name)) == 0 {
+			continue
+		}
+
+		t, err := time.Parse(time.RFC3339, ctx.Query(name))
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", name, err)
+		}
+		*value = &t
+	}
+
+	return &filter, nil
+}
+
+// ListAuditLogs lists the audit logs by filter, the latest logs are listed first.
+func ListAuditLogs(db *gorm.DB, filter *AuditFilter) ([]datamodel.AuditLogModel, int64, error) {
+	tx := db.Model(&datamodel.AuditLogModel{})
+	if filter.ActorID != 0 {
+		tx = tx.Where("actor_id = ?", filter.ActorID)
+	}
+	if len(filter.Action) > 0 {
+		tx = tx.Where("action = ?", filter.Action)
+	}
+	if len(filter.TargetType) > 0 {
+		tx = tx.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != 0 {
+		tx = tx.Where("target_id = ?", filter.TargetID)
+	}
+	if filter.From != nil {
+		tx = tx.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		tx = tx.Where("created_at < ?", *filter.To)
+	}
+
+	var total int64
+	if err := tx.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	logs := make([]datamodel.AuditLogModel, 0)
+	if err := tx.Order("id DESC").
+		Offset(int((filter.Page - 1) * filter.PageSize)).
+		Limit(int(filter.PageSize)).
+		Find(&logs).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return logs, total, nil
+}
@@ -0,0 +1,34 @@
+package base_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"infra-3.xyz/hyperdot-node/internal/apis/base"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+)
+
+func TestAuditDiff(t *testing.T) {
+	before := datamodel.DashboardModel{ID: 1, Name: "old", Description: "same", UpdatedAt: time.Now()}
+	after := before
+	after.Name = "new"
+	after.UpdatedAt = time.Now().Add(time.Minute)
+
+	b, a, err := base.AuditDiff(&before, &after)
+	assert.Nil(t, err)
+	assert.Equal(t, datamodel.JSON{"name": "old"}, b)
+	assert.Equal(t, datamodel.JSON{"name": "new"}, a)
+
+	// the whole target is recorded if it is created or deleted
+	b, a, err = base.AuditDiff(nil, &after)
+	assert.Nil(t, err)
+	assert.Nil(t, b)
+	assert.Equal(t, "same", a["description"])
+
+	b, a, err = base.AuditDiff(map[string]string{"email": "a@email.com"}, map[string]string{"email": "b@email.com"})
+	assert.Nil(t, err)
+	assert.Equal(t, datamodel.JSON{"email": "a@email.com"}, b)
+	assert.Equal(t, datamodel.JSON{"email": "b@email.com"}, a)
+}
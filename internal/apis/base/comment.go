@@ -0,0 +1,23 @@
+package base
+
+import (
+	"gorm.io/gorm"
+
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+)
+
+// DeleteComments deletes the comments of targets and their mentions, it should be
+// called in the transaction deleting the targets.
+func DeleteComments(tx *gorm.DB, targetType string, targetIds ...uint) error {
+	if len(targetIds) == 0 {
+		return nil
+	}
+
+	comments := tx.Model(&datamodel.CommentModel{}).Select("id").
+		Where("target_type = ? AND target_id IN ?", targetType, targetIds)
+	if err := tx.Where("comment_id IN (?)", comments).Delete(&datamodel.CommentMentionModel{}).Error; err != nil {
+		return err
+	}
+
+	return tx.Where("target_type = ? AND target_id IN ?", targetType, targetIds).Delete(&datamodel.CommentModel{}).Error
+}
@@ -6,11 +6,16 @@ import (
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
 )
 
 // ErrQueryNotFound is returned when the query is not found.
 var ErrQueryNotFound = errors.New("query not found")
 
+// GuestUserId is the user id of the visitor not login.
+const GuestUserId uint = 0
+
 // GetCurrentUser get current login user id
 func GetCurrentUserId(ctx *gin.Context) (uint, error) {
 	v, ok := ctx.Get("user_id")
@@ -25,6 +30,56 @@ func GetCurrentUserId(ctx *gin.Context) (uint, error) {
 	return currentLoginUserId, nil
 }
 
+// GetCurrentSessionId get the login session id of current token, it returns
+// 0 if the token is not issued by a login session.
+func GetCurrentSessionId(ctx *gin.Context) uint {
+	v, ok := ctx.Get("session_id")
+	if !ok {
+		return 0
+	}
+
+	sessionId, _ := v.(uint)
+	return sessionId
+}
+
+// GetCurrentApiKeyId get the api key id of current request, it returns
+// 0 if the request is not authenticated by api key.
+func GetCurrentApiKeyId(ctx *gin.Context) uint {
+	v, ok := ctx.Get("api_key_id")
+	if !ok {
+		return 0
+	}
+
+	apiKeyId, _ := v.(uint)
+	return apiKeyId
+}
+
+// GetCurrentUserRole get the role of current login user, it returns
+// datamodel.RoleUser if the role is not in token, e.g. the api keys.
+func GetCurrentUserRole(ctx *gin.Context) string {
+	v, ok := ctx.Get("role")
+	if !ok {
+		return datamodel.RoleUser
+	}
+
+	role, _ := v.(string)
+	if len(role) == 0 {
+		return datamodel.RoleUser
+	}
+	return role
+}
+
+// GetCurrentUserIdOrGuest get current login user id, it returns
+// GuestUserId if the user not login.
+func GetCurrentUserIdOrGuest(ctx *gin.Context) uint {
+	userId, err := GetCurrentUserId(ctx)
+	if err != nil {
+		return GuestUserId
+	}
+
+	return userId
+}
+
 // GetUintParam get uint param from gin context
 func GetUintParam(ctx *gin.Context, key string) (uint, error) {
 	v := ctx.Param(key)
@@ -74,6 +129,31 @@ func GetUIntQuery(ctx *gin.Context, key string) (uint, error) {
 
 }
 
+// maxPageSize is the most items listed in a page.
+const maxPageSize = 100
+
+// GetPage gets the page and page_size queries of list, the page is 1 and the page
+// size is defaultPageSize if they are not given. The page size is at most 100.
+func GetPage(ctx *gin.Context, defaultPageSize uint) (int, int, error) {
+	page, pageSize := uint(1), defaultPageSize
+	for name, value := range map[string]*uint{"page": &page, "page_size": &pageSize} {
+		v, err := GetUIntQuery(ctx, name)
+		if err != nil {
+			if err == ErrQueryNotFound {
+				continue
+			}
+			return 0, 0, err
+		}
+		*value = v
+	}
+
+	if page == 0 || pageSize == 0 || pageSize > maxPageSize {
+		return 0, 0, errors.New("invalid page or page_size")
+	}
+
+	return int(page), int(pageSize), nil
+}
+
 // GetUIntQueryRequired get uint query from gin context.
 // If the query is not found, return error.
 func GetUIntQueryRequired(ctx *gin.Context, key string) (uint, error) {
@@ -0,0 +1,40 @@
+package base_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"infra-3.xyz/hyperdot-node/internal/apis/base"
+)
+
+func TestGetPage(t *testing.T) {
+	cases := []struct {
+		query    string
+		page     int
+		pageSize int
+		invalid  bool
+	}{
+		{query: "", page: 1, pageSize: 20},
+		{query: "page=3&page_size=50", page: 3, pageSize: 50},
+		{query: "page=0", invalid: true},
+		{query: "page_size=101", invalid: true},
+		{query: "page=abc", invalid: true},
+	}
+
+	for _, c := range cases {
+		ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		ctx.Request = httptest.NewRequest("GET", "/?"+c.query, nil)
+
+		page, pageSize, err := base.GetPage(ctx, 20)
+		if c.invalid {
+			assert.NotNil(t, err, c.query)
+			continue
+		}
+		assert.Nil(t, err, c.query)
+		assert.Equal(t, c.page, page, c.query)
+		assert.Equal(t, c.pageSize, pageSize, c.query)
+	}
+}
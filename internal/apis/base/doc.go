@@ -0,0 +1,10 @@
+// Package base provides the helpers shared by the api services, e.g. the responses,
+// authentication, access checks and rate limits.
+//
+// # Post-commit side effects
+//
+// Audit, Notify and FireWebhooks record the side effects of an action. They are called
+// after the transaction of the action is committed, so that nothing is recorded for a
+// rolled back action, and their errors are logged only, so that a committed action is
+// never reported as failed because of its side effects.
+package base
@@ -1,24 +1,294 @@
 package base
 
 import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"sort"
+	"sync/atomic"
 	"time"
 
 	jwt "github.com/golang-jwt/jwt/v5"
 
+	"infra-3.xyz/hyperdot-node/internal/common"
 	"infra-3.xyz/hyperdot-node/internal/datamodel"
 )
 
 const (
 	TokenIssuer         = "hyperdot"
 	TokenDefaultSubject = "hyperdot-fronted"
-	todoSecret          = "hyperdot"
+
+	// AccessTokenTTL is the lifetime of access token, the token is renewed
+	// by refresh token of the session after it expires.
+	AccessTokenTTL = time.Minute * 15
+	// RefreshTokenTTL is the lifetime of login session.
+	RefreshTokenTTL = time.Hour * 24 * 30
 )
 
 // TokenDefaultExpireTime returns the default expire time of a token.
 func TokenDefaultExpireTime() time.Time {
-	return time.Now().Add(time.Hour * 24)
+	return time.Now().Add(AccessTokenTTL)
+}
+
+// RefreshTokenDefaultExpireTime returns the default expire time of a refresh token.
+func RefreshTokenDefaultExpireTime() time.Time {
+	return time.Now().Add(RefreshTokenTTL)
+}
+
+// JwtKey is a key to sign or verify jwt token.
+type JwtKey struct {
+	Kid       string
+	Method    jwt.SigningMethod
+	SignKey   any // nil if the key only verifies tokens
+	VerifyKey any
+}
+
+// JwtKeySet is the keys to sign and verify jwt tokens.
+type JwtKeySet struct {
+	signing *JwtKey
+	keys    map[string]*JwtKey
+}
+
+// Jwk is a public key in json web key set.
+type Jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+// Jwks is the json web key set of the public keys.
+type Jwks struct {
+	Keys []Jwk `json:"keys"`
+}
+
+var jwtKeys atomic.Pointer[JwtKeySet]
+
+func init() {
+	keys, err := NewRandomJwtKeySet()
+	if err != nil {
+		panic(err)
+	}
+	jwtKeys.Store(keys)
+}
+
+// NewRandomJwtKeySet creates a key set with a random HS256 secret.
+func NewRandomJwtKeySet() (*JwtKeySet, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+
+	key := &JwtKey{
+		Kid:       "default",
+		Method:    jwt.SigningMethodHS256,
+		SignKey:   secret,
+		VerifyKey: secret,
+	}
+	return &JwtKeySet{
+		signing: key,
+		keys:    map[string]*JwtKey{key.Kid: key},
+	}, nil
+}
+
+func readPEM(value string, file string) ([]byte, error) {
+	if len(value) > 0 {
+		return []byte(value), nil
+	}
+
+	if len(file) > 0 {
+		return os.ReadFile(file)
+	}
+
+	return nil, nil
+}
+
+func newJwtKey(cfg *common.JwtKeyConfig) (*JwtKey, error) {
+	key := &JwtKey{Kid: cfg.Kid}
+	privatePEM, err := readPEM(cfg.PrivateKey, cfg.PrivateKeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	publicPEM, err := readPEM(cfg.PublicKey, cfg.PublicKeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	switch cfg.Algorithm {
+	case "HS256":
+		if len(cfg.Secret) == 0 {
+			return nil, errors.New("secret is required")
+		}
+		key.Method = jwt.SigningMethodHS256
+		key.SignKey = []byte(cfg.Secret)
+		key.VerifyKey = []byte(cfg.Secret)
+
+	case "RS256":
+		key.Method = jwt.SigningMethodRS256
+		if privatePEM != nil {
+			privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(privatePEM)
+			if err != nil {
+				return nil, err
+			}
+			key.SignKey = privateKey
+			key.VerifyKey = &privateKey.PublicKey
+		}
+		if publicPEM != nil {
+			if key.VerifyKey, err = jwt.ParseRSAPublicKeyFromPEM(publicPEM); err != nil {
+				return nil, err
+			}
+		}
+
+	case "EdDSA":
+		key.Method = jwt.SigningMethodEdDSA
+		if privatePEM != nil {
+			privateKey, err := jwt.ParseEdPrivateKeyFromPEM(privatePEM)
+			if err != nil {
+				return nil, err
+			}
+			key.SignKey = privateKey
+			key.VerifyKey = privateKey.(crypto.Signer).Public()
+		}
+		if publicPEM != nil {
+			if key.VerifyKey, err = jwt.ParseEdPublicKeyFromPEM(publicPEM); err != nil {
+				return nil, err
+			}
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %s", cfg.Algorithm)
+	}
+
+	if key.VerifyKey == nil {
+		return nil, errors.New("private key or public key is required")
+	}
+
+	return key, nil
+}
+
+// NewJwtKeySet creates a key set by config.
+func NewJwtKeySet(cfg *common.JwtConfig) (*JwtKeySet, error) {
+	keys := &JwtKeySet{
+		keys: make(map[string]*JwtKey, len(cfg.Keys)),
+	}
+
+	for i := range cfg.Keys {
+		if len(cfg.Keys[i].Kid) == 0 {
+			return nil, fmt.Errorf("jwt key %d: kid is required", i)
+		}
+
+		if _, ok := keys.keys[cfg.Keys[i].Kid]; ok {
+			return nil, fmt.Errorf("jwt key %s: duplicate kid", cfg.Keys[i].Kid)
+		}
+
+		key, err := newJwtKey(&cfg.Keys[i])
+		if err != nil {
+			return nil, fmt.Errorf("jwt key %s: %w", cfg.Keys[i].Kid, err)
+		}
+		keys.keys[key.Kid] = key
+	}
+
+	signing, ok := keys.keys[cfg.SigningKey]
+	if !ok {
+		return nil, fmt.Errorf("jwt signing key %s not found", cfg.SigningKey)
+	}
+
+	if signing.SignKey == nil {
+		return nil, fmt.Errorf("jwt signing key %s: private key is required", cfg.SigningKey)
+	}
+	keys.signing = signing
+
+	return keys, nil
+}
+
+// SetupJwtKeys replaces the keys to sign and verify tokens by config,
+// it keeps the random key if no key is configured.
+func SetupJwtKeys(cfg *common.JwtConfig) error {
+	if len(cfg.Keys) == 0 {
+		log.Printf("No jwt key configured, the tokens are signed by a random secret")
+		return nil
+	}
+
+	keys, err := NewJwtKeySet(cfg)
+	if err != nil {
+		return err
+	}
+
+	jwtKeys.Store(keys)
+	return nil
+}
+
+// JwtSigningAlgorithm returns the algorithm of signing key.
+func JwtSigningAlgorithm() string {
+	return jwtKeys.Load().signing.Method.Alg()
+}
+
+// Sign signs the claims by signing key.
+func (k *JwtKeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(k.signing.Method, claims)
+	token.Header["kid"] = k.signing.Kid
+	return token.SignedString(k.signing.SignKey)
+}
+
+// Keyfunc returns the verification key by the kid of token.
+func (k *JwtKeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	key := k.signing
+	if kid, ok := token.Header["kid"].(string); ok {
+		if key, ok = k.keys[kid]; !ok {
+			return nil, fmt.Errorf("unknown key %s", kid)
+		}
+	}
+
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
+	}
+
+	return key.VerifyKey, nil
+}
+
+// Jwks returns the public keys of asymmetric algorithms.
+func (k *JwtKeySet) Jwks() Jwks {
+	jwks := Jwks{Keys: []Jwk{}}
+	for _, key := range k.keys {
+		jwk := Jwk{
+			Kid: key.Kid,
+			Use: "sig",
+			Alg: key.Method.Alg(),
+		}
+
+		switch v := key.VerifyKey.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(v.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(v.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(v)
+		default:
+			continue
+		}
+
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	sort.Slice(jwks.Keys, func(i, j int) bool {
+		return jwks.Keys[i].Kid < jwks.Keys[j].Kid
+	})
+
+	return jwks
 }
 
 // GenerateJwtToken generates a jwt token.
@@ -29,26 +299,50 @@ func GenerateJwtToken(claims *datamodel.UserClaims, expireAt time.Time) (string,
 		ExpiresAt: jwt.NewNumericDate(expireAt),
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(todoSecret)) // TODO: use generate
+	return jwtKeys.Load().Sign(claims)
 }
 
 // VerifyJwtToken verifies a jwt token.
 func VerifyJwtToken(tokenString string) (*datamodel.UserClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &datamodel.UserClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(todoSecret), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &datamodel.UserClaims{}, jwtKeys.Load().Keyfunc)
+	if err != nil {
+		return nil, err
+	}
+
+	// the action tokens are signed by the same keys but not for login
+	if claims, ok := token.Claims.(*datamodel.UserClaims); ok && token.Valid && claims.Subject == TokenDefaultSubject {
+		return claims, nil
+	}
+
+	return nil, errors.New("invalid token")
+}
+
+// GenerateActionToken generates a jwt token for the action of subject.
+func GenerateActionToken(claims *datamodel.ActionClaims, subject string, expireAt time.Time) (string, error) {
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		Issuer:    TokenIssuer,
+		Subject:   subject,
+		ExpiresAt: jwt.NewNumericDate(expireAt),
+	}
 
+	return jwtKeys.Load().Sign(claims)
+}
+
+// VerifyActionToken verifies a jwt token for the action of subject.
+func VerifyActionToken(tokenString string, subject string) (*datamodel.ActionClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &datamodel.ActionClaims{}, jwtKeys.Load().Keyfunc)
 	if err != nil {
 		return nil, err
 	}
 
-	if claims, ok := token.Claims.(*datamodel.UserClaims); ok && token.Valid {
+	if claims, ok := token.Claims.(*datamodel.ActionClaims); ok && token.Valid && claims.Subject == subject {
 		return claims, nil
 	}
 
 	return nil, errors.New("invalid token")
 }
+
+// GetJwks returns the json web key set of current keys.
+func GetJwks() Jwks {
+	return jwtKeys.Load().Jwks()
+}
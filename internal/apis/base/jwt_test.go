@@ -0,0 +1,139 @@
+package base_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+
+	"infra-3.xyz/hyperdot-node/internal/apis/base"
+	"infra-3.xyz/hyperdot-node/internal/common"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+)
+
+func encodePEM(t *testing.T, typ string, key any) string {
+	var (
+		der []byte
+		err error
+	)
+	if typ == "PRIVATE KEY" {
+		der, err = x509.MarshalPKCS8PrivateKey(key)
+	} else {
+		der, err = x509.MarshalPKIXPublicKey(key)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}))
+}
+
+func newClaims() *datamodel.UserClaims {
+	return &datamodel.UserClaims{
+		UserID: 1,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}
+}
+
+func TestJwtKeySetRotation(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the tokens are signed by rs256 key before rotation
+	oldKeys, err := base.NewJwtKeySet(&common.JwtConfig{
+		SigningKey: "rsa",
+		Keys: []common.JwtKeyConfig{
+			{Kid: "rsa", Algorithm: "RS256", PrivateKey: encodePEM(t, "PRIVATE KEY", rsaKey)},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldToken, err := oldKeys.Sign(newClaims())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// rotate to eddsa key, and rs256 key only verifies tokens
+	newKeys, err := base.NewJwtKeySet(&common.JwtConfig{
+		SigningKey: "ed",
+		Keys: []common.JwtKeyConfig{
+			{Kid: "rsa", Algorithm: "RS256", PublicKey: encodePEM(t, "PUBLIC KEY", &rsaKey.PublicKey)},
+			{Kid: "ed", Algorithm: "EdDSA", PrivateKey: encodePEM(t, "PRIVATE KEY", edPrivate)},
+			{Kid: "hs", Algorithm: "HS256", Secret: "secret"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newToken, err := newKeys.Sign(newClaims())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, token := range []string{oldToken, newToken} {
+		if _, err := jwt.ParseWithClaims(token, &datamodel.UserClaims{}, newKeys.Keyfunc); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the new token can not be verified by old keys
+	if _, err := jwt.ParseWithClaims(newToken, &datamodel.UserClaims{}, oldKeys.Keyfunc); err == nil {
+		t.Fatal("token of unknown kid should be rejected")
+	}
+
+	// the token claims hs256 with kid of rsa key is rejected
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, newClaims())
+	forged.Header["kid"] = "rsa"
+	forgedToken, err := forged.SignedString([]byte(encodePEM(t, "PUBLIC KEY", &rsaKey.PublicKey)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := jwt.ParseWithClaims(forgedToken, &datamodel.UserClaims{}, newKeys.Keyfunc); err == nil {
+		t.Fatal("token of mismatched algorithm should be rejected")
+	}
+
+	jwks := newKeys.Jwks()
+	if len(jwks.Keys) != 2 {
+		t.Fatalf("invalid jwks: %v", jwks)
+	}
+
+	if jwks.Keys[0].Kid != "ed" || jwks.Keys[0].Kty != "OKP" || len(jwks.Keys[0].X) == 0 {
+		t.Fatalf("invalid ed25519 jwk: %v", jwks.Keys[0])
+	}
+
+	if jwks.Keys[1].Kid != "rsa" || jwks.Keys[1].Kty != "RSA" || jwks.Keys[1].E != "AQAB" {
+		t.Fatalf("invalid rsa jwk: %v", jwks.Keys[1])
+	}
+}
+
+func TestJwtKeySetInvalidConfig(t *testing.T) {
+	cases := []common.JwtConfig{
+		{SigningKey: "missing", Keys: []common.JwtKeyConfig{{Kid: "hs", Algorithm: "HS256", Secret: "secret"}}},
+		{SigningKey: "hs", Keys: []common.JwtKeyConfig{{Kid: "hs", Algorithm: "HS256"}}},
+		{SigningKey: "hs", Keys: []common.JwtKeyConfig{{Kid: "hs", Algorithm: "none", Secret: "secret"}}},
+		{SigningKey: "hs", Keys: []common.JwtKeyConfig{{Algorithm: "HS256", Secret: "secret"}}},
+	}
+
+	for i, cfg := range cases {
+		if _, err := base.NewJwtKeySet(&cfg); err == nil {
+			t.Fatalf("case %d: invalid config should be rejected", i)
+		}
+	}
+}
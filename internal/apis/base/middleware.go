@@ -1,40 +1,151 @@
 package base
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
-	"strings"
+	"regexp"
 
 	"github.com/gin-gonic/gin"
+
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
 )
 
-// JwtAuthMiddleware is a middleware to verify jwt token
-func JwtAuthMiddleware() func(ctx *gin.Context) {
-	return func(ctx *gin.Context) {
-		if ctx.Request.URL.Path == "/apis/v1/user/auth/login" ||
-			ctx.Request.URL.Path == "/apis/v1/user/auth/createAccount" ||
-			ctx.Request.URL.Path == "/apis/v1/file" ||
-			strings.Contains(ctx.Request.URL.Path, "swager") ||
-			strings.Contains(ctx.Request.URL.Path, "/apis/v1/file") {
-			ctx.Next()
-			return
+// ErrTokenRevoked is returned when the session of token has been revoked.
+var ErrTokenRevoked = errors.New("token has been revoked")
+
+// TokenRevocationList reports whether the session of a token has been revoked.
+type TokenRevocationList interface {
+	IsRevoked(ctx context.Context, sessionId uint) (bool, error)
+}
+
+var revocationList TokenRevocationList
+
+// SetupTokenRevocationList sets the list checked by auth middlewares, the tokens
+// are not checked if the list is nil.
+func SetupTokenRevocationList(list TokenRevocationList) {
+	revocationList = list
+}
+
+// authenticateApiKey verifies the api key which must have the scope of route.
+func authenticateApiKey(ctx *gin.Context, key string, scope string) (int, error) {
+	if len(scope) == 0 || apiKeyVerifier == nil {
+		return http.StatusForbidden, errors.New("api key is not allowed")
+	}
+
+	apiKey, err := apiKeyVerifier.VerifyApiKey(ctx.Request.Context(), key)
+	if err != nil {
+		if errors.Is(err, ErrInvalidApiKey) {
+			return http.StatusUnauthorized, err
 		}
+		return http.StatusInternalServerError, err
+	}
+
+	if !apiKey.HasScope(scope) {
+		return http.StatusForbidden, fmt.Errorf("api key requires scope %s", scope)
+	}
+
+	ctx.Set("user_id", apiKey.UserID)
+	ctx.Set("api_key_id", apiKey.ID)
+	return http.StatusOK, nil
+}
+
+// authenticate verifies the api key or jwt token in authorization header and sets
+// the current login user to context. It returns false if neither is present.
+func authenticate(ctx *gin.Context, apiKeyScope string) (bool, int, error) {
+	if key := ctx.Request.Header.Get(ApiKeyHeader); key != "" {
+		code, err := authenticateApiKey(ctx, key, apiKeyScope)
+		return true, code, err
+	}
+
+	authHeader := ctx.Request.Header.Get("authorization")
+	if authHeader == "" {
+		return false, http.StatusUnauthorized, nil
+	}
+
+	claims, err := VerifyJwtToken(authHeader)
+	if err != nil {
+		return true, http.StatusUnauthorized, err
+	}
+
+	// the tokens issued by login sessions are revoked by logout
+	if claims.SessionID != 0 && revocationList != nil {
+		revoked, err := revocationList.IsRevoked(ctx.Request.Context(), claims.SessionID)
+		if err != nil {
+			return true, http.StatusUnauthorized, err
+		}
+		if revoked {
+			return true, http.StatusUnauthorized, ErrTokenRevoked
+		}
+	}
+
+	ctx.Set("user_id", claims.UserID)
+	ctx.Set("session_id", claims.SessionID)
+	ctx.Set("username", claims.Username)
+	ctx.Set("role", claims.Role)
+	return true, http.StatusOK, nil
+}
 
-		authHeader := ctx.Request.Header.Get("authorization")
-		if authHeader == "" {
+// JwtAuthMiddleware is a middleware to verify jwt token, the api key which has
+// apiKeyScope is accepted too. The api keys are rejected if apiKeyScope is empty.
+func JwtAuthMiddleware(apiKeyScope string) func(ctx *gin.Context) {
+	return func(ctx *gin.Context) {
+		found, code, err := authenticate(ctx, apiKeyScope)
+		if !found {
 			ResponseErr(ctx, http.StatusUnauthorized, "invalid authorization")
 			ctx.Abort()
 			return
 		}
 
-		claims, err := VerifyJwtToken(authHeader)
 		if err != nil {
-			ResponseErr(ctx, http.StatusUnauthorized, err.Error())
+			ResponseErr(ctx, code, err.Error())
+			ctx.Abort()
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// JwtOptionalAuthMiddleware is a middleware to verify jwt token or api key if it is present,
+// the request without them is served as guest.
+func JwtOptionalAuthMiddleware(apiKeyScope string) func(ctx *gin.Context) {
+	return func(ctx *gin.Context) {
+		if _, code, err := authenticate(ctx, apiKeyScope); err != nil {
+			ResponseErr(ctx, code, err.Error())
+			ctx.Abort()
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// RoleMiddleware is a middleware to reject the login user whose role does not
+// include the required role, it must be used after the auth middlewares.
+func RoleMiddleware(role string) func(ctx *gin.Context) {
+	return func(ctx *gin.Context) {
+		if !datamodel.RoleIncludes(GetCurrentUserRole(ctx), role) {
+			ResponseErr(ctx, http.StatusForbidden, "permission denied, %s role is required", role)
+			ctx.Abort()
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// PathRegexpMiddleware is a middleware to reject the request whose path
+// does not match the regexp.
+func PathRegexpMiddleware(re *regexp.Regexp) func(ctx *gin.Context) {
+	return func(ctx *gin.Context) {
+		if !re.MatchString(ctx.Request.URL.Path) {
+			ResponseErr(ctx, http.StatusNotFound, "%s not found", ctx.Request.URL.Path)
 			ctx.Abort()
 			return
 		}
 
-		ctx.Set("user_id", claims.UserID)
-		ctx.Set("username", claims.Username)
 		ctx.Next()
 	}
 }
@@ -0,0 +1,141 @@
+package base_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"infra-3.xyz/hyperdot-node/internal/apis/base"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+)
+
+type revokedSessions map[uint]bool
+
+func (r revokedSessions) IsRevoked(ctx context.Context, sessionId uint) (bool, error) {
+	return r[sessionId], nil
+}
+
+func TestJwtAuthMiddlewareRevocation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	base.SetupTokenRevocationList(revokedSessions{2: true})
+	defer base.SetupTokenRevocationList(nil)
+
+	engine := gin.New()
+	engine.GET("/", base.JwtAuthMiddleware(""), func(ctx *gin.Context) {
+		base.ResponseSuccess(ctx)
+	})
+
+	cases := []struct {
+		sessionId uint
+		code      int
+	}{
+		{sessionId: 0, code: http.StatusOK},
+		{sessionId: 1, code: http.StatusOK},
+		{sessionId: 2, code: http.StatusUnauthorized},
+	}
+
+	for _, c := range cases {
+		token, err := base.GenerateJwtToken(&datamodel.UserClaims{UserID: 1, SessionID: c.sessionId}, base.TokenDefaultExpireTime())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.Header.Add("Authorization", token)
+		engine.ServeHTTP(w, req)
+		if w.Code != c.code {
+			t.Fatalf("session %d: expect %d, got %d", c.sessionId, c.code, w.Code)
+		}
+	}
+}
+
+type apiKeys map[string]*base.ApiKey
+
+func (k apiKeys) VerifyApiKey(ctx context.Context, key string) (*base.ApiKey, error) {
+	apiKey, ok := k[key]
+	if !ok {
+		return nil, base.ErrInvalidApiKey
+	}
+	return apiKey, nil
+}
+
+func TestJwtAuthMiddlewareApiKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	base.SetupApiKeyVerifier(apiKeys{
+		"reader": {ID: 1, UserID: 1, Scopes: []string{base.ScopeDashboardRead}},
+	})
+	defer base.SetupApiKeyVerifier(nil)
+
+	engine := gin.New()
+	engine.GET("/dashboard", base.JwtAuthMiddleware(base.ScopeDashboardRead), func(ctx *gin.Context) {
+		base.ResponseSuccess(ctx)
+	})
+	engine.GET("/query", base.JwtOptionalAuthMiddleware(base.ScopeQueryRun), func(ctx *gin.Context) {
+		base.ResponseSuccess(ctx)
+	})
+	engine.GET("/user", base.JwtAuthMiddleware(""), func(ctx *gin.Context) {
+		base.ResponseSuccess(ctx)
+	})
+
+	cases := []struct {
+		path string
+		key  string
+		code int
+	}{
+		{path: "/dashboard", key: "reader", code: http.StatusOK},
+		{path: "/dashboard", key: "unknown", code: http.StatusUnauthorized},
+		{path: "/query", key: "reader", code: http.StatusForbidden},
+		{path: "/query", key: "", code: http.StatusOK},
+		{path: "/user", key: "reader", code: http.StatusForbidden},
+	}
+
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", c.path, nil)
+		if len(c.key) > 0 {
+			req.Header.Add(base.ApiKeyHeader, c.key)
+		}
+		engine.ServeHTTP(w, req)
+		if w.Code != c.code {
+			t.Fatalf("%s with key %q: expect %d, got %d", c.path, c.key, c.code, w.Code)
+		}
+	}
+}
+
+func TestRoleMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	engine := gin.New()
+	engine.GET("/", base.JwtAuthMiddleware(""), base.RoleMiddleware(datamodel.RoleModerator), func(ctx *gin.Context) {
+		base.ResponseSuccess(ctx)
+	})
+
+	cases := []struct {
+		role string
+		code int
+	}{
+		{role: "", code: http.StatusForbidden},
+		{role: datamodel.RoleUser, code: http.StatusForbidden},
+		{role: datamodel.RoleModerator, code: http.StatusOK},
+		{role: datamodel.RoleAdmin, code: http.StatusOK},
+	}
+
+	for _, c := range cases {
+		token, err := base.GenerateJwtToken(&datamodel.UserClaims{UserID: 1, Role: c.role}, base.TokenDefaultExpireTime())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.Header.Add("Authorization", token)
+		engine.ServeHTTP(w, req)
+		if w.Code != c.code {
+			t.Fatalf("role %q: expect %d, got %d", c.role, c.code, w.Code)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package base
+
+import (
+	"log"
+
+	"gorm.io/gorm"
+
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+)
+
+// Notify sends the notifications to their recipients. A recipient gets at most one of
+// them, the earlier is kept, and the notifications to the actor or of the types disabled
+// by recipient are dropped. It is a post-commit side effect.
+func Notify(db *gorm.DB, notifications ...datamodel.NotificationModel) {
+	var userIds []uint
+	pending := make(map[uint]datamodel.NotificationModel)
+	for _, notification := range notifications {
+		if notification.UserID == 0 || notification.UserID == notification.ActorID {
+			continue
+		}
+		if _, ok := pending[notification.UserID]; ok {
+			continue
+		}
+
+		pending[notification.UserID] = notification
+		userIds = append(userIds, notification.UserID)
+	}
+
+	if len(userIds) == 0 {
+		return
+	}
+
+	var users []datamodel.UserModel
+	if err := db.Select("id", "notification_preferences").Where("id IN ?", userIds).Find(&users).Error; err != nil {
+		log.Printf("Error load notification preferences of users %v: %v", userIds, err)
+		return
+	}
+
+	var created []datamodel.NotificationModel
+	for _, user := range users {
+		notification := pending[user.ID]
+		if user.NotificationEnabled(notification.Type) {
+			created = append(created, notification)
+		}
+	}
+
+	if len(created) == 0 {
+		return
+	}
+
+	if err := db.Create(&created).Error; err != nil {
+		log.Printf("Error create %s notifications: %v", created[0].Type, err)
+	}
+}
@@ -0,0 +1,185 @@
+package base
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+)
+
+// CheckPublishPermission checks whether the user can publish the content, the public
+// content requires a verified email. It responses error and returns false if not allowed.
+func CheckPublishPermission(ctx *gin.Context, db *gorm.DB, userId uint, isPrivacy bool) bool {
+	if isPrivacy {
+		return true
+	}
+
+	var user datamodel.UserModel
+	if err := db.Select("id", "confirmed_at").Where("id = ?", userId).First(&user).Error; err != nil {
+		ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+		return false
+	}
+
+	if user.ConfirmedAt == nil {
+		ResponseErr(ctx, http.StatusForbidden, "verify your email before publishing public content")
+		return false
+	}
+
+	return true
+}
+
+// ContentAccess is the access of user to a query or dashboard.
+type ContentAccess int
+
+const (
+	// ContentAccessNone can not access the content.
+	ContentAccessNone ContentAccess = iota
+	// ContentAccessView can view the content.
+	ContentAccessView
+	// ContentAccessEdit can update the content.
+	ContentAccessEdit
+	// ContentAccessManage can also delete, move and publish the content, and share it with others.
+	ContentAccessManage
+)
+
+// Content is the ownership of a query or dashboard.
+type Content struct {
+	Type        string
+	ID          uint
+	UserID      uint
+	WorkspaceID uint
+	IsPrivacy   bool
+}
+
+// QueryContent returns the ownership of query.
+func QueryContent(query *datamodel.QueryModel) *Content {
+	return &Content{
+		Type:        datamodel.ContentTypeQuery,
+		ID:          query.ID,
+		UserID:      query.UserID,
+		WorkspaceID: query.WorkspaceID,
+		IsPrivacy:   query.IsPrivacy,
+	}
+}
+
+// DashboardContent returns the ownership of dashboard.
+func DashboardContent(dashboard *datamodel.DashboardModel) *Content {
+	return &Content{
+		Type:        datamodel.ContentTypeDashboard,
+		ID:          dashboard.ID,
+		UserID:      dashboard.UserID,
+		WorkspaceID: dashboard.WorkspaceID,
+		IsPrivacy:   dashboard.IsPrivacy,
+	}
+}
+
+// LoadContent loads the ownership of query or dashboard, it returns gorm.ErrRecordNotFound
+// if the content does not exist.
+func LoadContent(db *gorm.DB, contentType string, id uint) (*Content, error) {
+	tx := db.Select("id", "user_id", "workspace_id", "is_privacy").Where("id = ?", id)
+	switch contentType {
+	case datamodel.ContentTypeQuery:
+		tx = tx.Model(&datamodel.QueryModel{})
+	case datamodel.ContentTypeDashboard:
+		tx = tx.Model(&datamodel.DashboardModel{})
+	default:
+		return nil, fmt.Errorf("unsupported content type %s", contentType)
+	}
+
+	content := Content{Type: contentType}
+	result := tx.Limit(1).Scan(&content)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return &content, nil
+}
+
+// GetContentAccess returns the access of user to the content. The personal content is
+// managed by its owner, and the content of workspace is accessed by the members by their
+// roles. The content is also shared with the users granted by permissions. Anyone can view
+// the public content.
+func GetContentAccess(db *gorm.DB, userId uint, content *Content) (ContentAccess, error) {
+	access := ContentAccessNone
+	if !content.IsPrivacy {
+		access = ContentAccessView
+	}
+
+	if userId == GuestUserId {
+		return access, nil
+	}
+
+	if content.WorkspaceID == 0 {
+		if content.UserID == userId {
+			return ContentAccessManage, nil
+		}
+	} else {
+		role, err := GetWorkspaceRole(db, content.WorkspaceID, userId)
+		if err != nil {
+			return ContentAccessNone, err
+		}
+
+		if datamodel.WorkspaceRoleIncludes(role, datamodel.WorkspaceRoleEditor) {
+			return ContentAccessManage, nil
+		}
+		if datamodel.WorkspaceRoleIncludes(role, datamodel.WorkspaceRoleViewer) {
+			access = ContentAccessView
+		}
+	}
+
+	if len(content.Type) == 0 || content.ID == 0 {
+		return access, nil
+	}
+
+	var permission datamodel.ContentPermissionModel
+	err := db.Select("permission").
+		Where("content_type = ? AND content_id = ? AND user_id = ?", content.Type, content.ID, userId).
+		First(&permission).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return access, nil
+		}
+		return ContentAccessNone, err
+	}
+
+	switch permission.Permission {
+	case datamodel.PermissionEditor:
+		return ContentAccessEdit, nil
+	case datamodel.PermissionViewer:
+		if access < ContentAccessView {
+			return ContentAccessView, nil
+		}
+	}
+
+	return access, nil
+}
+
+// CheckContentAccess checks whether the current user has the access to content. It
+// responses error and returns false if not allowed.
+func CheckContentAccess(ctx *gin.Context, db *gorm.DB, content *Content, required ContentAccess) bool {
+	userId := GetCurrentUserIdOrGuest(ctx)
+	access, err := GetContentAccess(db, userId, content)
+	if err != nil {
+		ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+		return false
+	}
+
+	if access < required {
+		if userId == GuestUserId {
+			ResponseErr(ctx, http.StatusUnauthorized, "Unauthorized")
+			return false
+		}
+
+		ResponseErr(ctx, http.StatusForbidden, "permission denied")
+		return false
+	}
+
+	return true
+}
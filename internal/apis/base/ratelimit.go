@@ -0,0 +1,112 @@
+package base
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"infra-3.xyz/hyperdot-node/internal/cache"
+)
+
+// RateLimit is a token bucket which holds Limit requests and is refilled fully
+// in Period, so it allows bursts of Limit requests.
+type RateLimit struct {
+	Limit  int
+	Period time.Duration
+}
+
+// RateLimiter takes a token from the bucket of key.
+type RateLimiter interface {
+	Take(ctx context.Context, key string, limit int, period time.Duration) (*cache.RateLimitResult, error)
+}
+
+var rateLimiter RateLimiter
+
+// SetupRateLimiter sets the limiter used by RateLimitMiddleware, the requests
+// are not limited if it is nil.
+func SetupRateLimiter(limiter RateLimiter) {
+	rateLimiter = limiter
+}
+
+// ceilSeconds returns the whole seconds of d rounded up.
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+// SetRateLimitHeaders sets the RateLimit-* headers of the bucket.
+func SetRateLimitHeaders(ctx *gin.Context, result *cache.RateLimitResult) {
+	ctx.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+	ctx.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	ctx.Header("RateLimit-Reset", ceilSeconds(result.Reset))
+}
+
+// ResponseTooManyRequests responses 429 which tells the client to retry after
+// the duration.
+func ResponseTooManyRequests(ctx *gin.Context, retryAfter time.Duration, format string, args ...any) {
+	if retryAfter < time.Second {
+		retryAfter = time.Second
+	}
+
+	ctx.Header("Retry-After", ceilSeconds(retryAfter))
+	ResponseErr(ctx, http.StatusTooManyRequests, format, args...)
+}
+
+// RateLimitMiddleware is a middleware to limit the requests of route by the
+// bucket of client ip and the bucket of login user, either limit can be nil.
+// It must be used after the auth middlewares, the guests are only limited by ip.
+// The requests are not limited if the limiter fails.
+func RateLimitMiddleware(route string, ipLimit *RateLimit, userLimit *RateLimit) func(ctx *gin.Context) {
+	return func(ctx *gin.Context) {
+		if rateLimiter == nil {
+			ctx.Next()
+			return
+		}
+
+		buckets := map[string]*RateLimit{}
+		if ipLimit != nil {
+			buckets[fmt.Sprintf("%s:ip:%s", route, ctx.ClientIP())] = ipLimit
+		}
+		if userId := GetCurrentUserIdOrGuest(ctx); userLimit != nil && userId != GuestUserId {
+			buckets[fmt.Sprintf("%s:user:%d", route, userId)] = userLimit
+		}
+
+		// the most restrictive bucket is reported in headers
+		var reported *cache.RateLimitResult
+		for key, limit := range buckets {
+			result, err := rateLimiter.Take(ctx.Request.Context(), key, limit.Limit, limit.Period)
+			if err != nil {
+				log.Printf("Error take rate limit of %s: %v", key, err)
+				continue
+			}
+
+			switch {
+			case reported == nil:
+				reported = result
+			case !result.Allowed && (reported.Allowed || result.RetryAfter > reported.RetryAfter):
+				reported = result
+			case reported.Allowed && result.Allowed && result.Remaining < reported.Remaining:
+				reported = result
+			}
+		}
+
+		if reported == nil {
+			ctx.Next()
+			return
+		}
+
+		SetRateLimitHeaders(ctx, reported)
+		if !reported.Allowed {
+			ResponseTooManyRequests(ctx, reported.RetryAfter, "too many requests, retry after %ss", ceilSeconds(reported.RetryAfter))
+			ctx.Abort()
+			return
+		}
+
+		ctx.Next()
+	}
+}
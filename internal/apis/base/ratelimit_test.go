@@ -0,0 +1,84 @@
+package base_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"infra-3.xyz/hyperdot-node/internal/apis/base"
+	"infra-3.xyz/hyperdot-node/internal/cache"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+)
+
+// fixedBuckets counts the requests of each key without refilling.
+type fixedBuckets map[string]int
+
+func (b fixedBuckets) Take(ctx context.Context, key string, limit int, period time.Duration) (*cache.RateLimitResult, error) {
+	b[key] += 1
+	result := &cache.RateLimitResult{
+		Allowed:   b[key] <= limit,
+		Limit:     limit,
+		Remaining: limit - b[key],
+		Reset:     period,
+	}
+	if !result.Allowed {
+		result.Remaining = 0
+		result.RetryAfter = period / time.Duration(limit)
+	}
+	return result, nil
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	buckets := fixedBuckets{}
+	base.SetupRateLimiter(buckets)
+	defer base.SetupRateLimiter(nil)
+
+	engine := gin.New()
+	engine.POST("/run", base.JwtOptionalAuthMiddleware(""),
+		base.RateLimitMiddleware("run", &base.RateLimit{Limit: 3, Period: time.Minute}, &base.RateLimit{Limit: 1, Period: time.Minute}),
+		func(ctx *gin.Context) {
+			base.ResponseSuccess(ctx)
+		})
+
+	serve := func(token string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/run", nil)
+		if len(token) > 0 {
+			req.Header.Add("Authorization", token)
+		}
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	// the guests are limited by ip
+	w := serve("")
+	if w.Code != http.StatusOK || w.Header().Get("RateLimit-Limit") != "3" || w.Header().Get("RateLimit-Remaining") != "2" {
+		t.Fatalf("unexpected response %d %v", w.Code, w.Header())
+	}
+
+	// the user bucket is more restrictive than the ip bucket
+	token, err := base.GenerateJwtToken(&datamodel.UserClaims{UserID: 1}, base.TokenDefaultExpireTime())
+	if err != nil {
+		t.Fatal(err)
+	}
+	w = serve(token)
+	if w.Code != http.StatusOK || w.Header().Get("RateLimit-Limit") != "1" || w.Header().Get("RateLimit-Remaining") != "0" {
+		t.Fatalf("unexpected response %d %v", w.Code, w.Header())
+	}
+
+	w = serve(token)
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "60" || w.Header().Get("RateLimit-Reset") != "60" {
+		t.Fatalf("unexpected response %d %v", w.Code, w.Header())
+	}
+
+	// the ip bucket is exhausted by the requests of both
+	w = serve("")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "20" {
+		t.Fatalf("unexpected response %d %v", w.Code, w.Header())
+	}
+}
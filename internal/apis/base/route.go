@@ -4,9 +4,22 @@ import "github.com/gin-gonic/gin"
 
 // RouterTable is a table to store route information
 type RouteTable struct {
-	Method     string
-	Path       string
+	Method string
+	Path   string
+	// AllowGuest allows the route to be accessed without login, the token is
+	// still verified if it is present so the handler can serve both.
 	AllowGuest bool
-	Regexp     string
-	Handler    gin.HandlerFunc
+	// ApiKeyScope is the scope of api key to access the route, the api keys
+	// are rejected if it is empty.
+	ApiKeyScope string
+	// Role is the least role of user to access the route, e.g. datamodel.RoleAdmin.
+	// Any login user can access the route if it is empty.
+	Role string
+	// IPRateLimit limits the requests of each client ip if it is not nil.
+	IPRateLimit *RateLimit
+	// UserRateLimit limits the requests of each login user if it is not nil.
+	UserRateLimit *RateLimit
+	// Regexp is matched against the request path if it is not empty.
+	Regexp  string
+	Handler gin.HandlerFunc
 }
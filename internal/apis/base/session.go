@@ -0,0 +1,52 @@
+package base
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"infra-3.xyz/hyperdot-node/internal/cache"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+)
+
+// RevokeSessions marks the sessions revoked and rejects their access tokens
+// until the tokens expire.
+func RevokeSessions(ctx context.Context, db *gorm.DB, revocations *cache.SessionRevocationList, sessions ...*datamodel.UserSessionModel) error {
+	if len(sessions) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, 0, len(sessions))
+	for _, session := range sessions {
+		ids = append(ids, session.ID)
+	}
+
+	now := time.Now()
+	if err := db.Model(&datamodel.UserSessionModel{}).
+		Where("id IN ? AND revoked_at IS NULL", ids).
+		Update("revoked_at", now).Error; err != nil {
+		return err
+	}
+
+	for _, session := range sessions {
+		session.RevokedAt = &now
+	}
+
+	return revocations.Revoke(ctx, AccessTokenTTL, ids...)
+}
+
+// RevokeUserSessions revokes all active sessions of the user, except the given sessions.
+func RevokeUserSessions(ctx context.Context, db *gorm.DB, revocations *cache.SessionRevocationList, userId uint, except ...uint) error {
+	tx := db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userId, time.Now())
+	if len(except) > 0 {
+		tx = tx.Where("id NOT IN ?", except)
+	}
+
+	var sessions []*datamodel.UserSessionModel
+	if err := tx.Find(&sessions).Error; err != nil {
+		return err
+	}
+
+	return RevokeSessions(ctx, db, revocations, sessions...)
+}
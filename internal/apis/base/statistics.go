@@ -0,0 +1,133 @@
+package base
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+)
+
+// saveUserStatistics updates the columns of user statistics, the statistics is
+// created if the user has none.
+func saveUserStatistics(tx *gorm.DB, userId uint, columns map[string]any) error {
+	result := tx.Model(&datamodel.UserStatistics{}).Where("user_id = ?", userId).Updates(columns)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		return nil
+	}
+
+	columns["user_id"] = userId
+	return tx.Model(&datamodel.UserStatistics{}).Create(columns).Error
+}
+
+// lockUsers locks the users in the transaction, so that the statistics of a user
+// are counted and saved by one transaction at a time and the concurrent changes are
+// not lost. The users are locked in the order of id to avoid deadlocks.
+func lockUsers(tx *gorm.DB, userIds []uint) ([]uint, error) {
+	var ids []uint
+	err := tx.Model(&datamodel.UserModel{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ?", userIds).
+		Order("id ASC").
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// countContentStatistics counts the saved queries, the dashboards and the stars of
+// them received by user, the trashed contents are not counted.
+func countContentStatistics(tx *gorm.DB, userId uint) (map[string]any, error) {
+	var queries, dashboards, queryStars, dashboardStars int64
+	if err := tx.Model(&datamodel.QueryModel{}).Where("user_id = ? AND unsaved = FALSE", userId).Count(&queries).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Model(&datamodel.DashboardModel{}).Where("user_id = ?", userId).Count(&dashboards).Error; err != nil {
+		return nil, err
+	}
+
+	userQueries := tx.Model(&datamodel.QueryModel{}).Select("id").Where("user_id = ?", userId)
+	if err := tx.Model(&datamodel.UserQueryFavorites{}).
+		Where("stared = TRUE AND query_id IN (?)", userQueries).
+		Count(&queryStars).Error; err != nil {
+		return nil, err
+	}
+	userDashboards := tx.Model(&datamodel.DashboardModel{}).Select("id").Where("user_id = ?", userId)
+	if err := tx.Model(&datamodel.UserDashboardFavorites{}).
+		Where("stared = TRUE AND dashboard_id IN (?)", userDashboards).
+		Count(&dashboardStars).Error; err != nil {
+		return nil, err
+	}
+
+	return map[string]any{
+		"queries":    queries,
+		"dashboards": dashboards,
+		"stars":      queryStars + dashboardStars,
+	}, nil
+}
+
+// countFollowStatistics counts the followers and followings of user.
+func countFollowStatistics(tx *gorm.DB, userId uint) (map[string]any, error) {
+	var followers, following int64
+	if err := tx.Model(&datamodel.UserFollowModel{}).Where("followee_id = ?", userId).Count(&followers).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Model(&datamodel.UserFollowModel{}).Where("follower_id = ?", userId).Count(&following).Error; err != nil {
+		return nil, err
+	}
+
+	return map[string]any{
+		"followers": followers,
+		"following": following,
+	}, nil
+}
+
+// refreshUserStatistics locks the users, counts their statistics by the count functions
+// and saves them.
+func refreshUserStatistics(tx *gorm.DB, userIds []uint, counts ...func(tx *gorm.DB, userId uint) (map[string]any, error)) error {
+	if len(userIds) == 0 {
+		return nil
+	}
+
+	ids, err := lockUsers(tx, userIds)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		columns := make(map[string]any)
+		for _, count := range counts {
+			counted, err := count(tx, id)
+			if err != nil {
+				return err
+			}
+			for k, v := range counted {
+				columns[k] = v
+			}
+		}
+
+		if err := saveUserStatistics(tx, id, columns); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RefreshContentStatistics counts the queries, dashboards and stars of users and saves
+// them to statistics, it should be called in the transaction creating, trashing or
+// restoring their contents, or changing the favorites of them.
+func RefreshContentStatistics(tx *gorm.DB, userIds ...uint) error {
+	return refreshUserStatistics(tx, userIds, countContentStatistics)
+}
+
+// RefreshFollowStatistics counts the followers and followings of users and saves
+// them to statistics, it should be called in the transaction changing them.
+func RefreshFollowStatistics(tx *gorm.DB, userIds ...uint) error {
+	return refreshUserStatistics(tx, userIds, countFollowStatistics)
+}
+
+// RefreshUserStatistics recounts all statistics of users from the source tables, it
+// fixes the statistics drifted from the contents and follows.
+func RefreshUserStatistics(tx *gorm.DB, userIds ...uint) error {
+	return refreshUserStatistics(tx, userIds, countContentStatistics, countFollowStatistics)
+}
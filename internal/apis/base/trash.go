@@ -0,0 +1,122 @@
+package base
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+)
+
+// PurgeQueries permanently deletes the queries, including the trashed ones, with their
+// charts, chart share tokens, permissions, comments, favorites and alerts, the panels
+// referencing them are kept without the references. It should be called in a transaction.
+func PurgeQueries(tx *gorm.DB, ids ...uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	if err := tx.Model(&datamodel.DashboardPanelModel{}).Where("query_id IN ?", ids).
+		UpdateColumns(map[string]any{"query_id": 0, "chart_id": 0}).Error; err != nil {
+		return err
+	}
+
+	chartIds := tx.Model(&datamodel.ChartModel{}).Select("id").Where("query_id IN ?", ids)
+	if err := tx.Where("chart_id IN (?)", chartIds).Delete(&datamodel.ShareTokenModel{}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("query_id IN ?", ids).Delete(&datamodel.ChartModel{}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("content_type = ? AND content_id IN ?", datamodel.ContentTypeQuery, ids).Delete(&datamodel.ContentPermissionModel{}).Error; err != nil {
+		return err
+	}
+
+	if err := DeleteComments(tx, datamodel.CommentTargetQuery, ids...); err != nil {
+		return err
+	}
+
+	if err := tx.Where("query_id IN ?", ids).Delete(&datamodel.UserQueryFavorites{}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("query_id IN ?", ids).Delete(&datamodel.AlertModel{}).Error; err != nil {
+		return err
+	}
+
+	return tx.Unscoped().Where("id IN ?", ids).Delete(&datamodel.QueryModel{}).Error
+}
+
+// PurgeDashboards permanently deletes the dashboards, including the trashed ones, with
+// their panels, permissions, comments, favorites and share tokens. It should be called
+// in a transaction.
+func PurgeDashboards(tx *gorm.DB, ids ...uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var panelIds []uint
+	if err := tx.Model(&datamodel.DashboardPanelModel{}).Where("dashboard_id IN ?", ids).Pluck("id", &panelIds).Error; err != nil {
+		return err
+	}
+
+	if err := DeleteComments(tx, datamodel.CommentTargetPanel, panelIds...); err != nil {
+		return err
+	}
+
+	if err := tx.Where("dashboard_id IN ?", ids).Delete(&datamodel.DashboardPanelModel{}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("content_type = ? AND content_id IN ?", datamodel.ContentTypeDashboard, ids).Delete(&datamodel.ContentPermissionModel{}).Error; err != nil {
+		return err
+	}
+
+	if err := DeleteComments(tx, datamodel.CommentTargetDashboard, ids...); err != nil {
+		return err
+	}
+
+	if err := tx.Where("dashboard_id IN ?", ids).Delete(&datamodel.UserDashboardFavorites{}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("dashboard_id IN ?", ids).Delete(&datamodel.ShareTokenModel{}).Error; err != nil {
+		return err
+	}
+
+	return tx.Unscoped().Where("id IN ?", ids).Delete(&datamodel.DashboardModel{}).Error
+}
+
+// TrashFilter returns the filter of the trashed contents listed to the current user, they
+// are the personal contents of user, or the contents of workspace if the workspace_id query
+// is given and the user is an editor of it. It responses error and returns false if not allowed.
+func TrashFilter(ctx *gin.Context, db *gorm.DB) (func(tx *gorm.DB) *gorm.DB, bool) {
+	userId, err := GetCurrentUserId(ctx)
+	if err != nil {
+		ResponseErr(ctx, http.StatusUnauthorized, err.Error())
+		return nil, false
+	}
+
+	workspaceId, err := GetUIntQuery(ctx, "workspace_id")
+	if err != nil && err != ErrQueryNotFound {
+		ResponseErr(ctx, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+
+	if workspaceId == 0 {
+		return func(tx *gorm.DB) *gorm.DB {
+			return tx.Unscoped().Where("deleted_at IS NOT NULL AND workspace_id = 0 AND user_id = ?", userId)
+		}, true
+	}
+
+	if !CheckWorkspaceRole(ctx, db, workspaceId, userId, datamodel.WorkspaceRoleEditor) {
+		return nil, false
+	}
+
+	return func(tx *gorm.DB) *gorm.DB {
+		return tx.Unscoped().Where("deleted_at IS NOT NULL AND workspace_id = ?", workspaceId)
+	}, true
+}
@@ -0,0 +1,70 @@
+package base
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+)
+
+// WebhookPayload is the json body posted to webhooks.
+type WebhookPayload struct {
+	Event     string    `json:"event"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// FireWebhooks queues the event for the enabled webhooks of user subscribing it, they
+// are posted by the webhook job. It is a post-commit side effect.
+func FireWebhooks(db *gorm.DB, userId uint, event string, data any) {
+	var webhooks []datamodel.WebhookModel
+	if err := db.Select("id", "events").Where("user_id = ? AND enabled = ?", userId, true).Find(&webhooks).Error; err != nil {
+		log.Printf("Error load webhooks of user %d: %v", userId, err)
+		return
+	}
+
+	var webhookIds []uint
+	for _, webhook := range webhooks {
+		if webhook.Events.Has(event) {
+			webhookIds = append(webhookIds, webhook.ID)
+		}
+	}
+
+	if err := QueueWebhookDeliveries(db, event, data, webhookIds...); err != nil {
+		log.Printf("Error queue webhook %s of user %d: %v", event, userId, err)
+	}
+}
+
+// QueueWebhookDeliveries queues the event to the webhooks, the deliveries are attempted
+// at once.
+func QueueWebhookDeliveries(db *gorm.DB, event string, data any, webhookIds ...uint) error {
+	if len(webhookIds) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	payload, err := json.Marshal(WebhookPayload{
+		Event:     event,
+		CreatedAt: now,
+		Data:      data,
+	})
+	if err != nil {
+		return err
+	}
+
+	deliveries := make([]datamodel.WebhookDeliveryModel, 0, len(webhookIds))
+	for _, webhookId := range webhookIds {
+		deliveries = append(deliveries, datamodel.WebhookDeliveryModel{
+			WebhookID:     webhookId,
+			Event:         event,
+			Payload:       string(payload),
+			Status:        datamodel.WebhookDeliveryPending,
+			NextAttemptAt: &now,
+		})
+	}
+
+	return db.Create(&deliveries).Error
+}
@@ -0,0 +1,99 @@
+package base
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+)
+
+// GetWorkspaceRole returns the role of user in workspace, it returns empty
+// if the user is not a member.
+func GetWorkspaceRole(db *gorm.DB, workspaceId uint, userId uint) (string, error) {
+	var member datamodel.WorkspaceMemberModel
+	err := db.Select("role").Where("workspace_id = ? AND user_id = ?", workspaceId, userId).First(&member).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	return member.Role, nil
+}
+
+// CheckWorkspaceRole checks whether the user has the role in workspace. It responses
+// error and returns false if not allowed.
+func CheckWorkspaceRole(ctx *gin.Context, db *gorm.DB, workspaceId uint, userId uint, role string) bool {
+	current, err := GetWorkspaceRole(db, workspaceId, userId)
+	if err != nil {
+		ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+		return false
+	}
+
+	if !datamodel.WorkspaceRoleIncludes(current, role) {
+		ResponseErr(ctx, http.StatusForbidden, "%s role of workspace %d is required", role, workspaceId)
+		return false
+	}
+
+	return true
+}
+
+// RefreshWorkspaceStatistics counts the members and contents of workspace and
+// saves them to statistics, it should be called in the transaction changing them.
+func RefreshWorkspaceStatistics(tx *gorm.DB, workspaceId uint) error {
+	statistics := datamodel.WorkspaceStatistics{WorkspaceID: workspaceId}
+
+	var count int64
+	if err := tx.Model(&datamodel.WorkspaceMemberModel{}).Where("workspace_id = ?", workspaceId).Count(&count).Error; err != nil {
+		return err
+	}
+	statistics.Members = uint(count)
+
+	if err := tx.Model(&datamodel.QueryModel{}).Where("workspace_id = ? AND unsaved = FALSE", workspaceId).Count(&count).Error; err != nil {
+		return err
+	}
+	statistics.Queries = uint(count)
+
+	if err := tx.Model(&datamodel.DashboardModel{}).Where("workspace_id = ?", workspaceId).Count(&count).Error; err != nil {
+		return err
+	}
+	statistics.Dashboards = uint(count)
+
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "workspace_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"members", "queries", "dashboards"}),
+	}).Create(&statistics).Error
+}
+
+// CheckContentWorkspace checks whether the user can put the content into workspace, it
+// requires the editor role of workspace. The personal content has no workspace.
+func CheckContentWorkspace(ctx *gin.Context, db *gorm.DB, workspaceId uint, userId uint) bool {
+	if workspaceId == 0 {
+		return true
+	}
+
+	return CheckWorkspaceRole(ctx, db, workspaceId, userId, datamodel.WorkspaceRoleEditor)
+}
+
+// RefreshWorkspacesStatistics refreshes the statistics of workspaces, the personal
+// content is skipped.
+func RefreshWorkspacesStatistics(tx *gorm.DB, workspaceIds ...uint) error {
+	refreshed := make(map[uint]bool)
+	for _, id := range workspaceIds {
+		if id == 0 || refreshed[id] {
+			continue
+		}
+
+		if err := RefreshWorkspaceStatistics(tx, id); err != nil {
+			return err
+		}
+		refreshed[id] = true
+	}
+
+	return nil
+}
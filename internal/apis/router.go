@@ -2,13 +2,27 @@ package apis
 
 import (
 	"fmt"
+	"regexp"
 
+	"infra-3.xyz/hyperdot-node/internal/apis/service/admin"
+	"infra-3.xyz/hyperdot-node/internal/apis/service/alert"
+	"infra-3.xyz/hyperdot-node/internal/apis/service/apikey"
+	"infra-3.xyz/hyperdot-node/internal/apis/service/comment"
 	"infra-3.xyz/hyperdot-node/internal/apis/service/dashboard"
 	"infra-3.xyz/hyperdot-node/internal/apis/service/file"
+	"infra-3.xyz/hyperdot-node/internal/apis/service/follow"
+	"infra-3.xyz/hyperdot-node/internal/apis/service/notification"
+	"infra-3.xyz/hyperdot-node/internal/apis/service/permission"
 	"infra-3.xyz/hyperdot-node/internal/apis/service/query"
+	"infra-3.xyz/hyperdot-node/internal/apis/service/share"
 	"infra-3.xyz/hyperdot-node/internal/apis/service/system"
+	"infra-3.xyz/hyperdot-node/internal/apis/service/webhook"
+	"infra-3.xyz/hyperdot-node/internal/apis/service/workspace"
+	"infra-3.xyz/hyperdot-node/internal/cache"
 	"infra-3.xyz/hyperdot-node/internal/clients"
 	"infra-3.xyz/hyperdot-node/internal/dataengine"
+	"infra-3.xyz/hyperdot-node/internal/jobs"
+	"infra-3.xyz/hyperdot-node/internal/mailer"
 
 	"github.com/gin-gonic/gin"
 	swaggerfiles "github.com/swaggo/files"
@@ -43,6 +57,7 @@ type RouterBuilder struct {
 	cfg         *common.Config
 	s3Client    *clients.SimpleS3Cliet
 	engines     map[string]dataengine.QueryEngine
+	jobManager  *jobs.JobManager
 }
 
 // NewRouterBuilder creates a new RouterBuilder
@@ -52,6 +67,7 @@ func NewRouterBuilder(
 	db *gorm.DB,
 	engines map[string]dataengine.QueryEngine,
 	s3Client *clients.SimpleS3Cliet,
+	jobManager *jobs.JobManager,
 ) *RouterBuilder {
 	return &RouterBuilder{
 		enableQuery: true,
@@ -60,26 +76,84 @@ func NewRouterBuilder(
 		cfg:         cfg,
 		engines:     engines,
 		s3Client:    s3Client,
+		jobManager:  jobManager,
 	}
 }
 
+// BuildHandlers wraps the handler of table by the path regexp, auth, role and rate
+// limit middlewares of the table.
+func BuildHandlers(versionUrl string, table *base.RouteTable) ([]gin.HandlerFunc, error) {
+	var handlers []gin.HandlerFunc
+	if len(table.Regexp) > 0 {
+		re, err := regexp.Compile(table.Regexp)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regexp of %s %s/%s: %w", table.Method, versionUrl, table.Path, err)
+		}
+		handlers = append(handlers, base.PathRegexpMiddleware(re))
+	}
+
+	if table.AllowGuest {
+		handlers = append(handlers, base.JwtOptionalAuthMiddleware(table.ApiKeyScope))
+	} else {
+		handlers = append(handlers, base.JwtAuthMiddleware(table.ApiKeyScope))
+	}
+
+	if len(table.Role) > 0 {
+		handlers = append(handlers, base.RoleMiddleware(table.Role))
+	}
+
+	if table.IPRateLimit != nil || table.UserRateLimit != nil {
+		route := fmt.Sprintf("%s:%s", table.Method, table.Path)
+		handlers = append(handlers, base.RateLimitMiddleware(route, table.IPRateLimit, table.UserRateLimit))
+	}
+
+	return append(handlers, table.Handler), nil
+}
+
 // Build builds gin engine
 func (r *RouterBuilder) Build() (*gin.Engine, error) {
+	if err := base.SetupJwtKeys(&r.cfg.Jwt); err != nil {
+		return nil, err
+	}
+	base.SetupTokenRevocationList(cache.NewSessionRevocationList(&r.cfg.Redis))
+	base.SetupRateLimiter(cache.NewRateLimiter(&r.cfg.Redis))
+
+	apiKeys := apikey.New(r.db)
+	base.SetupApiKeyVerifier(apiKeys)
+
+	mail, err := mailer.New(&r.cfg.Mail)
+	if err != nil {
+		return nil, err
+	}
+
 	engine := gin.Default()
 	versionUrl := fmt.Sprintf("%s/%s", BASE, CURRENT_VERSION)
 	docs.SwaggerInfo.BasePath = "/apis/v1"
-	engine.Use(base.JwtAuthMiddleware())
 	router := engine.Group(versionUrl)
 	{
 		var svcs []Router
 		svcs = append(svcs, system.New(r.cfg))
 		svcs = append(svcs, query.New(r.boltStore, r.cfg, r.db, r.engines))
-		svcs = append(svcs, dashboard.New(r.db))
-		svcs = append(svcs, user.New(r.db, r.engines, r.s3Client))
+		svcs = append(svcs, dashboard.New(r.db, r.engines))
+		svcs = append(svcs, user.New(r.cfg, r.db, r.engines, r.s3Client, mail))
 		svcs = append(svcs, file.New(r.s3Client))
+		svcs = append(svcs, share.New(r.cfg, r.db))
+		svcs = append(svcs, apiKeys)
+		svcs = append(svcs, admin.New(r.cfg, r.db, r.jobManager))
+		svcs = append(svcs, workspace.New(r.db))
+		svcs = append(svcs, permission.New(r.db))
+		svcs = append(svcs, follow.New(r.db))
+		svcs = append(svcs, comment.New(r.db))
+		svcs = append(svcs, notification.New(r.db))
+		svcs = append(svcs, webhook.New(r.cfg, r.db))
+		svcs = append(svcs, alert.New(r.db))
 		for _, svc := range svcs {
 			for _, table := range svc.RouteTables() {
-				router.Handle(table.Method, table.Path, table.Handler)
+				handlers, err := BuildHandlers(versionUrl, &table)
+				if err != nil {
+					return nil, fmt.Errorf("%s service: %w", svc.Name(), err)
+				}
+				router.Handle(table.Method, table.Path, handlers...)
 			}
 		}
 	}
@@ -12,6 +12,7 @@ import (
 	"gorm.io/gorm"
 	"infra-3.xyz/hyperdot-node/internal/clients"
 	"infra-3.xyz/hyperdot-node/internal/dataengine"
+	"infra-3.xyz/hyperdot-node/internal/jobs"
 
 	"infra-3.xyz/hyperdot-node/internal/common"
 	"infra-3.xyz/hyperdot-node/internal/store"
@@ -27,8 +28,9 @@ type ApiServer struct {
 // NewApiServer creates a new ApiServer
 func NewApiServer(boltStore *store.BoltStore, cfg *common.Config,
 	db *gorm.DB,
-	engines map[string]dataengine.QueryEngine, s3Client *clients.SimpleS3Cliet) (*ApiServer, error) {
-	engine, err := NewRouterBuilder(boltStore, cfg, db, engines, s3Client).Build()
+	engines map[string]dataengine.QueryEngine, s3Client *clients.SimpleS3Cliet,
+	jobManager *jobs.JobManager) (*ApiServer, error) {
+	engine, err := NewRouterBuilder(boltStore, cfg, db, engines, s3Client, jobManager).Build()
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,481 @@
+package admin
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"infra-3.xyz/hyperdot-node/internal/apis/base"
+	"infra-3.xyz/hyperdot-node/internal/cache"
+	"infra-3.xyz/hyperdot-node/internal/common"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+	"infra-3.xyz/hyperdot-node/internal/jobs"
+)
+
+const ServiceName = "admin"
+
+// Service admin service, it manages the users, moderates the public contents
+// and operates the jobs.
+type Service struct {
+	db          *gorm.DB
+	revocations *cache.SessionRevocationList
+	jobManager  *jobs.JobManager
+}
+
+// New admin service
+func New(cfg *common.Config, db *gorm.DB, jobManager *jobs.JobManager) *Service {
+	return &Service{
+		db:          db,
+		revocations: cache.NewSessionRevocationList(&cfg.Redis),
+		jobManager:  jobManager,
+	}
+}
+
+// getOtherUser gets the user of id param, the current user can not manage itself
+// by admin apis in case of locking out all admins.
+func (s *Service) getOtherUser(ctx *gin.Context) (*datamodel.UserModel, bool) {
+	userId, err := base.GetCurrentUserId(ctx)
+	if err != nil {
+		base.ResponseErr(ctx, http.StatusUnauthorized, err.Error())
+		return nil, false
+	}
+
+	id, err := base.GetUintParam(ctx, "id")
+	if err != nil {
+		base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+
+	if id == userId {
+		base.ResponseErr(ctx, http.StatusBadRequest, "can not manage yourself")
+		return nil, false
+	}
+
+	var user datamodel.UserModel
+	if err := s.db.Where("id = ?", id).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			base.ResponseErr(ctx, http.StatusNotFound, "user not found")
+			return nil, false
+		}
+
+		base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+		return nil, false
+	}
+
+	return &user, true
+}
+
+// updateUser updates the user and revokes the sessions of it, so the change
+// takes effect immediately rather than after the access tokens expire.
+func (s *Service) updateUser(ctx *gin.Context, user *datamodel.UserModel, action string, updates map[string]any) {
+	before := *user
+	if err := s.db.Model(user).Updates(updates).Error; err != nil {
+		base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	base.Audit(ctx, s.db, &base.AuditRecord{
+		Action:     action,
+		TargetType: datamodel.AuditTargetUser,
+		TargetID:   user.ID,
+		Before:     &before,
+		After:      user,
+	})
+
+	if err := base.RevokeUserSessions(ctx, s.db, s.revocations, user.ID); err != nil {
+		log.Printf("Error revoke sessions of user %d: %v", user.ID, err)
+	}
+
+	ctx.JSON(http.StatusOK, ResponseUser{
+		BaseResponse: base.ResponseOk(),
+		Data:         *user,
+	})
+}
+
+// @Summary list users
+// @Description List users for admin, filtered by keyword of username or email, role and disabled.
+// @Tags admin apis
+// @Accept application/json
+// @Produce application/json
+// @Security ApiKeyAuth
+// @Param Authorization header string true "token"
+// @Param page query int false "page"
+// @Param page_size query int false "page_size"
+// @Param keyword query string false "keyword of username or email"
+// @Param role query string false "role"
+// @Param disabled query bool false "only list the disabled users"
+// @Success 200 {object} ResponseListUsers
+// @Router /admin/users [get]
+func (s *Service) ListUsersHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		page, pageSize, err := base.GetPage(ctx, 10)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		tx := s.db.Model(&datamodel.UserModel{})
+		if keyword := ctx.Query("keyword"); len(keyword) > 0 {
+			tx = tx.Where("username LIKE ? OR email LIKE ?", "%"+keyword+"%", "%"+keyword+"%")
+		}
+		if role := ctx.Query("role"); len(role) > 0 {
+			tx = tx.Where("role = ?", role)
+		}
+		if ctx.Query("disabled") == "true" {
+			tx = tx.Where("disabled_at IS NOT NULL")
+		}
+
+		var data ResponseListUsersData
+		if err := tx.Count(&data.Total).Error; err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		if err := tx.Order("id ASC").Offset((page - 1) * pageSize).Limit(pageSize).
+			Find(&data.Users).Error; err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, ResponseListUsers{
+			BaseResponse: base.ResponseOk(),
+			Data:         data,
+		})
+	}
+}
+
+// @Summary update user role
+// @Description Update the role of user, the sessions of user are revoked so the new role takes effect after login again.
+// @Tags admin apis
+// @Accept application/json
+// @Produce application/json
+// @Security ApiKeyAuth
+// @Param Authorization header string true "token"
+// @Param id path int true "user id"
+// @Param body body RequestUpdateRole true "update role request"
+// @Success 200 {object} ResponseUser
+// @Router /admin/users/{id}/role [put]
+func (s *Service) UpdateUserRoleHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var request RequestUpdateRole
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if !datamodel.IsValidRole(request.Role) {
+			base.ResponseErr(ctx, http.StatusBadRequest, "invalid role %s", request.Role)
+			return
+		}
+
+		user, ok := s.getOtherUser(ctx)
+		if !ok {
+			return
+		}
+
+		s.updateUser(ctx, user, datamodel.AuditUserRoleChange, map[string]any{"role": request.Role})
+	}
+}
+
+// @Summary disable user
+// @Description Disable the user, the user can not login and the sessions and api keys of user are rejected.
+// @Tags admin apis
+// @Accept application/json
+// @Produce application/json
+// @Security ApiKeyAuth
+// @Param Authorization header string true "token"
+// @Param id path int true "user id"
+// @Success 200 {object} ResponseUser
+// @Router /admin/users/{id}/disable [post]
+func (s *Service) DisableUserHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, ok := s.getOtherUser(ctx)
+		if !ok {
+			return
+		}
+
+		if user.IsDisabled() {
+			base.ResponseErr(ctx, http.StatusBadRequest, "user is already disabled")
+			return
+		}
+
+		s.updateUser(ctx, user, datamodel.AuditUserDisable, map[string]any{"disabled_at": time.Now()})
+	}
+}
+
+// @Summary enable user
+// @Description Enable the disabled user.
+// @Tags admin apis
+// @Accept application/json
+// @Produce application/json
+// @Security ApiKeyAuth
+// @Param Authorization header string true "token"
+// @Param id path int true "user id"
+// @Success 200 {object} ResponseUser
+// @Router /admin/users/{id}/enable [post]
+func (s *Service) EnableUserHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, ok := s.getOtherUser(ctx)
+		if !ok {
+			return
+		}
+
+		if !user.IsDisabled() {
+			base.ResponseErr(ctx, http.StatusBadRequest, "user is not disabled")
+			return
+		}
+
+		before := *user
+		if err := s.db.Model(user).Update("disabled_at", nil).Error; err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+		user.DisabledAt = nil
+
+		base.Audit(ctx, s.db, &base.AuditRecord{
+			Action:     datamodel.AuditUserEnable,
+			TargetType: datamodel.AuditTargetUser,
+			TargetID:   user.ID,
+			Before:     &before,
+			After:      user,
+		})
+
+		ctx.JSON(http.StatusOK, ResponseUser{
+			BaseResponse: base.ResponseOk(),
+			Data:         *user,
+		})
+	}
+}
+
+// unpublish makes the public content of model private.
+func (s *Service) unpublish(ctx *gin.Context, model any, name string, action string) {
+	id, err := base.GetUintParam(ctx, "id")
+	if err != nil {
+		base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result := s.db.Model(model).Where("id = ?", id).Update("is_privacy", true)
+	if result.Error != nil {
+		base.ResponseErr(ctx, http.StatusInternalServerError, result.Error.Error())
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		base.ResponseErr(ctx, http.StatusNotFound, "%s not found", name)
+		return
+	}
+
+	base.Audit(ctx, s.db, &base.AuditRecord{
+		Action:     action,
+		TargetType: name,
+		TargetID:   id,
+		After:      map[string]bool{"is_privacy": true},
+	})
+
+	base.ResponseSuccess(ctx)
+}
+
+// @Summary unpublish query
+// @Description Make the public query private, e.g. the spam or abusive query.
+// @Tags admin apis
+// @Accept application/json
+// @Produce application/json
+// @Security ApiKeyAuth
+// @Param Authorization header string true "token"
+// @Param id path int true "query id"
+// @Success 200 {object} base.BaseResponse
+// @Router /admin/query/{id}/unpublish [post]
+func (s *Service) UnpublishQueryHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		s.unpublish(ctx, &datamodel.QueryModel{}, datamodel.AuditTargetQuery, datamodel.AuditQueryUnpublish)
+	}
+}
+
+// @Summary unpublish dashboard
+// @Description Make the public dashboard private, e.g. the spam or abusive dashboard.
+// @Tags admin apis
+// @Accept application/json
+// @Produce application/json
+// @Security ApiKeyAuth
+// @Param Authorization header string true "token"
+// @Param id path int true "dashboard id"
+// @Success 200 {object} base.BaseResponse
+// @Router /admin/dashboard/{id}/unpublish [post]
+func (s *Service) UnpublishDashboardHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		s.unpublish(ctx, &datamodel.DashboardModel{}, datamodel.AuditTargetDashboard, datamodel.AuditDashboardUnpublish)
+	}
+}
+
+// @Summary list audit logs
+// @Description List the audit logs of logins, account changes and content changes, the latest logs are listed first.
+// @Tags admin apis
+// @Accept application/json
+// @Produce application/json
+// @Security ApiKeyAuth
+// @Param Authorization header string true "token"
+// @Param page query int false "page"
+// @Param page_size query int false "page_size"
+// @Param actor_id query int false "the user who takes the action"
+// @Param action query string false "action, e.g. dashboard.delete"
+// @Param target_type query string false "target type, one of user, query, dashboard and panel"
+// @Param target_id query int false "target id"
+// @Param from query string false "RFC3339 time, the logs created at or after it"
+// @Param to query string false "RFC3339 time, the logs created before it"
+// @Success 200 {object} base.ResponseAuditLogs
+// @Router /admin/audit [get]
+func (s *Service) ListAuditLogsHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		filter, err := base.GetAuditFilter(ctx)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		logs, total, err := base.ListAuditLogs(s.db, filter)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, base.ResponseAuditLogs{
+			BaseResponse: base.ResponseOk(),
+			Data: base.ResponseAuditLogsData{
+				Logs:  logs,
+				Total: total,
+			},
+		})
+	}
+}
+
+// @Summary sync metadata
+// @Description Start to sync the chains and tables metadata of query engines in background.
+// @Tags admin apis
+// @Accept application/json
+// @Produce application/json
+// @Security ApiKeyAuth
+// @Param Authorization header string true "token"
+// @Success 200 {object} base.BaseResponse
+// @Router /admin/sync/metadata [post]
+func (s *Service) SyncMetadataHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if s.jobManager == nil {
+			base.ResponseErr(ctx, http.StatusServiceUnavailable, "jobs are not running")
+			return
+		}
+
+		if err := s.jobManager.TriggerBigQuerySync(); err != nil {
+			if errors.Is(err, jobs.ErrJobRunning) {
+				base.ResponseErr(ctx, http.StatusConflict, "metadata is syncing")
+				return
+			}
+
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		base.ResponseSuccess(ctx)
+	}
+}
+
+// @Summary reconcile user statistics
+// @Description Start to recount the queries, dashboards, stars, followers and followings of all users in background,
+// @Description it fixes the statistics drifted from the contents. The statistics are also reconciled daily.
+// @Tags admin apis
+// @Accept application/json
+// @Produce application/json
+// @Security ApiKeyAuth
+// @Param Authorization header string true "token"
+// @Success 200 {object} base.BaseResponse
+// @Router /admin/statistics/reconcile [post]
+func (s *Service) ReconcileStatisticsHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if s.jobManager == nil {
+			base.ResponseErr(ctx, http.StatusServiceUnavailable, "jobs are not running")
+			return
+		}
+
+		if err := s.jobManager.TriggerStatisticsReconcile(); err != nil {
+			if errors.Is(err, jobs.ErrJobRunning) {
+				base.ResponseErr(ctx, http.StatusConflict, "statistics are reconciling")
+				return
+			}
+
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		base.ResponseSuccess(ctx)
+	}
+}
+
+// Name service name
+func (s *Service) Name() string {
+	return ServiceName
+}
+
+// RouteTables route tables
+func (s *Service) RouteTables() []base.RouteTable {
+	group := "admin"
+	return []base.RouteTable{
+		{
+			Method:  "GET",
+			Path:    group + "/users",
+			Handler: s.ListUsersHandler(),
+			Role:    datamodel.RoleAdmin,
+		},
+		{
+			Method:  "PUT",
+			Path:    group + "/users/:id/role",
+			Handler: s.UpdateUserRoleHandler(),
+			Role:    datamodel.RoleAdmin,
+		},
+		{
+			Method:  "POST",
+			Path:    group + "/users/:id/disable",
+			Handler: s.DisableUserHandler(),
+			Role:    datamodel.RoleAdmin,
+		},
+		{
+			Method:  "POST",
+			Path:    group + "/users/:id/enable",
+			Handler: s.EnableUserHandler(),
+			Role:    datamodel.RoleAdmin,
+		},
+		{
+			Method:  "POST",
+			Path:    group + "/query/:id/unpublish",
+			Handler: s.UnpublishQueryHandler(),
+			Role:    datamodel.RoleModerator,
+		},
+		{
+			Method:  "POST",
+			Path:    group + "/dashboard/:id/unpublish",
+			Handler: s.UnpublishDashboardHandler(),
+			Role:    datamodel.RoleModerator,
+		},
+		{
+			Method:  "GET",
+			Path:    group + "/audit",
+			Handler: s.ListAuditLogsHandler(),
+			Role:    datamodel.RoleAdmin,
+		},
+		{
+			Method:  "POST",
+			Path:    group + "/sync/metadata",
+			Handler: s.SyncMetadataHandler(),
+			Role:    datamodel.RoleAdmin,
+		},
+		{
+			Method:  "POST",
+			Path:    group + "/statistics/reconcile",
+			Handler: s.ReconcileStatisticsHandler(),
+			Role:    datamodel.RoleAdmin,
+		},
+	}
+}
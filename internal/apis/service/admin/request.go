@@ -0,0 +1,7 @@
+package admin
+
+// RequestUpdateRole is request of PUT /admin/users/:id/role
+type RequestUpdateRole struct {
+	// Role is one of user, moderator and admin.
+	Role string `json:"role"`
+}
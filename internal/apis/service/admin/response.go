@@ -0,0 +1,24 @@
+package admin
+
+import (
+	"infra-3.xyz/hyperdot-node/internal/apis/base"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+)
+
+// ResponseListUsersData is data of response of GET /admin/users
+type ResponseListUsersData struct {
+	Users []datamodel.UserModel `json:"users"`
+	Total int64                 `json:"total"`
+}
+
+// ResponseListUsers is response of GET /admin/users
+type ResponseListUsers struct {
+	base.BaseResponse
+	Data ResponseListUsersData `json:"data"`
+}
+
+// ResponseUser is response of the apis managing a user
+type ResponseUser struct {
+	base.BaseResponse
+	Data datamodel.UserModel `json:"data"`
+}
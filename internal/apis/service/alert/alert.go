@@ -0,0 +1,405 @@
+package alert
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"infra-3.xyz/hyperdot-node/internal/apis/base"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+	"infra-3.xyz/hyperdot-node/internal/utils"
+)
+
+const (
+	ServiceName = "alert"
+
+	// maxAlerts is the most alerts of a user.
+	maxAlerts = 50
+	// defaultInterval, minInterval and maxInterval are the minutes between evaluations.
+	defaultInterval = 60
+	minInterval     = 5
+	maxInterval     = 7 * 24 * 60
+)
+
+// Service alert service, the users are notified when the results of saved queries
+// meet the conditions of their alerts. The alerts are evaluated by the alert job.
+type Service struct {
+	db *gorm.DB
+}
+
+// New alert service
+func New(db *gorm.DB) *Service {
+	return &Service{
+		db: db,
+	}
+}
+
+// checkAlert fills the defaults of alert and checks it, the query must be viewed by
+// the user and all its placeholders must be filled by params.
+func (s *Service) checkAlert(ctx *gin.Context, alert *datamodel.AlertModel) bool {
+	if len(alert.Reduce) == 0 {
+		alert.Reduce = datamodel.AlertReduceFirst
+	}
+	if alert.Interval == 0 {
+		alert.Interval = defaultInterval
+	}
+	if len(alert.Channels) == 0 {
+		alert.Channels = datamodel.Scopes{datamodel.AlertChannelInApp}
+	}
+
+	var err error
+	switch {
+	case len(alert.Name) == 0:
+		err = errors.New("name is required")
+	case !datamodel.IsValidAlertReduce(alert.Reduce):
+		err = fmt.Errorf("unsupported reduce %s", alert.Reduce)
+	case len(alert.Column) == 0 && alert.Reduce != datamodel.AlertReduceCount:
+		err = errors.New("column is required")
+	case !datamodel.IsValidAlertOperator(alert.Operator):
+		err = fmt.Errorf("unsupported operator %s", alert.Operator)
+	case alert.Interval < minInterval || alert.Interval > maxInterval:
+		err = fmt.Errorf("interval must be between %d and %d minutes", minInterval, maxInterval)
+	}
+	if err != nil {
+		base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+		return false
+	}
+
+	var channels datamodel.Scopes
+	for _, channel := range alert.Channels {
+		if !datamodel.IsValidAlertChannel(channel) {
+			base.ResponseErr(ctx, http.StatusBadRequest, "unsupported channel %s", channel)
+			return false
+		}
+		if !channels.Has(channel) {
+			channels = append(channels, channel)
+		}
+	}
+	alert.Channels = channels
+
+	var query datamodel.QueryModel
+	if err := s.db.Where("id = ?", alert.QueryID).First(&query).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			base.ResponseErr(ctx, http.StatusBadRequest, "query %d not found", alert.QueryID)
+			return false
+		}
+
+		base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+		return false
+	}
+
+	if !base.CheckContentAccess(ctx, s.db, base.QueryContent(&query), base.ContentAccessView) {
+		return false
+	}
+
+	if _, err := utils.RenderSafeTemplate(query.Query, alert.StringParams()); err != nil {
+		base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+		return false
+	}
+
+	return true
+}
+
+// toParams converts the params of request to the params of alert.
+func toParams(params map[string]string) datamodel.JSON {
+	if len(params) == 0 {
+		return nil
+	}
+
+	values := make(datamodel.JSON, len(params))
+	for name, value := range params {
+		values[name] = value
+	}
+	return values
+}
+
+// getAlert gets the alert of id param owned by current user.
+func (s *Service) getAlert(ctx *gin.Context) (*datamodel.AlertModel, bool) {
+	userId, err := base.GetCurrentUserId(ctx)
+	if err != nil {
+		base.ResponseErr(ctx, http.StatusUnauthorized, err.Error())
+		return nil, false
+	}
+
+	id, err := base.GetUintParam(ctx, "id")
+	if err != nil {
+		base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+
+	var alert datamodel.AlertModel
+	if err := s.db.Where("id = ? AND user_id = ?", id, userId).First(&alert).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			base.ResponseErr(ctx, http.StatusNotFound, "alert not found")
+			return nil, false
+		}
+
+		base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+		return nil, false
+	}
+
+	return &alert, true
+}
+
+// @Summary create alert
+// @Description Create an alert on a saved query which current user can view. The query is executed every interval minutes,
+// @Description and the column of rows reduced by first, min, max, sum or count is compared with the threshold.
+// @Description The user is notified by the channels when the alert is triggered or resolved, or when the query fails.
+// @Tags alert apis
+// @Accept application/json
+// @Produce application/json
+// @Param body body RequestCreateAlert true "alert"
+// @Success 200 {object} ResponseAlert
+// @Router /alert [post]
+func (s *Service) CreateAlertHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userId, err := base.GetCurrentUserId(ctx)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		var request RequestCreateAlert
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		now := time.Now()
+		alert := datamodel.AlertModel{
+			UserID:    userId,
+			QueryID:   request.QueryID,
+			Name:      request.Name,
+			Params:    toParams(request.Params),
+			Column:    request.Column,
+			Reduce:    request.Reduce,
+			Operator:  request.Operator,
+			Threshold: request.Threshold,
+			Interval:  request.Interval,
+			Channels:  request.Channels,
+			Enabled:   request.Enabled == nil || *request.Enabled,
+			State:     datamodel.AlertStateOK,
+			NextRunAt: &now,
+		}
+		if !s.checkAlert(ctx, &alert) {
+			return
+		}
+
+		var count int64
+		if err := s.db.Model(&datamodel.AlertModel{}).Where("user_id = ?", userId).Count(&count).Error; err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if count >= maxAlerts {
+			base.ResponseErr(ctx, http.StatusBadRequest, "at most %d alerts are allowed", maxAlerts)
+			return
+		}
+
+		if err := s.db.Create(&alert).Error; err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, ResponseAlert{
+			BaseResponse: base.ResponseOk(),
+			Data:         alert,
+		})
+	}
+}
+
+// @Summary list alerts
+// @Description List the alerts of current user.
+// @Tags alert apis
+// @Accept application/json
+// @Produce application/json
+// @Param query_id query int false "list the alerts of query"
+// @Success 200 {object} ResponseListAlerts
+// @Router /alert [get]
+func (s *Service) ListAlertsHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userId, err := base.GetCurrentUserId(ctx)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		tx := s.db.Where("user_id = ?", userId)
+		queryId, err := base.GetUIntQuery(ctx, "query_id")
+		if err != nil && err != base.ErrQueryNotFound {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err == nil {
+			tx = tx.Where("query_id = ?", queryId)
+		}
+
+		alerts := make([]datamodel.AlertModel, 0)
+		if err := tx.Order("id DESC").Find(&alerts).Error; err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, ResponseListAlerts{
+			BaseResponse: base.ResponseOk(),
+			Data:         alerts,
+		})
+	}
+}
+
+// @Summary get alert
+// @Description Get the alert of current user with its state.
+// @Tags alert apis
+// @Accept application/json
+// @Produce application/json
+// @Param id path int true "alert id"
+// @Success 200 {object} ResponseAlert
+// @Router /alert/{id} [get]
+func (s *Service) GetAlertHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		alert, ok := s.getAlert(ctx)
+		if !ok {
+			return
+		}
+
+		ctx.JSON(http.StatusOK, ResponseAlert{
+			BaseResponse: base.ResponseOk(),
+			Data:         *alert,
+		})
+	}
+}
+
+// @Summary update alert
+// @Description Update the alert of current user, its state is reset to ok and it is evaluated again soon.
+// @Tags alert apis
+// @Accept application/json
+// @Produce application/json
+// @Param id path int true "alert id"
+// @Param body body RequestUpdateAlert true "alert"
+// @Success 200 {object} ResponseAlert
+// @Router /alert/{id} [put]
+func (s *Service) UpdateAlertHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var request RequestUpdateAlert
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		alert, ok := s.getAlert(ctx)
+		if !ok {
+			return
+		}
+
+		if len(request.Name) > 0 {
+			alert.Name = request.Name
+		}
+		if request.Params != nil {
+			alert.Params = toParams(request.Params)
+		}
+		if len(request.Column) > 0 {
+			alert.Column = request.Column
+		}
+		if len(request.Reduce) > 0 {
+			alert.Reduce = request.Reduce
+		}
+		if len(request.Operator) > 0 {
+			alert.Operator = request.Operator
+		}
+		if request.Threshold != nil {
+			alert.Threshold = *request.Threshold
+		}
+		if request.Interval > 0 {
+			alert.Interval = request.Interval
+		}
+		if len(request.Channels) > 0 {
+			alert.Channels = request.Channels
+		}
+		if request.Enabled != nil {
+			alert.Enabled = *request.Enabled
+		}
+
+		if !s.checkAlert(ctx, alert) {
+			return
+		}
+
+		now := time.Now()
+		alert.State = datamodel.AlertStateOK
+		alert.LastError = ""
+		alert.TriggeredAt = nil
+		alert.NextRunAt = &now
+		if err := s.db.Save(alert).Error; err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, ResponseAlert{
+			BaseResponse: base.ResponseOk(),
+			Data:         *alert,
+		})
+	}
+}
+
+// @Summary delete alert
+// @Description Delete the alert of current user.
+// @Tags alert apis
+// @Accept application/json
+// @Produce application/json
+// @Param id path int true "alert id"
+// @Success 200 {object} base.BaseResponse
+// @Router /alert/{id} [delete]
+func (s *Service) DeleteAlertHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		alert, ok := s.getAlert(ctx)
+		if !ok {
+			return
+		}
+
+		if err := s.db.Delete(alert).Error; err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		base.ResponseSuccess(ctx)
+	}
+}
+
+// Name service name
+func (s *Service) Name() string {
+	return ServiceName
+}
+
+// RouteTables route tables
+func (s *Service) RouteTables() []base.RouteTable {
+	group := "alert"
+	return []base.RouteTable{
+		{
+			Method:  "POST",
+			Path:    group,
+			Handler: s.CreateAlertHandler(),
+		},
+		{
+			Method:  "GET",
+			Path:    group,
+			Handler: s.ListAlertsHandler(),
+		},
+		{
+			Method:  "GET",
+			Path:    group + "/:id",
+			Handler: s.GetAlertHandler(),
+		},
+		{
+			Method:  "PUT",
+			Path:    group + "/:id",
+			Handler: s.UpdateAlertHandler(),
+		},
+		{
+			Method:  "DELETE",
+			Path:    group + "/:id",
+			Handler: s.DeleteAlertHandler(),
+		},
+	}
+}
@@ -0,0 +1,36 @@
+package alert
+
+// RequestCreateAlert is request of POST /alert
+type RequestCreateAlert struct {
+	// QueryID is the saved query executed by alert.
+	QueryID uint   `json:"query_id"`
+	Name    string `json:"name"`
+	// Params are the values of the placeholders of query.
+	Params map[string]string `json:"params"`
+	// Column is the column of rows compared by alert, it is not required by count.
+	Column string `json:"column"`
+	// Reduce is one of first, min, max, sum and count, the default is first.
+	Reduce string `json:"reduce"`
+	// Operator is one of gt, gte, lt, lte, eq and ne.
+	Operator  string  `json:"operator"`
+	Threshold float64 `json:"threshold"`
+	// Interval is the minutes between evaluations, the default is 60.
+	Interval uint `json:"interval"`
+	// Channels are some of email, webhook and in_app, the default is in_app.
+	Channels []string `json:"channels"`
+	// Enabled is true by default.
+	Enabled *bool `json:"enabled"`
+}
+
+// RequestUpdateAlert is request of PUT /alert/:id, the empty fields are unchanged.
+type RequestUpdateAlert struct {
+	Name      string            `json:"name"`
+	Params    map[string]string `json:"params"`
+	Column    string            `json:"column"`
+	Reduce    string            `json:"reduce"`
+	Operator  string            `json:"operator"`
+	Threshold *float64          `json:"threshold"`
+	Interval  uint              `json:"interval"`
+	Channels  []string          `json:"channels"`
+	Enabled   *bool             `json:"enabled"`
+}
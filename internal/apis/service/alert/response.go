@@ -0,0 +1,18 @@
+package alert
+
+import (
+	"infra-3.xyz/hyperdot-node/internal/apis/base"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+)
+
+// ResponseAlert is response of an alert
+type ResponseAlert struct {
+	base.BaseResponse
+	Data datamodel.AlertModel `json:"data"`
+}
+
+// ResponseListAlerts is response of GET /alert
+type ResponseListAlerts struct {
+	base.BaseResponse
+	Data []datamodel.AlertModel `json:"data"`
+}
@@ -0,0 +1,249 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"infra-3.xyz/hyperdot-node/internal/apis/base"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+	"infra-3.xyz/hyperdot-node/internal/utils"
+)
+
+const (
+	ServiceName = "apikey"
+	// keyPrefix tells the hyperdot api keys apart from other secrets.
+	keyPrefix = "hdk_"
+	// keyBytes is the random bytes of an api key.
+	keyBytes = 32
+	// lastUsedInterval throttles the updates of last used time.
+	lastUsedInterval = time.Minute
+)
+
+// Service api key service, it is also the verifier of api keys.
+type Service struct {
+	db *gorm.DB
+}
+
+// New api key service
+func New(db *gorm.DB) *Service {
+	return &Service{
+		db: db,
+	}
+}
+
+// VerifyApiKey verifies the api key and records its last used time.
+func (s *Service) VerifyApiKey(ctx context.Context, key string) (*base.ApiKey, error) {
+	var model datamodel.ApiKeyModel
+	if err := s.db.WithContext(ctx).Where("key_hash = ?", utils.HashToken(key)).First(&model).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, base.ErrInvalidApiKey
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if !model.IsValid(now) {
+		return nil, base.ErrInvalidApiKey
+	}
+
+	// the keys of disabled users are rejected until the users are enabled
+	var user datamodel.UserModel
+	if err := s.db.WithContext(ctx).Select("id", "disabled_at").Where("id = ?", model.UserID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, base.ErrInvalidApiKey
+		}
+		return nil, err
+	}
+	if user.IsDisabled() {
+		return nil, base.ErrInvalidApiKey
+	}
+
+	if model.LastUsedAt == nil || now.Sub(*model.LastUsedAt) > lastUsedInterval {
+		if err := s.db.WithContext(ctx).Model(&model).Update("last_used_at", now).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return &base.ApiKey{
+		ID:     model.ID,
+		UserID: model.UserID,
+		Scopes: model.Scopes,
+	}, nil
+}
+
+// @Summary Create api key
+// @Description Create a personal api key, the key is sent in X-API-Key header to access the apis of its scopes.
+// @Description The key is only responded once, and all scopes are granted if scopes is empty.
+// @Tags apikey apis
+// @Accept application/json
+// @Produce application/json
+// @Param body body RequestCreateApiKey true "create api key request"
+// @Success 200 {object} ResponseCreateApiKey
+// @Router /apikey [post]
+func (s *Service) CreateApiKeyHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userId, err := base.GetCurrentUserId(ctx)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		var request RequestCreateApiKey
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if len(request.Name) == 0 {
+			base.ResponseErr(ctx, http.StatusBadRequest, "name is required")
+			return
+		}
+
+		if request.ExpiresIn < 0 {
+			base.ResponseErr(ctx, http.StatusBadRequest, "expires in must not be negative")
+			return
+		}
+
+		scopes := datamodel.Scopes(request.Scopes)
+		if len(scopes) == 0 {
+			scopes = base.ApiKeyScopes
+		}
+		for _, scope := range scopes {
+			if !datamodel.Scopes(base.ApiKeyScopes).Has(scope) {
+				base.ResponseErr(ctx, http.StatusBadRequest, "unsupported scope %s", scope)
+				return
+			}
+		}
+
+		key, err := utils.RandomToken(keyBytes)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+		key = keyPrefix + key
+
+		model := datamodel.ApiKeyModel{
+			UserID:  userId,
+			Name:    request.Name,
+			Prefix:  key[:len(keyPrefix)+6],
+			KeyHash: utils.HashToken(key),
+			Scopes:  scopes,
+		}
+		if request.ExpiresIn > 0 {
+			expiresAt := time.Now().Add(time.Duration(request.ExpiresIn) * time.Second)
+			model.ExpiresAt = &expiresAt
+		}
+
+		if err := s.db.Create(&model).Error; err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, ResponseCreateApiKey{
+			BaseResponse: base.ResponseOk(),
+			Data: ResponseCreateApiKeyData{
+				ApiKeyModel: model,
+				Key:         key,
+			},
+		})
+	}
+}
+
+// @Summary List api keys
+// @Description List the api keys of current user, include the revoked and expired keys.
+// @Tags apikey apis
+// @Accept application/json
+// @Produce application/json
+// @Success 200 {object} ResponseListApiKeys
+// @Router /apikey [get]
+func (s *Service) ListApiKeysHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userId, err := base.GetCurrentUserId(ctx)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		var keys []datamodel.ApiKeyModel
+		if err := s.db.Where("user_id = ?", userId).Order("id DESC").Find(&keys).Error; err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, ResponseListApiKeys{
+			BaseResponse: base.ResponseOk(),
+			Data:         keys,
+		})
+	}
+}
+
+// @Summary Revoke api key
+// @Description Revoke the api key of current user.
+// @Tags apikey apis
+// @Accept application/json
+// @Produce application/json
+// @Param id path int true "api key id"
+// @Success 200 {object} base.BaseResponse
+// @Router /apikey/{id} [delete]
+func (s *Service) RevokeApiKeyHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userId, err := base.GetCurrentUserId(ctx)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		id, err := base.GetUintParam(ctx, "id")
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		result := s.db.Model(&datamodel.ApiKeyModel{}).
+			Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userId).
+			Update("revoked_at", time.Now())
+		if result.Error != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, result.Error.Error())
+			return
+		}
+
+		if result.RowsAffected == 0 {
+			base.ResponseErr(ctx, http.StatusNotFound, "api key not found")
+			return
+		}
+
+		base.ResponseSuccess(ctx)
+	}
+}
+
+// Name service name
+func (s *Service) Name() string {
+	return ServiceName
+}
+
+// RouteTables route tables
+func (s *Service) RouteTables() []base.RouteTable {
+	group := "apikey"
+	return []base.RouteTable{
+		{
+			Method:  "POST",
+			Path:    group,
+			Handler: s.CreateApiKeyHandler(),
+		},
+		{
+			Method:  "GET",
+			Path:    group,
+			Handler: s.ListApiKeysHandler(),
+		},
+		{
+			Method:  "DELETE",
+			Path:    group + "/:id",
+			Handler: s.RevokeApiKeyHandler(),
+		},
+	}
+}
@@ -0,0 +1,10 @@
+package apikey
+
+// RequestCreateApiKey is request of POST /apikey
+type RequestCreateApiKey struct {
+	Name string `json:"name"`
+	// Scopes of the key, all scopes are granted if it is empty.
+	Scopes []string `json:"scopes"`
+	// ExpiresIn is the lifetime of key in seconds, 0 means never expires.
+	ExpiresIn int64 `json:"expires_in"`
+}
@@ -0,0 +1,25 @@
+package apikey
+
+import (
+	"infra-3.xyz/hyperdot-node/internal/apis/base"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+)
+
+// ResponseCreateApiKeyData is data of response of POST /apikey
+type ResponseCreateApiKeyData struct {
+	datamodel.ApiKeyModel
+	// Key is the api key, it is only responded once when it is created.
+	Key string `json:"key"`
+}
+
+// ResponseCreateApiKey is response of POST /apikey
+type ResponseCreateApiKey struct {
+	base.BaseResponse
+	Data ResponseCreateApiKeyData `json:"data"`
+}
+
+// ResponseListApiKeys is response of GET /apikey
+type ResponseListApiKeys struct {
+	base.BaseResponse
+	Data []datamodel.ApiKeyModel `json:"data"`
+}
@@ -0,0 +1,699 @@
+package comment
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"infra-3.xyz/hyperdot-node/internal/apis/base"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+)
+
+const (
+	ServiceName = "comment"
+
+	// maxBodyLength is the most characters of comment body.
+	maxBodyLength = 10000
+)
+
+// Service comment service, the users discuss the queries, dashboards and dashboard
+// panels they can view in threaded comments.
+type Service struct {
+	db *gorm.DB
+}
+
+// New comment service
+func New(db *gorm.DB) *Service {
+	return &Service{
+		db: db,
+	}
+}
+
+// loadTarget loads the content of comment target, the panel belongs to the content of
+// its dashboard. It returns gorm.ErrRecordNotFound if the target does not exist.
+func (s *Service) loadTarget(targetType string, targetId uint) (*base.Content, error) {
+	switch targetType {
+	case datamodel.CommentTargetQuery:
+		return base.LoadContent(s.db, datamodel.ContentTypeQuery, targetId)
+	case datamodel.CommentTargetDashboard:
+		return base.LoadContent(s.db, datamodel.ContentTypeDashboard, targetId)
+	case datamodel.CommentTargetPanel:
+		var panel datamodel.DashboardPanelModel
+		if err := s.db.Select("id", "dashboard_id").Where("id = ?", targetId).First(&panel).Error; err != nil {
+			return nil, err
+		}
+		return base.LoadContent(s.db, datamodel.ContentTypeDashboard, panel.DashboardID)
+	default:
+		return nil, errors.New("unsupported comment target " + targetType)
+	}
+}
+
+// checkTarget checks whether the current user can view the target, it responses
+// error and returns false if not allowed.
+func (s *Service) checkTarget(ctx *gin.Context, targetType string, targetId uint) (*base.Content, bool) {
+	content, err := s.loadTarget(targetType, targetId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			base.ResponseErr(ctx, http.StatusNotFound, "%s not found", targetType)
+			return nil, false
+		}
+
+		base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+		return nil, false
+	}
+
+	if !base.CheckContentAccess(ctx, s.db, content, base.ContentAccessView) {
+		return nil, false
+	}
+
+	return content, true
+}
+
+// getTarget gets the target of param which the current user can view.
+func (s *Service) getTarget(ctx *gin.Context, targetType string, param string) (uint, *base.Content, bool) {
+	targetId, err := base.GetUintParam(ctx, param)
+	if err != nil {
+		base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+		return 0, nil, false
+	}
+
+	content, ok := s.checkTarget(ctx, targetType, targetId)
+	if !ok {
+		return 0, nil, false
+	}
+
+	return targetId, content, true
+}
+
+// getComment gets the comment of id param whose target the current user can view.
+func (s *Service) getComment(ctx *gin.Context) (*datamodel.CommentModel, *base.Content, bool) {
+	id, err := base.GetUintParam(ctx, "id")
+	if err != nil {
+		base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+		return nil, nil, false
+	}
+
+	var comment datamodel.CommentModel
+	if err := s.db.Where("id = ?", id).First(&comment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			base.ResponseErr(ctx, http.StatusNotFound, "comment %d not found", id)
+			return nil, nil, false
+		}
+
+		base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+		return nil, nil, false
+	}
+
+	content, ok := s.checkTarget(ctx, comment.TargetType, comment.TargetID)
+	if !ok {
+		return nil, nil, false
+	}
+
+	return &comment, content, true
+}
+
+// checkBody trims the body and checks its length, it responses error and returns
+// false if invalid.
+func checkBody(ctx *gin.Context, body *string) bool {
+	*body = strings.TrimSpace(*body)
+	if len(*body) == 0 {
+		base.ResponseErr(ctx, http.StatusBadRequest, "comment body is required")
+		return false
+	}
+
+	if utf8.RuneCountInString(*body) > maxBodyLength {
+		base.ResponseErr(ctx, http.StatusBadRequest, "comment body is longer than %d characters", maxBodyLength)
+		return false
+	}
+
+	return true
+}
+
+// saveMentions saves the users mentioned in comment and returns them, the users who
+// can not view the content and the author are ignored.
+func saveMentions(tx *gorm.DB, comment *datamodel.CommentModel, content *base.Content) ([]uint, error) {
+	if err := tx.Where("comment_id = ?", comment.ID).Delete(&datamodel.CommentMentionModel{}).Error; err != nil {
+		return nil, err
+	}
+
+	names := parseMentions(comment.Body)
+	if comment.IsDeleted() || len(names) == 0 {
+		return nil, nil
+	}
+
+	var users []datamodel.UserModel
+	if err := tx.Select("id").Where("username IN ? AND id <> ?", names, comment.UserID).Find(&users).Error; err != nil {
+		return nil, err
+	}
+
+	var (
+		mentions []datamodel.CommentMentionModel
+		userIds  []uint
+	)
+	for _, user := range users {
+		access, err := base.GetContentAccess(tx, user.ID, content)
+		if err != nil {
+			return nil, err
+		}
+		if access < base.ContentAccessView {
+			continue
+		}
+
+		mentions = append(mentions, datamodel.CommentMentionModel{
+			CommentID: comment.ID,
+			UserID:    user.ID,
+		})
+		userIds = append(userIds, user.ID)
+	}
+
+	if len(mentions) == 0 {
+		return nil, nil
+	}
+	return userIds, tx.Create(&mentions).Error
+}
+
+// notifyComment notifies the mentioned users, the author of parent comment and the
+// owner of content, a user is notified once for the comment. The author of parent and
+// the owner are not notified if they can not view the content any more.
+func (s *Service) notifyComment(comment *datamodel.CommentModel, content *base.Content, parentUserId uint, mentioned []uint) {
+	newNotification := func(userId uint, notificationType string) datamodel.NotificationModel {
+		return datamodel.NotificationModel{
+			UserID:     userId,
+			Type:       notificationType,
+			ActorID:    comment.UserID,
+			TargetType: comment.TargetType,
+			TargetID:   comment.TargetID,
+			Data:       datamodel.JSON{"comment_id": comment.ID},
+		}
+	}
+
+	var notifications []datamodel.NotificationModel
+	for _, userId := range mentioned {
+		notifications = append(notifications, newNotification(userId, datamodel.NotificationMention))
+	}
+
+	recipients := []struct {
+		userId           uint
+		notificationType string
+	}{
+		{parentUserId, datamodel.NotificationReply},
+		{content.UserID, datamodel.NotificationComment},
+	}
+	for _, recipient := range recipients {
+		if recipient.userId == 0 || recipient.userId == comment.UserID {
+			continue
+		}
+
+		access, err := base.GetContentAccess(s.db, recipient.userId, content)
+		if err != nil {
+			log.Printf("Error get access of user %d to notify comment %d: %v", recipient.userId, comment.ID, err)
+			continue
+		}
+		if access >= base.ContentAccessView {
+			notifications = append(notifications, newNotification(recipient.userId, recipient.notificationType))
+		}
+	}
+
+	base.Notify(s.db, notifications...)
+}
+
+// fillComments fills the authors, replies and mentions of comments.
+func (s *Service) fillComments(comments []datamodel.CommentModel) ([]ResponseCommentData, error) {
+	data := make([]ResponseCommentData, 0, len(comments))
+	if len(comments) == 0 {
+		return data, nil
+	}
+
+	var ids, userIds []uint
+	for _, comment := range comments {
+		ids = append(ids, comment.ID)
+		userIds = append(userIds, comment.UserID)
+	}
+
+	var users []datamodel.UserModel
+	if err := s.db.Select("id", "username", "icon_url").Where("id IN ?", userIds).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	userMap := make(map[uint]datamodel.UserModel)
+	for _, user := range users {
+		userMap[user.ID] = user
+	}
+
+	var replies []struct {
+		ParentID uint
+		Count    int64
+	}
+	if err := s.db.Model(&datamodel.CommentModel{}).
+		Select("parent_id, COUNT(*) AS count").
+		Where("parent_id IN ?", ids).
+		Group("parent_id").
+		Scan(&replies).Error; err != nil {
+		return nil, err
+	}
+	replyMap := make(map[uint]int64)
+	for _, reply := range replies {
+		replyMap[reply.ParentID] = reply.Count
+	}
+
+	var mentions []ResponseMention
+	if err := s.db.Table(datamodel.CommentMentionModel{}.TableName()+" AS m").
+		Select("m.comment_id, m.user_id, u.username").
+		Joins("JOIN "+datamodel.UserModel{}.TableName()+" AS u ON u.id = m.user_id").
+		Where("m.comment_id IN ?", ids).
+		Order("m.id ASC").
+		Scan(&mentions).Error; err != nil {
+		return nil, err
+	}
+	mentionMap := make(map[uint][]ResponseMention)
+	for _, mention := range mentions {
+		mentionMap[mention.CommentID] = append(mentionMap[mention.CommentID], mention)
+	}
+
+	for _, comment := range comments {
+		item := ResponseCommentData{
+			CommentModel: comment,
+			Replies:      replyMap[comment.ID],
+			Mentions:     mentionMap[comment.ID],
+		}
+		if item.Mentions == nil {
+			item.Mentions = make([]ResponseMention, 0)
+		}
+
+		// the author of deleted comment is hidden
+		if comment.IsDeleted() {
+			item.UserID = 0
+		} else {
+			item.Username = userMap[comment.UserID].Username
+			item.IconUrl = userMap[comment.UserID].IconUrl
+		}
+
+		data = append(data, item)
+	}
+
+	return data, nil
+}
+
+// responseComment responses the comment with its author, replies and mentions.
+func (s *Service) responseComment(ctx *gin.Context, comment *datamodel.CommentModel) {
+	data, err := s.fillComments([]datamodel.CommentModel{*comment})
+	if err != nil {
+		base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ResponseComment{
+		BaseResponse: base.ResponseOk(),
+		Data:         data[0],
+	})
+}
+
+// listComments lists the comments matched by the conditions, the oldest are listed first.
+func (s *Service) listComments(ctx *gin.Context, query any, args ...any) {
+	page, pageSize, err := base.GetPage(ctx, 20)
+	if err != nil {
+		base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var data ResponseListCommentsData
+	if err := s.db.Model(&datamodel.CommentModel{}).Where(query, args...).Count(&data.Total).Error; err != nil {
+		base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var comments []datamodel.CommentModel
+	if err := s.db.Where(query, args...).
+		Order("id ASC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&comments).Error; err != nil {
+		base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	data.Comments, err = s.fillComments(comments)
+	if err != nil {
+		base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ResponseListComments{
+		BaseResponse: base.ResponseOk(),
+		Data:         data,
+	})
+}
+
+// @Summary list comments
+// @Description List the threads of comments on query, dashboard or dashboard panel, the oldest are listed first.
+// @Description The replies of a thread are listed by /comment/{id}/replies.
+// @Tags comment apis
+// @Accept application/json
+// @Produce application/json
+// @Param id path int true "query or dashboard id"
+// @Param panelId path int true "dashboard panel id"
+// @Param page query int false "page"
+// @Param page_size query int false "page_size"
+// @Success 200 {object} ResponseListComments
+// @Router /query/{id}/comments [get]
+// @Router /dashboard/{id}/comments [get]
+// @Router /dashboard/panel/{panelId}/comments [get]
+func (s *Service) ListCommentsHandler(targetType string, param string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		targetId, _, ok := s.getTarget(ctx, targetType, param)
+		if !ok {
+			return
+		}
+
+		s.listComments(ctx, "target_type = ? AND target_id = ? AND parent_id = 0", targetType, targetId)
+	}
+}
+
+// @Summary list replies
+// @Description List the replies of comment, the oldest are listed first.
+// @Tags comment apis
+// @Accept application/json
+// @Produce application/json
+// @Param id path int true "comment id"
+// @Param page query int false "page"
+// @Param page_size query int false "page_size"
+// @Success 200 {object} ResponseListComments
+// @Router /comment/{id}/replies [get]
+func (s *Service) ListRepliesHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		comment, _, ok := s.getComment(ctx)
+		if !ok {
+			return
+		}
+
+		s.listComments(ctx, "parent_id = ?", comment.ID)
+	}
+}
+
+// @Summary create comment
+// @Description Comment on query, dashboard or dashboard panel which current user can view, or reply to a comment by parent_id.
+// @Description The reply to a reply is added to the same thread. The mentioned users who can view the content are saved in mentions.
+// @Tags comment apis
+// @Accept application/json
+// @Produce application/json
+// @Param id path int true "query or dashboard id"
+// @Param panelId path int true "dashboard panel id"
+// @Param body body RequestCreateComment true "comment"
+// @Success 200 {object} ResponseComment
+// @Router /query/{id}/comments [post]
+// @Router /dashboard/{id}/comments [post]
+// @Router /dashboard/panel/{panelId}/comments [post]
+func (s *Service) CreateCommentHandler(targetType string, param string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userId, err := base.GetCurrentUserId(ctx)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		var request RequestCreateComment
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if !checkBody(ctx, &request.Body) {
+			return
+		}
+
+		targetId, content, ok := s.getTarget(ctx, targetType, param)
+		if !ok {
+			return
+		}
+
+		comment := datamodel.CommentModel{
+			TargetType: targetType,
+			TargetID:   targetId,
+			UserID:     userId,
+			Body:       request.Body,
+		}
+		var parentUserId uint
+
+		if request.ParentID != 0 {
+			var parent datamodel.CommentModel
+			if err := s.db.Where("id = ? AND target_type = ? AND target_id = ?", request.ParentID, targetType, targetId).
+				First(&parent).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					base.ResponseErr(ctx, http.StatusBadRequest, "parent comment %d not found", request.ParentID)
+					return
+				}
+
+				base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+				return
+			}
+
+			if parent.IsDeleted() {
+				base.ResponseErr(ctx, http.StatusBadRequest, "parent comment %d has been deleted", request.ParentID)
+				return
+			}
+
+			comment.ParentID = parent.ID
+			if parent.ParentID != 0 {
+				comment.ParentID = parent.ParentID
+			}
+			parentUserId = parent.UserID
+		}
+
+		var mentioned []uint
+		err = s.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&comment).Error; err != nil {
+				return err
+			}
+
+			mentioned, err = saveMentions(tx, &comment, content)
+			return err
+		})
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		s.notifyComment(&comment, content, parentUserId, mentioned)
+		s.responseComment(ctx, &comment)
+	}
+}
+
+// @Summary update comment
+// @Description Update the body of comment, only the author can update it.
+// @Tags comment apis
+// @Accept application/json
+// @Produce application/json
+// @Param id path int true "comment id"
+// @Param body body RequestUpdateComment true "comment"
+// @Success 200 {object} ResponseComment
+// @Router /comment/{id} [put]
+func (s *Service) UpdateCommentHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userId, err := base.GetCurrentUserId(ctx)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		var request RequestUpdateComment
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if !checkBody(ctx, &request.Body) {
+			return
+		}
+
+		comment, content, ok := s.getComment(ctx)
+		if !ok {
+			return
+		}
+
+		if comment.UserID != userId {
+			base.ResponseErr(ctx, http.StatusForbidden, "permission denied")
+			return
+		}
+
+		if comment.IsDeleted() {
+			base.ResponseErr(ctx, http.StatusBadRequest, "comment %d has been deleted", comment.ID)
+			return
+		}
+
+		var mentioned []uint
+		if err := s.db.Model(&datamodel.CommentMentionModel{}).Where("comment_id = ?", comment.ID).
+			Pluck("user_id", &mentioned).Error; err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		now := time.Now()
+		comment.Body = request.Body
+		comment.EditedAt = &now
+		var mentions []uint
+		err = s.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(comment).Select("body", "edited_at").Updates(comment).Error; err != nil {
+				return err
+			}
+
+			mentions, err = saveMentions(tx, comment, content)
+			return err
+		})
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		// only the users newly mentioned are notified
+		var notifications []datamodel.NotificationModel
+		for _, userId := range mentions {
+			if !slices.Contains(mentioned, userId) {
+				notifications = append(notifications, datamodel.NotificationModel{
+					UserID:     userId,
+					Type:       datamodel.NotificationMention,
+					ActorID:    comment.UserID,
+					TargetType: comment.TargetType,
+					TargetID:   comment.TargetID,
+					Data:       datamodel.JSON{"comment_id": comment.ID},
+				})
+			}
+		}
+		base.Notify(s.db, notifications...)
+
+		s.responseComment(ctx, comment)
+	}
+}
+
+// @Summary delete comment
+// @Description Delete the comment by its author, the manager of content or a moderator.
+// @Description The comment with replies is kept as a placeholder without body and author.
+// @Tags comment apis
+// @Accept application/json
+// @Produce application/json
+// @Param id path int true "comment id"
+// @Success 200 {object} base.BaseResponse
+// @Router /comment/{id} [delete]
+func (s *Service) DeleteCommentHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userId, err := base.GetCurrentUserId(ctx)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		comment, content, ok := s.getComment(ctx)
+		if !ok {
+			return
+		}
+
+		if comment.UserID != userId && !datamodel.RoleIncludes(base.GetCurrentUserRole(ctx), datamodel.RoleModerator) {
+			access, err := base.GetContentAccess(s.db, userId, content)
+			if err != nil {
+				base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+				return
+			}
+
+			if access < base.ContentAccessManage {
+				base.ResponseErr(ctx, http.StatusForbidden, "permission denied")
+				return
+			}
+		}
+
+		err = s.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("comment_id = ?", comment.ID).Delete(&datamodel.CommentMentionModel{}).Error; err != nil {
+				return err
+			}
+
+			var replies int64
+			if err := tx.Model(&datamodel.CommentModel{}).Where("parent_id = ?", comment.ID).Count(&replies).Error; err != nil {
+				return err
+			}
+
+			if replies > 0 {
+				return tx.Model(comment).Updates(map[string]any{
+					"body":       "",
+					"deleted_at": time.Now(),
+				}).Error
+			}
+
+			if err := tx.Delete(comment).Error; err != nil {
+				return err
+			}
+
+			// the deleted thread is removed with its last reply
+			if comment.ParentID == 0 {
+				return nil
+			}
+			return tx.Where("id = ? AND deleted_at IS NOT NULL AND NOT EXISTS (?)", comment.ParentID,
+				tx.Model(&datamodel.CommentModel{}).Select("1").Where("parent_id = ?", comment.ParentID)).
+				Delete(&datamodel.CommentModel{}).Error
+		})
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		base.ResponseSuccess(ctx)
+	}
+}
+
+// Name service name
+func (s *Service) Name() string {
+	return ServiceName
+}
+
+// RouteTables route tables
+func (s *Service) RouteTables() []base.RouteTable {
+	targets := []struct {
+		targetType string
+		path       string
+		param      string
+	}{
+		{datamodel.CommentTargetQuery, "query/:id/comments", "id"},
+		{datamodel.CommentTargetDashboard, "dashboard/:id/comments", "id"},
+		{datamodel.CommentTargetPanel, "dashboard/panel/:panelId/comments", "panelId"},
+	}
+
+	var tables []base.RouteTable
+	for _, target := range targets {
+		tables = append(tables,
+			base.RouteTable{
+				Method:     "GET",
+				Path:       target.path,
+				Handler:    s.ListCommentsHandler(target.targetType, target.param),
+				AllowGuest: true,
+			},
+			base.RouteTable{
+				Method:  "POST",
+				Path:    target.path,
+				Handler: s.CreateCommentHandler(target.targetType, target.param),
+			},
+		)
+	}
+
+	group := "comment"
+	return append(tables,
+		base.RouteTable{
+			Method:     "GET",
+			Path:       group + "/:id/replies",
+			Handler:    s.ListRepliesHandler(),
+			AllowGuest: true,
+		},
+		base.RouteTable{
+			Method:  "PUT",
+			Path:    group + "/:id",
+			Handler: s.UpdateCommentHandler(),
+		},
+		base.RouteTable{
+			Method:  "DELETE",
+			Path:    group + "/:id",
+			Handler: s.DeleteCommentHandler(),
+		},
+	)
+}
@@ -0,0 +1,33 @@
+package comment
+
+import (
+	"regexp"
+	"strings"
+)
+
+// maxMentions is the most users mentioned by a comment.
+const maxMentions = 20
+
+// mentionRegexp matches @username which is not a part of email address.
+var mentionRegexp = regexp.MustCompile(`(?:^|[^\w@])@([A-Za-z0-9_][A-Za-z0-9_.-]*)`)
+
+// parseMentions returns the unique usernames mentioned in body.
+func parseMentions(body string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, match := range mentionRegexp.FindAllStringSubmatch(body, -1) {
+		// the trailing dots end the sentence
+		name := strings.TrimRight(match[1], ".")
+		if len(name) == 0 || seen[name] {
+			continue
+		}
+
+		seen[name] = true
+		names = append(names, name)
+		if len(names) == maxMentions {
+			break
+		}
+	}
+
+	return names
+}
@@ -0,0 +1,15 @@
+package comment
+
+// RequestCreateComment is request of POST /query/:id/comments, POST /dashboard/:id/comments
+// and POST /dashboard/panel/:panelId/comments
+type RequestCreateComment struct {
+	// Body is the markdown text, the users are mentioned by @username.
+	Body string `json:"body"`
+	// ParentID is the comment replied to, it is 0 for a new thread.
+	ParentID uint `json:"parent_id"`
+}
+
+// RequestUpdateComment is request of PUT /comment/:id
+type RequestUpdateComment struct {
+	Body string `json:"body"`
+}
@@ -0,0 +1,41 @@
+package comment
+
+import (
+	"infra-3.xyz/hyperdot-node/internal/apis/base"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+)
+
+// ResponseMention is a user mentioned in comment
+type ResponseMention struct {
+	CommentID uint   `json:"-"`
+	UserID    uint   `json:"user_id"`
+	Username  string `json:"username"`
+}
+
+// ResponseCommentData is a comment with the profile of author
+type ResponseCommentData struct {
+	datamodel.CommentModel
+	Username string `json:"username"`
+	IconUrl  string `json:"icon_url"`
+	// Replies is the number of replies of a thread.
+	Replies  int64             `json:"replies"`
+	Mentions []ResponseMention `json:"mentions"`
+}
+
+// ResponseComment is response of creating or updating comment
+type ResponseComment struct {
+	base.BaseResponse
+	Data ResponseCommentData `json:"data"`
+}
+
+// ResponseListCommentsData is data of response of listing comments
+type ResponseListCommentsData struct {
+	Comments []ResponseCommentData `json:"comments"`
+	Total    int64                 `json:"total"`
+}
+
+// ResponseListComments is response of listing comments
+type ResponseListComments struct {
+	base.BaseResponse
+	Data ResponseListCommentsData `json:"data"`
+}
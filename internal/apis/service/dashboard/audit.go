@@ -0,0 +1,68 @@
+package dashboard
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"infra-3.xyz/hyperdot-node/internal/apis/base"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+)
+
+// auditCreate audits the created dashboard and its panels.
+func (s *Service) auditCreate(ctx *gin.Context, dashboard *datamodel.DashboardModel) {
+	s.auditUpdate(ctx, nil, dashboard)
+}
+
+// auditUpdate audits the changes of dashboard and its panels, the panels are audited
+// separately so that their changes can be found by panel id. The dashboard is created
+// if before is nil.
+func (s *Service) auditUpdate(ctx *gin.Context, before *datamodel.DashboardModel, after *datamodel.DashboardModel) {
+	beforePanels := make(map[uint]datamodel.DashboardPanelModel)
+	record := base.AuditRecord{
+		Action:     datamodel.AuditDashboardCreate,
+		TargetType: datamodel.AuditTargetDashboard,
+		TargetID:   after.ID,
+	}
+	if before != nil {
+		for _, panel := range before.Panels {
+			beforePanels[panel.ID] = panel
+		}
+
+		dashboard := *before
+		dashboard.Panels = nil
+		record.Action = datamodel.AuditDashboardUpdate
+		record.Before = &dashboard
+	}
+
+	dashboard := *after
+	dashboard.Panels = nil
+	record.After = &dashboard
+	base.Audit(ctx, s.db, &record)
+
+	for i := range after.Panels {
+		panel := after.Panels[i]
+		previous, ok := beforePanels[panel.ID]
+		if !ok {
+			base.Audit(ctx, s.db, &base.AuditRecord{
+				Action:     datamodel.AuditPanelCreate,
+				TargetType: datamodel.AuditTargetPanel,
+				TargetID:   panel.ID,
+				After:      &panel,
+			})
+			continue
+		}
+
+		// the panels are saved as a whole, only the changed ones are audited
+		b, a, err := base.AuditDiff(&previous, &panel)
+		if err == nil && len(b) == 0 && len(a) == 0 {
+			continue
+		}
+
+		base.Audit(ctx, s.db, &base.AuditRecord{
+			Action:     datamodel.AuditPanelUpdate,
+			TargetType: datamodel.AuditTargetPanel,
+			TargetID:   panel.ID,
+			Before:     &previous,
+			After:      &panel,
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package dashboard
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"infra-3.xyz/hyperdot-node/internal/dataengine"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+)
+
+// BundleVersion is the current format version of DashboardBundle.
+const BundleVersion = 1
+
+// DashboardBundle is a self-contained and portable dashboard which can be
+// exported from a node and imported to another one. The ids in the bundle
+// are only used to link panels, queries and charts together and they are
+// remapped on import.
+type DashboardBundle struct {
+	Version    int                      `json:"version"`
+	ExportedAt time.Time                `json:"exported_at"`
+	Dashboard  datamodel.DashboardModel `json:"dashboard"`
+	Queries    []datamodel.QueryModel   `json:"queries"`
+	Charts     []datamodel.ChartModel   `json:"charts"`
+}
+
+// newDashboardBundle creates a bundle from source and strips the owner of contents.
+func newDashboardBundle(source *dashboardSource) *DashboardBundle {
+	bundle := &DashboardBundle{
+		Version:    BundleVersion,
+		ExportedAt: time.Now(),
+		Dashboard:  source.Dashboard,
+		Queries:    make([]datamodel.QueryModel, 0, len(source.Queries)),
+		Charts:     make([]datamodel.ChartModel, 0, len(source.Charts)),
+	}
+
+	bundle.Dashboard.UserID = 0
+	bundle.Dashboard.WorkspaceID = 0
+	bundle.Dashboard.ForkedFromID = 0
+	bundle.Dashboard.DeletedAt = gorm.DeletedAt{}
+	bundle.Dashboard.Panels = make([]datamodel.DashboardPanelModel, 0, len(source.Dashboard.Panels))
+	for _, panel := range source.Dashboard.Panels {
+		panel.UserID = 0
+		bundle.Dashboard.Panels = append(bundle.Dashboard.Panels, panel)
+	}
+
+	for _, query := range source.Queries {
+		query.UserID = 0
+		query.WorkspaceID = 0
+		query.Stars = 0
+		query.DeletedAt = gorm.DeletedAt{}
+		bundle.Queries = append(bundle.Queries, query)
+	}
+
+	for _, chart := range source.Charts {
+		chart.UserID = 0
+		bundle.Charts = append(bundle.Charts, chart)
+	}
+
+	return bundle
+}
+
+// validate checks the bundle can be imported with engines.
+func (b *DashboardBundle) validate(engines map[string]dataengine.QueryEngine) error {
+	if b.Version == 0 {
+		return fmt.Errorf("bundle version is required")
+	}
+
+	if b.Version > BundleVersion {
+		return fmt.Errorf("unsupported bundle version %d, the latest supported version is %d", b.Version, BundleVersion)
+	}
+
+	if len(b.Dashboard.Name) == 0 {
+		return fmt.Errorf("dashboard name is required")
+	}
+
+	queries := make(map[uint]struct{}, len(b.Queries))
+	for _, query := range b.Queries {
+		if query.ID == 0 {
+			return fmt.Errorf("query %q: id is required", query.Name)
+		}
+
+		if _, ok := queries[query.ID]; ok {
+			return fmt.Errorf("query %d: duplicate id", query.ID)
+		}
+
+		if len(query.Query) == 0 {
+			return fmt.Errorf("query %d: query is required", query.ID)
+		}
+
+		if _, ok := engines[query.QueryEngine]; !ok {
+			return fmt.Errorf("query %d: the %s query engine unsupported now", query.ID, query.QueryEngine)
+		}
+
+		queries[query.ID] = struct{}{}
+	}
+
+	charts := make(map[uint]uint, len(b.Charts))
+	for _, chart := range b.Charts {
+		if chart.ID == 0 {
+			return fmt.Errorf("chart %q: id is required", chart.Name)
+		}
+
+		if _, ok := charts[chart.ID]; ok {
+			return fmt.Errorf("chart %d: duplicate id", chart.ID)
+		}
+
+		if _, ok := queries[chart.QueryID]; !ok {
+			return fmt.Errorf("chart %d: query %d not found in bundle", chart.ID, chart.QueryID)
+		}
+
+		charts[chart.ID] = chart.QueryID
+	}
+
+	for _, panel := range b.Dashboard.Panels {
+		if panel.QueryID != 0 {
+			if _, ok := queries[panel.QueryID]; !ok {
+				return fmt.Errorf("panel %q: query %d not found in bundle", panel.Name, panel.QueryID)
+			}
+		}
+
+		if panel.ChartID != 0 {
+			queryId, ok := charts[panel.ChartID]
+			if !ok {
+				return fmt.Errorf("panel %q: chart %d not found in bundle", panel.Name, panel.ChartID)
+			}
+
+			if queryId != panel.QueryID {
+				return fmt.Errorf("panel %q: chart %d does not belong to query %d", panel.Name, panel.ChartID, panel.QueryID)
+			}
+		}
+	}
+
+	return nil
+}
+
+// source converts the bundle to a duplication source.
+func (b *DashboardBundle) source() *dashboardSource {
+	return &dashboardSource{
+		Dashboard: b.Dashboard,
+		Queries:   b.Queries,
+		Charts:    b.Charts,
+	}
+}
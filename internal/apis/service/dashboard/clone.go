@@ -0,0 +1,234 @@
+package dashboard
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"infra-3.xyz/hyperdot-node/internal/apis/base"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+	"infra-3.xyz/hyperdot-node/internal/utils"
+)
+
+// errNoPermission is returned when the dashboard or query is private to other user.
+var errNoPermission = errors.New("no permission")
+
+// dashboardSource is a dashboard with all panels, queries and charts
+// it references and it is the source of duplication.
+type dashboardSource struct {
+	Dashboard datamodel.DashboardModel
+	Queries   []datamodel.QueryModel
+	Charts    []datamodel.ChartModel
+}
+
+// isPrivacy reports whether the dashboard and all of its queries are private.
+func (source *dashboardSource) isPrivacy() bool {
+	if !source.Dashboard.IsPrivacy {
+		return false
+	}
+
+	for _, query := range source.Queries {
+		if !query.IsPrivacy {
+			return false
+		}
+	}
+
+	return true
+}
+
+// loadDashboardSource loads the dashboard and its panels. The referenced queries and
+// charts are loaded when cloneQueries is true or the dashboard is a template, and they
+// must be readable by userId. The references of panels to the trashed queries are
+// cleared then.
+func (s *Service) loadDashboardSource(id uint, userId uint, cloneQueries bool) (*dashboardSource, error) {
+	var source dashboardSource
+	if err := s.db.First(&source.Dashboard, id).Error; err != nil {
+		return nil, err
+	}
+
+	access, err := base.GetContentAccess(s.db, userId, base.DashboardContent(&source.Dashboard))
+	if err != nil {
+		return nil, err
+	}
+	if access < base.ContentAccessView {
+		return nil, fmt.Errorf("dashboard %d is private: %w", id, errNoPermission)
+	}
+
+	if err := s.db.Where("dashboard_id = ?", id).Order("id ASC").Find(&source.Dashboard.Panels).Error; err != nil {
+		return nil, err
+	}
+
+	if !cloneQueries && !source.Dashboard.IsTemplate {
+		return &source, nil
+	}
+
+	var queryIds []uint
+	for _, panel := range source.Dashboard.Panels {
+		if panel.QueryID != 0 {
+			queryIds = append(queryIds, panel.QueryID)
+		}
+	}
+
+	if len(queryIds) == 0 {
+		return &source, nil
+	}
+
+	if err := s.db.Where("id IN ?", queryIds).Order("id ASC").Find(&source.Queries).Error; err != nil {
+		return nil, err
+	}
+
+	loaded := make(map[uint]bool, len(source.Queries))
+	queryIds = queryIds[:0]
+	for _, query := range source.Queries {
+		access, err := base.GetContentAccess(s.db, userId, base.QueryContent(&query))
+		if err != nil {
+			return nil, err
+		}
+		if access < base.ContentAccessView {
+			return nil, fmt.Errorf("query %d is private: %w", query.ID, errNoPermission)
+		}
+		loaded[query.ID] = true
+		queryIds = append(queryIds, query.ID)
+	}
+
+	// the panels of trashed queries are kept without the query and chart
+	for i := range source.Dashboard.Panels {
+		if panel := &source.Dashboard.Panels[i]; panel.QueryID != 0 && !loaded[panel.QueryID] {
+			panel.QueryID = 0
+			panel.ChartID = 0
+		}
+	}
+
+	if len(queryIds) == 0 {
+		return &source, nil
+	}
+
+	if err := s.db.Where("query_id IN ?", queryIds).Order("id ASC").Find(&source.Charts).Error; err != nil {
+		return nil, err
+	}
+
+	return &source, nil
+}
+
+// templateParams merges the default template parameters of dashboard and the given
+// params, and checks all placeholders in source can be filled.
+func (source *dashboardSource) templateParams(params map[string]string) (map[string]string, error) {
+	merged := make(map[string]string)
+	for k, v := range source.Dashboard.TemplateParams {
+		if v != nil {
+			merged[k] = fmt.Sprint(v)
+		}
+	}
+	for k, v := range params {
+		merged[k] = v
+	}
+
+	texts := []string{source.Dashboard.Name, source.Dashboard.Description}
+	for _, panel := range source.Dashboard.Panels {
+		texts = append(texts, panel.Name, panel.Description, panel.Text)
+	}
+	for _, query := range source.Queries {
+		texts = append(texts, query.Name, query.Description, query.Query)
+	}
+	for _, chart := range source.Charts {
+		texts = append(texts, chart.Name)
+	}
+
+	for _, text := range texts {
+		for _, name := range utils.TemplatePlaceholders(text) {
+			if _, ok := merged[name]; !ok {
+				return nil, fmt.Errorf("template parameter %s is required", name)
+			}
+		}
+	}
+
+	return merged, nil
+}
+
+// cloneDashboard deep copies the source to userId in the tx, the queries and charts of source
+// are copied too and the panels are remapped to them. All texts are rendered by params. The
+// copies are never trashed or starred even if the source, e.g. an imported bundle, says so.
+func cloneDashboard(tx *gorm.DB, source *dashboardSource, userId uint, params map[string]string) (*datamodel.DashboardModel, error) {
+	now := time.Now()
+	queryIds := make(map[uint]uint, len(source.Queries))
+	chartIds := make(map[uint]uint, len(source.Charts))
+
+	for _, query := range source.Queries {
+		sourceId := query.ID
+		query.ID = 0
+		query.UserID = userId
+		query.WorkspaceID = 0
+		query.Name = utils.RenderTemplate(query.Name, params)
+		query.Description = utils.RenderTemplate(query.Description, params)
+		query.Query = utils.RenderTemplate(query.Query, params)
+		query.Stars = 0
+		query.Charts = nil
+		query.CreatedAt = now
+		query.UpdatedAt = now
+		query.DeletedAt = gorm.DeletedAt{}
+		if err := tx.Create(&query).Error; err != nil {
+			return nil, err
+		}
+		queryIds[sourceId] = query.ID
+	}
+
+	for _, chart := range source.Charts {
+		queryId, ok := queryIds[chart.QueryID]
+		if !ok {
+			continue
+		}
+
+		sourceId := chart.ID
+		chart.ID = 0
+		chart.QueryID = queryId
+		chart.UserID = userId
+		chart.Name = utils.RenderTemplate(chart.Name, params)
+		if err := tx.Create(&chart).Error; err != nil {
+			return nil, err
+		}
+		chartIds[sourceId] = chart.ID
+	}
+
+	dashboard := source.Dashboard
+	dashboard.ID = 0
+	dashboard.UserID = userId
+	dashboard.WorkspaceID = 0
+	dashboard.Name = utils.RenderTemplate(dashboard.Name, params)
+	dashboard.Description = utils.RenderTemplate(dashboard.Description, params)
+	dashboard.Panels = nil
+	dashboard.CreatedAt = now
+	dashboard.UpdatedAt = now
+	dashboard.DeletedAt = gorm.DeletedAt{}
+	if err := tx.Create(&dashboard).Error; err != nil {
+		return nil, err
+	}
+
+	for _, panel := range source.Dashboard.Panels {
+		panel.ID = 0
+		panel.UserID = userId
+		panel.DashboardID = dashboard.ID
+		panel.Name = utils.RenderTemplate(panel.Name, params)
+		panel.Description = utils.RenderTemplate(panel.Description, params)
+		panel.Text = utils.RenderTemplate(panel.Text, params)
+		if id, ok := queryIds[panel.QueryID]; ok {
+			panel.QueryID = id
+		}
+		if id, ok := chartIds[panel.ChartID]; ok {
+			panel.ChartID = id
+		}
+		panel.CreatedAt = now
+		panel.UpdatedAt = now
+		if err := tx.Create(&panel).Error; err != nil {
+			return nil, err
+		}
+		dashboard.Panels = append(dashboard.Panels, panel)
+	}
+
+	if err := base.RefreshContentStatistics(tx, userId); err != nil {
+		return nil, err
+	}
+
+	return &dashboard, nil
+}
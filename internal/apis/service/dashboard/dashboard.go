@@ -1,6 +1,8 @@
 package dashboard
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 	"time"
@@ -9,18 +11,21 @@ import (
 	"gorm.io/gorm"
 
 	"infra-3.xyz/hyperdot-node/internal/apis/base"
+	"infra-3.xyz/hyperdot-node/internal/dataengine"
 	"infra-3.xyz/hyperdot-node/internal/datamodel"
 )
 
 const Name = "Dashboard"
 
 type Service struct {
-	db *gorm.DB
+	db      *gorm.DB
+	engines map[string]dataengine.QueryEngine
 }
 
-func New(db *gorm.DB) *Service {
+func New(db *gorm.DB, engines map[string]dataengine.QueryEngine) *Service {
 	return &Service{
-		db: db,
+		db:      db,
+		engines: engines,
 	}
 }
 
@@ -32,14 +37,18 @@ func (s *Service) RouteTables() []base.RouteTable {
 	group := "dashboard"
 	return []base.RouteTable{
 		{
-			Method:  "GET",
-			Path:    group + "/:id",
-			Handler: s.GetDashboardHandler(),
+			Method:      "GET",
+			Path:        group + "/:id",
+			Handler:     s.GetDashboardHandler(),
+			AllowGuest:  true,
+			ApiKeyScope: base.ScopeDashboardRead,
 		},
 		{
-			Method:  "GET",
-			Path:    group,
-			Handler: s.ListDashboardHandler(),
+			Method:      "GET",
+			Path:        group,
+			Handler:     s.ListDashboardHandler(),
+			AllowGuest:  true,
+			ApiKeyScope: base.ScopeDashboardRead,
 		},
 		{
 			Method:  "POST",
@@ -62,14 +71,16 @@ func (s *Service) RouteTables() []base.RouteTable {
 			Handler: s.ListFavoriteDashboardHandler(),
 		},
 		{
-			Method:  "GET",
-			Path:    group + "/browse",
-			Handler: s.ListBrowseUserDashboardHandler(),
+			Method:     "GET",
+			Path:       group + "/browse",
+			Handler:    s.ListBrowseUserDashboardHandler(),
+			AllowGuest: true,
 		},
 		{
-			Method:  "GET",
-			Path:    group + "/tag/populars",
-			Handler: s.ListPopularDashboardTags(),
+			Method:     "GET",
+			Path:       group + "/tag/populars",
+			Handler:    s.ListPopularDashboardTags(),
+			AllowGuest: true,
 		},
 
 		{
@@ -87,11 +98,36 @@ func (s *Service) RouteTables() []base.RouteTable {
 			Path:    group + "/panel/:panelId",
 			Handler: s.RemoveDashboardPanelHandler(),
 		},
+		{
+			Method:  "POST",
+			Path:    group + "/:id/duplicate",
+			Handler: s.DuplicateDashboardHandler(),
+		},
+		{
+			Method:  "GET",
+			Path:    group + "/:id/export",
+			Handler: s.ExportDashboardHandler(),
+		},
+		{
+			Method:  "POST",
+			Path:    group + "/import",
+			Handler: s.ImportDashboardHandler(),
+		},
+		{
+			Method:  "GET",
+			Path:    group + "/trash",
+			Handler: s.ListTrashDashboardHandler(),
+		},
+		{
+			Method:  "POST",
+			Path:    group + "/:id/restore",
+			Handler: s.RestoreDashboardHandler(),
+		},
 	}
 }
 
 // @Summary Get dashboard
-// @Description Get dashboard
+// @Description Get dashboard, the private dashboard can be accessed by its owner, the members of its workspace and the granted users.
 // @Tags Dashboard apis
 // @Accept application/json
 // @Produce application/json
@@ -119,6 +155,10 @@ func (s *Service) GetDashboardHandler() gin.HandlerFunc {
 			return
 		}
 
+		if !base.CheckContentAccess(ctx, s.db, base.DashboardContent(&dashboard), base.ContentAccessView) {
+			return
+		}
+
 		if err := s.db.Where("dashboard_id = ?", dashboard.ID).Find(&dashboard.Panels).Error; err != nil {
 			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
 			return
@@ -137,6 +177,7 @@ func (s *Service) getListParams(ctx *gin.Context) (*prePareListSQLParams, error)
 		page     uint
 		pageSize uint
 		userId   uint
+		wsId     uint
 	)
 	page, err = base.GetUIntQuery(ctx, "page")
 	if err != nil {
@@ -165,6 +206,15 @@ func (s *Service) getListParams(ctx *gin.Context) (*prePareListSQLParams, error)
 		}
 	}
 
+	wsId, err = base.GetUIntQuery(ctx, "workspace_id")
+	if err != nil {
+		if err == base.ErrQueryNotFound {
+			wsId = 0
+		} else {
+			return nil, err
+		}
+	}
+
 	timeRange, err := base.GetStringQuery(ctx, "time_range")
 	if err != nil {
 		if err == base.ErrQueryNotFound {
@@ -184,11 +234,12 @@ func (s *Service) getListParams(ctx *gin.Context) (*prePareListSQLParams, error)
 	}
 
 	return &prePareListSQLParams{
-		Page:      page,
-		PageSize:  pageSize,
-		Order:     order,
-		UserID:    userId,
-		TimeRange: timeRange,
+		Page:        page,
+		PageSize:    pageSize,
+		Order:       order,
+		UserID:      userId,
+		WorkspaceID: wsId,
+		TimeRange:   timeRange,
 	}, nil
 }
 
@@ -202,22 +253,22 @@ func (s *Service) getListParams(ctx *gin.Context) (*prePareListSQLParams, error)
 // @Param user_id query int false "user id"
 // @Param time_range query string false "time range"
 // @Param order query string false "order"
+// @Param workspace_id query int false "list the dashboards of workspace, including the private ones"
 // @Success 200
 // @Router /apis/v1/dashboard [get]
 func (s *Service) ListDashboardHandler() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		currentUserId, err := base.GetCurrentUserId(ctx)
+		currentUserId := base.GetCurrentUserIdOrGuest(ctx)
+		params, err := s.getListParams(ctx)
 		if err != nil {
 			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
 			return
 		}
+		params.CurrentUserId = currentUserId
 
-		params, err := s.getListParams(ctx)
-		if err != nil {
-			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+		if params.WorkspaceID != 0 && !base.CheckWorkspaceRole(ctx, s.db, params.WorkspaceID, currentUserId, datamodel.WorkspaceRoleViewer) {
 			return
 		}
-		params.CurrentUserId = currentUserId
 
 		queryRaw, countRaw, err := s.prepareListSQL(params)
 		if err != nil {
@@ -449,12 +500,7 @@ func (s *Service) ListPopularDashboardTags() gin.HandlerFunc {
 // @Router /apis/v1/dashboard/browse [get]
 func (s *Service) ListBrowseUserDashboardHandler() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		currentUserId, err := base.GetCurrentUserId(ctx)
-		if err != nil {
-			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
-			return
-		}
-
+		currentUserId := base.GetCurrentUserIdOrGuest(ctx)
 		params, err := s.getListParams(ctx)
 		if err != nil {
 			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
@@ -534,13 +580,36 @@ func (s *Service) CreateDashboardHandler() gin.HandlerFunc {
 			return
 		}
 		req.UserID = userId
+		req.ForkedFromID = 0
 		req.CreatedAt = time.Now()
 
-		if err := s.db.Create(&req).Error; err != nil {
+		if !base.CheckPublishPermission(ctx, s.db, userId, req.IsPrivacy) {
+			return
+		}
+
+		if !base.CheckContentWorkspace(ctx, s.db, req.WorkspaceID, userId) {
+			return
+		}
+
+		err = s.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&req).Error; err != nil {
+				return err
+			}
+
+			if err := base.RefreshWorkspacesStatistics(tx, req.WorkspaceID); err != nil {
+				return err
+			}
+
+			return base.RefreshContentStatistics(tx, req.UserID)
+		})
+		if err != nil {
 			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
 			return
 		}
 
+		s.auditCreate(ctx, &req)
+		s.firePublished(nil, &req)
+
 		ctx.JSON(http.StatusOK, Response{
 			BaseResponse: base.ResponseOk(),
 			Data:         req,
@@ -549,7 +618,9 @@ func (s *Service) CreateDashboardHandler() gin.HandlerFunc {
 }
 
 // @Summary Update dashboard
-// @Description Update dashboard
+// @Description Update dashboard, it requires the edit access of dashboard. The dashboard can only be moved and published by its owner or the editors of its workspace.
+// @Description The dashboard is moved to another workspace by workspace_id, 0 means the personal dashboard of its creator.
+// @Description The panels not on the dashboard are created as new panels, and the queries and charts of panels must be viewable by the current user.
 // @Tags Dashboard apis
 // @Accept application/json
 // @Produce application/json
@@ -570,25 +641,74 @@ func (s *Service) UpdateDashboardHandler() gin.HandlerFunc {
 			return
 		}
 
-		if req.UserID != userId {
-			base.ResponseErr(ctx, http.StatusUnauthorized, "You are not the owner of this dashboard")
+		var before datamodel.DashboardModel
+		if err := s.db.First(&before, req.ID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				base.ResponseErr(ctx, http.StatusNotFound, "dashboard not found")
+				return
+			}
+
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
 			return
 		}
 
-		req.UpdatedAt = time.Now()
-
-		if err := s.db.Save(&req).Error; err != nil {
+		if err := s.db.Where("dashboard_id = ?", before.ID).Find(&before.Panels).Error; err != nil {
 			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
 			return
 		}
 
-		for _, panel := range req.Panels {
-			panel.DashboardID = req.ID
-			if err := s.db.Save(&panel).Error; err != nil {
-				base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+		existing := base.DashboardContent(&before)
+		if !base.CheckContentAccess(ctx, s.db, existing, base.ContentAccessEdit) {
+			return
+		}
+
+		// only the managers move and publish the dashboard
+		if req.WorkspaceID != existing.WorkspaceID || req.IsPrivacy != existing.IsPrivacy {
+			if !base.CheckContentAccess(ctx, s.db, existing, base.ContentAccessManage) {
 				return
 			}
 		}
+		req.UserID = existing.UserID
+		req.ForkedFromID = before.ForkedFromID
+		req.CreatedAt = before.CreatedAt
+
+		if req.WorkspaceID != existing.WorkspaceID && !base.CheckContentWorkspace(ctx, s.db, req.WorkspaceID, userId) {
+			return
+		}
+
+		if !base.CheckPublishPermission(ctx, s.db, userId, req.IsPrivacy) {
+			return
+		}
+
+		if !s.checkPanels(ctx, &before, req.Panels) {
+			return
+		}
+
+		req.UpdatedAt = time.Now()
+		req.DeletedAt = gorm.DeletedAt{}
+
+		err = s.db.Transaction(func(tx *gorm.DB) error {
+			// the dashboard is trashed by delete only
+			if err := tx.Select("*").Omit("deleted_at", "created_at").Save(&req).Error; err != nil {
+				return err
+			}
+
+			for i := range req.Panels {
+				req.Panels[i].DashboardID = req.ID
+				if err := tx.Save(&req.Panels[i]).Error; err != nil {
+					return err
+				}
+			}
+
+			return base.RefreshWorkspacesStatistics(tx, existing.WorkspaceID, req.WorkspaceID)
+		})
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		s.auditUpdate(ctx, &before, &req)
+		s.firePublished(&before, &req)
 
 		ctx.JSON(http.StatusOK, Response{
 			BaseResponse: base.ResponseOk(),
@@ -597,8 +717,64 @@ func (s *Service) UpdateDashboardHandler() gin.HandlerFunc {
 	}
 }
 
+// checkPanels checks the panels to update the dashboard. The panels of other dashboards
+// are never moved, they are created as new panels. The queries and charts referenced by
+// the panels must be viewable by the current user, except the ones already on the dashboard.
+// It responses error and returns false if not allowed.
+func (s *Service) checkPanels(ctx *gin.Context, before *datamodel.DashboardModel, panels []datamodel.DashboardPanelModel) bool {
+	ids := make(map[uint]bool)
+	refs := make(map[[2]uint]bool)
+	for _, panel := range before.Panels {
+		ids[panel.ID] = true
+		refs[[2]uint{panel.QueryID, panel.ChartID}] = true
+	}
+
+	for i := range panels {
+		panel := &panels[i]
+		if !ids[panel.ID] {
+			panel.ID = 0
+		}
+		if panel.QueryID == 0 && panel.ChartID == 0 || refs[[2]uint{panel.QueryID, panel.ChartID}] {
+			continue
+		}
+
+		query, err := base.LoadContent(s.db, datamodel.ContentTypeQuery, panel.QueryID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				base.ResponseErr(ctx, http.StatusBadRequest, "panel %q: query %d not found", panel.Name, panel.QueryID)
+				return false
+			}
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return false
+		}
+		if !base.CheckContentAccess(ctx, s.db, query, base.ContentAccessView) {
+			return false
+		}
+
+		if panel.ChartID != 0 {
+			var count int64
+			err := s.db.Model(&datamodel.ChartModel{}).
+				Where("id = ? AND query_id = ?", panel.ChartID, panel.QueryID).
+				Count(&count).Error
+			if err != nil {
+				base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+				return false
+			}
+			if count == 0 {
+				base.ResponseErr(ctx, http.StatusBadRequest, "panel %q: chart %d does not belong to query %d", panel.Name, panel.ChartID, panel.QueryID)
+				return false
+			}
+		}
+
+		refs[[2]uint{panel.QueryID, panel.ChartID}] = true
+	}
+
+	return true
+}
+
 // @Summary Delete dashboard
-// @Description Delete dashboard
+// @Description Delete dashboard, it requires the owner or an editor of the workspace of dashboard.
+// @Description The dashboard is moved to trash, it can be restored before it is purged after the retention period.
 // @Tags Dashboard apis
 // @Accept application/json
 // @Produce application/json
@@ -606,6 +782,68 @@ func (s *Service) UpdateDashboardHandler() gin.HandlerFunc {
 // @Success 200 {object} base.BaseResponse
 // @Router /apis/v1/dashboard/{id} [delete]
 func (s *Service) DeleteDashboardHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := base.GetUintParam(ctx, "id")
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		var dashboard datamodel.DashboardModel
+		if err := s.db.First(&dashboard, id).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				base.ResponseErr(ctx, http.StatusNotFound, "dashboard not found")
+				return
+			}
+
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		if !base.CheckContentAccess(ctx, s.db, base.DashboardContent(&dashboard), base.ContentAccessManage) {
+			return
+		}
+
+		// the dashboard is moved to trash with its panels, they are purged after the retention period
+		err = s.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("id = ?", id).Delete(&datamodel.DashboardModel{}).Error; err != nil {
+				return err
+			}
+
+			if err := base.RefreshWorkspacesStatistics(tx, dashboard.WorkspaceID); err != nil {
+				return err
+			}
+
+			return base.RefreshContentStatistics(tx, dashboard.UserID)
+		})
+
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		base.Audit(ctx, s.db, &base.AuditRecord{
+			Action:     datamodel.AuditDashboardDelete,
+			TargetType: datamodel.AuditTargetDashboard,
+			TargetID:   dashboard.ID,
+			Before:     &dashboard,
+		})
+
+		base.ResponseSuccess(ctx)
+	}
+}
+
+// @Summary Duplicate dashboard
+// @Description Duplicate dashboard with all panels, optionally cloning the referenced queries and charts.
+// @Description If the dashboard is a template, the queries are always cloned and the placeholders are filled by params.
+// @Tags Dashboard apis
+// @Accept application/json
+// @Produce application/json
+// @Param id path int true "dashboard id"
+// @Param body body RequestDuplicateDashboard true "duplicate request"
+// @Success 200 {object} Response
+// @Router /apis/v1/dashboard/{id}/duplicate [post]
+func (s *Service) DuplicateDashboardHandler() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		userId, err := base.GetCurrentUserId(ctx)
 		if err != nil {
@@ -619,17 +857,162 @@ func (s *Service) DeleteDashboardHandler() gin.HandlerFunc {
 			return
 		}
 
-		// delete panels and then delete dashboard using transaction
+		var req RequestDuplicateDashboard
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		source, err := s.loadDashboardSource(id, userId, req.CloneQueries)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				base.ResponseErr(ctx, http.StatusNotFound, err.Error())
+				return
+			}
+
+			if errors.Is(err, errNoPermission) {
+				base.ResponseErr(ctx, http.StatusUnauthorized, err.Error())
+				return
+			}
+
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		var params map[string]string
+		if source.Dashboard.IsTemplate {
+			if params, err = source.templateParams(req.Params); err != nil {
+				base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+
+		if len(req.Name) > 0 {
+			source.Dashboard.Name = req.Name
+		}
+		if len(req.Description) > 0 {
+			source.Dashboard.Description = req.Description
+		}
+		ownerId := source.Dashboard.UserID
+		source.Dashboard.IsPrivacy = req.IsPrivacy
+		source.Dashboard.IsTemplate = false
+		source.Dashboard.ForkedFromID = id
+		source.Dashboard.TemplateParams = nil
+
+		if !base.CheckPublishPermission(ctx, s.db, userId, source.isPrivacy()) {
+			return
+		}
+
+		var dashboard *datamodel.DashboardModel
 		err = s.db.Transaction(func(tx *gorm.DB) error {
-			if err := tx.Where("dashboard_id = ?", id).Delete(&datamodel.DashboardPanelModel{}).Error; err != nil {
-				return err
+			dashboard, err = cloneDashboard(tx, source, userId, params)
+			return err
+		})
+
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		s.auditCreate(ctx, dashboard)
+		s.firePublished(nil, dashboard)
+		base.Notify(s.db, datamodel.NotificationModel{
+			UserID:     ownerId,
+			Type:       datamodel.NotificationFork,
+			ActorID:    userId,
+			TargetType: datamodel.ContentTypeDashboard,
+			TargetID:   id,
+			Data:       datamodel.JSON{"fork_id": dashboard.ID},
+		})
+
+		ctx.JSON(http.StatusOK, Response{
+			BaseResponse: base.ResponseOk(),
+			Data:         *dashboard,
+		})
+	}
+}
+
+// @Summary Export dashboard
+// @Description Export dashboard with its panels and all referenced queries and charts as a portable bundle.
+// @Tags Dashboard apis
+// @Accept application/json
+// @Produce application/json
+// @Param id path int true "dashboard id"
+// @Success 200 {object} DashboardBundle
+// @Router /apis/v1/dashboard/{id}/export [get]
+func (s *Service) ExportDashboardHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userId, err := base.GetCurrentUserId(ctx)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		id, err := base.GetUintParam(ctx, "id")
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		source, err := s.loadDashboardSource(id, userId, true)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				base.ResponseErr(ctx, http.StatusNotFound, err.Error())
+				return
 			}
 
-			if err := tx.Where("id = ? and user_id = ?", id, userId).Delete(&datamodel.DashboardModel{}).Error; err != nil {
-				return err
+			if errors.Is(err, errNoPermission) {
+				base.ResponseErr(ctx, http.StatusUnauthorized, err.Error())
+				return
 			}
 
-			return nil
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=dashboard-%d.json", id))
+		ctx.JSON(http.StatusOK, newDashboardBundle(source))
+	}
+}
+
+// @Summary Import dashboard
+// @Description Import dashboard from a bundle exported by hyperdot node. The ids are remapped and
+// @Description all contents are owned by the current user.
+// @Tags Dashboard apis
+// @Accept application/json
+// @Produce application/json
+// @Param bundle body DashboardBundle true "dashboard bundle"
+// @Success 200 {object} Response
+// @Router /apis/v1/dashboard/import [post]
+func (s *Service) ImportDashboardHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userId, err := base.GetCurrentUserId(ctx)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		var bundle DashboardBundle
+		if err := ctx.ShouldBindJSON(&bundle); err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, "invalid bundle: %v", err)
+			return
+		}
+
+		if err := bundle.validate(s.engines); err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, "invalid bundle: %v", err)
+			return
+		}
+
+		source := bundle.source()
+		source.Dashboard.ForkedFromID = 0
+		if !base.CheckPublishPermission(ctx, s.db, userId, source.isPrivacy()) {
+			return
+		}
+
+		var dashboard *datamodel.DashboardModel
+		err = s.db.Transaction(func(tx *gorm.DB) error {
+			dashboard, err = cloneDashboard(tx, source, userId, nil)
+			return err
 		})
 
 		if err != nil {
@@ -637,7 +1020,13 @@ func (s *Service) DeleteDashboardHandler() gin.HandlerFunc {
 			return
 		}
 
-		base.ResponseSuccess(ctx)
+		s.auditCreate(ctx, dashboard)
+		s.firePublished(nil, dashboard)
+
+		ctx.JSON(http.StatusOK, Response{
+			BaseResponse: base.ResponseOk(),
+			Data:         *dashboard,
+		})
 	}
 }
 
@@ -659,8 +1048,18 @@ func (s *Service) dashboardFavorite(ctx *gin.Context, star bool) {
 		return
 	}
 
-	if request.DashboardUserID == 0 {
-		base.ResponseErr(ctx, http.StatusBadRequest, "dashboard user id is required")
+	// the stars are counted to the owner of dashboard rather than the dashboard_user_id of request
+	var dashboard datamodel.DashboardModel
+	if err := s.db.Where("id = ?", request.DashboardID).First(&dashboard).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			base.ResponseErr(ctx, http.StatusNotFound, "dashboard not found")
+			return
+		}
+		base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if !base.CheckContentAccess(ctx, s.db, base.DashboardContent(&dashboard), base.ContentAccessView) {
 		return
 	}
 
@@ -677,6 +1076,7 @@ func (s *Service) dashboardFavorite(ctx *gin.Context, star bool) {
 		}
 
 	}
+	stared := finded && find.Stared
 
 	err = s.db.Transaction(func(tx *gorm.DB) error {
 		if finded {
@@ -697,15 +1097,7 @@ func (s *Service) dashboardFavorite(ctx *gin.Context, star bool) {
 			}
 		}
 
-		expr := "stars + ?"
-		if !star {
-			expr = "stars - ?"
-		}
-		if err := s.db.Model(&datamodel.UserStatistics{}).Where("user_id = ?", request.DashboardUserID).Update("stars", gorm.Expr(expr, 1)).Error; err != nil {
-			return err
-		}
-
-		return nil
+		return base.RefreshContentStatistics(tx, dashboard.UserID)
 	})
 
 	if err != nil {
@@ -713,6 +1105,34 @@ func (s *Service) dashboardFavorite(ctx *gin.Context, star bool) {
 		return
 	}
 
+	action := datamodel.AuditDashboardFavorite
+	if !star {
+		action = datamodel.AuditDashboardUnfavorite
+	}
+	base.Audit(ctx, s.db, &base.AuditRecord{
+		Action:     action,
+		TargetType: datamodel.AuditTargetDashboard,
+		TargetID:   request.DashboardID,
+	})
+
+	// the owner is notified only when the dashboard is newly favorited by others
+	if star && !stared {
+		base.Notify(s.db, datamodel.NotificationModel{
+			UserID:     dashboard.UserID,
+			Type:       datamodel.NotificationFavorite,
+			ActorID:    userId,
+			TargetType: datamodel.ContentTypeDashboard,
+			TargetID:   dashboard.ID,
+		})
+		if dashboard.UserID != userId {
+			base.FireWebhooks(s.db, dashboard.UserID, datamodel.WebhookFavoriteAdded, map[string]any{
+				"content_type": datamodel.ContentTypeDashboard,
+				"content_id":   dashboard.ID,
+				"user_id":      userId,
+			})
+		}
+	}
+
 	base.ResponseWithData(ctx, find)
 }
 
@@ -745,7 +1165,7 @@ func (s *Service) DashboardUnfavoriteHandler() gin.HandlerFunc {
 }
 
 // @Summary Remove dashboard panel
-// @Description Remove dashboard panel
+// @Description Remove dashboard panel, it requires the edit access of dashboard.
 // @Tags Dashboard apis
 // @Accept application/json
 // @Produce application/json
@@ -760,11 +1180,51 @@ func (s *Service) RemoveDashboardPanelHandler() gin.HandlerFunc {
 			return
 		}
 
-		if err := s.db.Where("id = ?", panelId).Delete(&datamodel.DashboardPanelModel{}).Error; err != nil {
+		var panel datamodel.DashboardPanelModel
+		if err := s.db.First(&panel, panelId).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				base.ResponseErr(ctx, http.StatusNotFound, "panel not found")
+				return
+			}
+
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		var dashboard datamodel.DashboardModel
+		if err := s.db.First(&dashboard, panel.DashboardID).Error; err != nil && err != gorm.ErrRecordNotFound {
 			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
 			return
 		}
 
+		// the panel of deleted dashboard can only be removed by its creator
+		if dashboard.ID == 0 {
+			dashboard.UserID = panel.UserID
+			dashboard.IsPrivacy = true
+		}
+		if !base.CheckContentAccess(ctx, s.db, base.DashboardContent(&dashboard), base.ContentAccessEdit) {
+			return
+		}
+
+		err = s.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("id = ?", panelId).Delete(&datamodel.DashboardPanelModel{}).Error; err != nil {
+				return err
+			}
+
+			return base.DeleteComments(tx, datamodel.CommentTargetPanel, panelId)
+		})
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		base.Audit(ctx, s.db, &base.AuditRecord{
+			Action:     datamodel.AuditPanelDelete,
+			TargetType: datamodel.AuditTargetPanel,
+			TargetID:   panel.ID,
+			Before:     &panel,
+		})
+
 		base.ResponseSuccess(ctx)
 	}
 }
@@ -0,0 +1,16 @@
+package dashboard
+
+// RequestDuplicateDashboard is request of POST /dashboard/:id/duplicate
+type RequestDuplicateDashboard struct {
+	// Name of the new dashboard, default is the name of the source dashboard.
+	Name string `json:"name"`
+	// Description of the new dashboard, default is the description of the source dashboard.
+	Description string `json:"description"`
+	// IsPrivacy of the new dashboard.
+	IsPrivacy bool `json:"is_privacy"`
+	// CloneQueries clones the queries and charts referenced by panels.
+	// It is always true when the source dashboard is a template.
+	CloneQueries bool `json:"clone_queries"`
+	// Params fill the template placeholders, e.g. {"chain_id": "2004"}.
+	Params map[string]string `json:"params"`
+}
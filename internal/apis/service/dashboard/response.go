@@ -10,3 +10,15 @@ type Response struct {
 	base.BaseResponse
 	Data datamodel.DashboardModel `json:"data"`
 }
+
+// ResponseTrashData is the data of ResponseTrash.
+type ResponseTrashData struct {
+	Dashboards []datamodel.DashboardModel `json:"dashboards"`
+	Total      int64                      `json:"total"`
+}
+
+// ResponseTrash is the response of GET /dashboard/trash
+type ResponseTrash struct {
+	base.BaseResponse
+	Data ResponseTrashData `json:"data"`
+}
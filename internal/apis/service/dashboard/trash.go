@@ -0,0 +1,117 @@
+package dashboard
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"infra-3.xyz/hyperdot-node/internal/apis/base"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+)
+
+// @Summary List trashed dashboards
+// @Description List the trashed personal dashboards of current user, or the trashed dashboards of workspace
+// @Description if workspace_id is given, it requires an editor of the workspace. The latest trashed are listed first.
+// @Tags Dashboard apis
+// @Accept application/json
+// @Produce application/json
+// @Param workspace_id query int false "workspace id"
+// @Param page query int false "page"
+// @Param page_size query int false "page_size"
+// @Success 200 {object} ResponseTrash
+// @Router /apis/v1/dashboard/trash [get]
+func (s *Service) ListTrashDashboardHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		filter, ok := base.TrashFilter(ctx, s.db)
+		if !ok {
+			return
+		}
+
+		page, pageSize, err := base.GetPage(ctx, 20)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		var data ResponseTrashData
+		if err := filter(s.db.Model(&datamodel.DashboardModel{})).Count(&data.Total).Error; err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		data.Dashboards = make([]datamodel.DashboardModel, 0)
+		if err := filter(s.db).Order("deleted_at DESC").
+			Offset((page - 1) * pageSize).
+			Limit(pageSize).
+			Find(&data.Dashboards).Error; err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, ResponseTrash{
+			BaseResponse: base.ResponseOk(),
+			Data:         data,
+		})
+	}
+}
+
+// @Summary Restore dashboard
+// @Description Restore the trashed dashboard with its panels, it requires the owner or an editor of the workspace of dashboard.
+// @Tags Dashboard apis
+// @Accept application/json
+// @Produce application/json
+// @Param id path int true "dashboard id"
+// @Success 200 {object} Response
+// @Router /apis/v1/dashboard/{id}/restore [post]
+func (s *Service) RestoreDashboardHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := base.GetUintParam(ctx, "id")
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		var dashboard datamodel.DashboardModel
+		if err := s.db.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).First(&dashboard).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				base.ResponseErr(ctx, http.StatusNotFound, "dashboard not found in trash")
+				return
+			}
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		if !base.CheckContentAccess(ctx, s.db, base.DashboardContent(&dashboard), base.ContentAccessManage) {
+			return
+		}
+
+		err = s.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Unscoped().Model(&dashboard).Update("deleted_at", nil).Error; err != nil {
+				return err
+			}
+
+			if err := base.RefreshWorkspacesStatistics(tx, dashboard.WorkspaceID); err != nil {
+				return err
+			}
+
+			return base.RefreshContentStatistics(tx, dashboard.UserID)
+		})
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		base.Audit(ctx, s.db, &base.AuditRecord{
+			Action:     datamodel.AuditDashboardRestore,
+			TargetType: datamodel.AuditTargetDashboard,
+			TargetID:   dashboard.ID,
+		})
+
+		ctx.JSON(http.StatusOK, Response{
+			BaseResponse: base.ResponseOk(),
+			Data:         dashboard,
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package dashboard
+
+import (
+	"infra-3.xyz/hyperdot-node/internal/apis/base"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+)
+
+// firePublished fires the published webhooks of owner if the dashboard is created
+// public or made public. The dashboard is created if before is nil.
+func (s *Service) firePublished(before *datamodel.DashboardModel, after *datamodel.DashboardModel) {
+	if after.IsPrivacy || (before != nil && !before.IsPrivacy) {
+		return
+	}
+
+	base.FireWebhooks(s.db, after.UserID, datamodel.WebhookDashboardPublished, map[string]any{
+		"dashboard_id":   after.ID,
+		"name":           after.Name,
+		"workspace_id":   after.WorkspaceID,
+		"forked_from_id": after.ForkedFromID,
+	})
+}
@@ -0,0 +1,327 @@
+package follow
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"infra-3.xyz/hyperdot-node/internal/apis/base"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+)
+
+const (
+	ServiceName = "follow"
+
+	feedTypeQuery     = "query"
+	feedTypeDashboard = "dashboard"
+	feedTypeFork      = "fork"
+)
+
+// Service follow service, the users follow other users to list their new
+// public contents in feed.
+type Service struct {
+	db *gorm.DB
+}
+
+// New follow service
+func New(db *gorm.DB) *Service {
+	return &Service{
+		db: db,
+	}
+}
+
+// getUserId gets the user of id param, it responses 404 if the user does not exist.
+func (s *Service) getUserId(ctx *gin.Context) (uint, bool) {
+	id, err := base.GetUintParam(ctx, "id")
+	if err != nil {
+		base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+		return 0, false
+	}
+
+	var count int64
+	if err := s.db.Model(&datamodel.UserModel{}).Where("id = ?", id).Count(&count).Error; err != nil {
+		base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+		return 0, false
+	}
+	if count == 0 {
+		base.ResponseErr(ctx, http.StatusNotFound, "user %d not found", id)
+		return 0, false
+	}
+
+	return id, true
+}
+
+// setFollow follows or unfollows the user and responses the follow state.
+func (s *Service) setFollow(ctx *gin.Context, followed bool) {
+	userId, err := base.GetCurrentUserId(ctx)
+	if err != nil {
+		base.ResponseErr(ctx, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	followeeId, ok := s.getUserId(ctx)
+	if !ok {
+		return
+	}
+
+	if followeeId == userId {
+		base.ResponseErr(ctx, http.StatusBadRequest, "can not follow yourself")
+		return
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if followed {
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&datamodel.UserFollowModel{
+				FollowerID: userId,
+				FolloweeID: followeeId,
+			}).Error; err != nil {
+				return err
+			}
+		} else {
+			if err := tx.Where("follower_id = ? AND followee_id = ?", userId, followeeId).
+				Delete(&datamodel.UserFollowModel{}).Error; err != nil {
+				return err
+			}
+		}
+
+		return base.RefreshFollowStatistics(tx, userId, followeeId)
+	})
+	if err != nil {
+		base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var statistics datamodel.UserStatistics
+	if err := s.db.Where("user_id = ?", followeeId).First(&statistics).Error; err != nil {
+		base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ResponseFollow{
+		BaseResponse: base.ResponseOk(),
+		Data: ResponseFollowData{
+			UserID:    followeeId,
+			Followed:  followed,
+			Followers: statistics.Followers,
+		},
+	})
+}
+
+// @Summary follow user
+// @Description Follow the user, the new public contents of the user are listed in the feed of current user.
+// @Tags follow apis
+// @Accept application/json
+// @Produce application/json
+// @Param id path int true "user id"
+// @Success 200 {object} ResponseFollow
+// @Router /user/{id}/follow [post]
+func (s *Service) FollowHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		s.setFollow(ctx, true)
+	}
+}
+
+// @Summary unfollow user
+// @Description Unfollow the user.
+// @Tags follow apis
+// @Accept application/json
+// @Produce application/json
+// @Param id path int true "user id"
+// @Success 200 {object} ResponseFollow
+// @Router /user/{id}/follow [delete]
+func (s *Service) UnfollowHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		s.setFollow(ctx, false)
+	}
+}
+
+// listFollowUsers lists the users on the other side of the follows of user, the
+// latest follows are listed first.
+func (s *Service) listFollowUsers(ctx *gin.Context, column string, otherColumn string) {
+	userId, ok := s.getUserId(ctx)
+	if !ok {
+		return
+	}
+
+	page, pageSize, err := base.GetPage(ctx, 10)
+	if err != nil {
+		base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var data ResponseListFollowUsersData
+	if err := s.db.Model(&datamodel.UserFollowModel{}).Where(column+" = ?", userId).Count(&data.Total).Error; err != nil {
+		base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	data.Users = make([]ResponseFollowUserData, 0)
+	if err := s.db.Table(datamodel.UserFollowModel{}.TableName()+" AS f").
+		Select(fmt.Sprintf("f.%s AS user_id, u.username, u.icon_url, u.bio, f.created_at AS followed_at", otherColumn)).
+		Joins(fmt.Sprintf("JOIN %s AS u ON u.id = f.%s", datamodel.UserModel{}.TableName(), otherColumn)).
+		Where("f."+column+" = ?", userId).
+		Order("f.id DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Scan(&data.Users).Error; err != nil {
+		base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ResponseListFollowUsers{
+		BaseResponse: base.ResponseOk(),
+		Data:         data,
+	})
+}
+
+// @Summary list followers
+// @Description List the followers of user, the latest followers are listed first.
+// @Tags follow apis
+// @Accept application/json
+// @Produce application/json
+// @Param id path int true "user id"
+// @Param page query int false "page"
+// @Param page_size query int false "page_size"
+// @Success 200 {object} ResponseListFollowUsers
+// @Router /user/{id}/followers [get]
+func (s *Service) ListFollowersHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		s.listFollowUsers(ctx, "followee_id", "follower_id")
+	}
+}
+
+// @Summary list following
+// @Description List the users followed by user, the latest followed users are listed first.
+// @Tags follow apis
+// @Accept application/json
+// @Produce application/json
+// @Param id path int true "user id"
+// @Param page query int false "page"
+// @Param page_size query int false "page_size"
+// @Success 200 {object} ResponseListFollowUsers
+// @Router /user/{id}/following [get]
+func (s *Service) ListFollowingHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		s.listFollowUsers(ctx, "follower_id", "followee_id")
+	}
+}
+
+// @Summary get feed
+// @Description List the new public queries, dashboards and forks of the users followed by current user, the latest are listed first.
+// @Description Pass the next_cursor of response as cursor to get the next page.
+// @Tags follow apis
+// @Accept application/json
+// @Produce application/json
+// @Param cursor query string false "cursor of next page"
+// @Param limit query int false "limit, default 20 and at most 100"
+// @Success 200 {object} ResponseFeed
+// @Router /user/feed [get]
+func (s *Service) FeedHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userId, err := base.GetCurrentUserId(ctx)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		limit, err := base.GetUIntQuery(ctx, "limit")
+		if err != nil {
+			if err != base.ErrQueryNotFound {
+				base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+				return
+			}
+			limit = 20
+		}
+		if limit == 0 || limit > 100 {
+			base.ResponseErr(ctx, http.StatusBadRequest, "invalid limit")
+			return
+		}
+
+		followees := fmt.Sprintf("SELECT followee_id FROM %s WHERE follower_id = @user", datamodel.UserFollowModel{}.TableName())
+		sql := fmt.Sprintf(`SELECT feed.*, u.username, u.icon_url FROM (
+			SELECT '%s' AS type, q.id, q.user_id, q.name, q.description, 0 AS forked_from_id, q.created_at
+				FROM %s AS q WHERE q.deleted_at IS NULL AND q.is_privacy = FALSE AND q.unsaved = FALSE AND q.user_id IN (%s)
+			UNION ALL
+			SELECT CASE WHEN d.forked_from_id <> 0 THEN '%s' ELSE '%s' END AS type, d.id, d.user_id, d.name, d.description, d.forked_from_id, d.created_at
+				FROM %s AS d WHERE d.deleted_at IS NULL AND d.is_privacy = FALSE AND d.user_id IN (%s)
+		) AS feed JOIN %s AS u ON u.id = feed.user_id`,
+			feedTypeQuery, datamodel.QueryModel{}.TableName(), followees,
+			feedTypeFork, feedTypeDashboard, datamodel.DashboardModel{}.TableName(), followees,
+			datamodel.UserModel{}.TableName())
+
+		args := map[string]any{"user": userId, "limit": limit + 1}
+		if text := ctx.Query("cursor"); len(text) > 0 {
+			cursor, err := decodeFeedCursor(text)
+			if err != nil {
+				base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+				return
+			}
+
+			sql += " WHERE (feed.created_at, feed.type, feed.id) < (@created_at, @type, @id)"
+			args["created_at"] = cursor.CreatedAt
+			args["type"] = cursor.Type
+			args["id"] = cursor.ID
+		}
+		sql += " ORDER BY feed.created_at DESC, feed.type DESC, feed.id DESC LIMIT @limit"
+
+		items := make([]ResponseFeedItem, 0)
+		if err := s.db.Raw(sql, args).Scan(&items).Error; err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		data := ResponseFeedData{Items: items}
+		if len(items) > int(limit) {
+			data.Items = items[:limit]
+			last := data.Items[limit-1]
+			data.NextCursor = (&feedCursor{CreatedAt: last.CreatedAt, Type: last.Type, ID: last.ID}).encode()
+		}
+
+		ctx.JSON(http.StatusOK, ResponseFeed{
+			BaseResponse: base.ResponseOk(),
+			Data:         data,
+		})
+	}
+}
+
+// Name service name
+func (s *Service) Name() string {
+	return ServiceName
+}
+
+// RouteTables route tables
+func (s *Service) RouteTables() []base.RouteTable {
+	group := "user"
+	return []base.RouteTable{
+		{
+			Method:  "GET",
+			Path:    group + "/feed",
+			Handler: s.FeedHandler(),
+		},
+		{
+			Method:  "POST",
+			Path:    group + "/:id/follow",
+			Handler: s.FollowHandler(),
+		},
+		{
+			Method:  "DELETE",
+			Path:    group + "/:id/follow",
+			Handler: s.UnfollowHandler(),
+		},
+		{
+			Method:     "GET",
+			Path:       group + "/:id/followers",
+			Handler:    s.ListFollowersHandler(),
+			AllowGuest: true,
+		},
+		{
+			Method:     "GET",
+			Path:       group + "/:id/following",
+			Handler:    s.ListFollowingHandler(),
+			AllowGuest: true,
+		},
+	}
+}
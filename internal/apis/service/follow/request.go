@@ -0,0 +1,35 @@
+package follow
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// feedCursor is the position of the last item of a feed page, the next page
+// starts after it.
+type feedCursor struct {
+	CreatedAt time.Time `json:"t"`
+	Type      string    `json:"k"`
+	ID        uint      `json:"i"`
+}
+
+func (c *feedCursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeFeedCursor(text string) (*feedCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(text)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	var cursor feedCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	return &cursor, nil
+}
@@ -0,0 +1,68 @@
+package follow
+
+import (
+	"time"
+
+	"infra-3.xyz/hyperdot-node/internal/apis/base"
+)
+
+// ResponseFollowData is the follow state between current user and the user
+type ResponseFollowData struct {
+	UserID    uint `json:"user_id"`
+	Followed  bool `json:"followed"`
+	Followers uint `json:"followers"`
+}
+
+// ResponseFollow is response of POST /user/:id/follow and DELETE /user/:id/follow
+type ResponseFollow struct {
+	base.BaseResponse
+	Data ResponseFollowData `json:"data"`
+}
+
+// ResponseFollowUserData is a follower or followee with the profile of user
+type ResponseFollowUserData struct {
+	UserID     uint      `json:"user_id"`
+	Username   string    `json:"username"`
+	IconUrl    string    `json:"icon_url"`
+	Bio        string    `json:"bio"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+// ResponseListFollowUsersData is data of response of GET /user/:id/followers and GET /user/:id/following
+type ResponseListFollowUsersData struct {
+	Users []ResponseFollowUserData `json:"users"`
+	Total int64                    `json:"total"`
+}
+
+// ResponseListFollowUsers is response of GET /user/:id/followers and GET /user/:id/following
+type ResponseListFollowUsers struct {
+	base.BaseResponse
+	Data ResponseListFollowUsersData `json:"data"`
+}
+
+// ResponseFeedItem is a new public content of followed user, the type is one of
+// query, dashboard and fork.
+type ResponseFeedItem struct {
+	Type         string    `json:"type"`
+	ID           uint      `json:"id"`
+	UserID       uint      `json:"user_id"`
+	Username     string    `json:"username"`
+	IconUrl      string    `json:"icon_url"`
+	Name         string    `json:"name"`
+	Description  string    `json:"description"`
+	ForkedFromID uint      `json:"forked_from_id"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// ResponseFeedData is data of response of GET /user/feed
+type ResponseFeedData struct {
+	Items []ResponseFeedItem `json:"items"`
+	// NextCursor is the cursor of next page, it is empty if there is no more items.
+	NextCursor string `json:"next_cursor"`
+}
+
+// ResponseFeed is response of GET /user/feed
+type ResponseFeed struct {
+	base.BaseResponse
+	Data ResponseFeedData `json:"data"`
+}
@@ -0,0 +1,298 @@
+package notification
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"infra-3.xyz/hyperdot-node/internal/apis/base"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+)
+
+const ServiceName = "notification"
+
+// Service notification service, the users are notified of the favorites, forks and
+// comments of their contents and the failures of their scheduled queries.
+type Service struct {
+	db *gorm.DB
+}
+
+// New notification service
+func New(db *gorm.DB) *Service {
+	return &Service{
+		db: db,
+	}
+}
+
+// countUnread counts the unread notifications of user.
+func (s *Service) countUnread(userId uint) (int64, error) {
+	var count int64
+	err := s.db.Model(&datamodel.NotificationModel{}).Where("user_id = ? AND read_at IS NULL", userId).Count(&count).Error
+	return count, err
+}
+
+// responseUnread responses the unread count of user.
+func (s *Service) responseUnread(ctx *gin.Context, userId uint) {
+	unread, err := s.countUnread(userId)
+	if err != nil {
+		base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ResponseUnread{
+		BaseResponse: base.ResponseOk(),
+		Data:         ResponseUnreadData{Unread: unread},
+	})
+}
+
+// @Summary list notifications
+// @Description List the notifications of current user, the latest are listed first.
+// @Tags notification apis
+// @Accept application/json
+// @Produce application/json
+// @Param unread query bool false "list the unread only"
+// @Param page query int false "page"
+// @Param page_size query int false "page_size"
+// @Success 200 {object} ResponseListNotifications
+// @Router /user/notifications [get]
+func (s *Service) ListNotificationsHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userId, err := base.GetCurrentUserId(ctx)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		page, pageSize, err := base.GetPage(ctx, 20)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		unread := ctx.Query("unread") == "true"
+		filter := func() *gorm.DB {
+			tx := s.db.Table(datamodel.NotificationModel{}.TableName()+" AS n").Where("n.user_id = ?", userId)
+			if unread {
+				tx = tx.Where("n.read_at IS NULL")
+			}
+			return tx
+		}
+
+		var data ResponseListNotificationsData
+		if err := filter().Count(&data.Total).Error; err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		data.Notifications = make([]ResponseNotificationData, 0)
+		if err := filter().Select("n.*, u.username AS actor_username, u.icon_url AS actor_icon_url").
+			Joins("LEFT JOIN " + datamodel.UserModel{}.TableName() + " AS u ON u.id = n.actor_id").
+			Order("n.id DESC").
+			Offset((page - 1) * pageSize).
+			Limit(pageSize).
+			Scan(&data.Notifications).Error; err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		if data.Unread, err = s.countUnread(userId); err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, ResponseListNotifications{
+			BaseResponse: base.ResponseOk(),
+			Data:         data,
+		})
+	}
+}
+
+// @Summary unread count
+// @Description Count the unread notifications of current user.
+// @Tags notification apis
+// @Accept application/json
+// @Produce application/json
+// @Success 200 {object} ResponseUnread
+// @Router /user/notifications/unread [get]
+func (s *Service) UnreadCountHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userId, err := base.GetCurrentUserId(ctx)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		s.responseUnread(ctx, userId)
+	}
+}
+
+// @Summary mark read
+// @Description Mark the notifications of current user as read, and response the unread count.
+// @Tags notification apis
+// @Accept application/json
+// @Produce application/json
+// @Param body body RequestMarkRead true "notifications"
+// @Success 200 {object} ResponseUnread
+// @Router /user/notifications/read [put]
+func (s *Service) MarkReadHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userId, err := base.GetCurrentUserId(ctx)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		var request RequestMarkRead
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if !request.All && len(request.IDs) == 0 {
+			base.ResponseErr(ctx, http.StatusBadRequest, "ids or all is required")
+			return
+		}
+
+		tx := s.db.Model(&datamodel.NotificationModel{}).Where("user_id = ? AND read_at IS NULL", userId)
+		if !request.All {
+			tx = tx.Where("id IN ?", request.IDs)
+		}
+		if err := tx.Update("read_at", time.Now()).Error; err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		s.responseUnread(ctx, userId)
+	}
+}
+
+// responsePreferences responses the preferences of all notification types.
+func responsePreferences(ctx *gin.Context, user *datamodel.UserModel) {
+	preferences := make(map[string]bool)
+	for _, notificationType := range datamodel.NotificationTypes {
+		preferences[notificationType] = user.NotificationEnabled(notificationType)
+	}
+
+	ctx.JSON(http.StatusOK, ResponsePreferences{
+		BaseResponse: base.ResponseOk(),
+		Data:         preferences,
+	})
+}
+
+// getCurrentUser gets the notification preferences of current user.
+func (s *Service) getCurrentUser(ctx *gin.Context) (*datamodel.UserModel, bool) {
+	userId, err := base.GetCurrentUserId(ctx)
+	if err != nil {
+		base.ResponseErr(ctx, http.StatusUnauthorized, err.Error())
+		return nil, false
+	}
+
+	var user datamodel.UserModel
+	if err := s.db.Select("id", "notification_preferences").Where("id = ?", userId).First(&user).Error; err != nil {
+		base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+		return nil, false
+	}
+
+	return &user, true
+}
+
+// @Summary get notification preferences
+// @Description Get whether current user receives each notification type.
+// @Tags notification apis
+// @Accept application/json
+// @Produce application/json
+// @Success 200 {object} ResponsePreferences
+// @Router /user/notifications/preferences [get]
+func (s *Service) GetPreferencesHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, ok := s.getCurrentUser(ctx)
+		if !ok {
+			return
+		}
+
+		responsePreferences(ctx, user)
+	}
+}
+
+// @Summary update notification preferences
+// @Description Enable or disable the notification types of current user.
+// @Tags notification apis
+// @Accept application/json
+// @Produce application/json
+// @Param body body RequestUpdatePreferences true "preferences"
+// @Success 200 {object} ResponsePreferences
+// @Router /user/notifications/preferences [put]
+func (s *Service) UpdatePreferencesHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var request RequestUpdatePreferences
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		for notificationType := range request.Preferences {
+			if !datamodel.IsValidNotificationType(notificationType) {
+				base.ResponseErr(ctx, http.StatusBadRequest, "invalid notification type %s", notificationType)
+				return
+			}
+		}
+
+		user, ok := s.getCurrentUser(ctx)
+		if !ok {
+			return
+		}
+
+		if user.NotificationPreferences == nil {
+			user.NotificationPreferences = make(datamodel.JSON)
+		}
+		for notificationType, enabled := range request.Preferences {
+			user.NotificationPreferences[notificationType] = enabled
+		}
+
+		if err := s.db.Model(user).Update("notification_preferences", user.NotificationPreferences).Error; err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		responsePreferences(ctx, user)
+	}
+}
+
+// Name service name
+func (s *Service) Name() string {
+	return ServiceName
+}
+
+// RouteTables route tables
+func (s *Service) RouteTables() []base.RouteTable {
+	group := "user/notifications"
+	return []base.RouteTable{
+		{
+			Method:  "GET",
+			Path:    group,
+			Handler: s.ListNotificationsHandler(),
+		},
+		{
+			Method:  "GET",
+			Path:    group + "/unread",
+			Handler: s.UnreadCountHandler(),
+		},
+		{
+			Method:  "PUT",
+			Path:    group + "/read",
+			Handler: s.MarkReadHandler(),
+		},
+		{
+			Method:  "GET",
+			Path:    group + "/preferences",
+			Handler: s.GetPreferencesHandler(),
+		},
+		{
+			Method:  "PUT",
+			Path:    group + "/preferences",
+			Handler: s.UpdatePreferencesHandler(),
+		},
+	}
+}
@@ -0,0 +1,16 @@
+package notification
+
+// RequestMarkRead is request of PUT /user/notifications/read
+type RequestMarkRead struct {
+	// IDs are the notifications to mark as read.
+	IDs []uint `json:"ids"`
+	// All marks all notifications of current user as read, the IDs are ignored.
+	All bool `json:"all"`
+}
+
+// RequestUpdatePreferences is request of PUT /user/notifications/preferences
+type RequestUpdatePreferences struct {
+	// Preferences enables or disables the notification types, the types not in
+	// it are unchanged.
+	Preferences map[string]bool `json:"preferences"`
+}
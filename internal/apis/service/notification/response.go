@@ -0,0 +1,44 @@
+package notification
+
+import (
+	"infra-3.xyz/hyperdot-node/internal/apis/base"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+)
+
+// ResponseNotificationData is a notification with the profile of actor
+type ResponseNotificationData struct {
+	datamodel.NotificationModel
+	ActorUsername string `json:"actor_username"`
+	ActorIconUrl  string `json:"actor_icon_url"`
+}
+
+// ResponseListNotificationsData is data of response of listing notifications
+type ResponseListNotificationsData struct {
+	Notifications []ResponseNotificationData `json:"notifications"`
+	Total         int64                      `json:"total"`
+	Unread        int64                      `json:"unread"`
+}
+
+// ResponseListNotifications is response of listing notifications
+type ResponseListNotifications struct {
+	base.BaseResponse
+	Data ResponseListNotificationsData `json:"data"`
+}
+
+// ResponseUnreadData is data of response of unread count
+type ResponseUnreadData struct {
+	Unread int64 `json:"unread"`
+}
+
+// ResponseUnread is response of unread count and marking read
+type ResponseUnread struct {
+	base.BaseResponse
+	Data ResponseUnreadData `json:"data"`
+}
+
+// ResponsePreferences is response of notification preferences, it has all
+// notification types.
+type ResponsePreferences struct {
+	base.BaseResponse
+	Data map[string]bool `json:"data"`
+}
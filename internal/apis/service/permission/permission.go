@@ -0,0 +1,256 @@
+package permission
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"infra-3.xyz/hyperdot-node/internal/apis/base"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+)
+
+const ServiceName = "permission"
+
+// Service permission service, the private queries and dashboards are shared with
+// other users as viewers or editors by it.
+type Service struct {
+	db *gorm.DB
+}
+
+// New permission service
+func New(db *gorm.DB) *Service {
+	return &Service{
+		db: db,
+	}
+}
+
+// getContent gets the content of id param which the current user has the access.
+func (s *Service) getContent(ctx *gin.Context, contentType string, required base.ContentAccess) (*base.Content, bool) {
+	id, err := base.GetUintParam(ctx, "id")
+	if err != nil {
+		base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+
+	content, err := base.LoadContent(s.db, contentType, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			base.ResponseErr(ctx, http.StatusNotFound, "%s not found", contentType)
+			return nil, false
+		}
+
+		base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+		return nil, false
+	}
+
+	if !base.CheckContentAccess(ctx, s.db, content, required) {
+		return nil, false
+	}
+
+	return content, true
+}
+
+// @Summary list permissions
+// @Description List the users granted the permissions of query or dashboard, it requires the edit access.
+// @Tags permission apis
+// @Accept application/json
+// @Produce application/json
+// @Param id path int true "query or dashboard id"
+// @Success 200 {object} ResponseListPermissions
+// @Router /query/{id}/permissions [get]
+// @Router /dashboard/{id}/permissions [get]
+func (s *Service) ListPermissionsHandler(contentType string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		content, ok := s.getContent(ctx, contentType, base.ContentAccessEdit)
+		if !ok {
+			return
+		}
+
+		permissions := make([]ResponsePermissionData, 0)
+		if err := s.db.Table(datamodel.ContentPermissionModel{}.TableName()+" AS p").
+			Select("p.user_id, u.username, u.icon_url, p.permission, p.granted_by, p.created_at").
+			Joins("LEFT JOIN "+datamodel.UserModel{}.TableName()+" AS u ON u.id = p.user_id").
+			Where("p.content_type = ? AND p.content_id = ?", contentType, content.ID).
+			Order("p.id ASC").
+			Scan(&permissions).Error; err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, ResponseListPermissions{
+			BaseResponse: base.ResponseOk(),
+			Data:         permissions,
+		})
+	}
+}
+
+// @Summary grant permission
+// @Description Grant a user the viewer or editor permission of query or dashboard, the permission is updated if it has been granted.
+// @Description It requires the owner or an editor of the workspace of content.
+// @Tags permission apis
+// @Accept application/json
+// @Produce application/json
+// @Param id path int true "query or dashboard id"
+// @Param body body RequestGrantPermission true "permission"
+// @Success 200 {object} ResponsePermission
+// @Router /query/{id}/permissions [post]
+// @Router /dashboard/{id}/permissions [post]
+func (s *Service) GrantPermissionHandler(contentType string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userId, err := base.GetCurrentUserId(ctx)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		var request RequestGrantPermission
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if !datamodel.IsValidPermission(request.Permission) {
+			base.ResponseErr(ctx, http.StatusBadRequest, "invalid permission %s", request.Permission)
+			return
+		}
+
+		if request.UserID == 0 && len(request.Username) == 0 {
+			base.ResponseErr(ctx, http.StatusBadRequest, "user id or username is required")
+			return
+		}
+
+		content, ok := s.getContent(ctx, contentType, base.ContentAccessManage)
+		if !ok {
+			return
+		}
+
+		var user datamodel.UserModel
+		tx := s.db.Select("id")
+		if request.UserID != 0 {
+			tx = tx.Where("id = ?", request.UserID)
+		} else {
+			tx = tx.Where("username = ?", request.Username)
+		}
+		if err := tx.First(&user).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				base.ResponseErr(ctx, http.StatusNotFound, "user not found")
+				return
+			}
+
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		if user.ID == content.UserID {
+			base.ResponseErr(ctx, http.StatusBadRequest, "can not grant the owner")
+			return
+		}
+
+		now := time.Now()
+		permission := datamodel.ContentPermissionModel{
+			ContentType: contentType,
+			ContentID:   content.ID,
+			UserID:      user.ID,
+			Permission:  request.Permission,
+			GrantedBy:   userId,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+		if err := s.db.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "content_type"}, {Name: "content_id"}, {Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"permission", "granted_by", "updated_at"}),
+		}).Create(&permission).Error; err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, ResponsePermission{
+			BaseResponse: base.ResponseOk(),
+			Data:         permission,
+		})
+	}
+}
+
+// @Summary revoke permission
+// @Description Revoke the permission of user, it requires the owner or an editor of the workspace of content unless the user revokes its own permission.
+// @Tags permission apis
+// @Accept application/json
+// @Produce application/json
+// @Param id path int true "query or dashboard id"
+// @Param userId path int true "user id"
+// @Success 200 {object} base.BaseResponse
+// @Router /query/{id}/permissions/{userId} [delete]
+// @Router /dashboard/{id}/permissions/{userId} [delete]
+func (s *Service) RevokePermissionHandler(contentType string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userId, err := base.GetCurrentUserId(ctx)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		granteeId, err := base.GetUintParam(ctx, "userId")
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		required := base.ContentAccessManage
+		if granteeId == userId {
+			required = base.ContentAccessNone
+		}
+		content, ok := s.getContent(ctx, contentType, required)
+		if !ok {
+			return
+		}
+
+		result := s.db.Where("content_type = ? AND content_id = ? AND user_id = ?", contentType, content.ID, granteeId).
+			Delete(&datamodel.ContentPermissionModel{})
+		if result.Error != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, result.Error.Error())
+			return
+		}
+
+		if result.RowsAffected == 0 {
+			base.ResponseErr(ctx, http.StatusNotFound, "permission not found")
+			return
+		}
+
+		base.ResponseSuccess(ctx)
+	}
+}
+
+// Name service name
+func (s *Service) Name() string {
+	return ServiceName
+}
+
+// RouteTables route tables
+func (s *Service) RouteTables() []base.RouteTable {
+	var tables []base.RouteTable
+	for _, contentType := range []string{datamodel.ContentTypeQuery, datamodel.ContentTypeDashboard} {
+		tables = append(tables,
+			base.RouteTable{
+				Method:  "GET",
+				Path:    contentType + "/:id/permissions",
+				Handler: s.ListPermissionsHandler(contentType),
+			},
+			base.RouteTable{
+				Method:  "POST",
+				Path:    contentType + "/:id/permissions",
+				Handler: s.GrantPermissionHandler(contentType),
+			},
+			base.RouteTable{
+				Method:  "DELETE",
+				Path:    contentType + "/:id/permissions/:userId",
+				Handler: s.RevokePermissionHandler(contentType),
+			},
+		)
+	}
+
+	return tables
+}
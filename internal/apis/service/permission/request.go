@@ -0,0 +1,10 @@
+package permission
+
+// RequestGrantPermission is request of POST /query/:id/permissions and POST /dashboard/:id/permissions
+type RequestGrantPermission struct {
+	// UserID is the user to grant, the user is found by Username if it is 0.
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username"`
+	// Permission is one of viewer and editor.
+	Permission string `json:"permission"`
+}
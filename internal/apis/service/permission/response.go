@@ -0,0 +1,30 @@
+package permission
+
+import (
+	"time"
+
+	"infra-3.xyz/hyperdot-node/internal/apis/base"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+)
+
+// ResponsePermissionData is a granted permission with the profile of user
+type ResponsePermissionData struct {
+	UserID     uint      `json:"user_id"`
+	Username   string    `json:"username"`
+	IconUrl    string    `json:"icon_url"`
+	Permission string    `json:"permission"`
+	GrantedBy  uint      `json:"granted_by"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ResponseListPermissions is response of GET /query/:id/permissions and GET /dashboard/:id/permissions
+type ResponseListPermissions struct {
+	base.BaseResponse
+	Data []ResponsePermissionData `json:"data"`
+}
+
+// ResponsePermission is response of POST /query/:id/permissions and POST /dashboard/:id/permissions
+type ResponsePermission struct {
+	base.BaseResponse
+	Data datamodel.ContentPermissionModel `json:"data"`
+}
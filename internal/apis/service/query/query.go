@@ -13,6 +13,7 @@ import (
 	"gorm.io/gorm"
 
 	"infra-3.xyz/hyperdot-node/internal/apis/base"
+	"infra-3.xyz/hyperdot-node/internal/cache"
 	"infra-3.xyz/hyperdot-node/internal/clients"
 	"infra-3.xyz/hyperdot-node/internal/common"
 	"infra-3.xyz/hyperdot-node/internal/dataengine"
@@ -28,6 +29,7 @@ type Service struct {
 	bboltStore     *store.BoltStore
 	bigqueryClient *clients.SimpleBigQueryClient
 	engines        map[string]dataengine.QueryEngine
+	resultCache    *cache.QueryResultCache
 }
 
 func New(bboltStore *store.BoltStore, cfg *common.Config, db *gorm.DB, engines map[string]dataengine.QueryEngine) *Service {
@@ -42,6 +44,7 @@ func New(bboltStore *store.BoltStore, cfg *common.Config, db *gorm.DB, engines m
 		bboltStore:     bboltStore,
 		bigqueryClient: bigqueryClient,
 		engines:        engines,
+		resultCache:    cache.NewQueryResultCache(&cfg.Redis),
 	}
 }
 
@@ -82,53 +85,25 @@ func (s *Service) RunHandler() gin.HandlerFunc {
 			return
 		}
 
-		iter, err := engine.Run(context.Background(), request.Query)
+		result, err := dataengine.Execute(context.Background(), engine, request.Query)
 		if err != nil {
 			base.ResponseErr(ctx, http.StatusBadRequest, "query error: %v", err)
 			return
 		}
 
-		var rows []map[string]interface{}
-		for {
-			row, err := iter.Next()
-			if err != nil {
-				if errors.Is(err, dataengine.IterDone) {
-					break
-				}
-				base.ResponseErr(ctx, http.StatusBadRequest, "query error: %v", err)
-				return
-			}
-			rows = append(rows, row)
-		}
-
-		schemas := iter.Schema()
-
 		ctx.JSON(http.StatusOK, ResponseRun{
 			BaseResponse: base.BaseResponse{
 				Success: true,
 			},
 			Data: ResponseRunData{
-				Rows:    rows,
-				Schemas: schemas,
+				Rows:    result.Rows,
+				Schemas: result.Schemas,
 			},
 		})
 	}
 }
 
-func (s *Service) checkUserQueryModelRequest(ctx *gin.Context, model *datamodel.QueryModel) bool {
-	currentUserId, err := base.GetCurrentUserId(ctx)
-	if err != nil {
-		base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
-		return false
-	}
-
-	if model.UserID != 0 && model.UserID != currentUserId {
-		base.ResponseErr(ctx, http.StatusUnauthorized, "Unauthorized")
-		return false
-	}
-
-	model.UserID = currentUserId
-
+func (s *Service) checkQueryModelRequest(ctx *gin.Context, model *datamodel.QueryModel) bool {
 	if len(model.QueryEngine) == 0 {
 		base.ResponseErr(ctx, http.StatusBadRequest, "query engine is required")
 		return false
@@ -151,7 +126,7 @@ func (s *Service) checkUserQueryModelRequest(ctx *gin.Context, model *datamodel.
 }
 
 // @Summary get query
-// @Description get query
+// @Description get query, the private query can be accessed by its owner, the members of its workspace and the granted users.
 // @Tags query apis
 // @Accept application/json
 // @Produce application/json
@@ -172,6 +147,10 @@ func (s *Service) GetQueryHandler() gin.HandlerFunc {
 			return
 		}
 
+		if !base.CheckContentAccess(ctx, s.db, base.QueryContent(&query), base.ContentAccessView) {
+			return
+		}
+
 		if err := s.db.Where("query_id = ? AND user_id = ?", query.ID, query.UserID).Order("id ASC").Find(&query.Charts).Error; err != nil {
 			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
 			return
@@ -192,6 +171,7 @@ func (s *Service) getListParams(ctx *gin.Context) (*prePareListSQLParams, error)
 		page     uint
 		pageSize uint
 		userId   uint
+		wsId     uint
 	)
 	page, err = base.GetUIntQuery(ctx, "page")
 	if err != nil {
@@ -220,6 +200,15 @@ func (s *Service) getListParams(ctx *gin.Context) (*prePareListSQLParams, error)
 		}
 	}
 
+	wsId, err = base.GetUIntQuery(ctx, "workspace_id")
+	if err != nil {
+		if err == base.ErrQueryNotFound {
+			wsId = 0
+		} else {
+			return nil, err
+		}
+	}
+
 	timeRange, err := base.GetStringQuery(ctx, "time_range")
 	if err != nil {
 		if err == base.ErrQueryNotFound {
@@ -239,11 +228,12 @@ func (s *Service) getListParams(ctx *gin.Context) (*prePareListSQLParams, error)
 	}
 
 	return &prePareListSQLParams{
-		Page:      page,
-		PageSize:  pageSize,
-		Order:     order,
-		UserID:    userId,
-		TimeRange: timeRange,
+		Page:        page,
+		PageSize:    pageSize,
+		Order:       order,
+		UserID:      userId,
+		WorkspaceID: wsId,
+		TimeRange:   timeRange,
 	}, nil
 }
 
@@ -257,22 +247,22 @@ func (s *Service) getListParams(ctx *gin.Context) (*prePareListSQLParams, error)
 // @Param user_id query int false "user_id"
 // @Param time_range query string false "time_range"
 // @Param order query string false "order"
+// @Param workspace_id query int false "list the queries of workspace, including the private ones"
 // @Success 200
 // @Router /query [get]
 func (s *Service) ListQueryHandler() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		currentUserId, err := base.GetCurrentUserId(ctx)
+		currentUserId := base.GetCurrentUserIdOrGuest(ctx)
+		params, err := s.getListParams(ctx)
 		if err != nil {
 			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
 			return
 		}
+		params.CurrentUserId = currentUserId
 
-		params, err := s.getListParams(ctx)
-		if err != nil {
-			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+		if params.WorkspaceID != 0 && !base.CheckWorkspaceRole(ctx, s.db, params.WorkspaceID, currentUserId, datamodel.WorkspaceRoleViewer) {
 			return
 		}
-		params.CurrentUserId = currentUserId
 
 		queryRaw, countRaw, err := s.prepareListSQL(params)
 		if err != nil {
@@ -414,12 +404,7 @@ func (s *Service) ListFavoriteQueryHandler() gin.HandlerFunc {
 // @Router /query/browse [get]
 func (s *Service) ListBrowseQueryHandler() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		currentUserId, err := base.GetCurrentUserId(ctx)
-		if err != nil {
-			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
-			return
-		}
-
+		currentUserId := base.GetCurrentUserIdOrGuest(ctx)
 		params, err := s.getListParams(ctx)
 		if err != nil {
 			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
@@ -523,6 +508,7 @@ func (s *Service) listUserQueryChart(ctx *gin.Context, userId uint) {
 			LEFT JOIN %s AS tb2 ON tb1.query_id = tb2.id 
 		WHERE
 			tb1.user_id = ? 
+			AND tb2.deleted_at IS NULL
 			LIMIT ? OFFSET ( ? - 1 ) * ?
 		`
 
@@ -557,14 +543,16 @@ func (s *Service) listUserQueryChart(ctx *gin.Context, userId uint) {
 
 	sql = `
 		SELECT COUNT
-			( ID ) 
+			( tb1.id ) 
 		FROM
-			%s 
+			%s AS tb1
+			LEFT JOIN %s AS tb2 ON tb1.query_id = tb2.id 
 		WHERE
-			user_id = ?
+			tb1.user_id = ?
+			AND tb2.deleted_at IS NULL
 		`
 
-	rows, err = s.db.Raw(fmt.Sprintf(sql, tb1), userId).Rows()
+	rows, err = s.db.Raw(fmt.Sprintf(sql, tb1, tb2), userId).Rows()
 	if err != nil {
 		base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
 		return
@@ -827,43 +815,41 @@ func (s *Service) CreateQueryHandler() gin.HandlerFunc {
 			request.Name = "unsaved"
 		}
 
-		request.CreatedAt = time.Now()
-		request.UpdatedAt = time.Now()
+		if !base.CheckPublishPermission(ctx, s.db, currentUserId, request.IsPrivacy || request.Unsaved) {
+			return
+		}
 
-		result := s.db.Create(&request)
-		if result.Error != nil {
-			base.ResponseErr(ctx, http.StatusInternalServerError, result.Error.Error())
+		if !base.CheckContentWorkspace(ctx, s.db, request.WorkspaceID, currentUserId) {
 			return
 		}
 
-		// create or update statistics
-		var statistics datamodel.UserStatistics
-		result = s.db.Where("user_id", currentUserId).First(&statistics)
-		if result.Error != nil {
-			if result.Error == gorm.ErrRecordNotFound {
-				statistics.Queries += 1
-				statistics.UserId = currentUserId
-				if err := s.db.Create(&statistics).Error; err != nil {
-					base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
-					return
-				}
-				ctx.JSON(http.StatusOK, Response{
-					BaseResponse: base.BaseResponse{
-						Success: true,
-					},
-					Data: request,
-				})
+		request.CreatedAt = time.Now()
+		request.UpdatedAt = time.Now()
 
-				return
+		err = s.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&request).Error; err != nil {
+				return err
 			}
 
-			base.ResponseErr(ctx, http.StatusInternalServerError, result.Error.Error())
+			if err := base.RefreshWorkspacesStatistics(tx, request.WorkspaceID); err != nil {
+				return err
+			}
+
+			return base.RefreshContentStatistics(tx, request.UserID)
+		})
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
 			return
 		}
 
-		if err := s.db.Model(&statistics).Update("queries", statistics.Queries+1).Error; err != nil {
-			base.ResponseErr(ctx, http.StatusInternalServerError, result.Error.Error())
-			return
+		// the unsaved queries are drafts of editor, they are audited once saved
+		if !request.Unsaved {
+			base.Audit(ctx, s.db, &base.AuditRecord{
+				Action:     datamodel.AuditQueryCreate,
+				TargetType: datamodel.AuditTargetQuery,
+				TargetID:   request.ID,
+				After:      &request,
+			})
 		}
 
 		ctx.JSON(http.StatusOK, Response{
@@ -876,7 +862,8 @@ func (s *Service) CreateQueryHandler() gin.HandlerFunc {
 }
 
 // @Summary update query
-// @Description update query
+// @Description update query, it requires the edit access of query. The query can only be moved and published by its owner or the editors of its workspace.
+// @Description The query is moved to another workspace by workspace_id, 0 means the personal query of its creator.
 // @Tags query apis
 // @Accept application/json
 // @Produce application/json
@@ -886,7 +873,7 @@ func (s *Service) CreateQueryHandler() gin.HandlerFunc {
 func (s *Service) UpdateQueryHandler() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 
-		_, err := base.GetCurrentUserId(ctx)
+		currentUserId, err := base.GetCurrentUserId(ctx)
 		if err != nil {
 			base.ResponseErr(ctx, http.StatusUnauthorized, err.Error())
 			return
@@ -898,22 +885,93 @@ func (s *Service) UpdateQueryHandler() gin.HandlerFunc {
 			return
 		}
 
-		if !s.checkUserQueryModelRequest(ctx, &request) {
+		if !s.checkQueryModelRequest(ctx, &request) {
+			return
+		}
+
+		// the query is created by update if id is empty
+		var before *datamodel.QueryModel
+		existing := &base.Content{Type: datamodel.ContentTypeQuery, UserID: currentUserId, IsPrivacy: request.IsPrivacy}
+		if request.ID != 0 {
+			before = &datamodel.QueryModel{}
+			if err := s.db.First(before, request.ID).Error; err != nil {
+				if err == gorm.ErrRecordNotFound {
+					base.ResponseErr(ctx, http.StatusNotFound, "query not found")
+					return
+				}
+				base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+				return
+			}
+
+			if err := s.db.Where("query_id = ?", before.ID).Order("id ASC").Find(&before.Charts).Error; err != nil {
+				base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+				return
+			}
+			existing = base.QueryContent(before)
+			request.CreatedAt = before.CreatedAt
+
+			if !base.CheckContentAccess(ctx, s.db, existing, base.ContentAccessEdit) {
+				return
+			}
+
+			// only the managers move and publish the query
+			if request.WorkspaceID != existing.WorkspaceID || request.IsPrivacy != existing.IsPrivacy {
+				if !base.CheckContentAccess(ctx, s.db, existing, base.ContentAccessManage) {
+					return
+				}
+			}
+		}
+		request.UserID = existing.UserID
+
+		if request.WorkspaceID != existing.WorkspaceID && !base.CheckContentWorkspace(ctx, s.db, request.WorkspaceID, currentUserId) {
+			return
+		}
+
+		if !base.CheckPublishPermission(ctx, s.db, currentUserId, request.IsPrivacy) {
 			return
 		}
+
 		request.UpdatedAt = time.Now()
 		request.Unsaved = false
+		request.Stars = 0
+		request.DeletedAt = gorm.DeletedAt{}
 
 		err = s.db.Transaction(func(tx *gorm.DB) error {
-			if err := s.db.Save(&request).Error; err != nil {
+			// the query is trashed by delete only, and the stars are counted by favorites
+			save := tx
+			if before != nil {
+				save = tx.Select("*").Omit("deleted_at", "stars", "created_at")
+				request.Stars = before.Stars
+			}
+			if err := save.Save(&request).Error; err != nil {
 				return err
 			}
 
-			if err := s.db.Save(&request.Charts).Error; err != nil {
+			// the charts of other queries are never taken over, they are created as new charts
+			charts := make(map[uint]bool)
+			if before != nil {
+				for _, chart := range before.Charts {
+					charts[chart.ID] = true
+				}
+			}
+			for i := range request.Charts {
+				if !charts[request.Charts[i].ID] {
+					request.Charts[i].ID = 0
+				}
+				request.Charts[i].QueryID = request.ID
+				request.Charts[i].UserID = request.UserID
+			}
+			if len(request.Charts) > 0 {
+				if err := tx.Save(&request.Charts).Error; err != nil {
+					return err
+				}
+			}
+
+			if err := base.RefreshWorkspacesStatistics(tx, existing.WorkspaceID, request.WorkspaceID); err != nil {
 				return err
 			}
 
-			return nil
+			return base.RefreshContentStatistics(tx, request.UserID)
 		})
 
 		if err != nil {
@@ -921,6 +979,18 @@ func (s *Service) UpdateQueryHandler() gin.HandlerFunc {
 			return
 		}
 
+		action := datamodel.AuditQueryUpdate
+		if before == nil {
+			action = datamodel.AuditQueryCreate
+		}
+		base.Audit(ctx, s.db, &base.AuditRecord{
+			Action:     action,
+			TargetType: datamodel.AuditTargetQuery,
+			TargetID:   request.ID,
+			Before:     before,
+			After:      &request,
+		})
+
 		ctx.JSON(http.StatusOK, Response{
 			BaseResponse: base.BaseResponse{
 				Success: true,
@@ -931,7 +1001,8 @@ func (s *Service) UpdateQueryHandler() gin.HandlerFunc {
 }
 
 // @Summary delete query
-// @Description delete query
+// @Description delete query, it requires the owner or an editor of the workspace of query.
+// @Description The query is moved to trash, it can be restored before it is purged after the retention period.
 // @Tags query apis
 // @Accept application/json
 // @Produce application/json
@@ -940,29 +1011,37 @@ func (s *Service) UpdateQueryHandler() gin.HandlerFunc {
 // @Router /query/:id [delete]
 func (s *Service) DeleteQueryHandler() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		userId, err := base.GetCurrentUserId(ctx)
+		id, err := base.GetUintParam(ctx, "id")
 		if err != nil {
-			base.ResponseErr(ctx, http.StatusUnauthorized, err.Error())
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
 			return
 		}
 
-		id, err := base.GetUintParam(ctx, "id")
-		if err != nil {
-			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+		var query datamodel.QueryModel
+		if err := s.db.First(&query, id).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				base.ResponseErr(ctx, http.StatusNotFound, "query not found")
+				return
+			}
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		if !base.CheckContentAccess(ctx, s.db, base.QueryContent(&query), base.ContentAccessManage) {
 			return
 		}
 
-		// first delete related charts and then delete query using transaction
+		// the query is moved to trash with its charts, they are purged after the retention period
 		err = s.db.Transaction(func(tx *gorm.DB) error {
-			if err := s.db.Where("query_id = ?", id).Delete(&datamodel.ChartModel{}).Error; err != nil {
+			if err := tx.Where("id = ?", id).Delete(&datamodel.QueryModel{}).Error; err != nil {
 				return err
 			}
 
-			if err := s.db.Where("id = ? and user_id = ?", id, userId).Delete(&datamodel.QueryModel{}).Error; err != nil {
+			if err := base.RefreshWorkspacesStatistics(tx, query.WorkspaceID); err != nil {
 				return err
 			}
 
-			return nil
+			return base.RefreshContentStatistics(tx, query.UserID)
 		})
 
 		if err != nil {
@@ -970,6 +1049,13 @@ func (s *Service) DeleteQueryHandler() gin.HandlerFunc {
 			return
 		}
 
+		base.Audit(ctx, s.db, &base.AuditRecord{
+			Action:     datamodel.AuditQueryDelete,
+			TargetType: datamodel.AuditTargetQuery,
+			TargetID:   query.ID,
+			Before:     &query,
+		})
+
 		base.ResponseSuccess(ctx)
 	}
 }
@@ -992,8 +1078,18 @@ func (s *Service) queryFavorite(ctx *gin.Context, star bool) {
 		return
 	}
 
-	if request.QueryUserID == 0 {
-		base.ResponseErr(ctx, http.StatusBadRequest, "query user id is required")
+	// the stars are counted to the owner of query rather than the query_user_id of request
+	var query datamodel.QueryModel
+	if err := s.db.Where("id = ?", request.QueryID).First(&query).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			base.ResponseErr(ctx, http.StatusNotFound, "query not found")
+			return
+		}
+		base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if !base.CheckContentAccess(ctx, s.db, base.QueryContent(&query), base.ContentAccessView) {
 		return
 	}
 
@@ -1010,6 +1106,7 @@ func (s *Service) queryFavorite(ctx *gin.Context, star bool) {
 		}
 
 	}
+	stared := finded && find.Stared
 
 	err = s.db.Transaction(func(tx *gorm.DB) error {
 		if finded {
@@ -1030,15 +1127,7 @@ func (s *Service) queryFavorite(ctx *gin.Context, star bool) {
 			}
 		}
 
-		expr := "stars + ?"
-		if !star {
-			expr = "stars - ?"
-		}
-		if err := s.db.Model(&datamodel.UserStatistics{}).Where("user_id = ?", request.QueryUserID).Update("stars", gorm.Expr(expr, 1)).Error; err != nil {
-			return err
-		}
-
-		return nil
+		return base.RefreshContentStatistics(tx, query.UserID)
 	})
 
 	if err != nil {
@@ -1046,6 +1135,34 @@ func (s *Service) queryFavorite(ctx *gin.Context, star bool) {
 		return
 	}
 
+	action := datamodel.AuditQueryFavorite
+	if !star {
+		action = datamodel.AuditQueryUnfavorite
+	}
+	base.Audit(ctx, s.db, &base.AuditRecord{
+		Action:     action,
+		TargetType: datamodel.AuditTargetQuery,
+		TargetID:   request.QueryID,
+	})
+
+	// the owner is notified only when the query is newly favorited by others
+	if star && !stared {
+		base.Notify(s.db, datamodel.NotificationModel{
+			UserID:     query.UserID,
+			Type:       datamodel.NotificationFavorite,
+			ActorID:    userId,
+			TargetType: datamodel.ContentTypeQuery,
+			TargetID:   query.ID,
+		})
+		if query.UserID != userId {
+			base.FireWebhooks(s.db, query.UserID, datamodel.WebhookFavoriteAdded, map[string]any{
+				"content_type": datamodel.ContentTypeQuery,
+				"content_id":   query.ID,
+				"user_id":      userId,
+			})
+		}
+	}
+
 	base.ResponseWithData(ctx, find)
 }
 
@@ -1084,19 +1201,36 @@ func (s *Service) Name() string {
 func (s *Service) RouteTables() []base.RouteTable {
 	return []base.RouteTable{
 		{
-			Method:  "POST",
-			Path:    s.group + "/run",
-			Handler: s.RunHandler(),
+			Method:      "POST",
+			Path:        s.group + "/run",
+			Handler:     s.RunHandler(),
+			ApiKeyScope: base.ScopeQueryRun,
+			// the queries are billed by the data scanned
+			IPRateLimit:   &base.RateLimit{Limit: 60, Period: time.Minute},
+			UserRateLimit: &base.RateLimit{Limit: 30, Period: time.Minute},
 		},
 		{
-			Method:  "GET",
-			Path:    s.group + "/:id",
-			Handler: s.GetQueryHandler(),
+			Method:      "GET",
+			Path:        s.group + "/:id",
+			Handler:     s.GetQueryHandler(),
+			AllowGuest:  true,
+			ApiKeyScope: base.ScopeQueryRun,
 		},
 		{
-			Method:  "GET",
-			Path:    s.group,
-			Handler: s.ListQueryHandler(),
+			Method:      "GET",
+			Path:        s.group + "/:id/results",
+			Handler:     s.GetQueryResultsHandler(),
+			AllowGuest:  true,
+			ApiKeyScope: base.ScopeQueryRun,
+			// the stale results are executed again, which is billed like run
+			IPRateLimit:   &base.RateLimit{Limit: 60, Period: time.Minute},
+			UserRateLimit: &base.RateLimit{Limit: 30, Period: time.Minute},
+		},
+		{
+			Method:     "GET",
+			Path:       s.group,
+			Handler:    s.ListQueryHandler(),
+			AllowGuest: true,
 		},
 		{
 			Method:  "POST",
@@ -1119,9 +1253,10 @@ func (s *Service) RouteTables() []base.RouteTable {
 			Handler: s.ListFavoriteQueryHandler(),
 		},
 		{
-			Method:  "GET",
-			Path:    s.group + "/browse",
-			Handler: s.ListBrowseQueryHandler(),
+			Method:     "GET",
+			Path:       s.group + "/browse",
+			Handler:    s.ListBrowseQueryHandler(),
+			AllowGuest: true,
 		},
 
 		{
@@ -1159,5 +1294,15 @@ func (s *Service) RouteTables() []base.RouteTable {
 			Path:    s.group + "/unfavorite",
 			Handler: s.QueryUnfavoriteHandler(),
 		},
+		{
+			Method:  "GET",
+			Path:    s.group + "/trash",
+			Handler: s.ListTrashQueryHandler(),
+		},
+		{
+			Method:  "POST",
+			Path:    s.group + "/:id/restore",
+			Handler: s.RestoreQueryHandler(),
+		},
 	}
 }
@@ -2,6 +2,7 @@ package query
 
 import (
 	"infra-3.xyz/hyperdot-node/internal/apis/base"
+	"infra-3.xyz/hyperdot-node/internal/cache"
 	"infra-3.xyz/hyperdot-node/internal/dataengine"
 	"infra-3.xyz/hyperdot-node/internal/datamodel"
 )
@@ -23,3 +24,21 @@ type Response struct {
 	base.BaseResponse
 	Data datamodel.QueryModel `json:"data"`
 }
+
+// ResponseQueryResult is response of GET /query/:id/results
+type ResponseQueryResult struct {
+	base.BaseResponse
+	Data cache.QueryResult `json:"data"`
+}
+
+// ResponseTrashData is the data of ResponseTrash.
+type ResponseTrashData struct {
+	Queries []datamodel.QueryModel `json:"queries"`
+	Total   int64                  `json:"total"`
+}
+
+// ResponseTrash is response of GET /query/trash
+type ResponseTrash struct {
+	base.BaseResponse
+	Data ResponseTrashData `json:"data"`
+}
@@ -0,0 +1,171 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"infra-3.xyz/hyperdot-node/internal/apis/base"
+	"infra-3.xyz/hyperdot-node/internal/cache"
+	"infra-3.xyz/hyperdot-node/internal/dataengine"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+	"infra-3.xyz/hyperdot-node/internal/utils"
+)
+
+const (
+	ResultFormatJSON = "json"
+	ResultFormatCSV  = "csv"
+)
+
+// isResultFresh reports whether the cached result can be served, it is stale if
+// it is older than max age or the query has been updated since it was cached.
+func isResultFresh(result *cache.QueryResult, query *datamodel.QueryModel, maxAge *time.Duration) bool {
+	if result == nil || result.UpdatedAt.Before(query.UpdatedAt) {
+		return false
+	}
+
+	return maxAge == nil || time.Since(result.UpdatedAt) <= *maxAge
+}
+
+// @Summary get query results
+// @Description Get the latest result of a saved query. The cached result is served if it is fresh enough, otherwise the query is executed again.
+// @Description Guests can only read the cached results whatever max_age is, the query is executed by signed in users only.
+// @Description The placeholders such as {{chain_id}} in query are filled by params[chain_id], the values can only be numbers, identifiers, dates or addresses.
+// @Description It can be accessed by api key with scope query:run.
+// @Tags query apis
+// @Produce application/json
+// @Produce text/csv
+// @Param id path int true "query id"
+// @Param max_age query int false "max age of cached result in seconds, 0 executes the query again"
+// @Param format query string false "json or csv, default is json"
+// @Param params query string false "parameter values, e.g. params[chain_id]=2004"
+// @Success 200 {object} ResponseQueryResult
+// @Router /query/{id}/results [get]
+func (s *Service) GetQueryResultsHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := base.GetUintParam(ctx, "id")
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		format := ctx.DefaultQuery("format", ResultFormatJSON)
+		if format != ResultFormatJSON && format != ResultFormatCSV {
+			base.ResponseErr(ctx, http.StatusBadRequest, "unsupported format %s", format)
+			return
+		}
+
+		var maxAge *time.Duration
+		if len(ctx.Query("max_age")) > 0 {
+			seconds, err := base.GetIntQuery(ctx, "max_age")
+			if err != nil || seconds < 0 {
+				base.ResponseErr(ctx, http.StatusBadRequest, "invalid max_age")
+				return
+			}
+			v := time.Duration(seconds) * time.Second
+			maxAge = &v
+		}
+
+		var query datamodel.QueryModel
+		if err := s.db.First(&query, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				base.ResponseErr(ctx, http.StatusNotFound, "query not found")
+				return
+			}
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		if !base.CheckContentAccess(ctx, s.db, base.QueryContent(&query), base.ContentAccessView) {
+			return
+		}
+
+		params := ctx.QueryMap("params")
+		sql, err := utils.RenderSafeTemplate(query.Query, params)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		result, err := s.resultCache.Get(ctx, query.ID, params)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		// the guests can not execute the queries billed to the deployment
+		guest := base.GetCurrentUserIdOrGuest(ctx) == base.GuestUserId
+		if guest && result == nil {
+			base.ResponseErr(ctx, http.StatusNotFound, "result is not cached, sign in to execute the query")
+			return
+		}
+
+		if !guest && !isResultFresh(result, &query, maxAge) {
+			engine, ok := s.engines[query.QueryEngine]
+			if !ok {
+				base.ResponseErr(ctx, http.StatusBadRequest, "The %s query engine unsupported now", query.QueryEngine)
+				return
+			}
+
+			started := time.Now()
+			executed, err := dataengine.Execute(ctx, engine, sql)
+			s.fireExecuted(&query, params, executed, err, time.Since(started))
+			if err != nil {
+				base.ResponseErr(ctx, http.StatusBadRequest, "query error: %v", err)
+				return
+			}
+
+			result = &cache.QueryResult{
+				QueryID:   query.ID,
+				Params:    params,
+				Result:    *executed,
+				UpdatedAt: time.Now(),
+			}
+			if err := s.resultCache.Set(ctx, result); err != nil {
+				base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+				return
+			}
+		}
+
+		if format == ResultFormatCSV {
+			ctx.Header("Content-Type", "text/csv; charset=utf-8")
+			ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=query-%d.csv", query.ID))
+			ctx.Header("Last-Modified", result.UpdatedAt.UTC().Format(http.TimeFormat))
+			ctx.Status(http.StatusOK)
+			if err := result.WriteCSV(ctx.Writer); err != nil {
+				_ = ctx.Error(err)
+			}
+			return
+		}
+
+		ctx.JSON(http.StatusOK, ResponseQueryResult{
+			BaseResponse: base.ResponseOk(),
+			Data:         *result,
+		})
+	}
+}
+
+// fireExecuted fires the finished or failed webhooks of the owner of query after it is
+// executed.
+func (s *Service) fireExecuted(query *datamodel.QueryModel, params map[string]string, result *dataengine.Result, err error, elapsed time.Duration) {
+	data := map[string]any{
+		"query_id":    query.ID,
+		"name":        query.Name,
+		"params":      params,
+		"duration_ms": elapsed.Milliseconds(),
+	}
+
+	event := datamodel.WebhookQueryFinished
+	if err != nil {
+		event = datamodel.WebhookQueryFailed
+		data["error"] = err.Error()
+	} else {
+		data["rows"] = len(result.Rows)
+	}
+
+	base.FireWebhooks(s.db, query.UserID, event, data)
+}
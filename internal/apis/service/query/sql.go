@@ -13,6 +13,7 @@ type prePareListSQLParams struct {
 	PageSize      uint
 	Order         string
 	UserID        uint
+	WorkspaceID   uint
 	CurrentUserId uint
 	TimeRange     string
 }
@@ -45,7 +46,8 @@ func (s *Service) prepareListSQL(params *prePareListSQLParams) (queryRaw *gorm.D
 		LEFT JOIN %s AS tb4 ON tb1.ID = tb4.query_id
 			AND tb4.stared = TRUE  
 	WHERE
-		tb1.is_privacy = FALSE
+		tb1.deleted_at IS NULL
+		AND tb1.is_privacy = FALSE
 		%s
 	GROUP BY
 		tb1.ID,
@@ -63,9 +65,14 @@ func (s *Service) prepareListSQL(params *prePareListSQLParams) (queryRaw *gorm.D
 	FROM
 		%s AS tb1
 	WHERE
-		tb1.is_privacy = FALSE
+		tb1.deleted_at IS NULL
+		AND tb1.is_privacy = FALSE
 		%s 
 	`
+	if params.WorkspaceID != 0 {
+		return s.prepareWorkspaceListSQL(params)
+	}
+
 	if params.UserID != 0 {
 		return s.prepateUserListSQL(params)
 	}
@@ -175,7 +182,8 @@ func (s *Service) prepateUserListSQL(params *prePareListSQLParams) (queryRaw *go
 		LEFT JOIN %s AS tb4 ON tb1.ID = tb4.query_id
 			AND tb4.stared = TRUE  
 	WHERE
-		tb1.is_privacy = FALSE 
+		tb1.deleted_at IS NULL
+		AND tb1.is_privacy = FALSE 
 		AND tb1.user_id = ?
 	GROUP BY
 		tb1.id,
@@ -195,7 +203,8 @@ func (s *Service) prepateUserListSQL(params *prePareListSQLParams) (queryRaw *go
 	FROM
 		%s AS tb1
 	WHERE
-		tb1.is_privacy = FALSE 
+		tb1.deleted_at IS NULL
+		AND tb1.is_privacy = FALSE 
 		AND tb1.user_id = ?
 	`
 	countSql = fmt.Sprintf(countSql, tb1)
@@ -203,6 +212,64 @@ func (s *Service) prepateUserListSQL(params *prePareListSQLParams) (queryRaw *go
 	return
 }
 
+// prepareWorkspaceListSQL lists the contents of workspace including the private ones,
+// the membership should be checked by caller.
+func (s *Service) prepareWorkspaceListSQL(params *prePareListSQLParams) (queryRaw *gorm.DB, countRaw *gorm.DB, err error) {
+	tb1 := datamodel.QueryModel{}.TableName()
+	tb2 := datamodel.UserModel{}.TableName()
+	tb3 := datamodel.UserQueryFavorites{}.TableName()
+	sql := `
+	SELECT
+		tb1.*,
+		tb2.username,
+		tb2.email,
+		tb2.icon_url,
+		tb3.stared,
+		COUNT( tb4.query_id ) AS favorites_count
+	FROM
+		%s AS tb1
+		LEFT JOIN %s AS tb2 ON tb1.user_id = tb2.id
+		LEFT JOIN %s AS tb3 ON tb1.ID = tb3.query_id
+			AND tb3.user_id = ?
+		LEFT JOIN %s AS tb4 ON tb1.ID = tb4.query_id
+			AND tb4.stared = TRUE
+	WHERE
+		tb1.deleted_at IS NULL
+		AND tb1.workspace_id = ?
+		AND tb1.unsaved = FALSE
+	GROUP BY
+		tb1.id,
+		tb2.username,
+		tb2.email,
+		tb2.icon_url,
+		tb3.stared
+	ORDER BY
+		tb1.updated_at DESC
+		LIMIT ? OFFSET ( ? - 1 ) * ?
+	`
+	sql = fmt.Sprintf(sql, tb1, tb2, tb3, tb3)
+	queryRaw = s.db.Raw(sql,
+		params.CurrentUserId, // current user for stared
+		params.WorkspaceID,
+		params.PageSize,
+		params.Page,
+		params.PageSize,
+	)
+
+	countSql := `
+	SELECT COUNT(tb1.id)
+	FROM
+		%s AS tb1
+	WHERE
+		tb1.deleted_at IS NULL
+		AND tb1.workspace_id = ?
+		AND tb1.unsaved = FALSE
+	`
+	countSql = fmt.Sprintf(countSql, tb1)
+	countRaw = s.db.Raw(countSql, params.WorkspaceID)
+	return
+}
+
 func (s *Service) prepateBrowseUserListSQL(params *prePareListSQLParams) (queryRaw *gorm.DB, countRaw *gorm.DB, err error) {
 	tb1 := datamodel.QueryModel{}.TableName()
 	tb2 := datamodel.UserModel{}.TableName()
@@ -223,7 +290,8 @@ func (s *Service) prepateBrowseUserListSQL(params *prePareListSQLParams) (queryR
 		LEFT JOIN %s AS tb4 ON tb1.ID = tb4.query_id
 			AND tb4.stared = TRUE  
 	WHERE
-		tb1.is_privacy = FALSE 
+		tb1.deleted_at IS NULL
+		AND tb1.is_privacy = FALSE 
 		AND tb1.user_id = ?
 	GROUP BY
 		tb1.id,
@@ -249,7 +317,8 @@ func (s *Service) prepateBrowseUserListSQL(params *prePareListSQLParams) (queryR
 	FROM
 		%s AS tb1
 	WHERE
-		tb1.is_privacy = FALSE 
+		tb1.deleted_at IS NULL
+		AND tb1.is_privacy = FALSE 
 		AND tb1.user_id = ?
 	`
 	countSql = fmt.Sprintf(countSql, tb1)
@@ -287,7 +356,8 @@ func (s *Service) prepareListStaredSQL(params *prePareListSQLParams) (queryRaw *
 		LEFT JOIN %s AS tb4 ON tb1.ID = tb4.query_id
 			AND tb4.stared = TRUE
 	WHERE
-		tb1.is_privacy = FALSE
+		tb1.deleted_at IS NULL
+		AND tb1.is_privacy = FALSE
 		AND tb3.stared = TRUE
 		%s 
 	GROUP BY
@@ -310,7 +380,8 @@ func (s *Service) prepareListStaredSQL(params *prePareListSQLParams) (queryRaw *
 			AND tb1.id = tb3.query_id 
 			AND tb3.stared = TRUE
 	WHERE
-		tb1.is_privacy = FALSE
+		tb1.deleted_at IS NULL
+		AND tb1.is_privacy = FALSE
 		AND tb3.stared = TRUE
 		%s
 	`
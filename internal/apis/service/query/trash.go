@@ -0,0 +1,117 @@
+package query
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"infra-3.xyz/hyperdot-node/internal/apis/base"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+)
+
+// @Summary list trashed queries
+// @Description List the trashed personal queries of current user, or the trashed queries of workspace
+// @Description if workspace_id is given, it requires an editor of the workspace. The latest trashed are listed first.
+// @Tags query apis
+// @Accept application/json
+// @Produce application/json
+// @Param workspace_id query int false "workspace id"
+// @Param page query int false "page"
+// @Param page_size query int false "page_size"
+// @Success 200 {object} ResponseTrash
+// @Router /query/trash [get]
+func (s *Service) ListTrashQueryHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		filter, ok := base.TrashFilter(ctx, s.db)
+		if !ok {
+			return
+		}
+
+		page, pageSize, err := base.GetPage(ctx, 20)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		var data ResponseTrashData
+		if err := filter(s.db.Model(&datamodel.QueryModel{})).Count(&data.Total).Error; err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		data.Queries = make([]datamodel.QueryModel, 0)
+		if err := filter(s.db).Order("deleted_at DESC").
+			Offset((page - 1) * pageSize).
+			Limit(pageSize).
+			Find(&data.Queries).Error; err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, ResponseTrash{
+			BaseResponse: base.ResponseOk(),
+			Data:         data,
+		})
+	}
+}
+
+// @Summary restore query
+// @Description Restore the trashed query with its charts, it requires the owner or an editor of the workspace of query.
+// @Tags query apis
+// @Accept application/json
+// @Produce application/json
+// @Param id path int true "query id"
+// @Success 200 {object} Response
+// @Router /query/{id}/restore [post]
+func (s *Service) RestoreQueryHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := base.GetUintParam(ctx, "id")
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		var query datamodel.QueryModel
+		if err := s.db.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).First(&query).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				base.ResponseErr(ctx, http.StatusNotFound, "query not found in trash")
+				return
+			}
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		if !base.CheckContentAccess(ctx, s.db, base.QueryContent(&query), base.ContentAccessManage) {
+			return
+		}
+
+		err = s.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Unscoped().Model(&query).Update("deleted_at", nil).Error; err != nil {
+				return err
+			}
+
+			if err := base.RefreshWorkspacesStatistics(tx, query.WorkspaceID); err != nil {
+				return err
+			}
+
+			return base.RefreshContentStatistics(tx, query.UserID)
+		})
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		base.Audit(ctx, s.db, &base.AuditRecord{
+			Action:     datamodel.AuditQueryRestore,
+			TargetType: datamodel.AuditTargetQuery,
+			TargetID:   query.ID,
+		})
+
+		ctx.JSON(http.StatusOK, Response{
+			BaseResponse: base.ResponseOk(),
+			Data:         query,
+		})
+	}
+}
@@ -0,0 +1,11 @@
+package share
+
+// RequestCreateShare is request of POST /share
+type RequestCreateShare struct {
+	// DashboardID is the shared dashboard, one of DashboardID and ChartID is required.
+	DashboardID uint `json:"dashboard_id"`
+	// ChartID is the shared chart, one of DashboardID and ChartID is required.
+	ChartID uint `json:"chart_id"`
+	// ExpiresIn is the lifetime of token in seconds, 0 means never expires.
+	ExpiresIn int64 `json:"expires_in"`
+}
@@ -0,0 +1,32 @@
+package share
+
+import (
+	"infra-3.xyz/hyperdot-node/internal/apis/base"
+	"infra-3.xyz/hyperdot-node/internal/cache"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+)
+
+// ResponseShare is response of POST /share
+type ResponseShare struct {
+	base.BaseResponse
+	Data datamodel.ShareTokenModel `json:"data"`
+}
+
+// ResponseSharedData is data of response of GET /share/public/:token
+type ResponseSharedData struct {
+	Dashboard *datamodel.DashboardModel `json:"dashboard,omitempty"`
+	Chart     *datamodel.ChartModel     `json:"chart,omitempty"`
+	Queries   []datamodel.QueryModel    `json:"queries"`
+}
+
+// ResponseShared is response of GET /share/public/:token
+type ResponseShared struct {
+	base.BaseResponse
+	Data ResponseSharedData `json:"data"`
+}
+
+// ResponseSharedResult is response of GET /share/public/:token/results/:queryId
+type ResponseSharedResult struct {
+	base.BaseResponse
+	Data cache.QueryResult `json:"data"`
+}
@@ -0,0 +1,441 @@
+package share
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"infra-3.xyz/hyperdot-node/internal/apis/base"
+	"infra-3.xyz/hyperdot-node/internal/cache"
+	"infra-3.xyz/hyperdot-node/internal/common"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+	"infra-3.xyz/hyperdot-node/internal/utils"
+)
+
+const (
+	ServiceName = "share"
+	// tokenBytes is the random bytes of a share token.
+	tokenBytes = 24
+)
+
+// Service share service
+type Service struct {
+	db          *gorm.DB
+	resultCache *cache.QueryResultCache
+}
+
+// New share service
+func New(cfg *common.Config, db *gorm.DB) *Service {
+	return &Service{
+		db:          db,
+		resultCache: cache.NewQueryResultCache(&cfg.Redis),
+	}
+}
+
+// allowEmbedding allows the shared content to be embedded in an iframe of any site.
+func allowEmbedding(ctx *gin.Context) {
+	ctx.Writer.Header().Del("X-Frame-Options")
+	ctx.Header("Content-Security-Policy", "frame-ancestors *")
+}
+
+// getValidToken gets the share token by the token param and checks it is valid.
+func (s *Service) getValidToken(ctx *gin.Context) (*datamodel.ShareTokenModel, bool) {
+	var token datamodel.ShareTokenModel
+	if err := s.db.Where("token_hash = ?", utils.HashToken(ctx.Param("token"))).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			base.ResponseErr(ctx, http.StatusNotFound, "share token not found")
+			return nil, false
+		}
+
+		base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+		return nil, false
+	}
+
+	if !token.IsValid(time.Now()) {
+		base.ResponseErr(ctx, http.StatusForbidden, "share token is revoked or expired")
+		return nil, false
+	}
+
+	return &token, true
+}
+
+// sharedQueryIds returns the ids of queries which can be read by the token, the
+// trashed and purged queries are never read.
+func (s *Service) sharedQueryIds(token *datamodel.ShareTokenModel) ([]uint, error) {
+	var ids []uint
+	if token.DashboardID != 0 {
+		err := s.db.Model(&datamodel.DashboardPanelModel{}).
+			Where("dashboard_id = ? AND query_id <> 0", token.DashboardID).
+			Distinct().Pluck("query_id", &ids).Error
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		err := s.db.Model(&datamodel.ChartModel{}).Where("id = ?", token.ChartID).Pluck("query_id", &ids).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(ids) == 0 {
+		return ids, nil
+	}
+
+	var queryIds []uint
+	err := s.db.Model(&datamodel.QueryModel{}).Where("id IN ?", ids).Pluck("id", &queryIds).Error
+	return queryIds, err
+}
+
+// @Summary Create share token
+// @Description Create a read-only share token of a dashboard or chart owned by current user, the token is only responded once.
+// @Tags share apis
+// @Accept application/json
+// @Produce application/json
+// @Param body body RequestCreateShare true "create share request"
+// @Success 200 {object} ResponseShare
+// @Router /share [post]
+func (s *Service) CreateShareHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userId, err := base.GetCurrentUserId(ctx)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		var request RequestCreateShare
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if (request.DashboardID == 0) == (request.ChartID == 0) {
+			base.ResponseErr(ctx, http.StatusBadRequest, "one of dashboard id and chart id is required")
+			return
+		}
+
+		if request.ExpiresIn < 0 {
+			base.ResponseErr(ctx, http.StatusBadRequest, "expires in must not be negative")
+			return
+		}
+
+		var ownerId uint
+		if request.DashboardID != 0 {
+			err = s.db.Model(&datamodel.DashboardModel{}).Where("id = ?", request.DashboardID).Pluck("user_id", &ownerId).Error
+		} else {
+			err = s.db.Model(&datamodel.ChartModel{}).Where("id = ?", request.ChartID).Pluck("user_id", &ownerId).Error
+		}
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		if ownerId == 0 {
+			base.ResponseErr(ctx, http.StatusNotFound, "shared content not found")
+			return
+		}
+
+		if ownerId != userId {
+			base.ResponseErr(ctx, http.StatusUnauthorized, "You are not the owner of shared content")
+			return
+		}
+
+		token, err := utils.RandomToken(tokenBytes)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		now := time.Now()
+		share := datamodel.ShareTokenModel{
+			Token:       token,
+			Prefix:      token[:6],
+			TokenHash:   utils.HashToken(token),
+			UserID:      userId,
+			DashboardID: request.DashboardID,
+			ChartID:     request.ChartID,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+		if request.ExpiresIn > 0 {
+			expiresAt := now.Add(time.Duration(request.ExpiresIn) * time.Second)
+			share.ExpiresAt = &expiresAt
+		}
+
+		if err := s.db.Create(&share).Error; err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, ResponseShare{
+			BaseResponse: base.ResponseOk(),
+			Data:         share,
+		})
+	}
+}
+
+// @Summary List share tokens
+// @Description List share tokens of current user.
+// @Tags share apis
+// @Accept application/json
+// @Produce application/json
+// @Param dashboard_id query int false "dashboard id"
+// @Param chart_id query int false "chart id"
+// @Success 200
+// @Router /share [get]
+func (s *Service) ListShareHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userId, err := base.GetCurrentUserId(ctx)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		tx := s.db.Where("user_id = ?", userId)
+		if dashboardId, err := base.GetUIntQuery(ctx, "dashboard_id"); err == nil {
+			tx = tx.Where("dashboard_id = ?", dashboardId)
+		} else if err != base.ErrQueryNotFound {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if chartId, err := base.GetUIntQuery(ctx, "chart_id"); err == nil {
+			tx = tx.Where("chart_id = ?", chartId)
+		} else if err != base.ErrQueryNotFound {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		var shares []datamodel.ShareTokenModel
+		if err := tx.Order("id DESC").Find(&shares).Error; err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		base.ResponseWithData(ctx, shares)
+	}
+}
+
+// @Summary Revoke share token
+// @Description Revoke share token of current user.
+// @Tags share apis
+// @Accept application/json
+// @Produce application/json
+// @Param id path int true "share token id"
+// @Success 200 {object} base.BaseResponse
+// @Router /share/{id} [delete]
+func (s *Service) RevokeShareHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userId, err := base.GetCurrentUserId(ctx)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		id, err := base.GetUintParam(ctx, "id")
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		now := time.Now()
+		result := s.db.Model(&datamodel.ShareTokenModel{}).
+			Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userId).
+			Updates(map[string]any{"revoked_at": now, "updated_at": now})
+		if result.Error != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, result.Error.Error())
+			return
+		}
+
+		if result.RowsAffected == 0 {
+			base.ResponseErr(ctx, http.StatusNotFound, "share token not found")
+			return
+		}
+
+		base.ResponseSuccess(ctx)
+	}
+}
+
+// @Summary Get shared content
+// @Description Get the shared dashboard or chart with the referenced queries by share token, it can be accessed by guest.
+// @Description The sql of queries is not shared.
+// @Tags share apis
+// @Accept application/json
+// @Produce application/json
+// @Param token path string true "share token"
+// @Success 200 {object} ResponseShared
+// @Router /share/public/{token} [get]
+func (s *Service) GetSharedHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		allowEmbedding(ctx)
+
+		token, ok := s.getValidToken(ctx)
+		if !ok {
+			return
+		}
+
+		var data ResponseSharedData
+		if token.DashboardID != 0 {
+			var dashboard datamodel.DashboardModel
+			if err := s.db.First(&dashboard, token.DashboardID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					base.ResponseErr(ctx, http.StatusNotFound, "shared dashboard not found")
+					return
+				}
+
+				base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+				return
+			}
+
+			if err := s.db.Where("dashboard_id = ?", dashboard.ID).Find(&dashboard.Panels).Error; err != nil {
+				base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+				return
+			}
+			data.Dashboard = &dashboard
+		} else {
+			var chart datamodel.ChartModel
+			if err := s.db.First(&chart, token.ChartID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					base.ResponseErr(ctx, http.StatusNotFound, "shared chart not found")
+					return
+				}
+
+				base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+				return
+			}
+			data.Chart = &chart
+		}
+
+		queryIds, err := s.sharedQueryIds(token)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		if len(queryIds) > 0 {
+			if err := s.db.Where("id IN ?", queryIds).Find(&data.Queries).Error; err != nil {
+				base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+				return
+			}
+		}
+
+		for i := range data.Queries {
+			data.Queries[i].Query = ""
+			tx := s.db.Where("query_id = ?", data.Queries[i].ID)
+			if data.Chart != nil {
+				tx = tx.Where("id = ?", data.Chart.ID)
+			}
+			if err := tx.Order("id ASC").Find(&data.Queries[i].Charts).Error; err != nil {
+				base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+				return
+			}
+		}
+
+		ctx.JSON(http.StatusOK, ResponseShared{
+			BaseResponse: base.ResponseOk(),
+			Data:         data,
+		})
+	}
+}
+
+// @Summary Get shared query result
+// @Description Get the cached result of a query referenced by the shared content, it can be accessed by guest.
+// @Description The query is never executed by share token, it responds not found if the result is not cached.
+// @Tags share apis
+// @Accept application/json
+// @Produce application/json
+// @Param token path string true "share token"
+// @Param queryId path int true "query id"
+// @Success 200 {object} ResponseSharedResult
+// @Router /share/public/{token}/results/{queryId} [get]
+func (s *Service) GetSharedResultHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		allowEmbedding(ctx)
+
+		token, ok := s.getValidToken(ctx)
+		if !ok {
+			return
+		}
+
+		queryId, err := base.GetUintParam(ctx, "queryId")
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		queryIds, err := s.sharedQueryIds(token)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		shared := false
+		for _, id := range queryIds {
+			if id == queryId {
+				shared = true
+				break
+			}
+		}
+		if !shared {
+			base.ResponseErr(ctx, http.StatusForbidden, "query %d is not shared", queryId)
+			return
+		}
+
+		result, err := s.resultCache.Get(ctx, queryId, nil)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		if result == nil {
+			base.ResponseErr(ctx, http.StatusNotFound, "result of query %d is not cached", queryId)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, ResponseSharedResult{
+			BaseResponse: base.ResponseOk(),
+			Data:         *result,
+		})
+	}
+}
+
+// Name service name
+func (s *Service) Name() string {
+	return ServiceName
+}
+
+// RouteTables route tables
+func (s *Service) RouteTables() []base.RouteTable {
+	group := "share"
+	return []base.RouteTable{
+		{
+			Method:  "POST",
+			Path:    group,
+			Handler: s.CreateShareHandler(),
+		},
+		{
+			Method:  "GET",
+			Path:    group,
+			Handler: s.ListShareHandler(),
+		},
+		{
+			Method:  "DELETE",
+			Path:    group + "/:id",
+			Handler: s.RevokeShareHandler(),
+		},
+		{
+			Method:     "GET",
+			Path:       group + "/public/:token",
+			Handler:    s.GetSharedHandler(),
+			AllowGuest: true,
+		},
+		{
+			Method:     "GET",
+			Path:       group + "/public/:token/results/:queryId",
+			Handler:    s.GetSharedResultHandler(),
+			AllowGuest: true,
+		},
+	}
+}
@@ -164,14 +164,16 @@ func (s *Service) RouteTables() []base.RouteTable {
 	group := "system"
 	return []base.RouteTable{
 		{
-			Method:  "GET",
-			Path:    group + "/engines",
-			Handler: s.ListEnginesHandler(),
+			Method:     "GET",
+			Path:       group + "/engines",
+			Handler:    s.ListEnginesHandler(),
+			AllowGuest: true,
 		},
 		{
-			Method:  "GET",
-			Path:    group + "/engines/:engineId",
-			Handler: s.GetQueryEngineDatasetHandle(),
+			Method:     "GET",
+			Path:       group + "/engines/:engineId",
+			Handler:    s.GetQueryEngineDatasetHandle(),
+			AllowGuest: true,
 		},
 	}
 }
@@ -0,0 +1,341 @@
+package user
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"infra-3.xyz/hyperdot-node/internal/apis/base"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+	"infra-3.xyz/hyperdot-node/internal/mailer"
+	"infra-3.xyz/hyperdot-node/internal/utils"
+)
+
+const (
+	// accountDeletionGracePeriod is the period the user can cancel the deletion of account
+	// before it is purged.
+	accountDeletionGracePeriod = 14 * 24 * time.Hour
+	// recentLoginPeriod is the period after login in which the user without password can
+	// delete the account by the session.
+	recentLoginPeriod = 10 * time.Minute
+)
+
+// errSoleOwner is returned when the user requested to delete the account is the only
+// owner of a workspace with other members.
+var errSoleOwner = errors.New("transfer the ownership of workspaces with other members before deleting the account")
+
+// checkSoleOwner returns errSoleOwner if the user is the only owner of a workspace
+// with other members, the workspace would be left to a member silently otherwise.
+func checkSoleOwner(db *gorm.DB, userId uint) error {
+	var workspaceIds []uint
+	if err := db.Model(&datamodel.WorkspaceMemberModel{}).
+		Where("user_id = ? AND role = ?", userId, datamodel.WorkspaceRoleOwner).
+		Pluck("workspace_id", &workspaceIds).Error; err != nil {
+		return err
+	}
+
+	for _, id := range workspaceIds {
+		var others, owners int64
+		if err := db.Model(&datamodel.WorkspaceMemberModel{}).
+			Where("workspace_id = ? AND user_id <> ?", id, userId).
+			Count(&others).Error; err != nil {
+			return err
+		}
+		if err := db.Model(&datamodel.WorkspaceMemberModel{}).
+			Where("workspace_id = ? AND user_id <> ? AND role = ?", id, userId, datamodel.WorkspaceRoleOwner).
+			Count(&owners).Error; err != nil {
+			return err
+		}
+
+		if others > 0 && owners == 0 {
+			return errSoleOwner
+		}
+	}
+
+	return nil
+}
+
+// reauthenticate checks the user proves the identity again, so that a stolen access token
+// can not delete the account. The password is required if the user has a password,
+// otherwise the two-factor code if it is enabled, otherwise a session logged in recently.
+// It responses the error and returns false if failed.
+func (s *Service) reauthenticate(ctx *gin.Context, user *datamodel.UserModel, request *RequestDeleteAccount) bool {
+	if len(user.EncryptedPassword) > 0 {
+		if !utils.VerifyPassword(user.EncryptedPassword, request.Password) {
+			base.ResponseErr(ctx, http.StatusBadRequest, "password not match")
+			return false
+		}
+		return true
+	}
+
+	twoFactor, err := s.loadTwoFactor(user.ID)
+	if err != nil {
+		base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+		return false
+	}
+	if twoFactor != nil && twoFactor.IsEnabled() {
+		if err := s.verifySecondFactor(twoFactor, request.Code); err != nil {
+			if errors.Is(err, errInvalidTwoFactorCode) {
+				base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+				return false
+			}
+
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return false
+		}
+		return true
+	}
+
+	// the sessions keep the time of login when they are refreshed
+	var session datamodel.UserSessionModel
+	sessionId := base.GetCurrentSessionId(ctx)
+	if sessionId != 0 {
+		if err := s.db.Where("id = ? AND user_id = ?", sessionId, user.ID).First(&session).Error; err != nil &&
+			!errors.Is(err, gorm.ErrRecordNotFound) {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return false
+		}
+	}
+	if session.ID == 0 || time.Since(session.CreatedAt) > recentLoginPeriod {
+		base.ResponseErr(ctx, http.StatusUnauthorized, "login again within %s to delete the account", recentLoginPeriod)
+		return false
+	}
+
+	return true
+}
+
+// @Summary Request to delete account
+// @Description Request to delete the account of current user, the account is purged after a grace period of 14 days
+// @Description and the deletion can be cancelled before it. The private queries and dashboards are deleted,
+// @Description and the public ones are kept with the anonymized user. The username confirms the account,
+// @Description and the password is required if the user has a password. The user without password provides
+// @Description the two-factor code if it is enabled, or logins again within 10 minutes before the request.
+// @Tags user apis
+// @Accept application/json
+// @Produce application/json
+// @Security ApiKeyAuth
+// @Param Authorization header string true "token"
+// @Param body body RequestDeleteAccount true "delete account request"
+// @Success 200 {object} ResponseAccountDeletion
+// @Router /user/deletion [post]
+func (s *Service) RequestAccountDeletionHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, ok := s.getCurrentUser(ctx)
+		if !ok {
+			return
+		}
+
+		var request RequestDeleteAccount
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if user.IsDeletionScheduled() {
+			base.ResponseErr(ctx, http.StatusBadRequest, "account deletion is already requested")
+			return
+		}
+
+		if request.Username != user.Username {
+			base.ResponseErr(ctx, http.StatusBadRequest, "username not match")
+			return
+		}
+
+		if !s.reauthenticate(ctx, user, &request) {
+			return
+		}
+
+		if err := checkSoleOwner(s.db, user.ID); err != nil {
+			if errors.Is(err, errSoleOwner) {
+				base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+				return
+			}
+
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		scheduledAt := time.Now().Add(accountDeletionGracePeriod)
+		if err := s.db.Model(user).Update("deletion_scheduled_at", scheduledAt).Error; err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		base.Audit(ctx, s.db, &base.AuditRecord{
+			Action:     datamodel.AuditUserDeletionRequest,
+			TargetType: datamodel.AuditTargetUser,
+			TargetID:   user.ID,
+			After:      map[string]any{"deletion_scheduled_at": scheduledAt},
+		})
+
+		if user.ConfirmedAt != nil && len(user.Email) > 0 {
+			if err := s.mailer.Send(ctx, &mailer.Message{
+				To:      user.Email,
+				Subject: "Your hyperdot account will be deleted",
+				Body: fmt.Sprintf("Hi %s,\n\nYour account will be deleted at %s. Login and cancel the deletion before it if you did not request it.\n",
+					user.Username, scheduledAt.Format(time.RFC1123)),
+			}); err != nil {
+				log.Printf("Error send deletion mail to user %d: %v", user.ID, err)
+			}
+		}
+
+		ctx.JSON(http.StatusOK, ResponseAccountDeletion{
+			BaseResponse: base.ResponseOk(),
+			Data:         ResponseAccountDeletionData{DeletionScheduledAt: &scheduledAt},
+		})
+	}
+}
+
+// @Summary Cancel account deletion
+// @Description Cancel the requested deletion of the account of current user before it is purged.
+// @Tags user apis
+// @Accept application/json
+// @Produce application/json
+// @Security ApiKeyAuth
+// @Param Authorization header string true "token"
+// @Success 200 {object} ResponseAccountDeletion
+// @Router /user/deletion [delete]
+func (s *Service) CancelAccountDeletionHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, ok := s.getCurrentUser(ctx)
+		if !ok {
+			return
+		}
+
+		if !user.IsDeletionScheduled() {
+			base.ResponseErr(ctx, http.StatusBadRequest, "account deletion is not requested")
+			return
+		}
+
+		if err := s.db.Model(user).Update("deletion_scheduled_at", nil).Error; err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		base.Audit(ctx, s.db, &base.AuditRecord{
+			Action:     datamodel.AuditUserDeletionCancel,
+			TargetType: datamodel.AuditTargetUser,
+			TargetID:   user.ID,
+		})
+
+		ctx.JSON(http.StatusOK, ResponseAccountDeletion{
+			BaseResponse: base.ResponseOk(),
+		})
+	}
+}
+
+// exportProfile is the profile of user in the data export.
+type exportProfile struct {
+	datamodel.UserModel
+	NotificationPreferences datamodel.JSON `json:"notification_preferences"`
+}
+
+// exportFavorites is the favorites of user in the data export.
+type exportFavorites struct {
+	Queries    []datamodel.UserQueryFavorites     `json:"queries"`
+	Dashboards []datamodel.UserDashboardFavorites `json:"dashboards"`
+}
+
+// exportData loads the personal data of user, the keys are the file names in archive.
+func (s *Service) exportData(user *datamodel.UserModel) (map[string]any, error) {
+	queries := make([]datamodel.QueryModel, 0)
+	if err := s.db.Where("user_id = ?", user.ID).Order("id ASC").Find(&queries).Error; err != nil {
+		return nil, err
+	}
+
+	charts := make([]datamodel.ChartModel, 0)
+	userQueries := s.db.Model(&datamodel.QueryModel{}).Select("id").Where("user_id = ?", user.ID)
+	if err := s.db.Where("query_id IN (?)", userQueries).Order("id ASC").Find(&charts).Error; err != nil {
+		return nil, err
+	}
+
+	dashboards := make([]datamodel.DashboardModel, 0)
+	if err := s.db.Where("user_id = ?", user.ID).Order("id ASC").Find(&dashboards).Error; err != nil {
+		return nil, err
+	}
+
+	for i := range dashboards {
+		dashboards[i].Panels = make([]datamodel.DashboardPanelModel, 0)
+		if err := s.db.Where("dashboard_id = ?", dashboards[i].ID).Order("id ASC").Find(&dashboards[i].Panels).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	favorites := exportFavorites{
+		Queries:    make([]datamodel.UserQueryFavorites, 0),
+		Dashboards: make([]datamodel.UserDashboardFavorites, 0),
+	}
+	if err := s.db.Where("user_id = ? AND stared = TRUE", user.ID).Find(&favorites.Queries).Error; err != nil {
+		return nil, err
+	}
+	if err := s.db.Where("user_id = ? AND stared = TRUE", user.ID).Find(&favorites.Dashboards).Error; err != nil {
+		return nil, err
+	}
+
+	return map[string]any{
+		"profile.json": exportProfile{
+			UserModel:               *user,
+			NotificationPreferences: user.NotificationPreferences,
+		},
+		"queries.json":    queries,
+		"charts.json":     charts,
+		"dashboards.json": dashboards,
+		"favorites.json":  favorites,
+	}, nil
+}
+
+// @Summary Export personal data
+// @Description Download the personal data of current user as a zip archive, it contains the profile, queries, charts,
+// @Description dashboards with panels and favorites as json files.
+// @Tags user apis
+// @Accept application/json
+// @Produce application/zip
+// @Security ApiKeyAuth
+// @Param Authorization header string true "token"
+// @Router /user/export [get]
+func (s *Service) ExportDataHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, ok := s.getCurrentUser(ctx)
+		if !ok {
+			return
+		}
+
+		files, err := s.exportData(user)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.Header("Content-Type", "application/zip")
+		ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=hyperdot-%d-%s.zip", user.ID, time.Now().Format("20060102")))
+		ctx.Status(http.StatusOK)
+
+		// the headers are sent, so the errors are only logged
+		archive := zip.NewWriter(ctx.Writer)
+		for _, name := range []string{"profile.json", "queries.json", "charts.json", "dashboards.json", "favorites.json"} {
+			w, err := archive.Create(name)
+			if err != nil {
+				log.Printf("Error export %s of user %d: %v", name, user.ID, err)
+				return
+			}
+
+			encoder := json.NewEncoder(w)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(files[name]); err != nil {
+				log.Printf("Error export %s of user %d: %v", name, user.ID, err)
+				return
+			}
+		}
+
+		if err := archive.Close(); err != nil {
+			log.Printf("Error export data of user %d: %v", user.ID, err)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package user
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"infra-3.xyz/hyperdot-node/internal/apis/base"
+)
+
+// ListActivityHandler List the audit logs of current user.
+// @Summary List the audit logs of current user.
+// @Description List the actions taken by current user, such as logins, account changes and content changes. The latest logs are listed first.
+// @Tags user apis
+// @Accept application/json
+// @Produce application/json
+// @Security ApiKeyAuth
+// @Param page query int false "page"
+// @Param page_size query int false "page_size"
+// @Param action query string false "action, e.g. user.login"
+// @Param target_type query string false "target type, one of user, query, dashboard and panel"
+// @Param target_id query int false "target id"
+// @Param from query string false "RFC3339 time, the logs created at or after it"
+// @Param to query string false "RFC3339 time, the logs created before it"
+// @Success 200 {object} base.ResponseAuditLogs
+// @Router /user/activity [get]
+func (s *Service) ListActivityHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userId, err := base.GetCurrentUserId(ctx)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		filter, err := base.GetAuditFilter(ctx)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+		filter.ActorID = userId
+
+		logs, total, err := base.ListAuditLogs(s.db, filter)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, base.ResponseAuditLogs{
+			BaseResponse: base.ResponseOk(),
+			Data: base.ResponseAuditLogsData{
+				Logs:  logs,
+				Total: total,
+			},
+		})
+	}
+}
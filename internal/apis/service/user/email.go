@@ -0,0 +1,321 @@
+package user
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"infra-3.xyz/hyperdot-node/internal/apis/base"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+	"infra-3.xyz/hyperdot-node/internal/mailer"
+	"infra-3.xyz/hyperdot-node/internal/utils"
+)
+
+const (
+	verifyEmailSubject   = "verify-email"
+	resetPasswordSubject = "reset-password"
+
+	verifyEmailTokenTTL   = 24 * time.Hour
+	resetPasswordTokenTTL = time.Hour
+)
+
+// passwordFingerprint binds the reset token to current password, so the token
+// can not be used again after the password is reset.
+func passwordFingerprint(user *datamodel.UserModel) string {
+	return utils.HashToken(user.EncryptedPassword)[:16]
+}
+
+// emailFingerprint binds the verify-email token to current email, so an old link
+// can not change the email back after it changes.
+func emailFingerprint(user *datamodel.UserModel) string {
+	return utils.HashToken(user.Email)[:16]
+}
+
+// link returns the link of site page with token.
+func (s *Service) link(page string, token string) string {
+	return fmt.Sprintf("%s/%s?token=%s", s.siteUrl, page, url.QueryEscape(token))
+}
+
+// sendVerifyEmail sends a link to confirm that the email belongs to user, the
+// email of user is changed to it after confirmed.
+func (s *Service) sendVerifyEmail(ctx *gin.Context, user *datamodel.UserModel, email string) error {
+	token, err := base.GenerateActionToken(&datamodel.ActionClaims{
+		UserID:      user.ID,
+		Email:       email,
+		Fingerprint: emailFingerprint(user),
+	}, verifyEmailSubject, time.Now().Add(verifyEmailTokenTTL))
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, &mailer.Message{
+		To:      email,
+		Subject: "Verify your email for hyperdot",
+		Body: fmt.Sprintf("Hi %s,\n\nOpen the link in %s to verify your email:\n\n%s\n\nIgnore this mail if you did not request it.\n",
+			user.Username, verifyEmailTokenTTL, s.link("verify-email", token)),
+	})
+}
+
+// SendVerifyEmailHandler Send the verification mail of current email.
+// @Summary Send the verification mail of current email.
+// @Description Send the verification mail of current email, the public contents can be published after verified.
+// @Tags user apis
+// @Accept application/json
+// @Produce application/json
+// @Security ApiKeyAuth
+// @Param Authorization header string true "token"
+// @Success 200 {object} base.BaseResponse
+// @Router /user/email/verify [post]
+func (s *Service) SendVerifyEmailHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userId, err := base.GetCurrentUserId(ctx)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		var user datamodel.UserModel
+		if err := s.db.Where("id = ?", userId).First(&user).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				base.ResponseErr(ctx, http.StatusNotFound, "user not found")
+				return
+			}
+
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		if len(user.Email) == 0 {
+			base.ResponseErr(ctx, http.StatusBadRequest, "email is not set")
+			return
+		}
+
+		if user.ConfirmedAt != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, "email already verified")
+			return
+		}
+
+		if err := s.sendVerifyEmail(ctx, &user, user.Email); err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		base.ResponseSuccess(ctx)
+	}
+}
+
+// VerifyEmailHandler Verify email by the token in verification mail.
+// @Summary Verify email by the token in verification mail.
+// @Description Verify email by the token in verification mail. The email of user is changed if the token is sent to a new email.
+// @Tags user apis
+// @Accept application/json
+// @Produce application/json
+// @Param body body RequestVerifyEmail true "verify email request"
+// @Success 200 {object} ResponseUpdateUser
+// @Router /user/auth/verifyEmail [post]
+func (s *Service) VerifyEmailHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var request RequestVerifyEmail
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		claims, err := base.VerifyActionToken(request.Token, verifyEmailSubject)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, "invalid token: %s", err.Error())
+			return
+		}
+
+		var user datamodel.UserModel
+		if err := s.db.Where("id = ?", claims.UserID).First(&user).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				base.ResponseErr(ctx, http.StatusBadRequest, "user not found")
+				return
+			}
+
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		if claims.Fingerprint != emailFingerprint(&user) {
+			base.ResponseErr(ctx, http.StatusBadRequest, "invalid token: the email has been changed")
+			return
+		}
+
+		if user.Email != claims.Email {
+			var count int64
+			if err := s.db.Model(&datamodel.UserModel{}).Where("email = ? AND id <> ?", claims.Email, user.ID).
+				Count(&count).Error; err != nil {
+				base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+				return
+			}
+			if count > 0 {
+				base.ResponseErr(ctx, http.StatusBadRequest, "the email %s already exists", claims.Email)
+				return
+			}
+		}
+
+		now := time.Now()
+		if err := s.db.Model(&user).Updates(map[string]any{
+			"email":        claims.Email,
+			"confirmed_at": now,
+		}).Error; err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		if user.Email != claims.Email {
+			base.Audit(ctx, s.db, &base.AuditRecord{
+				ActorID:    user.ID,
+				Action:     datamodel.AuditUserEmailChange,
+				TargetType: datamodel.AuditTargetUser,
+				TargetID:   user.ID,
+				Before:     map[string]string{"email": user.Email},
+				After:      map[string]string{"email": claims.Email},
+			})
+		}
+		user.Email = claims.Email
+		user.ConfirmedAt = &now
+
+		ctx.JSON(http.StatusOK, ResponseUpdateUser{
+			Data:         user,
+			BaseResponse: base.ResponseOk(),
+		})
+	}
+}
+
+// ForgotPasswordHandler Send the password reset mail.
+// @Summary Send the password reset mail.
+// @Description Send the password reset mail to the email of user signed up by password.
+// @Description It always succeeds so that the registered emails are not exposed.
+// @Tags user apis
+// @Accept application/json
+// @Produce application/json
+// @Param body body RequestForgotPassword true "forgot password request"
+// @Success 200 {object} base.BaseResponse
+// @Router /user/auth/forgotPassword [post]
+func (s *Service) ForgotPasswordHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var request RequestForgotPassword
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if len(request.Email) == 0 {
+			base.ResponseErr(ctx, http.StatusBadRequest, "email is required")
+			return
+		}
+
+		var user datamodel.UserModel
+		err := s.db.Where("email = ? AND provider = ?", request.Email, PasswordProvider).First(&user).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				base.ResponseSuccess(ctx)
+				return
+			}
+
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		token, err := base.GenerateActionToken(&datamodel.ActionClaims{
+			UserID:      user.ID,
+			Fingerprint: passwordFingerprint(&user),
+		}, resetPasswordSubject, time.Now().Add(resetPasswordTokenTTL))
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		if err := s.mailer.Send(ctx, &mailer.Message{
+			To:      user.Email,
+			Subject: "Reset your password for hyperdot",
+			Body: fmt.Sprintf("Hi %s,\n\nOpen the link in %s to reset your password:\n\n%s\n\nIgnore this mail if you did not request it.\n",
+				user.Username, resetPasswordTokenTTL, s.link("reset-password", token)),
+		}); err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		base.ResponseSuccess(ctx)
+	}
+}
+
+// ResetPasswordHandler Reset password by the token in password reset mail.
+// @Summary Reset password by the token in password reset mail.
+// @Description Reset password by the token in password reset mail, all sessions of user are logged out.
+// @Tags user apis
+// @Accept application/json
+// @Produce application/json
+// @Param body body RequestResetPassword true "reset password request"
+// @Success 200 {object} base.BaseResponse
+// @Router /user/auth/resetPassword [post]
+func (s *Service) ResetPasswordHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var request RequestResetPassword
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if len(request.NewPassword) == 0 {
+			base.ResponseErr(ctx, http.StatusBadRequest, "new password is required")
+			return
+		}
+
+		claims, err := base.VerifyActionToken(request.Token, resetPasswordSubject)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, "invalid token: %s", err.Error())
+			return
+		}
+
+		var user datamodel.UserModel
+		if err := s.db.Where("id = ?", claims.UserID).First(&user).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				base.ResponseErr(ctx, http.StatusBadRequest, "user not found")
+				return
+			}
+
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		if claims.Fingerprint != passwordFingerprint(&user) {
+			base.ResponseErr(ctx, http.StatusBadRequest, "invalid token: the token has been used")
+			return
+		}
+
+		encryptedPassword, err := utils.GeneratePassword(request.NewPassword)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if err := s.db.Model(&user).Update("encrypted_password", encryptedPassword).Error; err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		base.Audit(ctx, s.db, &base.AuditRecord{
+			ActorID:    user.ID,
+			Action:     datamodel.AuditUserPasswordReset,
+			TargetType: datamodel.AuditTargetUser,
+			TargetID:   user.ID,
+		})
+
+		// the sessions may be taken by the one who stole the password
+		if err := s.revokeUserSessions(ctx, user.ID); err != nil {
+			log.Printf("Error revoke sessions of user %d: %v", user.ID, err)
+		}
+
+		base.ResponseSuccess(ctx)
+	}
+}
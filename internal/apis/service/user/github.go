@@ -0,0 +1,388 @@
+package user
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"infra-3.xyz/hyperdot-node/internal/apis/base"
+	"infra-3.xyz/hyperdot-node/internal/clients"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+	"infra-3.xyz/hyperdot-node/internal/utils"
+)
+
+const (
+	githubStateCookie = "github_oauth_state"
+	githubLinkCookie  = "github_link"
+	githubStateMaxAge = 10 * 60
+	githubLinkSubject = "github-link"
+)
+
+var (
+	// errGithubEmailTaken is returned when the email of github account belongs to a
+	// user whose email is not confirmed, the user has to link the account explicitly.
+	errGithubEmailTaken = errors.New("the email of github account is registered, sign in and link the github account instead")
+	// errGithubLinked is returned when the github account is linked to another user.
+	errGithubLinked = errors.New("the github account is linked to another user")
+)
+
+// findGithubUser finds the user signed up by or linked to the github account.
+func (s *Service) findGithubUser(uid string) (*datamodel.UserModel, error) {
+	var user datamodel.UserModel
+	err := s.db.Where("(provider = ? AND uid = ?) OR github_uid = ?", GithubProvider, uid, uid).First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// linkGithubUser links the github account to the user, the profile fills the github
+// login and avatar of user if they are empty.
+func (s *Service) linkGithubUser(user *datamodel.UserModel, profile *clients.GithubUser) error {
+	updates := map[string]any{
+		"github_uid": strconv.FormatInt(profile.ID, 10),
+	}
+	if len(user.Github) == 0 {
+		updates["github"] = profile.Login
+	}
+	if len(user.IconUrl) == 0 {
+		updates["icon_url"] = profile.AvatarUrl
+	}
+
+	return s.db.Model(user).Updates(updates).Error
+}
+
+// findOrCreateGithubUser finds the user signed up by or linked to the github account,
+// or links the account to the user of same email if the email is confirmed by the
+// user. A new user is created if neither is found.
+func (s *Service) findOrCreateGithubUser(profile *clients.GithubUser) (*datamodel.UserModel, error) {
+	uid := strconv.FormatInt(profile.ID, 10)
+
+	found, err := s.findGithubUser(uid)
+	if err == nil {
+		return found, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	var user datamodel.UserModel
+	if len(profile.Email) > 0 {
+		err := s.db.Where("email = ?", profile.Email).First(&user).Error
+		if err == nil {
+			// an unconfirmed email may be registered by anyone before the owner signs in
+			if user.ConfirmedAt == nil || len(user.GithubUID) > 0 {
+				return nil, errGithubEmailTaken
+			}
+
+			if err := s.linkGithubUser(&user, profile); err != nil {
+				return nil, err
+			}
+
+			return &user, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+
+	// the github login may be taken by a user signed up by password
+	username := profile.Login
+	var count int64
+	if err := s.db.Model(&datamodel.UserModel{}).Where("username = ?", username).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		username = fmt.Sprintf("%s-%s", profile.Login, uid)
+	}
+
+	now := time.Now()
+	user = datamodel.UserModel{
+		UserBasic: datamodel.UserBasic{
+			Provider: GithubProvider,
+			UID:      uid,
+			Username: username,
+			Email:    profile.Email,
+			Bio:      profile.Bio,
+			IconUrl:  profile.AvatarUrl,
+			Github:   profile.Login,
+			Location: profile.Location,
+		},
+		GithubUID: uid,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if len(profile.Email) > 0 {
+		user.ConfirmedAt = &now
+	}
+
+	if err := s.db.Create(&user).Error; err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// GithubLoginHandler Redirect to the github authorization page.
+// @Summary Redirect to the github authorization page.
+// @Description Redirect to the github authorization page, github redirects back to the callback api after authorized.
+// @Tags user apis
+// @Success 302
+// @Router /user/auth/github [get]
+func (s *Service) GithubLoginHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if s.github == nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, "unsupported provider %s", GithubProvider)
+			return
+		}
+
+		state, err := newGithubState(ctx)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.Redirect(http.StatusFound, s.github.AuthCodeURL(state))
+	}
+}
+
+// newGithubState generates the oauth state and sets it to the cookie.
+func newGithubState(ctx *gin.Context) (string, error) {
+	state, err := utils.RandomToken(16)
+	if err != nil {
+		return "", err
+	}
+
+	http.SetCookie(ctx.Writer, &http.Cookie{
+		Name:     githubStateCookie,
+		Value:    state,
+		Path:     "/",
+		MaxAge:   githubStateMaxAge,
+		HttpOnly: true,
+	})
+	return state, nil
+}
+
+// GithubLinkHandler Link a github account to current user.
+// @Summary Link a github account to current user.
+// @Description Start linking a github account to current user, it responses the url of github authorization page.
+// @Description The github account is linked to current user when github redirects back to the callback api, then
+// @Description the user can login by the github account.
+// @Tags user apis
+// @Produce application/json
+// @Security ApiKeyAuth
+// @Param Authorization header string true "token"
+// @Success 200 {object} ResponseGithubLink
+// @Router /user/auth/github/link [post]
+func (s *Service) GithubLinkHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if s.github == nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, "unsupported provider %s", GithubProvider)
+			return
+		}
+
+		userId, err := base.GetCurrentUserId(ctx)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		state, err := newGithubState(ctx)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		// the link token is bound to the state, so it is used by this authorization only
+		token, err := base.GenerateActionToken(&datamodel.ActionClaims{
+			UserID:      userId,
+			Fingerprint: state,
+		}, githubLinkSubject, time.Now().Add(githubStateMaxAge*time.Second))
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		http.SetCookie(ctx.Writer, &http.Cookie{
+			Name:     githubLinkCookie,
+			Value:    token,
+			Path:     "/",
+			MaxAge:   githubStateMaxAge,
+			HttpOnly: true,
+		})
+
+		ctx.JSON(http.StatusOK, ResponseGithubLink{
+			BaseResponse: base.ResponseOk(),
+			Data:         ResponseGithubLinkData{Url: s.github.AuthCodeURL(state)},
+		})
+	}
+}
+
+// linkingUser returns the user linking the github account by the link cookie, it
+// returns nil if the authorization is a login.
+func (s *Service) linkingUser(ctx *gin.Context, state string) (*datamodel.UserModel, error) {
+	token, err := ctx.Cookie(githubLinkCookie)
+	if err != nil || len(token) == 0 {
+		return nil, nil
+	}
+
+	http.SetCookie(ctx.Writer, &http.Cookie{
+		Name:   githubLinkCookie,
+		Path:   "/",
+		MaxAge: -1,
+	})
+
+	claims, err := base.VerifyActionToken(token, githubLinkSubject)
+	if err != nil || claims.Fingerprint != state {
+		return nil, nil
+	}
+
+	var user datamodel.UserModel
+	if err := s.db.Where("id = ?", claims.UserID).First(&user).Error; err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// GithubCallbackHandler Login by the github authorization code.
+// @Summary Login by the github authorization code.
+// @Description Login by the github authorization code. The github account is linked to the user of same confirmed email,
+// @Description or a new user is created. If the email is registered but not confirmed, it responses 409 and the user
+// @Description has to link the github account by POST /user/auth/github/link. If the authorization is started by the
+// @Description link api, the github account is linked to the user instead of login. It redirects to the configured
+// @Description login page, or responses the tokens if not configured. If the user enabled two-factor authentication,
+// @Description the pending token is responsed, or passed to the login page by the two_factor_token query.
+// @Tags user apis
+// @Produce application/json
+// @Param code query string true "authorization code"
+// @Param state query string true "state"
+// @Success 200 {object} ResponseLogin
+// @Router /user/auth/github/callback [get]
+func (s *Service) GithubCallbackHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if s.github == nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, "unsupported provider %s", GithubProvider)
+			return
+		}
+
+		if reason := ctx.Query("error"); len(reason) > 0 {
+			base.ResponseErr(ctx, http.StatusUnauthorized, "github authorization failed: %s", reason)
+			return
+		}
+
+		state, err := ctx.Cookie(githubStateCookie)
+		if err != nil || len(state) == 0 || state != ctx.Query("state") {
+			base.ResponseErr(ctx, http.StatusBadRequest, "invalid oauth state")
+			return
+		}
+
+		http.SetCookie(ctx.Writer, &http.Cookie{
+			Name:   githubStateCookie,
+			Path:   "/",
+			MaxAge: -1,
+		})
+
+		code := ctx.Query("code")
+		if len(code) == 0 {
+			base.ResponseErr(ctx, http.StatusBadRequest, "code is required")
+			return
+		}
+
+		profile, err := s.github.Exchange(ctx, code)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		linking, err := s.linkingUser(ctx, state)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				base.ResponseErr(ctx, http.StatusNotFound, "user not found")
+				return
+			}
+
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if linking != nil {
+			s.completeGithubLink(ctx, linking, profile)
+			return
+		}
+
+		user, err := s.findOrCreateGithubUser(profile)
+		if err != nil {
+			if errors.Is(err, errGithubEmailTaken) {
+				base.ResponseErr(ctx, http.StatusConflict, err.Error())
+				return
+			}
+
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		data, err := s.startLogin(ctx, user)
+		if err != nil || len(s.loginRedirectUrl) == 0 {
+			s.responseLogin(ctx, data, err)
+			return
+		}
+
+		// the pending token is passed to the login page to complete the second factor
+		redirectUrl := s.loginRedirectUrl
+		if data.TwoFactorRequired {
+			redirectUrl, err = withQuery(redirectUrl, "two_factor_token", data.TwoFactorToken)
+			if err != nil {
+				base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+				return
+			}
+		}
+
+		ctx.Redirect(http.StatusFound, redirectUrl)
+	}
+}
+
+// completeGithubLink links the github account to the user and responses, it redirects
+// to the configured login page if any.
+func (s *Service) completeGithubLink(ctx *gin.Context, user *datamodel.UserModel, profile *clients.GithubUser) {
+	found, err := s.findGithubUser(strconv.FormatInt(profile.ID, 10))
+	if err == nil && found.ID != user.ID {
+		base.ResponseErr(ctx, http.StatusConflict, errGithubLinked.Error())
+		return
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := s.linkGithubUser(user, profile); err != nil {
+		base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if len(s.loginRedirectUrl) > 0 {
+		ctx.Redirect(http.StatusFound, s.loginRedirectUrl)
+		return
+	}
+
+	base.ResponseSuccess(ctx)
+}
+
+// withQuery adds the query parameter to the url.
+func withQuery(rawUrl string, key string, value string) (string, error) {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return "", err
+	}
+
+	query := u.Query()
+	query.Set(key, value)
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
@@ -14,6 +14,21 @@ type RequestLogin struct {
 	UserId   string `json:"userId"`
 	Email    string `json:"email"`
 	Password string `json:"password"`
+	// Address is the SS58 address of substrate account
+	Address string `json:"address"`
+	// Signature is the hex encoded signature of the nonce message by substrate account
+	Signature string `json:"signature"`
+}
+
+// RequestSubstrateNonce is request of POST /user/auth/substrate/nonce
+type RequestSubstrateNonce struct {
+	Address string `json:"address"`
+	ChainId uint   `json:"chain_id"`
+}
+
+// RequestRefreshToken is request of POST /user/auth/refresh
+type RequestRefreshToken struct {
+	RefreshToken string `json:"refresh_token"`
 }
 
 // RequestUpdateUserEmail is request of PUT /user/email
@@ -26,3 +41,50 @@ type RequestUpdatePassword struct {
 	CurrentPassword string `json:"current_password"`
 	NewPassword     string `json:"new_password"`
 }
+
+// RequestVerifyEmail is request of POST /user/auth/verifyEmail
+type RequestVerifyEmail struct {
+	Token string `json:"token"`
+}
+
+// RequestForgotPassword is request of POST /user/auth/forgotPassword
+type RequestForgotPassword struct {
+	Email string `json:"email"`
+}
+
+// RequestResetPassword is request of POST /user/auth/resetPassword
+type RequestResetPassword struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+// RequestTwoFactorCode is request of POST /user/2fa/verify and POST /user/2fa/recoveryCodes
+type RequestTwoFactorCode struct {
+	Code string `json:"code"`
+}
+
+// RequestTwoFactorLogin is request of POST /user/auth/2fa/login
+type RequestTwoFactorLogin struct {
+	// Token is the pending token responded by POST /user/auth/login
+	Token string `json:"token"`
+	// Code is the TOTP code of authenticator or a recovery code
+	Code string `json:"code"`
+}
+
+// RequestDisableTwoFactor is request of POST /user/2fa/disable
+type RequestDisableTwoFactor struct {
+	Password string `json:"password"`
+	// Code is the TOTP code of authenticator or a recovery code
+	Code string `json:"code"`
+}
+
+// RequestDeleteAccount is request of POST /user/deletion
+type RequestDeleteAccount struct {
+	// Username confirms the account to be deleted.
+	Username string `json:"username"`
+	// Password is required if the user has a password.
+	Password string `json:"password"`
+	// Code is the two-factor code or a recovery code, it is required if the user has no
+	// password but enabled two-factor authentication.
+	Code string `json:"code"`
+}
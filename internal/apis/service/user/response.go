@@ -21,12 +21,17 @@ type ResponseGetUserData struct {
 	Telgram           string     `json:"telgram"`
 	Discord           string     `json:"discord"`
 	Location          string     `json:"location"`
+	Role              string     `json:"role"`
 	ConfirmedAt       *time.Time `json:"confirmed_at"`
 	CreatedAt         *time.Time `json:"created_at"`
 	UpdatedAt         *time.Time `json:"updated_at"`
 	Stars             uint       `json:"stars"`
 	Queries           uint       `json:"queries"`
 	Dashboards        uint       `json:"dashboards"`
+	Followers         uint       `json:"followers"`
+	Following         uint       `json:"following"`
+	// Followed reports whether the current user follows the user.
+	Followed bool `json:"followed"`
 }
 
 // ResponseGetUser is response of GET /user or GET /user/:id
@@ -47,8 +52,55 @@ type ResponseCreateAccount struct {
 
 // ResponseLogin is response of POST /user/auth/login
 type ResponseLogin struct {
-	Algorithm string `json:"algorithm"`
-	Token     string `json:"token"`
+	Algorithm    string    `json:"algorithm"`
+	Token        string    `json:"token"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	RefreshToken string    `json:"refresh_token"`
+	// TwoFactorRequired is true if the password is verified but the second factor
+	// is required, the login is completed by POST /user/auth/2fa/login with TwoFactorToken.
+	TwoFactorRequired bool   `json:"two_factor_required,omitempty"`
+	TwoFactorToken    string `json:"two_factor_token,omitempty"`
+}
+
+// ResponseSubstrateNonceData is data of response of POST /user/auth/substrate/nonce
+type ResponseSubstrateNonceData struct {
+	// Message is the message to sign, it includes the nonce.
+	Message   string    `json:"message"`
+	ExpiresAt time.Time `json:"expires_at"`
+	// Prefix is the SS58 prefix of address.
+	Prefix uint16 `json:"prefix"`
+	// Networks is the chain names of the address prefix.
+	Networks []string `json:"networks"`
+}
+
+// ResponseSubstrateNonce is response of POST /user/auth/substrate/nonce
+type ResponseSubstrateNonce struct {
+	base.BaseResponse
+	Data ResponseSubstrateNonceData `json:"data"`
+}
+
+// ResponseGithubLinkData is data of response of POST /user/auth/github/link
+type ResponseGithubLinkData struct {
+	// Url is the url of github authorization page.
+	Url string `json:"url"`
+}
+
+// ResponseGithubLink is response of POST /user/auth/github/link
+type ResponseGithubLink struct {
+	base.BaseResponse
+	Data ResponseGithubLinkData `json:"data"`
+}
+
+// ResponseSession is a login session of user
+type ResponseSession struct {
+	datamodel.UserSessionModel
+	Current bool `json:"current"`
+}
+
+// ResponseListSessions is response of GET /user/sessions
+type ResponseListSessions struct {
+	base.BaseResponse
+	Data []ResponseSession `json:"data"`
 }
 
 // ResponseUploadAvatarData is data of response of POST /user/avatar/upload
@@ -62,3 +114,49 @@ type ResponseUploadAvatar struct {
 	base.BaseResponse
 	Data ResponseUploadAvatarData `json:"data"`
 }
+
+// ResponseTwoFactorStatusData is data of response of GET /user/2fa
+type ResponseTwoFactorStatusData struct {
+	Enabled   bool       `json:"enabled"`
+	EnabledAt *time.Time `json:"enabled_at"`
+	// RecoveryCodes is the number of unused recovery codes
+	RecoveryCodes int64 `json:"recovery_codes"`
+}
+
+// ResponseTwoFactorStatus is response of GET /user/2fa
+type ResponseTwoFactorStatus struct {
+	base.BaseResponse
+	Data ResponseTwoFactorStatusData `json:"data"`
+}
+
+// ResponseTwoFactorEnrollData is data of response of POST /user/2fa/enroll
+type ResponseTwoFactorEnrollData struct {
+	Secret string `json:"secret"`
+	// URI is the otpauth provisioning uri, the authenticator apps scan the QR code of it.
+	URI string `json:"uri"`
+}
+
+// ResponseTwoFactorEnroll is response of POST /user/2fa/enroll
+type ResponseTwoFactorEnroll struct {
+	base.BaseResponse
+	Data ResponseTwoFactorEnrollData `json:"data"`
+}
+
+// ResponseRecoveryCodes is response of POST /user/2fa/verify and POST /user/2fa/recoveryCodes,
+// the recovery codes are only responded once.
+type ResponseRecoveryCodes struct {
+	base.BaseResponse
+	Data []string `json:"data"`
+}
+
+// ResponseAccountDeletionData is the deletion state of account.
+type ResponseAccountDeletionData struct {
+	// DeletionScheduledAt is the time the account is purged, it is null if the deletion is cancelled.
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at"`
+}
+
+// ResponseAccountDeletion is response of POST /user/deletion and DELETE /user/deletion
+type ResponseAccountDeletion struct {
+	base.BaseResponse
+	Data ResponseAccountDeletionData `json:"data"`
+}
@@ -0,0 +1,470 @@
+package user
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"infra-3.xyz/hyperdot-node/internal/apis/base"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+	"infra-3.xyz/hyperdot-node/internal/utils"
+)
+
+const (
+	tokenCookie        = "token"
+	refreshTokenCookie = "refresh_token"
+	// maxSignLogs is the number of latest sign logs kept for user.
+	maxSignLogs = 10
+)
+
+// errInvalidRefreshToken is returned when the refresh token is unknown, reused,
+// revoked or expired.
+var errInvalidRefreshToken = errors.New("invalid refresh token")
+
+// errUserDisabled is returned when the user disabled by admin logins.
+var errUserDisabled = errors.New("user is disabled")
+
+func setTokenCookies(ctx *gin.Context, login *ResponseLogin, sessionExpiresAt time.Time) {
+	http.SetCookie(ctx.Writer, &http.Cookie{
+		Name:    tokenCookie,
+		Value:   url.QueryEscape(login.Token),
+		Path:    "/",
+		Expires: login.ExpiresAt,
+	})
+
+	http.SetCookie(ctx.Writer, &http.Cookie{
+		Name:     refreshTokenCookie,
+		Value:    login.RefreshToken,
+		Path:     "/",
+		Expires:  sessionExpiresAt,
+		HttpOnly: true,
+	})
+}
+
+func clearTokenCookies(ctx *gin.Context) {
+	for _, name := range []string{tokenCookie, refreshTokenCookie} {
+		http.SetCookie(ctx.Writer, &http.Cookie{
+			Name:   name,
+			Path:   "/",
+			MaxAge: -1,
+		})
+	}
+}
+
+// issueAccessToken signs a short-lived access token of the session.
+func issueAccessToken(user *datamodel.UserModel, session *datamodel.UserSessionModel, refreshToken string) (*ResponseLogin, error) {
+	claims := user.ToClaims()
+	claims.SessionID = session.ID
+
+	expireAt := base.TokenDefaultExpireTime()
+	signing, err := base.GenerateJwtToken(claims, expireAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ResponseLogin{
+		Algorithm:    base.JwtSigningAlgorithm(),
+		Token:        signing,
+		ExpiresAt:    expireAt,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// newSession creates a new session of the user and sets the tokens of it to cookies.
+// It returns errUserDisabled if the user is disabled.
+func (s *Service) newSession(ctx *gin.Context, user *datamodel.UserModel) (*ResponseLogin, error) {
+	if user.IsDisabled() {
+		return nil, errUserDisabled
+	}
+
+	refreshToken, err := utils.RandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	session := datamodel.UserSessionModel{
+		UserID:           user.ID,
+		RefreshTokenHash: utils.HashToken(refreshToken),
+		UserAgent:        ctx.Request.UserAgent(),
+		IP:               ctx.ClientIP(),
+		ExpiresAt:        base.RefreshTokenDefaultExpireTime(),
+		RefreshedAt:      time.Now(),
+	}
+	if err := s.db.Create(&session).Error; err != nil {
+		return nil, err
+	}
+
+	data, err := issueAccessToken(user, &session, refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	s.recordSignIn(ctx, user)
+	setTokenCookies(ctx, data, session.ExpiresAt)
+	return data, nil
+}
+
+// recordSignIn appends the client to the sign logs of user and audits the login.
+func (s *Service) recordSignIn(ctx *gin.Context, user *datamodel.UserModel) {
+	now := time.Now()
+	logs := user.UserSignLogs
+	logs.SignInCount += 1
+	logs.Logs = append(logs.Logs, datamodel.UserSignLog{
+		UserAgent: ctx.Request.UserAgent(),
+		At:        &now,
+		IP:        ctx.ClientIP(),
+	})
+	if len(logs.Logs) > maxSignLogs {
+		logs.Logs = logs.Logs[len(logs.Logs)-maxSignLogs:]
+	}
+
+	if err := s.db.Model(user).UpdateColumn("user_sign_logs", logs).Error; err != nil {
+		log.Printf("Error record sign logs of user %d: %v", user.ID, err)
+	} else {
+		user.UserSignLogs = logs
+	}
+
+	base.Audit(ctx, s.db, &base.AuditRecord{
+		ActorID:    user.ID,
+		Action:     datamodel.AuditUserLogin,
+		TargetType: datamodel.AuditTargetUser,
+		TargetID:   user.ID,
+		After:      map[string]string{"provider": user.Provider},
+	})
+}
+
+// startLogin logins the user verified by any provider, every login goes through it
+// so that the second factor is never skipped. It creates a new session of the user,
+// or returns a pending token if the user enabled two-factor authentication.
+func (s *Service) startLogin(ctx *gin.Context, user *datamodel.UserModel) (*ResponseLogin, error) {
+	if user.IsDisabled() {
+		return nil, errUserDisabled
+	}
+
+	twoFactor, err := s.loadTwoFactor(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if twoFactor != nil && twoFactor.IsEnabled() {
+		return pendingTwoFactor(user)
+	}
+
+	return s.newSession(ctx, user)
+}
+
+// login starts the login of user and responses the tokens.
+func (s *Service) login(ctx *gin.Context, user *datamodel.UserModel) {
+	data, err := s.startLogin(ctx, user)
+	s.responseLogin(ctx, data, err)
+}
+
+// responseLogin responses the tokens of login, or the error of it.
+func (s *Service) responseLogin(ctx *gin.Context, data *ResponseLogin, err error) {
+	if err != nil {
+		if errors.Is(err, errUserDisabled) {
+			base.ResponseErr(ctx, http.StatusForbidden, err.Error())
+			return
+		}
+
+		base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	base.ResponseWithData(ctx, data)
+}
+
+// rotateRefreshToken replaces the refresh token of session and returns the
+// session. Presenting a rotated token means it has been stolen, so the whole
+// session is revoked.
+func (s *Service) rotateRefreshToken(ctx *gin.Context, refreshToken string) (*datamodel.UserSessionModel, string, error) {
+	hash := utils.HashToken(refreshToken)
+
+	var session datamodel.UserSessionModel
+	err := s.db.Where("refresh_token_hash = ?", hash).First(&session).Error
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", err
+		}
+
+		err = s.db.Where("previous_token_hash = ?", hash).First(&session).Error
+		if err == nil && session.RevokedAt == nil {
+			if err := s.revokeSessions(ctx, &session); err != nil {
+				return nil, "", err
+			}
+		}
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", err
+		}
+
+		return nil, "", errInvalidRefreshToken
+	}
+
+	now := time.Now()
+	if !session.IsValid(now) {
+		return nil, "", errInvalidRefreshToken
+	}
+
+	newRefreshToken, err := utils.RandomToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// the conditional update keeps the token from being rotated twice concurrently
+	result := s.db.Model(&datamodel.UserSessionModel{}).
+		Where("id = ? AND refresh_token_hash = ?", session.ID, hash).
+		Updates(map[string]any{
+			"refresh_token_hash":  utils.HashToken(newRefreshToken),
+			"previous_token_hash": hash,
+			"refreshed_at":        now,
+			"ip":                  ctx.ClientIP(),
+			"user_agent":          ctx.Request.UserAgent(),
+		})
+	if result.Error != nil {
+		return nil, "", result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, "", errInvalidRefreshToken
+	}
+
+	return &session, newRefreshToken, nil
+}
+
+// revokeSessions marks the sessions revoked and rejects their access tokens
+// until the tokens expire.
+func (s *Service) revokeSessions(ctx *gin.Context, sessions ...*datamodel.UserSessionModel) error {
+	return base.RevokeSessions(ctx, s.db, s.revocations, sessions...)
+}
+
+// revokeUserSessions revokes all active sessions of the user, except the given sessions.
+func (s *Service) revokeUserSessions(ctx *gin.Context, userId uint, except ...uint) error {
+	return base.RevokeUserSessions(ctx, s.db, s.revocations, userId, except...)
+}
+
+// RefreshTokenHandler Renew the access token by refresh token.
+// @Summary Renew the access token by refresh token.
+// @Description Renew the access token by refresh token. The refresh token is rotated and the old one can not be used again.
+// @Description The refresh token is read from the refresh_token cookie if it is absent in body.
+// @Tags user apis
+// @Accept application/json
+// @Produce application/json
+// @Param body body RequestRefreshToken false "refresh token request"
+// @Success 200 {object} ResponseLogin
+// @Router /user/auth/refresh [post]
+func (s *Service) RefreshTokenHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var request RequestRefreshToken
+		if ctx.Request.ContentLength > 0 {
+			if err := ctx.ShouldBindJSON(&request); err != nil {
+				base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+
+		if len(request.RefreshToken) == 0 {
+			if cookie, err := ctx.Cookie(refreshTokenCookie); err == nil {
+				request.RefreshToken = cookie
+			}
+		}
+
+		if len(request.RefreshToken) == 0 {
+			base.ResponseErr(ctx, http.StatusBadRequest, "refresh token is required")
+			return
+		}
+
+		session, refreshToken, err := s.rotateRefreshToken(ctx, request.RefreshToken)
+		if err != nil {
+			if errors.Is(err, errInvalidRefreshToken) {
+				clearTokenCookies(ctx)
+				base.ResponseErr(ctx, http.StatusUnauthorized, err.Error())
+				return
+			}
+
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		var user datamodel.UserModel
+		if err := s.db.Where("id = ?", session.UserID).First(&user).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				base.ResponseErr(ctx, http.StatusUnauthorized, "user not found")
+				return
+			}
+
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		if user.IsDisabled() {
+			clearTokenCookies(ctx)
+			base.ResponseErr(ctx, http.StatusUnauthorized, errUserDisabled.Error())
+			return
+		}
+
+		data, err := issueAccessToken(&user, session, refreshToken)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		setTokenCookies(ctx, data, session.ExpiresAt)
+		base.ResponseWithData(ctx, data)
+	}
+}
+
+// LogoutHandler Logout the current session.
+// @Summary Logout the current session.
+// @Description Revoke the session of current token, the access token and refresh token of it are rejected.
+// @Tags user apis
+// @Accept application/json
+// @Produce application/json
+// @Security ApiKeyAuth
+// @Param Authorization header string true "token"
+// @Success 200 {object} base.BaseResponse
+// @Router /user/auth/logout [post]
+func (s *Service) LogoutHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userId, err := base.GetCurrentUserId(ctx)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		sessionId := base.GetCurrentSessionId(ctx)
+		if sessionId == 0 {
+			base.ResponseErr(ctx, http.StatusBadRequest, "the token is not issued by a login session")
+			return
+		}
+
+		var session datamodel.UserSessionModel
+		if err := s.db.Where("id = ? AND user_id = ?", sessionId, userId).First(&session).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				base.ResponseErr(ctx, http.StatusNotFound, "session not found")
+				return
+			}
+
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		if err := s.revokeSessions(ctx, &session); err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		clearTokenCookies(ctx)
+		base.ResponseSuccess(ctx)
+	}
+}
+
+// LogoutAllHandler Logout all sessions of the current user.
+// @Summary Logout all sessions of the current user.
+// @Description Revoke all sessions of the current user, include the current session.
+// @Tags user apis
+// @Accept application/json
+// @Produce application/json
+// @Security ApiKeyAuth
+// @Param Authorization header string true "token"
+// @Success 200 {object} base.BaseResponse
+// @Router /user/auth/logoutAll [post]
+func (s *Service) LogoutAllHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userId, err := base.GetCurrentUserId(ctx)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if err := s.revokeUserSessions(ctx, userId); err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		clearTokenCookies(ctx)
+		base.ResponseSuccess(ctx)
+	}
+}
+
+// ListSessionsHandler List the active sessions of the current user.
+// @Summary List the active sessions of the current user.
+// @Description List the active sessions of the current user.
+// @Tags user apis
+// @Accept application/json
+// @Produce application/json
+// @Security ApiKeyAuth
+// @Param Authorization header string true "token"
+// @Success 200 {object} ResponseListSessions
+// @Router /user/sessions [get]
+func (s *Service) ListSessionsHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userId, err := base.GetCurrentUserId(ctx)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		var sessions []datamodel.UserSessionModel
+		if err := s.db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userId, time.Now()).
+			Order("refreshed_at DESC").
+			Find(&sessions).Error; err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		currentId := base.GetCurrentSessionId(ctx)
+		data := make([]ResponseSession, 0, len(sessions))
+		for _, session := range sessions {
+			data = append(data, ResponseSession{
+				UserSessionModel: session,
+				Current:          session.ID == currentId,
+			})
+		}
+
+		ctx.JSON(http.StatusOK, ResponseListSessions{
+			BaseResponse: base.ResponseOk(),
+			Data:         data,
+		})
+	}
+}
+
+// isLockedOut responses 429 if the user is locked out by the login failures.
+// The login is not blocked if the lockout fails.
+func (s *Service) isLockedOut(ctx *gin.Context, user *datamodel.UserModel) bool {
+	lockedFor, err := s.lockout.LockedFor(ctx, user.ID)
+	if err != nil {
+		log.Printf("Error get login lockout of user %d: %v", user.ID, err)
+		return false
+	}
+	if lockedFor == 0 {
+		return false
+	}
+
+	base.ResponseTooManyRequests(ctx, lockedFor, "too many failed logins, retry after %s", lockedFor.Round(time.Second))
+	return true
+}
+
+// failLogin audits the login failure and locks the user out progressively.
+func (s *Service) failLogin(ctx *gin.Context, user *datamodel.UserModel, reason string) {
+	base.Audit(ctx, s.db, &base.AuditRecord{
+		ActorID:    user.ID,
+		Action:     datamodel.AuditUserLoginFailed,
+		TargetType: datamodel.AuditTargetUser,
+		TargetID:   user.ID,
+		After:      map[string]string{"reason": reason},
+	})
+
+	if _, err := s.lockout.Fail(ctx, user.ID); err != nil {
+		log.Printf("Error record login failure of user %d: %v", user.ID, err)
+	}
+}
+
+// resetLockout clears the login failures of user after the login succeeds.
+func (s *Service) resetLockout(ctx *gin.Context, user *datamodel.UserModel) {
+	if err := s.lockout.Reset(ctx, user.ID); err != nil {
+		log.Printf("Error reset login lockout of user %d: %v", user.ID, err)
+	}
+}
@@ -0,0 +1,186 @@
+package user
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+
+	"infra-3.xyz/hyperdot-node/internal/apis/base"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+	"infra-3.xyz/hyperdot-node/internal/utils"
+)
+
+// substrateNonceTTL is the time for the wallet to sign the nonce.
+const substrateNonceTTL = 5 * time.Minute
+
+func substrateNonceKey(address string) string {
+	return fmt.Sprintf("hyperdot:substrate:nonce:%s", address)
+}
+
+// substrateNetworks returns the chains of the address prefix.
+func (s *Service) substrateNetworks(ctx *gin.Context, prefix uint16) ([]datamodel.ChainModel, error) {
+	values, err := s.redisClient.HGetAll(ctx, datamodel.BigQueryRawPolkadotChainsKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var chains []datamodel.ChainModel
+	for _, v := range values {
+		var chain datamodel.ChainModel
+		if err := json.Unmarshal([]byte(v), &chain); err != nil {
+			return nil, err
+		}
+
+		if chain.Prefix == int(prefix) {
+			chains = append(chains, chain)
+		}
+	}
+
+	return chains, nil
+}
+
+// SubstrateNonceHandler Issue a nonce for the substrate account to sign.
+// @Summary Issue a nonce for the substrate account to sign.
+// @Description Issue a one-time message with nonce, the wallet signs the message by sr25519 or ed25519
+// @Description and login by POST /user/auth/login with provider substrate in 5 minutes.
+// @Description If chain_id is given, the address must be encoded by the prefix of chain or the generic prefix 42.
+// @Tags user apis
+// @Accept application/json
+// @Produce application/json
+// @Param body body RequestSubstrateNonce true "substrate nonce request"
+// @Success 200 {object} ResponseSubstrateNonce
+// @Router /user/auth/substrate/nonce [post]
+func (s *Service) SubstrateNonceHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var request RequestSubstrateNonce
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		prefix, publicKey, err := utils.DecodeSS58(request.Address)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, "invalid address: %s", err.Error())
+			return
+		}
+
+		chains, err := s.substrateNetworks(ctx, prefix)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		if request.ChainId > 0 && prefix != utils.SS58GenericPrefix {
+			matched := false
+			for _, chain := range chains {
+				matched = matched || chain.ChainID == request.ChainId
+			}
+			if !matched {
+				base.ResponseErr(ctx, http.StatusBadRequest, "the address prefix %d does not match chain %d", prefix, request.ChainId)
+				return
+			}
+		}
+
+		nonce, err := utils.RandomToken(16)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		// the nonce is bound to the account rather than the address of a network
+		account := utils.EncodeSS58(utils.SS58GenericPrefix, publicKey)
+		message := fmt.Sprintf("Sign in to hyperdot with %s, nonce: %s", request.Address, nonce)
+		if err := s.redisClient.Set(ctx, substrateNonceKey(account), message, substrateNonceTTL).Err(); err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		networks := make([]string, 0, len(chains))
+		for _, chain := range chains {
+			networks = append(networks, chain.ChainName)
+		}
+
+		ctx.JSON(http.StatusOK, ResponseSubstrateNonce{
+			BaseResponse: base.ResponseOk(),
+			Data: ResponseSubstrateNonceData{
+				Message:   message,
+				ExpiresAt: time.Now().Add(substrateNonceTTL),
+				Prefix:    prefix,
+				Networks:  networks,
+			},
+		})
+	}
+}
+
+// loginBySubstrate verifies the signature of the nonce message, and logs in the
+// user bound to the account. A new user is created on the first login.
+func (s *Service) loginBySubstrate(ctx *gin.Context, request *RequestLogin) {
+	if len(request.Address) == 0 || len(request.Signature) == 0 {
+		base.ResponseErr(ctx, http.StatusBadRequest, "address and signature are required")
+		return
+	}
+
+	_, publicKey, err := utils.DecodeSS58(request.Address)
+	if err != nil {
+		base.ResponseErr(ctx, http.StatusBadRequest, "invalid address: %s", err.Error())
+		return
+	}
+
+	signature, err := hex.DecodeString(strings.TrimPrefix(request.Signature, "0x"))
+	if err != nil {
+		base.ResponseErr(ctx, http.StatusBadRequest, "invalid signature: %s", err.Error())
+		return
+	}
+
+	// the nonce is consumed by the first attempt
+	account := utils.EncodeSS58(utils.SS58GenericPrefix, publicKey)
+	message, err := s.redisClient.GetDel(ctx, substrateNonceKey(account)).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			base.ResponseErr(ctx, http.StatusUnauthorized, "nonce not found or expired")
+			return
+		}
+
+		base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := utils.VerifySubstrateSignature(publicKey, []byte(message), signature); err != nil {
+		base.ResponseErr(ctx, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	var user datamodel.UserModel
+	err = s.db.Where("provider = ? AND uid = ?", SubstrateProvider, account).First(&user).Error
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		now := time.Now()
+		user = datamodel.UserModel{
+			UserBasic: datamodel.UserBasic{
+				Provider: SubstrateProvider,
+				UID:      account,
+				Username: account,
+			},
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		if err := s.db.Create(&user).Error; err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	s.login(ctx, &user)
+}
@@ -0,0 +1,559 @@
+package user
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"infra-3.xyz/hyperdot-node/internal/apis/base"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+	"infra-3.xyz/hyperdot-node/internal/utils"
+)
+
+const (
+	twoFactorLoginSubject  = "two-factor-login"
+	twoFactorLoginTokenTTL = 5 * time.Minute
+	totpIssuer             = "hyperdot"
+	recoveryCodeCount      = 10
+)
+
+// errInvalidTwoFactorCode is returned when the code is neither a valid TOTP code
+// nor an unused recovery code.
+var errInvalidTwoFactorCode = errors.New("invalid two-factor code")
+
+// loadTwoFactor returns the second factor of user, it returns nil if the user
+// has not enrolled.
+func (s *Service) loadTwoFactor(userId uint) (*datamodel.UserTwoFactorModel, error) {
+	var twoFactor datamodel.UserTwoFactorModel
+	if err := s.db.Where("user_id = ?", userId).First(&twoFactor).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &twoFactor, nil
+}
+
+// useTOTPCode accepts the TOTP code of second factor only once.
+func (s *Service) useTOTPCode(twoFactor *datamodel.UserTwoFactorModel, code string) error {
+	step, ok := utils.ValidateTOTP(twoFactor.Secret, code, time.Now())
+	if !ok {
+		return errInvalidTwoFactorCode
+	}
+
+	// the conditional update keeps the code from being replayed concurrently
+	result := s.db.Model(&datamodel.UserTwoFactorModel{}).
+		Where("id = ? AND last_used_step < ?", twoFactor.ID, step).
+		Update("last_used_step", step)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errInvalidTwoFactorCode
+	}
+
+	twoFactor.LastUsedStep = step
+	return nil
+}
+
+// normalizeRecoveryCode removes the separators and spaces of recovery code.
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
+
+// useRecoveryCode marks the unused recovery code of user used.
+func (s *Service) useRecoveryCode(userId uint, code string) error {
+	result := s.db.Model(&datamodel.UserRecoveryCodeModel{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userId, utils.HashToken(normalizeRecoveryCode(code))).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errInvalidTwoFactorCode
+	}
+
+	return nil
+}
+
+// verifySecondFactor accepts either a TOTP code or a recovery code.
+func (s *Service) verifySecondFactor(twoFactor *datamodel.UserTwoFactorModel, code string) error {
+	if len(strings.TrimSpace(code)) == utils.TOTPDigits {
+		return s.useTOTPCode(twoFactor, code)
+	}
+
+	return s.useRecoveryCode(twoFactor.UserID, code)
+}
+
+// resetRecoveryCodes replaces the recovery codes of user, it returns the new codes.
+func resetRecoveryCodes(tx *gorm.DB, userId uint) ([]string, error) {
+	if err := tx.Where("user_id = ?", userId).Delete(&datamodel.UserRecoveryCodeModel{}).Error; err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	models := make([]datamodel.UserRecoveryCodeModel, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+
+		code := hex.EncodeToString(b)
+		codes = append(codes, code[:5]+"-"+code[5:])
+		models = append(models, datamodel.UserRecoveryCodeModel{
+			UserID:   userId,
+			CodeHash: utils.HashToken(code),
+		})
+	}
+
+	if err := tx.Create(&models).Error; err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// pendingTwoFactor returns a pending token instead of the session tokens, the
+// login is completed after the second factor is verified.
+func pendingTwoFactor(user *datamodel.UserModel) (*ResponseLogin, error) {
+	expireAt := time.Now().Add(twoFactorLoginTokenTTL)
+	token, err := base.GenerateActionToken(&datamodel.ActionClaims{
+		UserID:      user.ID,
+		Fingerprint: passwordFingerprint(user),
+	}, twoFactorLoginSubject, expireAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ResponseLogin{
+		ExpiresAt:         expireAt,
+		TwoFactorRequired: true,
+		TwoFactorToken:    token,
+	}, nil
+}
+
+// getCurrentUser loads the current user, it responses the error if failed.
+func (s *Service) getCurrentUser(ctx *gin.Context) (*datamodel.UserModel, bool) {
+	userId, err := base.GetCurrentUserId(ctx)
+	if err != nil {
+		base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+
+	var user datamodel.UserModel
+	if err := s.db.Where("id = ?", userId).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			base.ResponseErr(ctx, http.StatusNotFound, "user not found")
+			return nil, false
+		}
+
+		base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+		return nil, false
+	}
+
+	return &user, true
+}
+
+// GetTwoFactorHandler Get the two-factor authentication status of current user.
+// @Summary Get the two-factor authentication status of current user.
+// @Description Get the two-factor authentication status and the number of unused recovery codes of current user.
+// @Tags user apis
+// @Accept application/json
+// @Produce application/json
+// @Security ApiKeyAuth
+// @Param Authorization header string true "token"
+// @Success 200 {object} ResponseTwoFactorStatus
+// @Router /user/2fa [get]
+func (s *Service) GetTwoFactorHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userId, err := base.GetCurrentUserId(ctx)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		twoFactor, err := s.loadTwoFactor(userId)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		var data ResponseTwoFactorStatusData
+		if twoFactor != nil && twoFactor.IsEnabled() {
+			data.Enabled = true
+			data.EnabledAt = twoFactor.EnabledAt
+			if err := s.db.Model(&datamodel.UserRecoveryCodeModel{}).
+				Where("user_id = ? AND used_at IS NULL", userId).
+				Count(&data.RecoveryCodes).Error; err != nil {
+				base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+				return
+			}
+		}
+
+		ctx.JSON(http.StatusOK, ResponseTwoFactorStatus{
+			BaseResponse: base.ResponseOk(),
+			Data:         data,
+		})
+	}
+}
+
+// EnrollTwoFactorHandler Enroll the TOTP two-factor authentication.
+// @Summary Enroll the TOTP two-factor authentication.
+// @Description Generate a new TOTP secret of current user, add it to the authenticator app by the QR code of provisioning uri.
+// @Description The two-factor authentication is enabled after a code is verified by POST /user/2fa/verify.
+// @Description It is only supported for the accounts signed up by password.
+// @Tags user apis
+// @Accept application/json
+// @Produce application/json
+// @Security ApiKeyAuth
+// @Param Authorization header string true "token"
+// @Success 200 {object} ResponseTwoFactorEnroll
+// @Router /user/2fa/enroll [post]
+func (s *Service) EnrollTwoFactorHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, ok := s.getCurrentUser(ctx)
+		if !ok {
+			return
+		}
+
+		if user.Provider != PasswordProvider {
+			base.ResponseErr(ctx, http.StatusBadRequest, "two-factor authentication is only supported for password accounts")
+			return
+		}
+
+		twoFactor, err := s.loadTwoFactor(user.ID)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if twoFactor != nil && twoFactor.IsEnabled() {
+			base.ResponseErr(ctx, http.StatusBadRequest, "two-factor authentication is already enabled")
+			return
+		}
+
+		secret, err := utils.GenerateTOTPSecret()
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		// the pending secret is replaced on every enrollment
+		if twoFactor == nil {
+			err = s.db.Create(&datamodel.UserTwoFactorModel{UserID: user.ID, Secret: secret}).Error
+		} else {
+			err = s.db.Model(twoFactor).Updates(map[string]any{
+				"secret":         secret,
+				"last_used_step": 0,
+			}).Error
+		}
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, ResponseTwoFactorEnroll{
+			BaseResponse: base.ResponseOk(),
+			Data: ResponseTwoFactorEnrollData{
+				Secret: secret,
+				URI:    utils.TOTPProvisioningURI(totpIssuer, user.Username, secret),
+			},
+		})
+	}
+}
+
+// VerifyTwoFactorHandler Verify the enrolled TOTP secret and enable two-factor authentication.
+// @Summary Verify the enrolled TOTP secret and enable two-factor authentication.
+// @Description Verify a code of the secret enrolled by POST /user/2fa/enroll and enable two-factor authentication.
+// @Description The recovery codes are responded only once, each of them can login once if the authenticator is lost.
+// @Tags user apis
+// @Accept application/json
+// @Produce application/json
+// @Security ApiKeyAuth
+// @Param Authorization header string true "token"
+// @Param body body RequestTwoFactorCode true "verify two-factor request"
+// @Success 200 {object} ResponseRecoveryCodes
+// @Router /user/2fa/verify [post]
+func (s *Service) VerifyTwoFactorHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userId, err := base.GetCurrentUserId(ctx)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		var request RequestTwoFactorCode
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		twoFactor, err := s.loadTwoFactor(userId)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if twoFactor == nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, "enroll two-factor authentication first")
+			return
+		}
+		if twoFactor.IsEnabled() {
+			base.ResponseErr(ctx, http.StatusBadRequest, "two-factor authentication is already enabled")
+			return
+		}
+
+		if err := s.useTOTPCode(twoFactor, request.Code); err != nil {
+			if errors.Is(err, errInvalidTwoFactorCode) {
+				base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+				return
+			}
+
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		var codes []string
+		err = s.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(twoFactor).Update("enabled_at", time.Now()).Error; err != nil {
+				return err
+			}
+
+			var err error
+			codes, err = resetRecoveryCodes(tx, userId)
+			return err
+		})
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		base.Audit(ctx, s.db, &base.AuditRecord{
+			Action:     datamodel.AuditUserTwoFactorEnable,
+			TargetType: datamodel.AuditTargetUser,
+			TargetID:   userId,
+		})
+		ctx.JSON(http.StatusOK, ResponseRecoveryCodes{
+			BaseResponse: base.ResponseOk(),
+			Data:         codes,
+		})
+	}
+}
+
+// RegenerateRecoveryCodesHandler Regenerate the recovery codes.
+// @Summary Regenerate the recovery codes.
+// @Description Replace the recovery codes of current user by new ones, it requires a TOTP code of the authenticator.
+// @Tags user apis
+// @Accept application/json
+// @Produce application/json
+// @Security ApiKeyAuth
+// @Param Authorization header string true "token"
+// @Param body body RequestTwoFactorCode true "regenerate recovery codes request"
+// @Success 200 {object} ResponseRecoveryCodes
+// @Router /user/2fa/recoveryCodes [post]
+func (s *Service) RegenerateRecoveryCodesHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userId, err := base.GetCurrentUserId(ctx)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		var request RequestTwoFactorCode
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		twoFactor, err := s.loadTwoFactor(userId)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if twoFactor == nil || !twoFactor.IsEnabled() {
+			base.ResponseErr(ctx, http.StatusBadRequest, "two-factor authentication is not enabled")
+			return
+		}
+
+		if err := s.useTOTPCode(twoFactor, request.Code); err != nil {
+			if errors.Is(err, errInvalidTwoFactorCode) {
+				base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+				return
+			}
+
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		var codes []string
+		err = s.db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			codes, err = resetRecoveryCodes(tx, userId)
+			return err
+		})
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		base.Audit(ctx, s.db, &base.AuditRecord{
+			Action:     datamodel.AuditUserRecoveryCodes,
+			TargetType: datamodel.AuditTargetUser,
+			TargetID:   userId,
+		})
+		ctx.JSON(http.StatusOK, ResponseRecoveryCodes{
+			BaseResponse: base.ResponseOk(),
+			Data:         codes,
+		})
+	}
+}
+
+// DisableTwoFactorHandler Disable the two-factor authentication.
+// @Summary Disable the two-factor authentication.
+// @Description Disable the two-factor authentication of current user, it requires the password and a TOTP code or recovery code.
+// @Tags user apis
+// @Accept application/json
+// @Produce application/json
+// @Security ApiKeyAuth
+// @Param Authorization header string true "token"
+// @Param body body RequestDisableTwoFactor true "disable two-factor request"
+// @Success 200 {object} base.BaseResponse
+// @Router /user/2fa/disable [post]
+func (s *Service) DisableTwoFactorHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, ok := s.getCurrentUser(ctx)
+		if !ok {
+			return
+		}
+
+		var request RequestDisableTwoFactor
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		twoFactor, err := s.loadTwoFactor(user.ID)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if twoFactor == nil || !twoFactor.IsEnabled() {
+			base.ResponseErr(ctx, http.StatusBadRequest, "two-factor authentication is not enabled")
+			return
+		}
+
+		// the stolen access token can not disable the second factor
+		if !utils.VerifyPassword(user.EncryptedPassword, request.Password) {
+			base.ResponseErr(ctx, http.StatusBadRequest, "password not match")
+			return
+		}
+
+		if err := s.verifySecondFactor(twoFactor, request.Code); err != nil {
+			if errors.Is(err, errInvalidTwoFactorCode) {
+				base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+				return
+			}
+
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		err = s.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Delete(twoFactor).Error; err != nil {
+				return err
+			}
+
+			return tx.Where("user_id = ?", user.ID).Delete(&datamodel.UserRecoveryCodeModel{}).Error
+		})
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		base.Audit(ctx, s.db, &base.AuditRecord{
+			Action:     datamodel.AuditUserTwoFactorDisable,
+			TargetType: datamodel.AuditTargetUser,
+			TargetID:   user.ID,
+		})
+		base.ResponseSuccess(ctx)
+	}
+}
+
+// TwoFactorLoginHandler Complete the login by the second factor.
+// @Summary Complete the login by the second factor.
+// @Description Complete the login of user who enabled two-factor authentication, by the pending token responded by POST /user/auth/login
+// @Description and a TOTP code or recovery code. Each recovery code can be used only once.
+// @Description The failures lock the account out like the failures of password.
+// @Tags user apis
+// @Accept application/json
+// @Produce application/json
+// @Param body body RequestTwoFactorLogin true "two-factor login request"
+// @Success 200 {object} ResponseLogin
+// @Router /user/auth/2fa/login [post]
+func (s *Service) TwoFactorLoginHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var request RequestTwoFactorLogin
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		claims, err := base.VerifyActionToken(request.Token, twoFactorLoginSubject)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusUnauthorized, "invalid token: %s", err.Error())
+			return
+		}
+
+		var user datamodel.UserModel
+		if err := s.db.Where("id = ?", claims.UserID).First(&user).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				base.ResponseErr(ctx, http.StatusUnauthorized, "user not found")
+				return
+			}
+
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		// the pending token is invalid after the password changes
+		if claims.Fingerprint != passwordFingerprint(&user) {
+			base.ResponseErr(ctx, http.StatusUnauthorized, "invalid token")
+			return
+		}
+
+		if s.isLockedOut(ctx, &user) {
+			return
+		}
+
+		twoFactor, err := s.loadTwoFactor(user.ID)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if twoFactor == nil || !twoFactor.IsEnabled() {
+			base.ResponseErr(ctx, http.StatusUnauthorized, "two-factor authentication is not enabled")
+			return
+		}
+
+		if err := s.verifySecondFactor(twoFactor, request.Code); err != nil {
+			if errors.Is(err, errInvalidTwoFactorCode) {
+				s.failLogin(ctx, &user, err.Error())
+				base.ResponseErr(ctx, http.StatusUnauthorized, err.Error())
+				return
+			}
+
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		s.resetLockout(ctx, &user)
+		data, err := s.newSession(ctx, &user)
+		s.responseLogin(ctx, data, err)
+	}
+}
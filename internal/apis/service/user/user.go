@@ -4,26 +4,32 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
-	"net/url"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
 	_ "gorm.io/driver/postgres"
 	"gorm.io/gorm"
 
 	"infra-3.xyz/hyperdot-node/internal/apis/base"
+	"infra-3.xyz/hyperdot-node/internal/cache"
 	"infra-3.xyz/hyperdot-node/internal/clients"
+	"infra-3.xyz/hyperdot-node/internal/common"
 	"infra-3.xyz/hyperdot-node/internal/dataengine"
 	"infra-3.xyz/hyperdot-node/internal/datamodel"
+	"infra-3.xyz/hyperdot-node/internal/mailer"
 	"infra-3.xyz/hyperdot-node/internal/utils"
 )
 
 const (
-	ServiceName      = "user"
-	PasswordProvider = "password"
+	ServiceName       = "user"
+	PasswordProvider  = "password"
+	GithubProvider    = "github"
+	SubstrateProvider = "substrate"
 )
 
 // Service user service
@@ -32,24 +38,53 @@ type Service struct {
 	s3Cliet       *clients.SimpleS3Cliet
 	authProviders map[string]bool
 	engines       map[string]dataengine.QueryEngine
+	revocations   *cache.SessionRevocationList
+	lockout       *cache.LoginLockout
+	redisClient   *redis.Client
+	mailer        mailer.Mailer
+	siteUrl       string
+
+	// github is nil if the github provider is disabled
+	github           *clients.GithubOAuthClient
+	loginRedirectUrl string
 }
 
 // New user service
-func New(db *gorm.DB, engines map[string]dataengine.QueryEngine, s3Client *clients.SimpleS3Cliet) *Service {
+func New(
+	cfg *common.Config,
+	db *gorm.DB,
+	engines map[string]dataengine.QueryEngine,
+	s3Client *clients.SimpleS3Cliet,
+	mail mailer.Mailer,
+) *Service {
 	svc := &Service{
-		db:      db,
-		engines: engines,
-		s3Cliet: s3Client,
+		db:          db,
+		engines:     engines,
+		s3Cliet:     s3Client,
+		mailer:      mail,
+		siteUrl:     strings.TrimSuffix(cfg.Mail.SiteUrl, "/"),
+		revocations: cache.NewSessionRevocationList(&cfg.Redis),
+		lockout:     cache.NewLoginLockout(&cfg.Redis),
+		redisClient: redis.NewClient(&redis.Options{
+			Addr: cfg.Redis.Addr,
+		}),
 		authProviders: map[string]bool{
-			PasswordProvider: true,
+			PasswordProvider:  true,
+			GithubProvider:    cfg.Github.Enable,
+			SubstrateProvider: true,
 		},
 	}
+
+	if cfg.Github.Enable {
+		svc.github = clients.NewGithubOAuthClient(&cfg.Github)
+		svc.loginRedirectUrl = cfg.Github.LoginRedirectUrl
+	}
 	return svc
 }
 
 func (s *Service) getUserInternalHandler(id uint, ctx *gin.Context) {
 	sql := `SELECT u.id, u.uid, u.username, u.email, u.encrypted_password, u.bio, u.icon_url, u.twitter, u.github, u.telgram, u.discord, u.location, 
-						u.confirmed_at, u.created_at, u.updated_at, us.stars, us.queries, us.dashboards FROM hyperdot_user as u LEFT JOIN 
+						u.role, u.confirmed_at, u.created_at, u.updated_at, us.stars, us.queries, us.dashboards, us.followers, us.following FROM hyperdot_user as u LEFT JOIN 
 						hyperdot_user_statistics as us ON u.id = us.user_id WHERE u.id = ?`
 
 	rows, err := s.db.Raw(sql, id).Rows()
@@ -70,6 +105,24 @@ func (s *Service) getUserInternalHandler(id uint, ctx *gin.Context) {
 		return
 	}
 
+	// hide the private fields from other users and guests
+	currentUserId := base.GetCurrentUserIdOrGuest(ctx)
+	if currentUserId != id {
+		data.Email = ""
+		data.EncryptedPassword = ""
+	}
+
+	if currentUserId != base.GuestUserId && currentUserId != id {
+		var count int64
+		if err := s.db.Model(&datamodel.UserFollowModel{}).
+			Where("follower_id = ? AND followee_id = ?", currentUserId, id).
+			Count(&count).Error; err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+		data.Followed = count > 0
+	}
+
 	ctx.JSON(http.StatusOK, ResponseGetUser{
 		Data: data,
 		BaseResponse: base.BaseResponse{
@@ -208,7 +261,7 @@ func (s *Service) UpdateUserHandler() gin.HandlerFunc {
 
 // UpdateEmailHandler Update logined user email.
 // @Summary Update user email.
-// @Description Update user email.
+// @Description Send a verification mail to the new email, the email is changed after it is verified by POST /user/auth/verifyEmail.
 // @Tags user apis
 // @Accept application/json
 // @Produce application/json
@@ -248,8 +301,23 @@ func (s *Service) UpdateEmailHandler() gin.HandlerFunc {
 			return
 		}
 
-		user.Email = request.NewEmail
-		if err := s.db.Save(&user).Error; err != nil {
+		if request.NewEmail == user.Email {
+			base.ResponseErr(ctx, http.StatusBadRequest, "email not change")
+			return
+		}
+
+		var count int64
+		if err := s.db.Model(&datamodel.UserModel{}).Where("email = ?", request.NewEmail).Count(&count).Error; err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if count > 0 {
+			base.ResponseErr(ctx, http.StatusBadRequest, "the email %s already exists", request.NewEmail)
+			return
+		}
+
+		// the email is changed after the new email is verified
+		if err := s.sendVerifyEmail(ctx, &user, request.NewEmail); err != nil {
 			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
 			return
 		}
@@ -265,7 +333,7 @@ func (s *Service) UpdateEmailHandler() gin.HandlerFunc {
 
 // UpdatePasswordHandler Update logined user password.
 // @Summary Update user password.
-// @Description Update user password.
+// @Description Update user password, the other sessions of user are logged out.
 // @Tags user apis
 // @Accept application/json
 // @Produce application/json
@@ -333,6 +401,18 @@ func (s *Service) UpdatePasswordHandler() gin.HandlerFunc {
 			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
 			return
 		}
+
+		// the other sessions are logged out, the current one is kept
+		if err := s.revokeUserSessions(ctx, user.ID, base.GetCurrentSessionId(ctx)); err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		base.Audit(ctx, s.db, &base.AuditRecord{
+			Action:     datamodel.AuditUserPasswordChange,
+			TargetType: datamodel.AuditTargetUser,
+			TargetID:   user.ID,
+		})
 		ctx.JSON(http.StatusOK, ResponseUpdateUser{
 			Data: user,
 			BaseResponse: base.BaseResponse{
@@ -438,6 +518,12 @@ func (s *Service) GetAvatarHandler() gin.HandlerFunc {
 			return
 		}
 
+		// the avatar of oauth provider is not stored in s3
+		if strings.HasPrefix(user.IconUrl, "http://") || strings.HasPrefix(user.IconUrl, "https://") {
+			ctx.Redirect(http.StatusFound, user.IconUrl)
+			return
+		}
+
 		obj, err := s.s3Cliet.Get(ctx, "hyperdot", user.IconUrl)
 		if err != nil {
 			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
@@ -550,12 +636,22 @@ func (s *Service) CreateAccountHandler() gin.HandlerFunc {
 				return
 			}
 
+			// the account can be used before verified, the mail can be sent again
+			if err := s.sendVerifyEmail(ctx, &user, user.Email); err != nil {
+				log.Printf("Error send verification mail to user %d: %v", user.ID, err)
+			}
+
 			base.ResponseWithData(ctx, ResponseCreateAccount{})
 			return
 
-		case "github":
-			// TODO: support login by github
-			base.ResponseErr(ctx, http.StatusBadRequest, "unsupported provider %s", request.Provider)
+		case SubstrateProvider:
+			// the substrate account is created on the first login
+			base.ResponseErr(ctx, http.StatusBadRequest, "login by the signature of account to sign up with substrate")
+			return
+
+		case GithubProvider:
+			// the github account is created on the first login
+			base.ResponseErr(ctx, http.StatusBadRequest, "login by GET /user/auth/github to sign up with github")
 			return
 
 		default:
@@ -568,6 +664,9 @@ func (s *Service) CreateAccountHandler() gin.HandlerFunc {
 // LoginHandle Login by username and password.
 // @Summary Login by username and password.
 // @Description Login by username and password.
+// @Description If the user enabled two-factor authentication, the session tokens are not responded but a pending token
+// @Description with two_factor_required, complete the login by POST /user/auth/2fa/login with it.
+// @Description The account is locked out progressively after repeated failures, 429 is responded with Retry-After header during the lockout.
 // @Tags user apis
 // @Accept application/json
 // @Produce application/json
@@ -613,28 +712,21 @@ func (s *Service) LoginHandle() gin.HandlerFunc {
 				return
 			}
 
-			if !utils.VerifyPassword(existingUser.EncryptedPassword, request.Password) {
-				base.ResponseErr(ctx, http.StatusOK, "password not match")
+			if s.isLockedOut(ctx, &existingUser) {
 				return
 			}
 
-			expireAt := base.TokenDefaultExpireTime()
-			signing, err := base.GenerateJwtToken(existingUser.ToClaims(), expireAt)
-			if err != nil {
-				base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			if !utils.VerifyPassword(existingUser.EncryptedPassword, request.Password) {
+				s.failLogin(ctx, &existingUser, "password not match")
+				base.ResponseErr(ctx, http.StatusOK, "password not match")
 				return
 			}
 
-			http.SetCookie(ctx.Writer, &http.Cookie{
-				Name:    "token",
-				Value:   url.QueryEscape(signing),
-				Expires: expireAt,
-			})
+			s.resetLockout(ctx, &existingUser)
+			s.login(ctx, &existingUser)
 
-			base.ResponseWithData(ctx, ResponseLogin{
-				Algorithm: "HS256",
-				Token:     signing,
-			})
+		case SubstrateProvider:
+			s.loginBySubstrate(ctx, &request)
 
 		default:
 			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported provider"})
@@ -643,6 +735,20 @@ func (s *Service) LoginHandle() gin.HandlerFunc {
 	}
 }
 
+// JwksHandler Get the json web key set to verify tokens.
+// @Summary Get the json web key set to verify tokens.
+// @Description Get the public keys to verify tokens signed by RS256 or EdDSA, the HS256 secrets are never exposed.
+// @Tags user apis
+// @Accept application/json
+// @Produce application/json
+// @Success 200 {object} base.Jwks
+// @Router /user/auth/jwks [get]
+func (s *Service) JwksHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, base.GetJwks())
+	}
+}
+
 // Name service name
 func (s *Service) Name() string {
 	return ServiceName
@@ -659,21 +765,61 @@ func (s *Service) RouteTables() []base.RouteTable {
 		},
 
 		{
-			Method:  "GET",
-			Path:    group + "/:id",
-			Handler: s.GetUserHandler(),
+			Method:     "GET",
+			Path:       group + "/:id",
+			Handler:    s.GetUserHandler(),
+			AllowGuest: true,
 		},
 		{
 			Method:  "PUT",
 			Path:    group,
 			Handler: s.UpdateUserHandler(),
 		},
+		{
+			Method:  "GET",
+			Path:    group + "/activity",
+			Handler: s.ListActivityHandler(),
+		},
+
+		{
+			Method:  "GET",
+			Path:    group + "/2fa",
+			Handler: s.GetTwoFactorHandler(),
+		},
+		{
+			Method:  "POST",
+			Path:    group + "/2fa/enroll",
+			Handler: s.EnrollTwoFactorHandler(),
+		},
+		{
+			Method:        "POST",
+			Path:          group + "/2fa/verify",
+			Handler:       s.VerifyTwoFactorHandler(),
+			UserRateLimit: &base.RateLimit{Limit: 5, Period: time.Minute},
+		},
+		{
+			Method:        "POST",
+			Path:          group + "/2fa/recoveryCodes",
+			Handler:       s.RegenerateRecoveryCodesHandler(),
+			UserRateLimit: &base.RateLimit{Limit: 5, Period: time.Minute},
+		},
+		{
+			Method:        "POST",
+			Path:          group + "/2fa/disable",
+			Handler:       s.DisableTwoFactorHandler(),
+			UserRateLimit: &base.RateLimit{Limit: 5, Period: time.Minute},
+		},
 
 		{
 			Method:  "PUT",
 			Path:    group + "/email",
 			Handler: s.UpdateEmailHandler(),
 		},
+		{
+			Method:  "POST",
+			Path:    group + "/email/verify",
+			Handler: s.SendVerifyEmailHandler(),
+		},
 		{
 			Method:  "PUT",
 			Path:    group + "/password",
@@ -689,20 +835,116 @@ func (s *Service) RouteTables() []base.RouteTable {
 			Path:    group + "/avatar",
 			Handler: s.GetAvatarHandler(),
 		},
+		{
+			Method:  "POST",
+			Path:    group + "/deletion",
+			Handler: s.RequestAccountDeletionHandler(),
+		},
+		{
+			Method:  "DELETE",
+			Path:    group + "/deletion",
+			Handler: s.CancelAccountDeletionHandler(),
+		},
+		{
+			Method:        "GET",
+			Path:          group + "/export",
+			UserRateLimit: &base.RateLimit{Limit: 5, Period: time.Hour},
+			Handler:       s.ExportDataHandler(),
+		},
 
+		{
+			Method:      "POST",
+			Path:        group + "/auth/createAccount",
+			IPRateLimit: &base.RateLimit{Limit: 5, Period: time.Hour},
+			Handler:     s.CreateAccountHandler(),
+			AllowGuest:  true,
+			Regexp:      "",
+		},
+		{
+			Method:      "POST",
+			Path:        group + "/auth/login",
+			IPRateLimit: &base.RateLimit{Limit: 20, Period: time.Minute},
+			Handler:     s.LoginHandle(),
+			AllowGuest:  true,
+			Regexp:      "",
+		},
+		{
+			Method:      "POST",
+			Path:        group + "/auth/2fa/login",
+			IPRateLimit: &base.RateLimit{Limit: 10, Period: time.Minute},
+			Handler:     s.TwoFactorLoginHandler(),
+			AllowGuest:  true,
+		},
 		{
 			Method:     "POST",
-			Path:       group + "/auth/createAccount",
-			Handler:    s.CreateAccountHandler(),
+			Path:       group + "/auth/verifyEmail",
+			Handler:    s.VerifyEmailHandler(),
+			AllowGuest: true,
+		},
+		{
+			Method:      "POST",
+			Path:        group + "/auth/forgotPassword",
+			IPRateLimit: &base.RateLimit{Limit: 5, Period: time.Hour},
+			Handler:     s.ForgotPasswordHandler(),
+			AllowGuest:  true,
+		},
+		{
+			Method:      "POST",
+			Path:        group + "/auth/resetPassword",
+			IPRateLimit: &base.RateLimit{Limit: 10, Period: time.Hour},
+			Handler:     s.ResetPasswordHandler(),
+			AllowGuest:  true,
+		},
+		{
+			Method:      "POST",
+			Path:        group + "/auth/substrate/nonce",
+			IPRateLimit: &base.RateLimit{Limit: 30, Period: time.Minute},
+			Handler:     s.SubstrateNonceHandler(),
+			AllowGuest:  true,
+		},
+		{
+			Method:     "GET",
+			Path:       group + "/auth/github",
+			Handler:    s.GithubLoginHandler(),
+			AllowGuest: true,
+		},
+		{
+			Method:  "POST",
+			Path:    group + "/auth/github/link",
+			Handler: s.GithubLinkHandler(),
+		},
+		{
+			Method:     "GET",
+			Path:       group + "/auth/github/callback",
+			Handler:    s.GithubCallbackHandler(),
 			AllowGuest: true,
-			Regexp:     "",
 		},
 		{
 			Method:     "POST",
-			Path:       group + "/auth/login",
-			Handler:    s.LoginHandle(),
+			Path:       group + "/auth/refresh",
+			Handler:    s.RefreshTokenHandler(),
+			AllowGuest: true,
+		},
+		{
+			Method:  "POST",
+			Path:    group + "/auth/logout",
+			Handler: s.LogoutHandler(),
+		},
+		{
+			Method:  "POST",
+			Path:    group + "/auth/logoutAll",
+			Handler: s.LogoutAllHandler(),
+		},
+		{
+			Method:  "GET",
+			Path:    group + "/sessions",
+			Handler: s.ListSessionsHandler(),
+		},
+		{
+			Method:     "GET",
+			Path:       group + "/auth/jwks",
+			Handler:    s.JwksHandler(),
 			AllowGuest: true,
-			Regexp:     "",
 		},
 	}
 }
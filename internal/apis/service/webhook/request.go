@@ -0,0 +1,22 @@
+package webhook
+
+// RequestCreateWebhook is request of POST /webhook
+type RequestCreateWebhook struct {
+	Name string `json:"name"`
+	// URL is the http or https url which the events are posted to.
+	URL string `json:"url"`
+	// Events are the subscribed events, e.g. query.finished and dashboard.published.
+	Events []string `json:"events"`
+	// Enabled is true by default.
+	Enabled *bool `json:"enabled"`
+}
+
+// RequestUpdateWebhook is request of PUT /webhook/:id, the empty fields are unchanged.
+type RequestUpdateWebhook struct {
+	Name    string   `json:"name"`
+	URL     string   `json:"url"`
+	Events  []string `json:"events"`
+	Enabled *bool    `json:"enabled"`
+	// RotateSecret generates a new secret, it is responded once.
+	RotateSecret bool `json:"rotate_secret"`
+}
@@ -0,0 +1,37 @@
+package webhook
+
+import (
+	"infra-3.xyz/hyperdot-node/internal/apis/base"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+)
+
+// ResponseWebhookData is a webhook with its secret
+type ResponseWebhookData struct {
+	datamodel.WebhookModel
+	// Secret signs the payloads, it is only responded when it is created or rotated.
+	Secret string `json:"secret,omitempty"`
+}
+
+// ResponseWebhook is response of creating or updating webhook
+type ResponseWebhook struct {
+	base.BaseResponse
+	Data ResponseWebhookData `json:"data"`
+}
+
+// ResponseListWebhooks is response of GET /webhook
+type ResponseListWebhooks struct {
+	base.BaseResponse
+	Data []datamodel.WebhookModel `json:"data"`
+}
+
+// ResponseListDeliveriesData is data of response of listing deliveries
+type ResponseListDeliveriesData struct {
+	Deliveries []datamodel.WebhookDeliveryModel `json:"deliveries"`
+	Total      int64                            `json:"total"`
+}
+
+// ResponseListDeliveries is response of GET /webhook/:id/deliveries
+type ResponseListDeliveries struct {
+	base.BaseResponse
+	Data ResponseListDeliveriesData `json:"data"`
+}
@@ -0,0 +1,447 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"infra-3.xyz/hyperdot-node/internal/apis/base"
+	"infra-3.xyz/hyperdot-node/internal/common"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+	"infra-3.xyz/hyperdot-node/internal/utils"
+)
+
+const (
+	ServiceName = "webhook"
+
+	// maxWebhooks is the most webhooks of a user.
+	maxWebhooks = 20
+	// secretBytes is the random bytes of webhook secret.
+	secretBytes = 32
+)
+
+// Service webhook service, the events of users' contents are posted to the webhooks
+// configured by them.
+type Service struct {
+	db *gorm.DB
+	// allowLoopback allows the webhook urls of loopback ip, it is only for tests.
+	allowLoopback bool
+}
+
+// New webhook service
+func New(cfg *common.Config, db *gorm.DB) *Service {
+	return &Service{
+		db:            db,
+		allowLoopback: cfg.Webhook.AllowLoopback,
+	}
+}
+
+// checkURL checks the webhook url is an absolute http or https url, and the ip of url
+// is public. The resolved ip of host name is checked when the webhook is posted.
+func (s *Service) checkURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+
+	if (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+		return errors.New("url must be an absolute http or https url")
+	}
+
+	if ip := net.ParseIP(u.Hostname()); ip != nil {
+		if err := utils.CheckWebhookIP(ip, s.allowLoopback); err != nil {
+			return fmt.Errorf("invalid url: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// checkEvents checks the events can be subscribed and removes the duplicated.
+func checkEvents(events []string) (datamodel.Scopes, error) {
+	if len(events) == 0 {
+		return nil, errors.New("events are required")
+	}
+
+	var checked datamodel.Scopes
+	for _, event := range events {
+		if !datamodel.IsValidWebhookEvent(event) {
+			return nil, fmt.Errorf("unsupported event %s", event)
+		}
+		if !checked.Has(event) {
+			checked = append(checked, event)
+		}
+	}
+
+	return checked, nil
+}
+
+// getWebhook gets the webhook of id param owned by current user.
+func (s *Service) getWebhook(ctx *gin.Context) (*datamodel.WebhookModel, bool) {
+	userId, err := base.GetCurrentUserId(ctx)
+	if err != nil {
+		base.ResponseErr(ctx, http.StatusUnauthorized, err.Error())
+		return nil, false
+	}
+
+	id, err := base.GetUintParam(ctx, "id")
+	if err != nil {
+		base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+
+	var webhook datamodel.WebhookModel
+	if err := s.db.Where("id = ? AND user_id = ?", id, userId).First(&webhook).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			base.ResponseErr(ctx, http.StatusNotFound, "webhook not found")
+			return nil, false
+		}
+
+		base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+		return nil, false
+	}
+
+	return &webhook, true
+}
+
+// @Summary create webhook
+// @Description Create a webhook, the subscribed events of current user's contents are posted to its url.
+// @Description The payloads are signed by HMAC-SHA256 of the secret, the X-Hyperdot-Signature header is
+// @Description "sha256=" + hex(hmac(secret, X-Hyperdot-Timestamp + "." + body)). The secret is only responded once.
+// @Tags webhook apis
+// @Accept application/json
+// @Produce application/json
+// @Param body body RequestCreateWebhook true "webhook"
+// @Success 200 {object} ResponseWebhook
+// @Router /webhook [post]
+func (s *Service) CreateWebhookHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userId, err := base.GetCurrentUserId(ctx)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		var request RequestCreateWebhook
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if len(request.Name) == 0 {
+			base.ResponseErr(ctx, http.StatusBadRequest, "name is required")
+			return
+		}
+
+		if err := s.checkURL(request.URL); err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		events, err := checkEvents(request.Events)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		var count int64
+		if err := s.db.Model(&datamodel.WebhookModel{}).Where("user_id = ?", userId).Count(&count).Error; err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if count >= maxWebhooks {
+			base.ResponseErr(ctx, http.StatusBadRequest, "at most %d webhooks are allowed", maxWebhooks)
+			return
+		}
+
+		secret, err := utils.RandomToken(secretBytes)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		webhook := datamodel.WebhookModel{
+			UserID:  userId,
+			Name:    request.Name,
+			URL:     request.URL,
+			Secret:  secret,
+			Events:  events,
+			Enabled: request.Enabled == nil || *request.Enabled,
+		}
+		if err := s.db.Create(&webhook).Error; err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, ResponseWebhook{
+			BaseResponse: base.ResponseOk(),
+			Data: ResponseWebhookData{
+				WebhookModel: webhook,
+				Secret:       secret,
+			},
+		})
+	}
+}
+
+// @Summary list webhooks
+// @Description List the webhooks of current user.
+// @Tags webhook apis
+// @Accept application/json
+// @Produce application/json
+// @Success 200 {object} ResponseListWebhooks
+// @Router /webhook [get]
+func (s *Service) ListWebhooksHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userId, err := base.GetCurrentUserId(ctx)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		webhooks := make([]datamodel.WebhookModel, 0)
+		if err := s.db.Where("user_id = ?", userId).Order("id DESC").Find(&webhooks).Error; err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, ResponseListWebhooks{
+			BaseResponse: base.ResponseOk(),
+			Data:         webhooks,
+		})
+	}
+}
+
+// @Summary update webhook
+// @Description Update the webhook of current user, the secret is responded if it is rotated.
+// @Tags webhook apis
+// @Accept application/json
+// @Produce application/json
+// @Param id path int true "webhook id"
+// @Param body body RequestUpdateWebhook true "webhook"
+// @Success 200 {object} ResponseWebhook
+// @Router /webhook/{id} [put]
+func (s *Service) UpdateWebhookHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var request RequestUpdateWebhook
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		webhook, ok := s.getWebhook(ctx)
+		if !ok {
+			return
+		}
+
+		if len(request.Name) > 0 {
+			webhook.Name = request.Name
+		}
+
+		if len(request.URL) > 0 {
+			if err := s.checkURL(request.URL); err != nil {
+				base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+				return
+			}
+			webhook.URL = request.URL
+		}
+
+		if len(request.Events) > 0 {
+			events, err := checkEvents(request.Events)
+			if err != nil {
+				base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+				return
+			}
+			webhook.Events = events
+		}
+
+		if request.Enabled != nil {
+			webhook.Enabled = *request.Enabled
+		}
+
+		var data ResponseWebhookData
+		if request.RotateSecret {
+			secret, err := utils.RandomToken(secretBytes)
+			if err != nil {
+				base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+				return
+			}
+			webhook.Secret = secret
+			data.Secret = secret
+		}
+
+		if err := s.db.Model(webhook).Select("name", "url", "secret", "events", "enabled").Updates(webhook).Error; err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		data.WebhookModel = *webhook
+		ctx.JSON(http.StatusOK, ResponseWebhook{
+			BaseResponse: base.ResponseOk(),
+			Data:         data,
+		})
+	}
+}
+
+// @Summary delete webhook
+// @Description Delete the webhook of current user with its delivery log.
+// @Tags webhook apis
+// @Accept application/json
+// @Produce application/json
+// @Param id path int true "webhook id"
+// @Success 200 {object} base.BaseResponse
+// @Router /webhook/{id} [delete]
+func (s *Service) DeleteWebhookHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		webhook, ok := s.getWebhook(ctx)
+		if !ok {
+			return
+		}
+
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("webhook_id = ?", webhook.ID).Delete(&datamodel.WebhookDeliveryModel{}).Error; err != nil {
+				return err
+			}
+
+			return tx.Delete(webhook).Error
+		})
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		base.ResponseSuccess(ctx)
+	}
+}
+
+// @Summary ping webhook
+// @Description Queue a ping event to the webhook to test it, the result is found in its deliveries.
+// @Tags webhook apis
+// @Accept application/json
+// @Produce application/json
+// @Param id path int true "webhook id"
+// @Success 200 {object} base.BaseResponse
+// @Router /webhook/{id}/ping [post]
+func (s *Service) PingWebhookHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		webhook, ok := s.getWebhook(ctx)
+		if !ok {
+			return
+		}
+
+		if !webhook.Enabled {
+			base.ResponseErr(ctx, http.StatusBadRequest, "webhook is disabled")
+			return
+		}
+
+		data := map[string]any{"webhook_id": webhook.ID}
+		if err := base.QueueWebhookDeliveries(s.db, datamodel.WebhookPing, data, webhook.ID); err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		base.ResponseSuccess(ctx)
+	}
+}
+
+// @Summary list deliveries
+// @Description List the deliveries of webhook, the latest are listed first.
+// @Tags webhook apis
+// @Accept application/json
+// @Produce application/json
+// @Param id path int true "webhook id"
+// @Param status query string false "pending, success or failed"
+// @Param page query int false "page"
+// @Param page_size query int false "page_size"
+// @Success 200 {object} ResponseListDeliveries
+// @Router /webhook/{id}/deliveries [get]
+func (s *Service) ListDeliveriesHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		webhook, ok := s.getWebhook(ctx)
+		if !ok {
+			return
+		}
+
+		page, pageSize, err := base.GetPage(ctx, 20)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		filter := func() *gorm.DB {
+			tx := s.db.Model(&datamodel.WebhookDeliveryModel{}).Where("webhook_id = ?", webhook.ID)
+			if status := ctx.Query("status"); len(status) > 0 {
+				tx = tx.Where("status = ?", status)
+			}
+			return tx
+		}
+
+		var data ResponseListDeliveriesData
+		if err := filter().Count(&data.Total).Error; err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		data.Deliveries = make([]datamodel.WebhookDeliveryModel, 0)
+		if err := filter().Order("id DESC").
+			Offset(int((page - 1) * pageSize)).
+			Limit(int(pageSize)).
+			Find(&data.Deliveries).Error; err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, ResponseListDeliveries{
+			BaseResponse: base.ResponseOk(),
+			Data:         data,
+		})
+	}
+}
+
+// Name service name
+func (s *Service) Name() string {
+	return ServiceName
+}
+
+// RouteTables route tables
+func (s *Service) RouteTables() []base.RouteTable {
+	group := "webhook"
+	return []base.RouteTable{
+		{
+			Method:  "POST",
+			Path:    group,
+			Handler: s.CreateWebhookHandler(),
+		},
+		{
+			Method:  "GET",
+			Path:    group,
+			Handler: s.ListWebhooksHandler(),
+		},
+		{
+			Method:  "PUT",
+			Path:    group + "/:id",
+			Handler: s.UpdateWebhookHandler(),
+		},
+		{
+			Method:  "DELETE",
+			Path:    group + "/:id",
+			Handler: s.DeleteWebhookHandler(),
+		},
+		{
+			Method:        "POST",
+			Path:          group + "/:id/ping",
+			Handler:       s.PingWebhookHandler(),
+			UserRateLimit: &base.RateLimit{Limit: 10, Period: time.Minute},
+		},
+		{
+			Method:  "GET",
+			Path:    group + "/:id/deliveries",
+			Handler: s.ListDeliveriesHandler(),
+		},
+	}
+}
@@ -0,0 +1,22 @@
+package workspace
+
+// RequestWorkspace is request of POST /workspace and PUT /workspace/:id
+type RequestWorkspace struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// RequestAddMember is request of POST /workspace/:id/members
+type RequestAddMember struct {
+	// UserID is the user to add, the user is found by Username if it is 0.
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username"`
+	// Role is one of owner, editor and viewer.
+	Role string `json:"role"`
+}
+
+// RequestUpdateMember is request of PUT /workspace/:id/members/:userId
+type RequestUpdateMember struct {
+	// Role is one of owner, editor and viewer.
+	Role string `json:"role"`
+}
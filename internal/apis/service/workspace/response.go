@@ -0,0 +1,53 @@
+package workspace
+
+import (
+	"time"
+
+	"infra-3.xyz/hyperdot-node/internal/apis/base"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+)
+
+// ResponseWorkspaceData is a workspace with the role of current user
+type ResponseWorkspaceData struct {
+	datamodel.WorkspaceModel
+	Role string `json:"role"`
+}
+
+// ResponseWorkspace is response of GET /workspace/:id
+type ResponseWorkspace struct {
+	base.BaseResponse
+	Data ResponseWorkspaceData `json:"data"`
+}
+
+// ResponseListWorkspaces is response of GET /workspace
+type ResponseListWorkspaces struct {
+	base.BaseResponse
+	Data []ResponseWorkspaceData `json:"data"`
+}
+
+// ResponseMemberData is a member of workspace with the profile of user
+type ResponseMemberData struct {
+	UserID    uint      `json:"user_id"`
+	Username  string    `json:"username"`
+	IconUrl   string    `json:"icon_url"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ResponseListMembers is response of GET /workspace/:id/members
+type ResponseListMembers struct {
+	base.BaseResponse
+	Data []ResponseMemberData `json:"data"`
+}
+
+// ResponseMember is response of POST /workspace/:id/members and PUT /workspace/:id/members/:userId
+type ResponseMember struct {
+	base.BaseResponse
+	Data datamodel.WorkspaceMemberModel `json:"data"`
+}
+
+// ResponseStatistics is response of GET /workspace/:id/statistics
+type ResponseStatistics struct {
+	base.BaseResponse
+	Data datamodel.WorkspaceStatistics `json:"data"`
+}
@@ -0,0 +1,632 @@
+package workspace
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"infra-3.xyz/hyperdot-node/internal/apis/base"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+)
+
+const ServiceName = "workspace"
+
+// errLastOwner is returned when the last owner of workspace is demoted or removed.
+var errLastOwner = errors.New("workspace must have at least one owner")
+
+// Service workspace service, the workspaces co-own queries and dashboards
+// by their members.
+type Service struct {
+	db *gorm.DB
+}
+
+// New workspace service
+func New(db *gorm.DB) *Service {
+	return &Service{
+		db: db,
+	}
+}
+
+// getWorkspace gets the workspace of id param which the current user has the role.
+func (s *Service) getWorkspace(ctx *gin.Context, role string) (*ResponseWorkspaceData, bool) {
+	userId, err := base.GetCurrentUserId(ctx)
+	if err != nil {
+		base.ResponseErr(ctx, http.StatusUnauthorized, err.Error())
+		return nil, false
+	}
+
+	id, err := base.GetUintParam(ctx, "id")
+	if err != nil {
+		base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+
+	var data ResponseWorkspaceData
+	if err := s.db.First(&data.WorkspaceModel, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			base.ResponseErr(ctx, http.StatusNotFound, "workspace not found")
+			return nil, false
+		}
+
+		base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+		return nil, false
+	}
+
+	if data.Role, err = base.GetWorkspaceRole(s.db, id, userId); err != nil {
+		base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+		return nil, false
+	}
+
+	if !datamodel.WorkspaceRoleIncludes(data.Role, role) {
+		base.ResponseErr(ctx, http.StatusForbidden, "%s role of workspace %d is required", role, id)
+		return nil, false
+	}
+
+	return &data, true
+}
+
+// checkOtherOwners returns errLastOwner if the user is the only owner of workspace.
+func checkOtherOwners(tx *gorm.DB, workspaceId uint, userId uint) error {
+	var count int64
+	if err := tx.Model(&datamodel.WorkspaceMemberModel{}).
+		Where("workspace_id = ? AND role = ? AND user_id <> ?", workspaceId, datamodel.WorkspaceRoleOwner, userId).
+		Count(&count).Error; err != nil {
+		return err
+	}
+
+	if count == 0 {
+		return errLastOwner
+	}
+	return nil
+}
+
+// @Summary create workspace
+// @Description Create a workspace, the current user is the owner of it.
+// @Tags workspace apis
+// @Accept application/json
+// @Produce application/json
+// @Param body body RequestWorkspace true "workspace"
+// @Success 200 {object} ResponseWorkspace
+// @Router /workspace [post]
+func (s *Service) CreateWorkspaceHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userId, err := base.GetCurrentUserId(ctx)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		var request RequestWorkspace
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if len(request.Name) == 0 {
+			base.ResponseErr(ctx, http.StatusBadRequest, "name is required")
+			return
+		}
+
+		data := ResponseWorkspaceData{
+			WorkspaceModel: datamodel.WorkspaceModel{
+				UserID:      userId,
+				Name:        request.Name,
+				Description: request.Description,
+			},
+			Role: datamodel.WorkspaceRoleOwner,
+		}
+		err = s.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&data.WorkspaceModel).Error; err != nil {
+				return err
+			}
+
+			if err := tx.Create(&datamodel.WorkspaceMemberModel{
+				WorkspaceID: data.ID,
+				UserID:      userId,
+				Role:        datamodel.WorkspaceRoleOwner,
+			}).Error; err != nil {
+				return err
+			}
+
+			return base.RefreshWorkspaceStatistics(tx, data.ID)
+		})
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, ResponseWorkspace{
+			BaseResponse: base.ResponseOk(),
+			Data:         data,
+		})
+	}
+}
+
+// @Summary list workspaces
+// @Description List the workspaces which the current user is a member of.
+// @Tags workspace apis
+// @Accept application/json
+// @Produce application/json
+// @Success 200 {object} ResponseListWorkspaces
+// @Router /workspace [get]
+func (s *Service) ListWorkspacesHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userId, err := base.GetCurrentUserId(ctx)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		data := make([]ResponseWorkspaceData, 0)
+		if err := s.db.Table(datamodel.WorkspaceModel{}.TableName()+" AS w").
+			Select("w.*, m.role").
+			Joins("JOIN "+datamodel.WorkspaceMemberModel{}.TableName()+" AS m ON m.workspace_id = w.id").
+			Where("m.user_id = ?", userId).
+			Order("w.id ASC").
+			Scan(&data).Error; err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, ResponseListWorkspaces{
+			BaseResponse: base.ResponseOk(),
+			Data:         data,
+		})
+	}
+}
+
+// @Summary get workspace
+// @Description Get the workspace with the role of current user, it can be accessed by members.
+// @Tags workspace apis
+// @Accept application/json
+// @Produce application/json
+// @Param id path int true "workspace id"
+// @Success 200 {object} ResponseWorkspace
+// @Router /workspace/{id} [get]
+func (s *Service) GetWorkspaceHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		data, ok := s.getWorkspace(ctx, datamodel.WorkspaceRoleViewer)
+		if !ok {
+			return
+		}
+
+		ctx.JSON(http.StatusOK, ResponseWorkspace{
+			BaseResponse: base.ResponseOk(),
+			Data:         *data,
+		})
+	}
+}
+
+// @Summary update workspace
+// @Description Update the name and description of workspace, it requires owner role.
+// @Tags workspace apis
+// @Accept application/json
+// @Produce application/json
+// @Param id path int true "workspace id"
+// @Param body body RequestWorkspace true "workspace"
+// @Success 200 {object} ResponseWorkspace
+// @Router /workspace/{id} [put]
+func (s *Service) UpdateWorkspaceHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var request RequestWorkspace
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if len(request.Name) == 0 {
+			base.ResponseErr(ctx, http.StatusBadRequest, "name is required")
+			return
+		}
+
+		data, ok := s.getWorkspace(ctx, datamodel.WorkspaceRoleOwner)
+		if !ok {
+			return
+		}
+
+		if err := s.db.Model(&data.WorkspaceModel).Updates(map[string]any{
+			"name":        request.Name,
+			"description": request.Description,
+		}).Error; err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, ResponseWorkspace{
+			BaseResponse: base.ResponseOk(),
+			Data:         *data,
+		})
+	}
+}
+
+// @Summary delete workspace
+// @Description Delete the workspace, it requires owner role. The queries and dashboards of workspace are moved back to their creators.
+// @Tags workspace apis
+// @Accept application/json
+// @Produce application/json
+// @Param id path int true "workspace id"
+// @Success 200 {object} base.BaseResponse
+// @Router /workspace/{id} [delete]
+func (s *Service) DeleteWorkspaceHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		data, ok := s.getWorkspace(ctx, datamodel.WorkspaceRoleOwner)
+		if !ok {
+			return
+		}
+
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Unscoped().Model(&datamodel.QueryModel{}).Where("workspace_id = ?", data.ID).
+				Update("workspace_id", 0).Error; err != nil {
+				return err
+			}
+
+			if err := tx.Unscoped().Model(&datamodel.DashboardModel{}).Where("workspace_id = ?", data.ID).
+				Update("workspace_id", 0).Error; err != nil {
+				return err
+			}
+
+			if err := tx.Where("workspace_id = ?", data.ID).Delete(&datamodel.WorkspaceMemberModel{}).Error; err != nil {
+				return err
+			}
+
+			if err := tx.Where("workspace_id = ?", data.ID).Delete(&datamodel.WorkspaceStatistics{}).Error; err != nil {
+				return err
+			}
+
+			return tx.Delete(&data.WorkspaceModel).Error
+		})
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		base.ResponseSuccess(ctx)
+	}
+}
+
+// @Summary list workspace members
+// @Description List the members of workspace, it can be accessed by members.
+// @Tags workspace apis
+// @Accept application/json
+// @Produce application/json
+// @Param id path int true "workspace id"
+// @Success 200 {object} ResponseListMembers
+// @Router /workspace/{id}/members [get]
+func (s *Service) ListMembersHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		data, ok := s.getWorkspace(ctx, datamodel.WorkspaceRoleViewer)
+		if !ok {
+			return
+		}
+
+		members := make([]ResponseMemberData, 0)
+		if err := s.db.Table(datamodel.WorkspaceMemberModel{}.TableName()+" AS m").
+			Select("m.user_id, u.username, u.icon_url, m.role, m.created_at").
+			Joins("LEFT JOIN "+datamodel.UserModel{}.TableName()+" AS u ON u.id = m.user_id").
+			Where("m.workspace_id = ?", data.ID).
+			Order("m.id ASC").
+			Scan(&members).Error; err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, ResponseListMembers{
+			BaseResponse: base.ResponseOk(),
+			Data:         members,
+		})
+	}
+}
+
+// @Summary add workspace member
+// @Description Add a user to workspace with role, it requires owner role.
+// @Tags workspace apis
+// @Accept application/json
+// @Produce application/json
+// @Param id path int true "workspace id"
+// @Param body body RequestAddMember true "member"
+// @Success 200 {object} ResponseMember
+// @Router /workspace/{id}/members [post]
+func (s *Service) AddMemberHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var request RequestAddMember
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if !datamodel.IsValidWorkspaceRole(request.Role) {
+			base.ResponseErr(ctx, http.StatusBadRequest, "invalid role %s", request.Role)
+			return
+		}
+
+		if request.UserID == 0 && len(request.Username) == 0 {
+			base.ResponseErr(ctx, http.StatusBadRequest, "user id or username is required")
+			return
+		}
+
+		data, ok := s.getWorkspace(ctx, datamodel.WorkspaceRoleOwner)
+		if !ok {
+			return
+		}
+
+		var user datamodel.UserModel
+		tx := s.db.Select("id")
+		if request.UserID != 0 {
+			tx = tx.Where("id = ?", request.UserID)
+		} else {
+			tx = tx.Where("username = ?", request.Username)
+		}
+		if err := tx.First(&user).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				base.ResponseErr(ctx, http.StatusNotFound, "user not found")
+				return
+			}
+
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		role, err := base.GetWorkspaceRole(s.db, data.ID, user.ID)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if len(role) > 0 {
+			base.ResponseErr(ctx, http.StatusBadRequest, "user is already a member")
+			return
+		}
+
+		member := datamodel.WorkspaceMemberModel{
+			WorkspaceID: data.ID,
+			UserID:      user.ID,
+			Role:        request.Role,
+		}
+		err = s.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&member).Error; err != nil {
+				return err
+			}
+
+			return base.RefreshWorkspaceStatistics(tx, data.ID)
+		})
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, ResponseMember{
+			BaseResponse: base.ResponseOk(),
+			Data:         member,
+		})
+	}
+}
+
+// @Summary update workspace member
+// @Description Update the role of member, it requires owner role. The last owner can not be demoted.
+// @Tags workspace apis
+// @Accept application/json
+// @Produce application/json
+// @Param id path int true "workspace id"
+// @Param userId path int true "user id"
+// @Param body body RequestUpdateMember true "member"
+// @Success 200 {object} ResponseMember
+// @Router /workspace/{id}/members/{userId} [put]
+func (s *Service) UpdateMemberHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var request RequestUpdateMember
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if !datamodel.IsValidWorkspaceRole(request.Role) {
+			base.ResponseErr(ctx, http.StatusBadRequest, "invalid role %s", request.Role)
+			return
+		}
+
+		memberId, err := base.GetUintParam(ctx, "userId")
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		data, ok := s.getWorkspace(ctx, datamodel.WorkspaceRoleOwner)
+		if !ok {
+			return
+		}
+
+		var member datamodel.WorkspaceMemberModel
+		err = s.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("workspace_id = ? AND user_id = ?", data.ID, memberId).First(&member).Error; err != nil {
+				return err
+			}
+
+			if member.Role == datamodel.WorkspaceRoleOwner && request.Role != datamodel.WorkspaceRoleOwner {
+				if err := checkOtherOwners(tx, data.ID, memberId); err != nil {
+					return err
+				}
+			}
+
+			member.Role = request.Role
+			member.UpdatedAt = time.Now()
+			return tx.Save(&member).Error
+		})
+		if err != nil {
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				base.ResponseErr(ctx, http.StatusNotFound, "member not found")
+			case errors.Is(err, errLastOwner):
+				base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			default:
+				base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			}
+			return
+		}
+
+		ctx.JSON(http.StatusOK, ResponseMember{
+			BaseResponse: base.ResponseOk(),
+			Data:         member,
+		})
+	}
+}
+
+// @Summary remove workspace member
+// @Description Remove the member from workspace, it requires owner role unless the member leaves by itself. The last owner can not be removed.
+// @Tags workspace apis
+// @Accept application/json
+// @Produce application/json
+// @Param id path int true "workspace id"
+// @Param userId path int true "user id"
+// @Success 200 {object} base.BaseResponse
+// @Router /workspace/{id}/members/{userId} [delete]
+func (s *Service) RemoveMemberHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userId, err := base.GetCurrentUserId(ctx)
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		memberId, err := base.GetUintParam(ctx, "userId")
+		if err != nil {
+			base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		role := datamodel.WorkspaceRoleOwner
+		if memberId == userId {
+			role = datamodel.WorkspaceRoleViewer
+		}
+		data, ok := s.getWorkspace(ctx, role)
+		if !ok {
+			return
+		}
+
+		err = s.db.Transaction(func(tx *gorm.DB) error {
+			var member datamodel.WorkspaceMemberModel
+			if err := tx.Where("workspace_id = ? AND user_id = ?", data.ID, memberId).First(&member).Error; err != nil {
+				return err
+			}
+
+			if member.Role == datamodel.WorkspaceRoleOwner {
+				if err := checkOtherOwners(tx, data.ID, memberId); err != nil {
+					return err
+				}
+			}
+
+			if err := tx.Delete(&member).Error; err != nil {
+				return err
+			}
+
+			return base.RefreshWorkspaceStatistics(tx, data.ID)
+		})
+		if err != nil {
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				base.ResponseErr(ctx, http.StatusNotFound, "member not found")
+			case errors.Is(err, errLastOwner):
+				base.ResponseErr(ctx, http.StatusBadRequest, err.Error())
+			default:
+				base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+			}
+			return
+		}
+
+		base.ResponseSuccess(ctx)
+	}
+}
+
+// @Summary get workspace statistics
+// @Description Get the number of members, queries and dashboards of workspace, it can be accessed by members.
+// @Tags workspace apis
+// @Accept application/json
+// @Produce application/json
+// @Param id path int true "workspace id"
+// @Success 200 {object} ResponseStatistics
+// @Router /workspace/{id}/statistics [get]
+func (s *Service) GetStatisticsHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		data, ok := s.getWorkspace(ctx, datamodel.WorkspaceRoleViewer)
+		if !ok {
+			return
+		}
+
+		var statistics datamodel.WorkspaceStatistics
+		if err := s.db.Where("workspace_id = ?", data.ID).First(&statistics).Error; err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				base.ResponseErr(ctx, http.StatusInternalServerError, err.Error())
+				return
+			}
+			statistics.WorkspaceID = data.ID
+		}
+
+		ctx.JSON(http.StatusOK, ResponseStatistics{
+			BaseResponse: base.ResponseOk(),
+			Data:         statistics,
+		})
+	}
+}
+
+// Name service name
+func (s *Service) Name() string {
+	return ServiceName
+}
+
+// RouteTables route tables
+func (s *Service) RouteTables() []base.RouteTable {
+	group := "workspace"
+	return []base.RouteTable{
+		{
+			Method:  "POST",
+			Path:    group,
+			Handler: s.CreateWorkspaceHandler(),
+		},
+		{
+			Method:  "GET",
+			Path:    group,
+			Handler: s.ListWorkspacesHandler(),
+		},
+		{
+			Method:  "GET",
+			Path:    group + "/:id",
+			Handler: s.GetWorkspaceHandler(),
+		},
+		{
+			Method:  "PUT",
+			Path:    group + "/:id",
+			Handler: s.UpdateWorkspaceHandler(),
+		},
+		{
+			Method:  "DELETE",
+			Path:    group + "/:id",
+			Handler: s.DeleteWorkspaceHandler(),
+		},
+		{
+			Method:  "GET",
+			Path:    group + "/:id/members",
+			Handler: s.ListMembersHandler(),
+		},
+		{
+			Method:  "POST",
+			Path:    group + "/:id/members",
+			Handler: s.AddMemberHandler(),
+		},
+		{
+			Method:  "PUT",
+			Path:    group + "/:id/members/:userId",
+			Handler: s.UpdateMemberHandler(),
+		},
+		{
+			Method:  "DELETE",
+			Path:    group + "/:id/members/:userId",
+			Handler: s.RemoveMemberHandler(),
+		},
+		{
+			Method:  "GET",
+			Path:    group + "/:id/statistics",
+			Handler: s.GetStatisticsHandler(),
+		},
+	}
+}
@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"infra-3.xyz/hyperdot-node/internal/common"
+	"infra-3.xyz/hyperdot-node/internal/dataengine"
+	"infra-3.xyz/hyperdot-node/internal/utils"
+)
+
+// QueryResultDefaultTTL is the default expiration of a cached query result.
+const QueryResultDefaultTTL = time.Hour
+
+// QueryResult is the cached result of a saved query.
+type QueryResult struct {
+	QueryID uint `json:"query_id"`
+	// Params are the parameter values rendered into the query.
+	Params map[string]string `json:"params,omitempty"`
+	dataengine.Result
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// QueryResultCache is a redis cache for the results of saved queries.
+type QueryResultCache struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// NewQueryResultCache creates a new QueryResultCache.
+func NewQueryResultCache(cfg *common.RedisConfig) *QueryResultCache {
+	return &QueryResultCache{
+		client: redis.NewClient(&redis.Options{
+			Addr: cfg.Addr,
+		}),
+		ttl: QueryResultDefaultTTL,
+	}
+}
+
+// key returns the cache key of query, the results of different parameter values
+// are cached separately.
+func (c *QueryResultCache) key(queryId uint, params map[string]string) string {
+	if len(params) == 0 {
+		return fmt.Sprintf("hyperdot:query:%d:result", queryId)
+	}
+
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&b, "%s=%s\n", name, params[name])
+	}
+
+	return fmt.Sprintf("hyperdot:query:%d:result:%s", queryId, utils.HashToken(b.String()))
+}
+
+// Get gets the cached result of query with the parameter values, it returns nil
+// if the result is not cached.
+func (c *QueryResultCache) Get(ctx context.Context, queryId uint, params map[string]string) (*QueryResult, error) {
+	data, err := c.client.Get(ctx, c.key(queryId, params)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	result := new(QueryResult)
+	if err := json.Unmarshal(data, result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Set caches the result of query.
+func (c *QueryResultCache) Set(ctx context.Context, result *QueryResult) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	return c.client.Set(ctx, c.key(result.QueryID, result.Params), data, c.ttl).Err()
+}
@@ -0,0 +1,196 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"infra-3.xyz/hyperdot-node/internal/common"
+)
+
+// tokenBucketScript takes a token from the bucket of KEYS[1] atomically. The
+// bucket holds at most ARGV[1] tokens and is refilled fully in ARGV[2] ms.
+var tokenBucketScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(bucket[1])
+local ts = tonumber(bucket[2])
+if tokens == nil or ts == nil then
+	tokens = capacity
+	ts = now
+end
+
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * capacity / period)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], period)
+return {allowed, tostring(tokens)}
+`)
+
+// RateLimitResult is the state of a token bucket after taking a token.
+type RateLimitResult struct {
+	Allowed bool
+	// Limit is the capacity of bucket.
+	Limit int
+	// Remaining is the number of whole tokens left in bucket.
+	Remaining int
+	// RetryAfter is the time until a token is available if it is not allowed.
+	RetryAfter time.Duration
+	// Reset is the time until the bucket is full again.
+	Reset time.Duration
+}
+
+// RateLimiter is a redis token bucket rate limiter, the buckets are shared by
+// all nodes.
+type RateLimiter struct {
+	client *redis.Client
+}
+
+// NewRateLimiter creates a new RateLimiter.
+func NewRateLimiter(cfg *common.RedisConfig) *RateLimiter {
+	return &RateLimiter{
+		client: redis.NewClient(&redis.Options{
+			Addr: cfg.Addr,
+		}),
+	}
+}
+
+// Take takes a token from the bucket of key which holds limit tokens and is
+// refilled fully in period.
+func (l *RateLimiter) Take(ctx context.Context, key string, limit int, period time.Duration) (*RateLimitResult, error) {
+	if limit <= 0 || period <= 0 {
+		return nil, fmt.Errorf("invalid rate limit %d per %s", limit, period)
+	}
+
+	values, err := tokenBucketScript.Run(ctx, l.client, []string{"hyperdot:ratelimit:" + key},
+		limit, period.Milliseconds(), time.Now().UnixMilli()).Slice()
+	if err != nil {
+		return nil, err
+	}
+	if len(values) != 2 {
+		return nil, fmt.Errorf("unexpected result of rate limit script: %v", values)
+	}
+
+	allowed, _ := values[0].(int64)
+	text, _ := values[1].(string)
+	tokens, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	// the time to refill a token
+	interval := float64(period) / float64(limit)
+	result := &RateLimitResult{
+		Allowed:   allowed == 1,
+		Limit:     limit,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     time.Duration(math.Ceil((float64(limit) - tokens) * interval)),
+	}
+	if !result.Allowed {
+		result.RetryAfter = time.Duration(math.Ceil((1 - tokens) * interval))
+	}
+
+	return result, nil
+}
+
+const (
+	// loginLockoutThreshold is the number of failures before the account is locked.
+	loginLockoutThreshold = 5
+	// loginLockoutBase is the lockout after the threshold, it doubles on every
+	// further failure.
+	loginLockoutBase = time.Minute
+	// loginLockoutMax is the longest lockout.
+	loginLockoutMax = time.Hour
+	// loginFailuresTTL is how long the failures are counted since the last one.
+	loginFailuresTTL = 24 * time.Hour
+)
+
+// LoginLockout locks the account out progressively after repeated login failures.
+type LoginLockout struct {
+	client *redis.Client
+}
+
+// NewLoginLockout creates a new LoginLockout.
+func NewLoginLockout(cfg *common.RedisConfig) *LoginLockout {
+	return &LoginLockout{
+		client: redis.NewClient(&redis.Options{
+			Addr: cfg.Addr,
+		}),
+	}
+}
+
+func (l *LoginLockout) failuresKey(userId uint) string {
+	return fmt.Sprintf("hyperdot:login:%d:failures", userId)
+}
+
+func (l *LoginLockout) lockKey(userId uint) string {
+	return fmt.Sprintf("hyperdot:login:%d:locked", userId)
+}
+
+// LockoutDuration returns the lockout after the number of failures.
+func LockoutDuration(failures int64) time.Duration {
+	if failures < loginLockoutThreshold {
+		return 0
+	}
+
+	lockout := loginLockoutBase
+	for i := int64(loginLockoutThreshold); i < failures && lockout < loginLockoutMax; i++ {
+		lockout *= 2
+	}
+	if lockout > loginLockoutMax {
+		lockout = loginLockoutMax
+	}
+	return lockout
+}
+
+// LockedFor returns the remaining lockout of the user, it is zero if the user
+// is not locked.
+func (l *LoginLockout) LockedFor(ctx context.Context, userId uint) (time.Duration, error) {
+	ttl, err := l.client.PTTL(ctx, l.lockKey(userId)).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	// the negative ttl means the key does not exist
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+// Fail records a login failure of the user and returns the lockout caused by it.
+func (l *LoginLockout) Fail(ctx context.Context, userId uint) (time.Duration, error) {
+	pipe := l.client.TxPipeline()
+	incr := pipe.Incr(ctx, l.failuresKey(userId))
+	pipe.Expire(ctx, l.failuresKey(userId), loginFailuresTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+
+	lockout := LockoutDuration(incr.Val())
+	if lockout == 0 {
+		return 0, nil
+	}
+
+	if err := l.client.Set(ctx, l.lockKey(userId), incr.Val(), lockout).Err(); err != nil {
+		return 0, err
+	}
+	return lockout, nil
+}
+
+// Reset clears the failures of the user after a successful login.
+func (l *LoginLockout) Reset(ctx context.Context, userId uint) error {
+	return l.client.Del(ctx, l.failuresKey(userId), l.lockKey(userId)).Err()
+}
@@ -0,0 +1,56 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"infra-3.xyz/hyperdot-node/internal/common"
+)
+
+// SessionRevocationList is a redis list of the revoked sessions whose
+// access tokens are not expired yet.
+type SessionRevocationList struct {
+	client *redis.Client
+}
+
+// NewSessionRevocationList creates a new SessionRevocationList.
+func NewSessionRevocationList(cfg *common.RedisConfig) *SessionRevocationList {
+	return &SessionRevocationList{
+		client: redis.NewClient(&redis.Options{
+			Addr: cfg.Addr,
+		}),
+	}
+}
+
+func (l *SessionRevocationList) key(sessionId uint) string {
+	return fmt.Sprintf("hyperdot:session:%d:revoked", sessionId)
+}
+
+// Revoke adds the sessions to list, the sessions are removed from list after
+// ttl which should be the lifetime of access token.
+func (l *SessionRevocationList) Revoke(ctx context.Context, ttl time.Duration, sessionIds ...uint) error {
+	if len(sessionIds) == 0 {
+		return nil
+	}
+
+	pipe := l.client.Pipeline()
+	for _, id := range sessionIds {
+		pipe.Set(ctx, l.key(id), time.Now().Unix(), ttl)
+	}
+
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// IsRevoked reports whether the session is revoked.
+func (l *SessionRevocationList) IsRevoked(ctx context.Context, sessionId uint) (bool, error) {
+	n, err := l.client.Exists(ctx, l.key(sessionId)).Result()
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
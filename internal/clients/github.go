@@ -0,0 +1,122 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/github"
+
+	"infra-3.xyz/hyperdot-node/internal/common"
+)
+
+const githubApiUrl = "https://api.github.com"
+
+// GithubUser is the profile of github user.
+type GithubUser struct {
+	ID        int64  `json:"id"`
+	Login     string `json:"login"`
+	Name      string `json:"name"`
+	AvatarUrl string `json:"avatar_url"`
+	HtmlUrl   string `json:"html_url"`
+	Bio       string `json:"bio"`
+	Location  string `json:"location"`
+	// Email is the primary verified email, it is empty if the user has no verified email.
+	Email string `json:"-"`
+}
+
+type githubEmail struct {
+	Email    string `json:"email"`
+	Primary  bool   `json:"primary"`
+	Verified bool   `json:"verified"`
+}
+
+// GithubOAuthClient is a client of github oauth app, it implements the
+// authorization code flow and reads the profile of user.
+type GithubOAuthClient struct {
+	config *oauth2.Config
+	apiUrl string
+}
+
+// NewGithubOAuthClient creates a new github oauth client.
+func NewGithubOAuthClient(cfg *common.GithubConfig) *GithubOAuthClient {
+	endpoint := github.Endpoint
+	if len(cfg.AuthUrl) > 0 {
+		endpoint.AuthURL = cfg.AuthUrl
+	}
+	if len(cfg.TokenUrl) > 0 {
+		endpoint.TokenURL = cfg.TokenUrl
+	}
+
+	apiUrl := githubApiUrl
+	if len(cfg.ApiUrl) > 0 {
+		apiUrl = strings.TrimSuffix(cfg.ApiUrl, "/")
+	}
+
+	return &GithubOAuthClient{
+		config: &oauth2.Config{
+			ClientID:     cfg.ClientId,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectUrl,
+			Endpoint:     endpoint,
+			Scopes:       []string{"read:user", "user:email"},
+		},
+		apiUrl: apiUrl,
+	}
+}
+
+// AuthCodeURL returns the url of github authorization page.
+func (c *GithubOAuthClient) AuthCodeURL(state string) string {
+	return c.config.AuthCodeURL(state)
+}
+
+// Exchange exchanges the authorization code for the profile of user.
+func (c *GithubOAuthClient) Exchange(ctx context.Context, code string) (*GithubUser, error) {
+	token, err := c.config.Exchange(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	client := c.config.Client(ctx, token)
+	user := new(GithubUser)
+	if err := c.get(client, "/user", user); err != nil {
+		return nil, err
+	}
+
+	var emails []githubEmail
+	if err := c.get(client, "/user/emails", &emails); err != nil {
+		return nil, err
+	}
+
+	for _, email := range emails {
+		if email.Primary && email.Verified {
+			user.Email = email.Email
+			break
+		}
+	}
+
+	return user, nil
+}
+
+func (c *GithubOAuthClient) get(client *http.Client, path string, to any) error {
+	req, err := http.NewRequest(http.MethodGet, c.apiUrl+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("github api %s: %s", path, resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(to)
+}
@@ -0,0 +1,74 @@
+package clients_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"infra-3.xyz/hyperdot-node/internal/clients"
+	"infra-3.xyz/hyperdot-node/internal/common"
+)
+
+// newFakeGithub starts a fake github server which authorizes the code "code".
+func newFakeGithub(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("code") != "code" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"access_token": "token", "token_type": "bearer"})
+	})
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"id": 42, "login": "octocat", "avatar_url": "https://avatars.example/42"})
+	})
+	mux.HandleFunc("/user/emails", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]map[string]any{
+			{"email": "unverified@example.com", "primary": false, "verified": false},
+			{"email": "octocat@example.com", "primary": true, "verified": true},
+		})
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestGithubOAuthClient(t *testing.T) {
+	server := newFakeGithub(t)
+	client := clients.NewGithubOAuthClient(&common.GithubConfig{
+		ClientId:    "client",
+		RedirectUrl: "http://localhost/callback",
+		AuthUrl:     server.URL + "/login/oauth/authorize",
+		TokenUrl:    server.URL + "/login/oauth/access_token",
+		ApiUrl:      server.URL,
+	})
+
+	authUrl, err := url.Parse(client.AuthCodeURL("state"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if authUrl.Query().Get("state") != "state" || authUrl.Query().Get("client_id") != "client" {
+		t.Fatalf("invalid auth url: %s", authUrl)
+	}
+
+	user, err := client.Exchange(context.Background(), "code")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.ID != 42 || user.Login != "octocat" || user.Email != "octocat@example.com" {
+		t.Fatalf("invalid user: %+v", user)
+	}
+
+	if _, err := client.Exchange(context.Background(), "invalid"); err == nil {
+		t.Fatal("invalid code should be rejected")
+	}
+}
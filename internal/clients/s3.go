@@ -60,3 +60,8 @@ func (s *SimpleS3Cliet) Put(ctx context.Context, bucketName, objectName string,
 func (s *SimpleS3Cliet) Get(ctx context.Context, bucketName, objectName string) (*minio.Object, error) {
 	return s.client.GetObject(ctx, bucketName, objectName, minio.GetObjectOptions{})
 }
+
+// Remove object from associated bucket, it returns nil if the object does not exist.
+func (s *SimpleS3Cliet) Remove(ctx context.Context, bucketName, objectName string) error {
+	return s.client.RemoveObject(ctx, bucketName, objectName, minio.RemoveObjectOptions{})
+}
@@ -1,5 +1,7 @@
 package common
 
+import "time"
+
 // PolkaholicConfig is the config for polkaholic.
 type PolkaholicConfig struct {
 	// ApiKey is the api key for polkaholic.
@@ -55,6 +57,107 @@ type RedisConfig struct {
 	Addr string `json:"addr"`
 }
 
+// JwtKeyConfig is a key to sign or verify jwt token.
+type JwtKeyConfig struct {
+	// Kid is the key id in the header of jwt token.
+	Kid string `json:"kid"`
+	// Algorithm is one of HS256, RS256 and EdDSA.
+	Algorithm string `json:"algorithm"`
+	// Secret is the secret of HS256.
+	Secret string `json:"secret"`
+	// PrivateKey is the PEM encoded private key of RS256 and EdDSA.
+	PrivateKey string `json:"privateKey"`
+	// PrivateKeyFile is the path of PEM encoded private key, it is used if PrivateKey is empty.
+	PrivateKeyFile string `json:"privateKeyFile"`
+	// PublicKey is the PEM encoded public key of RS256 and EdDSA.
+	// It is derived from private key if it is empty.
+	PublicKey string `json:"publicKey"`
+	// PublicKeyFile is the path of PEM encoded public key, it is used if PublicKey is empty.
+	PublicKeyFile string `json:"publicKeyFile"`
+}
+
+// JwtConfig is the config for jwt token.
+type JwtConfig struct {
+	// SigningKey is the kid of the key to sign new tokens.
+	// The other keys only verify tokens, e.g. the retired keys during rotation.
+	SigningKey string `json:"signingKey"`
+	// Keys is the keys to sign or verify tokens.
+	// A random HS256 secret is generated on startup if it is empty.
+	Keys []JwtKeyConfig `json:"keys"`
+}
+
+// GithubConfig is the config for login by github oauth app.
+type GithubConfig struct {
+	// Enable enables the github login provider.
+	Enable bool `json:"enable"`
+	// ClientId is the client id of github oauth app.
+	ClientId string `json:"clientId"`
+	// ClientSecret is the client secret of github oauth app.
+	ClientSecret string `json:"clientSecret"`
+	// RedirectUrl is the authorization callback url of github oauth app,
+	// e.g. https://hyperdot.example/apis/v1/user/auth/github/callback.
+	RedirectUrl string `json:"redirectUrl"`
+	// LoginRedirectUrl is the page redirected to after login, the tokens are set in cookies.
+	// The tokens are responded as json if it is empty.
+	LoginRedirectUrl string `json:"loginRedirectUrl"`
+	// AuthUrl is the authorization endpoint, default is https://github.com/login/oauth/authorize.
+	AuthUrl string `json:"authUrl"`
+	// TokenUrl is the token endpoint, default is https://github.com/login/oauth/access_token.
+	TokenUrl string `json:"tokenUrl"`
+	// ApiUrl is the base url of github rest api, default is https://api.github.com.
+	ApiUrl string `json:"apiUrl"`
+}
+
+// MailConfig is the config for sending mails.
+type MailConfig struct {
+	// Host is the host of smtp server, it is required unless Memory is enabled.
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	// From is the sender address of mails.
+	From string `json:"from"`
+	// SiteUrl is the url of hyperdot site, the links in mails are relative to it.
+	SiteUrl string `json:"siteUrl"`
+	// Memory keeps the latest mails in memory instead of sending them if Host is empty,
+	// it is only for development.
+	Memory bool `json:"memory"`
+}
+
+// TrashConfig is the config for the trash of queries and dashboards.
+type TrashConfig struct {
+	// RetentionDays is the days the trashed contents are kept before purged, default is 30.
+	RetentionDays int `json:"retentionDays"`
+}
+
+// Retention returns the period the trashed contents are kept.
+func (c TrashConfig) Retention() time.Duration {
+	days := c.RetentionDays
+	if days <= 0 {
+		days = 30
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// WebhookConfig is the config for posting webhooks.
+type WebhookConfig struct {
+	// AllowLoopback allows the webhooks posted to the loopback addresses, it is only
+	// for tests and development. The other non-public addresses are always rejected.
+	AllowLoopback bool `json:"allowLoopback"`
+	// DeliveryRetentionDays is the days the finished deliveries are kept in the delivery
+	// log, default is 30.
+	DeliveryRetentionDays int `json:"deliveryRetentionDays"`
+}
+
+// DeliveryRetention returns the period the finished deliveries are kept.
+func (c WebhookConfig) DeliveryRetention() time.Duration {
+	days := c.DeliveryRetentionDays
+	if days <= 0 {
+		days = 30
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
 // Config is the config for hyperdot-node.
 type Config struct {
 	// Refer to PolkaholicConfig
@@ -71,4 +174,14 @@ type Config struct {
 	S3 S3Config `json:"s3"`
 	// Refer to RedisConfig
 	Redis RedisConfig `json:"redis"`
+	// Refer to JwtConfig
+	Jwt JwtConfig `json:"jwt"`
+	// Refer to GithubConfig
+	Github GithubConfig `json:"github"`
+	// Refer to MailConfig
+	Mail MailConfig `json:"mail"`
+	// Refer to TrashConfig
+	Trash TrashConfig `json:"trash"`
+	// Refer to WebhookConfig
+	Webhook WebhookConfig `json:"webhook"`
 }
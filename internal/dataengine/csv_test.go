@@ -0,0 +1,32 @@
+package dataengine_test
+
+import (
+	"bytes"
+	"testing"
+
+	"infra-3.xyz/hyperdot-node/internal/dataengine"
+)
+
+func TestResultWriteCSV(t *testing.T) {
+	result := dataengine.Result{
+		Schemas: []*dataengine.FieldSchema{
+			{Name: "chain"},
+			{Name: "blocks"},
+			{Name: "tags", Repeated: true},
+		},
+		Rows: []map[string]interface{}{
+			{"chain": "polkadot", "blocks": 100, "tags": []string{"relay"}},
+			{"chain": "a,b", "blocks": 1.5},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := result.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "chain,blocks,tags\npolkadot,100,\"[\"\"relay\"\"]\"\n\"a,b\",1.5,\n"
+	if buf.String() != expected {
+		t.Fatalf("invalid csv: %q", buf.String())
+	}
+}
@@ -53,6 +53,37 @@ type QueryEngine interface {
 	Run(ctx context.Context, query string) (RowIterator, error)
 }
 
+// Result is the rows and schemas of an executed query.
+type Result struct {
+	Rows    []map[string]interface{} `json:"rows"`
+	Schemas []*FieldSchema           `json:"schemas"`
+}
+
+// Execute runs the query by engine and reads all rows.
+func Execute(ctx context.Context, engine QueryEngine, query string) (*Result, error) {
+	iter, err := engine.Run(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []map[string]interface{}
+	for {
+		row, err := iter.Next()
+		if err != nil {
+			if errors.Is(err, IterDone) {
+				break
+			}
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+
+	return &Result{
+		Rows:    rows,
+		Schemas: iter.Schema(),
+	}, nil
+}
+
 // Make creates a new query engine by given engine name and config.
 func Make(engine string, cfg interface{}) (QueryEngine, error) {
 	switch engine {
@@ -0,0 +1,116 @@
+package datamodel
+
+import "time"
+
+const (
+	AlertOperatorGT  = "gt"
+	AlertOperatorGTE = "gte"
+	AlertOperatorLT  = "lt"
+	AlertOperatorLTE = "lte"
+	AlertOperatorEQ  = "eq"
+	AlertOperatorNE  = "ne"
+)
+
+// IsValidAlertOperator reports whether the comparison is defined.
+func IsValidAlertOperator(operator string) bool {
+	switch operator {
+	case AlertOperatorGT, AlertOperatorGTE, AlertOperatorLT, AlertOperatorLTE, AlertOperatorEQ, AlertOperatorNE:
+		return true
+	}
+	return false
+}
+
+const (
+	// AlertReduceFirst compares the column of the first row, it is the default.
+	AlertReduceFirst = "first"
+	AlertReduceMin   = "min"
+	AlertReduceMax   = "max"
+	AlertReduceSum   = "sum"
+	// AlertReduceCount compares the number of rows, the column is not required.
+	AlertReduceCount = "count"
+)
+
+// IsValidAlertReduce reports whether the reduce of rows is defined.
+func IsValidAlertReduce(reduce string) bool {
+	switch reduce {
+	case AlertReduceFirst, AlertReduceMin, AlertReduceMax, AlertReduceSum, AlertReduceCount:
+		return true
+	}
+	return false
+}
+
+const (
+	AlertChannelEmail   = "email"
+	AlertChannelWebhook = "webhook"
+	AlertChannelInApp   = "in_app"
+)
+
+// IsValidAlertChannel reports whether the alert can be sent by the channel.
+func IsValidAlertChannel(channel string) bool {
+	return channel == AlertChannelEmail || channel == AlertChannelWebhook || channel == AlertChannelInApp
+}
+
+const (
+	// AlertStateOK is the state before the condition is met.
+	AlertStateOK = "ok"
+	// AlertStateTriggered is the state while the condition is met.
+	AlertStateTriggered = "triggered"
+	// AlertStateResolved is the state after the condition is not met any more, it
+	// becomes ok at the next evaluation if the condition is still not met.
+	AlertStateResolved = "resolved"
+)
+
+// AlertModel is a condition on the results of a saved query, the query is executed
+// every Interval minutes and the user is notified by Channels when the alert is
+// triggered or resolved.
+type AlertModel struct {
+	ID      uint   `json:"id" gorm:"primarykey"`
+	UserID  uint   `json:"user_id" gorm:"index:idx_alerts_user_id"`
+	QueryID uint   `json:"query_id" gorm:"index:idx_alerts_query_id"`
+	Name    string `json:"name"`
+	// Params are the values of placeholders of query.
+	Params    JSON    `json:"params" gorm:"type:json"`
+	Column    string  `json:"column"`
+	Reduce    string  `json:"reduce"`
+	Operator  string  `json:"operator"`
+	Threshold float64 `json:"threshold"`
+	// Interval is the minutes between evaluations.
+	Interval uint   `json:"interval"`
+	Channels Scopes `json:"channels" gorm:"type:text"`
+	Enabled  bool   `json:"enabled"`
+
+	State       string     `json:"state"`
+	LastValue   *float64   `json:"last_value"`
+	LastError   string     `json:"last_error" gorm:"type:text"`
+	LastRunAt   *time.Time `json:"last_run_at"`
+	TriggeredAt *time.Time `json:"triggered_at"`
+	NextRunAt   *time.Time `json:"next_run_at" gorm:"index:idx_alerts_next_run_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+func (AlertModel) TableName() string {
+	return "hyperdot_alerts"
+}
+
+// StringParams returns the params as the values of placeholders.
+func (m AlertModel) StringParams() map[string]string {
+	params := make(map[string]string, len(m.Params))
+	for name, value := range m.Params {
+		if text, ok := value.(string); ok {
+			params[name] = text
+		}
+	}
+	return params
+}
+
+// NextState returns the state after the condition is evaluated.
+func (m AlertModel) NextState(met bool) string {
+	if met {
+		return AlertStateTriggered
+	}
+	if m.State == AlertStateTriggered {
+		return AlertStateResolved
+	}
+	return AlertStateOK
+}
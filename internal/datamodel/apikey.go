@@ -0,0 +1,84 @@
+package datamodel
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Scopes is a list of scopes stored as comma separated text.
+type Scopes []string
+
+// Scan implements the Scanner interface.
+func (s *Scopes) Scan(value interface{}) error {
+	var text string
+	switch v := value.(type) {
+	case nil:
+		*s = nil
+		return nil
+	case []byte:
+		text = string(v)
+	case string:
+		text = v
+	default:
+		return errors.New(fmt.Sprint("Failed to unmarshal scopes value:", value))
+	}
+
+	if len(text) == 0 {
+		*s = nil
+		return nil
+	}
+
+	*s = strings.Split(text, ",")
+	return nil
+}
+
+// Value implements the driver Valuer interface.
+func (s Scopes) Value() (driver.Value, error) {
+	return strings.Join(s, ","), nil
+}
+
+// Has reports whether the scope is in the list.
+func (s Scopes) Has(scope string) bool {
+	for _, v := range s {
+		if v == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// ApiKeyModel is a personal api key of user, only the hash of key is stored.
+type ApiKeyModel struct {
+	ID     uint   `json:"id" gorm:"primarykey"`
+	UserID uint   `json:"user_id" gorm:"index:idx_api_keys_user_id"`
+	Name   string `json:"name"`
+	// Prefix is the beginning of key to tell keys apart.
+	Prefix     string     `json:"prefix"`
+	KeyHash    string     `json:"-" gorm:"uniqueIndex:idx_api_keys_key_hash"`
+	Scopes     Scopes     `json:"scopes" gorm:"type:text"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+func (ApiKeyModel) TableName() string {
+	return "hyperdot_api_keys"
+}
+
+// IsValid reports whether the key is neither revoked nor expired at now.
+func (m ApiKeyModel) IsValid(now time.Time) bool {
+	if m.RevokedAt != nil {
+		return false
+	}
+
+	if m.ExpiresAt != nil && !now.Before(*m.ExpiresAt) {
+		return false
+	}
+
+	return true
+}
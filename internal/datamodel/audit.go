@@ -0,0 +1,66 @@
+package datamodel
+
+import "time"
+
+const (
+	AuditUserLogin            = "user.login"
+	AuditUserLoginFailed      = "user.login_failed"
+	AuditUserPasswordChange   = "user.password_change"
+	AuditUserPasswordReset    = "user.password_reset"
+	AuditUserEmailChange      = "user.email_change"
+	AuditUserRoleChange       = "user.role_change"
+	AuditUserDisable          = "user.disable"
+	AuditUserEnable           = "user.enable"
+	AuditUserTwoFactorEnable  = "user.2fa_enable"
+	AuditUserTwoFactorDisable = "user.2fa_disable"
+	AuditUserRecoveryCodes    = "user.2fa_recovery_codes"
+	AuditUserDeletionRequest  = "user.deletion_request"
+	AuditUserDeletionCancel   = "user.deletion_cancel"
+	AuditUserDelete           = "user.delete"
+
+	AuditQueryCreate     = "query.create"
+	AuditQueryUpdate     = "query.update"
+	AuditQueryDelete     = "query.delete"
+	AuditQueryFavorite   = "query.favorite"
+	AuditQueryUnfavorite = "query.unfavorite"
+	AuditQueryUnpublish  = "query.unpublish"
+	AuditQueryRestore    = "query.restore"
+
+	AuditDashboardCreate     = "dashboard.create"
+	AuditDashboardUpdate     = "dashboard.update"
+	AuditDashboardDelete     = "dashboard.delete"
+	AuditDashboardFavorite   = "dashboard.favorite"
+	AuditDashboardUnfavorite = "dashboard.unfavorite"
+	AuditDashboardUnpublish  = "dashboard.unpublish"
+	AuditDashboardRestore    = "dashboard.restore"
+
+	AuditPanelCreate = "panel.create"
+	AuditPanelUpdate = "panel.update"
+	AuditPanelDelete = "panel.delete"
+)
+
+const (
+	AuditTargetUser      = "user"
+	AuditTargetQuery     = "query"
+	AuditTargetDashboard = "dashboard"
+	AuditTargetPanel     = "panel"
+)
+
+// AuditLogModel records an action of user, the Before and After are the changed
+// fields of target.
+type AuditLogModel struct {
+	ID         uint      `json:"id" gorm:"primarykey"`
+	ActorID    uint      `json:"actor_id" gorm:"index:idx_audit_logs_actor_id"`
+	Action     string    `json:"action" gorm:"index:idx_audit_logs_action"`
+	TargetType string    `json:"target_type" gorm:"index:idx_audit_logs_target"`
+	TargetID   uint      `json:"target_id" gorm:"index:idx_audit_logs_target"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	Before     JSON      `json:"before" gorm:"type:json"`
+	After      JSON      `json:"after" gorm:"type:json"`
+	CreatedAt  time.Time `json:"created_at" gorm:"index:idx_audit_logs_created_at"`
+}
+
+func (AuditLogModel) TableName() string {
+	return "hyperdot_audit_logs"
+}
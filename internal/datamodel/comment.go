@@ -0,0 +1,46 @@
+package datamodel
+
+import "time"
+
+const (
+	CommentTargetQuery     = "query"
+	CommentTargetDashboard = "dashboard"
+	CommentTargetPanel     = "panel"
+)
+
+// CommentModel is a markdown comment on a query, dashboard or dashboard panel.
+// The replies of a comment have its id as ParentID, and the threads are one level
+// deep. The comment with replies is kept as a placeholder after deleted.
+type CommentModel struct {
+	ID         uint       `json:"id" gorm:"primarykey"`
+	TargetType string     `json:"target_type" gorm:"index:idx_comments_target"`
+	TargetID   uint       `json:"target_id" gorm:"index:idx_comments_target"`
+	ParentID   uint       `json:"parent_id" gorm:"index:idx_comments_parent_id"`
+	UserID     uint       `json:"user_id" gorm:"index:idx_comments_user_id"`
+	Body       string     `json:"body" gorm:"type:text"`
+	EditedAt   *time.Time `json:"edited_at"`
+	DeletedAt  *time.Time `json:"deleted_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+func (CommentModel) TableName() string {
+	return "hyperdot_comments"
+}
+
+// IsDeleted reports whether the comment is a placeholder of deleted comment.
+func (m CommentModel) IsDeleted() bool {
+	return m.DeletedAt != nil
+}
+
+// CommentMentionModel is a user mentioned by @username in comment.
+type CommentMentionModel struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CommentID uint      `json:"comment_id" gorm:"index:idx_comment_mentions_comment_id"`
+	UserID    uint      `json:"user_id" gorm:"index:idx_comment_mentions_user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (CommentMentionModel) TableName() string {
+	return "hyperdot_comment_mentions"
+}
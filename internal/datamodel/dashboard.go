@@ -1,6 +1,10 @@
 package datamodel
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 // DashboardPanelModel represents a dashboard panel model ant the text is a json string.
 type DashboardPanelModel struct {
@@ -26,17 +30,27 @@ func (DashboardPanelModel) TableName() string {
 }
 
 // DashboardModel represents a dashboard model ant the config is a json string.
+//
+// A dashboard marked as template can be instantiated by duplicating it, the
+// placeholders such as {{chain_id}} in the dashboard, panels and queries will
+// be filled with the given parameters and the defaults in TemplateParams.
+// ForkedFromID is the dashboard duplicated from, it is zero if the dashboard is not a fork.
 type DashboardModel struct {
-	ID          uint                  `json:"id" gorm:"primarykey"`
-	UserID      uint                  `json:"user_id" gorm:"index:idx_user_query_user_id"`
-	Name        string                `json:"name"`
-	Description string                `json:"description"`
-	IsPrivacy   bool                  `json:"is_privacy"`
-	Tags        string                `json:"tags"`
-	Panels      []DashboardPanelModel `json:"panels" gorm:"-"`
-	CreatedAt   time.Time             `json:"created_At"`
-	UpdatedAt   time.Time             `json:"updated_At"`
-	DeletedAt   time.Time             `json:"deleted_at"`
+	ID             uint                  `json:"id" gorm:"primarykey"`
+	UserID         uint                  `json:"user_id" gorm:"index:idx_user_query_user_id"`
+	WorkspaceID    uint                  `json:"workspace_id" gorm:"index:idx_dashboards_workspace_id"`
+	Name           string                `json:"name"`
+	Description    string                `json:"description"`
+	IsPrivacy      bool                  `json:"is_privacy"`
+	Tags           string                `json:"tags"`
+	IsTemplate     bool                  `json:"is_template"`
+	TemplateParams JSON                  `json:"template_params" gorm:"type:json"`
+	ForkedFromID   uint                  `json:"forked_from_id"`
+	Panels         []DashboardPanelModel `json:"panels" gorm:"-"`
+	CreatedAt      time.Time             `json:"created_At"`
+	UpdatedAt      time.Time             `json:"updated_At"`
+	// DeletedAt is the time the dashboard is moved to trash, it is purged after the retention period.
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index:idx_dashboards_deleted_at" swaggertype:"string" format:"date-time"`
 }
 
 func (DashboardModel) TableName() string {
@@ -0,0 +1,16 @@
+package datamodel
+
+import "time"
+
+// UserFollowModel is a follow of user, the public contents of the followee
+// are listed in the feed of follower.
+type UserFollowModel struct {
+	ID         uint      `json:"id" gorm:"primarykey"`
+	FollowerID uint      `json:"follower_id" gorm:"uniqueIndex:idx_user_follows_pair"`
+	FolloweeID uint      `json:"followee_id" gorm:"uniqueIndex:idx_user_follows_pair;index:idx_user_follows_followee_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (UserFollowModel) TableName() string {
+	return "hyperdot_user_follows"
+}
@@ -0,0 +1,60 @@
+package datamodel
+
+import "time"
+
+const (
+	// NotificationFavorite is sent to the owner when a query or dashboard is favorited.
+	NotificationFavorite = "favorite"
+	// NotificationFork is sent to the owner when a dashboard is forked.
+	NotificationFork = "fork"
+	// NotificationComment is sent to the owner when a query, dashboard or panel is commented.
+	NotificationComment = "comment"
+	// NotificationReply is sent to the author when a comment is replied.
+	NotificationReply = "reply"
+	// NotificationMention is sent to the users mentioned in a comment.
+	NotificationMention = "mention"
+	// NotificationQueryFailed is sent to the owner when a scheduled run of query fails.
+	NotificationQueryFailed = "query_failed"
+	// NotificationAlert is sent to the owner when an alert is triggered or resolved.
+	NotificationAlert = "alert"
+)
+
+// NotificationTypes are all types of notifications.
+var NotificationTypes = []string{
+	NotificationFavorite,
+	NotificationFork,
+	NotificationComment,
+	NotificationReply,
+	NotificationMention,
+	NotificationQueryFailed,
+	NotificationAlert,
+}
+
+// IsValidNotificationType reports whether the notification type is defined.
+func IsValidNotificationType(notificationType string) bool {
+	for _, t := range NotificationTypes {
+		if t == notificationType {
+			return true
+		}
+	}
+	return false
+}
+
+// NotificationModel is an event notified to the user. The TargetType and TargetID
+// are the query, dashboard or panel of event, and Data holds its details, e.g. the
+// comment id.
+type NotificationModel struct {
+	ID         uint       `json:"id" gorm:"primarykey"`
+	UserID     uint       `json:"user_id" gorm:"index:idx_notifications_user_id"`
+	Type       string     `json:"type"`
+	ActorID    uint       `json:"actor_id"`
+	TargetType string     `json:"target_type"`
+	TargetID   uint       `json:"target_id"`
+	Data       JSON       `json:"data" gorm:"type:json"`
+	ReadAt     *time.Time `json:"read_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (NotificationModel) TableName() string {
+	return "hyperdot_notifications"
+}
@@ -0,0 +1,42 @@
+package datamodel
+
+import "time"
+
+const (
+	ContentTypeQuery     = "query"
+	ContentTypeDashboard = "dashboard"
+)
+
+const (
+	// PermissionViewer views the shared content.
+	PermissionViewer = "viewer"
+	// PermissionEditor views and updates the shared content.
+	PermissionEditor = "editor"
+)
+
+// IsValidContentType reports whether the content type can be shared.
+func IsValidContentType(contentType string) bool {
+	return contentType == ContentTypeQuery || contentType == ContentTypeDashboard
+}
+
+// IsValidPermission reports whether the permission is defined.
+func IsValidPermission(permission string) bool {
+	return permission == PermissionViewer || permission == PermissionEditor
+}
+
+// ContentPermissionModel grants a user the permission of a query or dashboard,
+// the private content is shared with the user by it.
+type ContentPermissionModel struct {
+	ID          uint      `json:"id" gorm:"primarykey"`
+	ContentType string    `json:"content_type" gorm:"uniqueIndex:idx_content_permissions_grantee"`
+	ContentID   uint      `json:"content_id" gorm:"uniqueIndex:idx_content_permissions_grantee"`
+	UserID      uint      `json:"user_id" gorm:"uniqueIndex:idx_content_permissions_grantee;index:idx_content_permissions_user_id"`
+	Permission  string    `json:"permission"`
+	GrantedBy   uint      `json:"granted_by"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (ContentPermissionModel) TableName() string {
+	return "hyperdot_content_permissions"
+}
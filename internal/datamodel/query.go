@@ -1,6 +1,10 @@
 package datamodel
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 // ChartModel represents a chart model ant the config is a json string.
 type ChartModel struct {
@@ -21,6 +25,7 @@ func (ChartModel) TableName() string {
 type QueryModel struct {
 	ID          uint         `json:"id" gorm:"primarykey"`
 	UserID      uint         `json:"user_id" gorm:"index:idx_user_query_user_id"`
+	WorkspaceID uint         `json:"workspace_id" gorm:"index:idx_queries_workspace_id"`
 	Name        string       `json:"name"`
 	Description string       `json:"description"`
 	Query       string       `json:"query"`
@@ -31,6 +36,8 @@ type QueryModel struct {
 	Charts      []ChartModel `json:"charts" gorm:"-"`
 	CreatedAt   time.Time    `json:"created_at"`
 	UpdatedAt   time.Time    `json:"updated_at"`
+	// DeletedAt is the time the query is moved to trash, it is purged after the retention period.
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index:idx_queries_deleted_at" swaggertype:"string" format:"date-time"`
 }
 
 func (QueryModel) TableName() string {
@@ -0,0 +1,28 @@
+package datamodel
+
+import "time"
+
+// UserSessionModel is a login session of user. The refresh token of session is
+// rotated on every refresh and only its hash is stored.
+type UserSessionModel struct {
+	ID                uint       `json:"id" gorm:"primarykey"`
+	UserID            uint       `json:"user_id" gorm:"index:idx_user_sessions_user_id"`
+	RefreshTokenHash  string     `json:"-" gorm:"uniqueIndex:idx_user_sessions_refresh_token_hash"`
+	PreviousTokenHash string     `json:"-" gorm:"index:idx_user_sessions_previous_token_hash"`
+	UserAgent         string     `json:"user_agent"`
+	IP                string     `json:"ip"`
+	ExpiresAt         time.Time  `json:"expires_at"`
+	RevokedAt         *time.Time `json:"revoked_at"`
+	RefreshedAt       time.Time  `json:"refreshed_at"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+func (UserSessionModel) TableName() string {
+	return "hyperdot_user_sessions"
+}
+
+// IsValid reports whether the session is neither revoked nor expired at now.
+func (m UserSessionModel) IsValid(now time.Time) bool {
+	return m.RevokedAt == nil && now.Before(m.ExpiresAt)
+}
@@ -0,0 +1,39 @@
+package datamodel
+
+import "time"
+
+// ShareTokenModel is a revocable and optionally expiring token which grants
+// read-only access of a dashboard or a single chart to guests, only the hash
+// of token is stored.
+type ShareTokenModel struct {
+	ID uint `json:"id" gorm:"primarykey"`
+	// Token is only responded when it is created.
+	Token string `json:"token,omitempty" gorm:"-"`
+	// Prefix is the beginning of token to tell tokens apart.
+	Prefix      string     `json:"prefix"`
+	TokenHash   string     `json:"-" gorm:"uniqueIndex:idx_share_tokens_token_hash"`
+	UserID      uint       `json:"user_id" gorm:"index:idx_share_tokens_user_id"`
+	DashboardID uint       `json:"dashboard_id"`
+	ChartID     uint       `json:"chart_id"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+func (ShareTokenModel) TableName() string {
+	return "hyperdot_share_tokens"
+}
+
+// IsValid reports whether the token is neither revoked nor expired at now.
+func (m ShareTokenModel) IsValid(now time.Time) bool {
+	if m.RevokedAt != nil {
+		return false
+	}
+
+	if m.ExpiresAt != nil && !now.Before(*m.ExpiresAt) {
+		return false
+	}
+
+	return true
+}
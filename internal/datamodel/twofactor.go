@@ -0,0 +1,40 @@
+package datamodel
+
+import "time"
+
+// UserTwoFactorModel is the TOTP second factor of user. The secret is pending
+// until the user verifies a code of it, and the login requires a code after it
+// is enabled.
+type UserTwoFactorModel struct {
+	ID     uint   `json:"id" gorm:"primarykey"`
+	UserID uint   `json:"user_id" gorm:"uniqueIndex:idx_user_two_factors_user_id"`
+	Secret string `json:"-"`
+	// LastUsedStep is the time step of the last accepted code, a code can not be used twice.
+	LastUsedStep int64      `json:"-"`
+	EnabledAt    *time.Time `json:"enabled_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+func (UserTwoFactorModel) TableName() string {
+	return "hyperdot_user_two_factors"
+}
+
+// IsEnabled reports whether the second factor is verified and enabled.
+func (m UserTwoFactorModel) IsEnabled() bool {
+	return m.EnabledAt != nil
+}
+
+// UserRecoveryCodeModel is a one-off code to login when the authenticator of
+// user is lost, only the hash of code is stored.
+type UserRecoveryCodeModel struct {
+	ID        uint       `json:"id" gorm:"primarykey"`
+	UserID    uint       `json:"user_id" gorm:"index:idx_user_recovery_codes_user_id"`
+	CodeHash  string     `json:"-"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (UserRecoveryCodeModel) TableName() string {
+	return "hyperdot_user_recovery_codes"
+}
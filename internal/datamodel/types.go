@@ -5,6 +5,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
+
+	"gorm.io/gorm"
 )
 
 // ArrayJSON represents a json array.
@@ -36,6 +39,11 @@ type JSON map[string]interface{}
 
 // Scan implements the Scanner interface.
 func (j *JSON) Scan(value interface{}) error {
+	if value == nil {
+		*j = nil
+		return nil
+	}
+
 	bytes, ok := value.([]byte)
 	if !ok {
 		return errors.New(fmt.Sprint("Failed to unmarshal JSONB value:", value))
@@ -55,3 +63,17 @@ func (j JSON) Value() (driver.Value, error) {
 
 	return json.Marshal(&j)
 }
+
+// MigrateZeroDeletedAt clears the zero deleted_at of the models saved before they
+// were soft deleted, otherwise the rows are regarded as deleted.
+func MigrateZeroDeletedAt(db *gorm.DB, models ...any) error {
+	for _, model := range models {
+		if err := db.Unscoped().Model(model).
+			Where("deleted_at < ?", time.Unix(0, 0)).
+			UpdateColumn("deleted_at", nil).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -7,19 +7,66 @@ import (
 	"time"
 
 	jwt "github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
 )
 
+const (
+	// RoleUser is the role of users signed up.
+	RoleUser = "user"
+	// RoleModerator is the role of users moderating the public contents.
+	RoleModerator = "moderator"
+	// RoleAdmin is the role of users managing the users and system.
+	RoleAdmin = "admin"
+)
+
+var roleLevels = map[string]int{
+	RoleUser:      1,
+	RoleModerator: 2,
+	RoleAdmin:     3,
+}
+
+// IsValidRole reports whether the role is defined.
+func IsValidRole(role string) bool {
+	_, ok := roleLevels[role]
+	return ok
+}
+
+// RoleIncludes reports whether the role has the permissions of required role,
+// e.g. admin includes moderator. The empty role is regarded as user.
+func RoleIncludes(role string, required string) bool {
+	if len(role) == 0 {
+		role = RoleUser
+	}
+	if len(required) == 0 {
+		required = RoleUser
+	}
+
+	return roleLevels[role] >= roleLevels[required]
+}
+
 // UserClaims is jwt auth claims
 type UserClaims struct {
 	UserID                           uint           `json:"user_id"`
+	SessionID                        uint           `json:"sid,omitempty"`
 	Provider                         string         `json:"provider,omitempty"`
 	Username                         string         `json:"username,omitempty"`
+	Role                             string         `json:"role,omitempty"`
 	LastLoginAt                      *time.Time     `json:"last_login,omitempty"`
 	LastActiveAt                     *time.Time     `json:"last_active,omitempty"`
 	LongestDistractionSinceLastLogin *time.Duration `json:"distraction_time,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// ActionClaims is jwt claims of the token for a one-off action of user,
+// e.g. email verification and password reset.
+type ActionClaims struct {
+	UserID uint   `json:"user_id"`
+	Email  string `json:"email,omitempty"`
+	// Fingerprint binds the token to the state of user, the token is invalid after the state changes.
+	Fingerprint string `json:"fp,omitempty"`
+	jwt.RegisteredClaims
+}
+
 // UserSignLog sign log
 type UserSignLog struct {
 	UserAgent string     `json:"user_agent"`
@@ -82,22 +129,53 @@ type UserBasic struct {
 type UserModel struct {
 	ID uint `gorm:"primarykey" json:"id"`
 	UserBasic
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	DeletedAt time.Time `json:"deleted_at"`
+	Role string `json:"role" gorm:"default:user"`
+	// GithubUID is the id of github account linked to the user, the user logins by
+	// the github account besides its own provider.
+	GithubUID string `json:"-" gorm:"index:idx_user_github_uid"`
+	// DisabledAt is the time the user is disabled by admin, the disabled user can not login.
+	DisabledAt *time.Time `json:"disabled_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	// DeletedAt is the time the account is purged, the deleted user is anonymized and
+	// excluded from queries.
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index:idx_user_deleted_at" swaggertype:"string" format:"date-time"`
+	// DeletionScheduledAt is the time the account requested to be deleted is purged,
+	// the user can cancel the deletion before it.
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at"`
+	// NotificationPreferences are the notification types enabled or disabled by the
+	// user, the types not in it are enabled.
+	NotificationPreferences JSON `json:"-" gorm:"type:json"`
 
 	UserSignLogs
 }
 
+// NotificationEnabled reports whether the user receives the notification type.
+func (model UserModel) NotificationEnabled(notificationType string) bool {
+	enabled, ok := model.NotificationPreferences[notificationType].(bool)
+	return !ok || enabled
+}
+
 // ToClaims convert to auth Claims
 func (model UserModel) ToClaims() *UserClaims {
 	claims := &UserClaims{}
 	claims.Provider = model.Provider
 	claims.Username = model.Username
+	claims.Role = model.Role
 	claims.UserID = model.ID
 	return claims
 }
 
+// IsDisabled reports whether the user is disabled by admin.
+func (model UserModel) IsDisabled() bool {
+	return model.DisabledAt != nil
+}
+
+// IsDeletionScheduled reports whether the user requested to delete the account.
+func (model UserModel) IsDeletionScheduled() bool {
+	return model.DeletionScheduledAt != nil
+}
+
 func (UserModel) TableName() string {
 	return "hyperdot_user"
 }
@@ -110,6 +188,8 @@ type UserStatistics struct {
 	Stars      uint `json:"stars"`
 	Queries    uint `json:"queries"`
 	Dashboards uint `json:"dashboards"`
+	Followers  uint `json:"followers"`
+	Following  uint `json:"following"`
 }
 
 func (UserStatistics) TableName() string {
@@ -0,0 +1,80 @@
+package datamodel
+
+import "time"
+
+const (
+	// WebhookQueryFinished fires when a saved query is executed.
+	WebhookQueryFinished = "query.finished"
+	// WebhookQueryFailed fires when the execution of a saved query fails.
+	WebhookQueryFailed = "query.failed"
+	// WebhookDashboardPublished fires when a dashboard is created public or made public.
+	WebhookDashboardPublished = "dashboard.published"
+	// WebhookFavoriteAdded fires when a query or dashboard is favorited.
+	WebhookFavoriteAdded = "favorite.added"
+	// WebhookAlertTriggered fires when an alert on query results is triggered.
+	WebhookAlertTriggered = "alert.triggered"
+	// WebhookAlertResolved fires when a triggered alert is resolved.
+	WebhookAlertResolved = "alert.resolved"
+	// WebhookPing is sent to test the webhook, it can not be subscribed.
+	WebhookPing = "ping"
+)
+
+// WebhookEvents are the events can be subscribed by webhooks.
+var WebhookEvents = []string{
+	WebhookQueryFinished,
+	WebhookQueryFailed,
+	WebhookDashboardPublished,
+	WebhookFavoriteAdded,
+	WebhookAlertTriggered,
+	WebhookAlertResolved,
+}
+
+// IsValidWebhookEvent reports whether the event can be subscribed.
+func IsValidWebhookEvent(event string) bool {
+	return Scopes(WebhookEvents).Has(event)
+}
+
+// WebhookModel is an url of user which the events of the user's contents are posted
+// to. The payloads are signed by Secret.
+type WebhookModel struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	UserID    uint      `json:"user_id" gorm:"index:idx_webhooks_user_id"`
+	Name      string    `json:"name"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"-"`
+	Events    Scopes    `json:"events" gorm:"type:text"`
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (WebhookModel) TableName() string {
+	return "hyperdot_webhooks"
+}
+
+const (
+	WebhookDeliveryPending = "pending"
+	WebhookDeliverySuccess = "success"
+	WebhookDeliveryFailed  = "failed"
+)
+
+// WebhookDeliveryModel is a delivery of event to webhook. The pending delivery is
+// attempted at NextAttemptAt until it succeeds or fails too many times.
+type WebhookDeliveryModel struct {
+	ID            uint       `json:"id" gorm:"primarykey"`
+	WebhookID     uint       `json:"webhook_id" gorm:"index:idx_webhook_deliveries_webhook_id"`
+	Event         string     `json:"event"`
+	Payload       string     `json:"payload" gorm:"type:text"`
+	Status        string     `json:"status" gorm:"index:idx_webhook_deliveries_pending,priority:1"`
+	Attempts      int        `json:"attempts"`
+	ResponseCode  int        `json:"response_code"`
+	Error         string     `json:"error" gorm:"type:text"`
+	NextAttemptAt *time.Time `json:"next_attempt_at" gorm:"index:idx_webhook_deliveries_pending,priority:2"`
+	DeliveredAt   *time.Time `json:"delivered_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+func (WebhookDeliveryModel) TableName() string {
+	return "hyperdot_webhook_deliveries"
+}
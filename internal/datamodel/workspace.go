@@ -0,0 +1,73 @@
+package datamodel
+
+import "time"
+
+const (
+	// WorkspaceRoleOwner manages the workspace and its members.
+	WorkspaceRoleOwner = "owner"
+	// WorkspaceRoleEditor creates, updates and deletes the contents of workspace.
+	WorkspaceRoleEditor = "editor"
+	// WorkspaceRoleViewer views the contents of workspace.
+	WorkspaceRoleViewer = "viewer"
+)
+
+var workspaceRoleLevels = map[string]int{
+	WorkspaceRoleViewer: 1,
+	WorkspaceRoleEditor: 2,
+	WorkspaceRoleOwner:  3,
+}
+
+// IsValidWorkspaceRole reports whether the workspace role is defined.
+func IsValidWorkspaceRole(role string) bool {
+	_, ok := workspaceRoleLevels[role]
+	return ok
+}
+
+// WorkspaceRoleIncludes reports whether the workspace role has the permissions
+// of required role, e.g. owner includes editor. The empty role is not a member.
+func WorkspaceRoleIncludes(role string, required string) bool {
+	level, ok := workspaceRoleLevels[role]
+	return ok && level >= workspaceRoleLevels[required]
+}
+
+// WorkspaceModel is a team which co-owns queries and dashboards.
+type WorkspaceModel struct {
+	ID          uint      `json:"id" gorm:"primarykey"`
+	UserID      uint      `json:"user_id" gorm:"index:idx_workspaces_user_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (WorkspaceModel) TableName() string {
+	return "hyperdot_workspaces"
+}
+
+// WorkspaceMemberModel is a member of workspace with role.
+type WorkspaceMemberModel struct {
+	ID          uint      `json:"id" gorm:"primarykey"`
+	WorkspaceID uint      `json:"workspace_id" gorm:"uniqueIndex:idx_workspace_members_member"`
+	UserID      uint      `json:"user_id" gorm:"uniqueIndex:idx_workspace_members_member;index:idx_workspace_members_user_id"`
+	Role        string    `json:"role"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (WorkspaceMemberModel) TableName() string {
+	return "hyperdot_workspace_members"
+}
+
+// WorkspaceStatistics hyperdot_workspace_statistics table and it store
+// workspace's statistics information.
+type WorkspaceStatistics struct {
+	ID          uint `json:"id" gorm:"primarykey"`
+	WorkspaceID uint `json:"workspace_id" gorm:"uniqueIndex:idx_workspace_statistics_workspace_id"`
+	Members     uint `json:"members"`
+	Queries     uint `json:"queries"`
+	Dashboards  uint `json:"dashboards"`
+}
+
+func (WorkspaceStatistics) TableName() string {
+	return "hyperdot_workspace_statistics"
+}
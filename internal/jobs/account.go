@@ -0,0 +1,370 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"infra-3.xyz/hyperdot-node/internal/apis/base"
+	"infra-3.xyz/hyperdot-node/internal/cache"
+	"infra-3.xyz/hyperdot-node/internal/clients"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+)
+
+const (
+	// accountBatchSize is the most accounts purged in a run.
+	accountBatchSize = 20
+	// avatarBucket is the s3 bucket of uploaded avatars.
+	avatarBucket = "hyperdot"
+)
+
+// AccountPurger is a job to purge the accounts whose deletion grace period is
+// over. The private contents of the user are deleted, the public contents are
+// kept with the anonymized user, and the uploaded avatar is removed.
+type AccountPurger struct {
+	db          *gorm.DB
+	s3Client    *clients.SimpleS3Cliet
+	revocations *cache.SessionRevocationList
+}
+
+// NewAccountPurger creates a new AccountPurger, the avatars are kept if s3Client is nil.
+func NewAccountPurger(db *gorm.DB, s3Client *clients.SimpleS3Cliet, revocations *cache.SessionRevocationList) *AccountPurger {
+	return &AccountPurger{
+		db:          db,
+		s3Client:    s3Client,
+		revocations: revocations,
+	}
+}
+
+// Do purges the accounts scheduled to be deleted before now.
+func (p *AccountPurger) Do() error {
+	var users []datamodel.UserModel
+	if err := p.db.Where("deletion_scheduled_at <= ?", time.Now()).
+		Order("deletion_scheduled_at ASC").
+		Limit(accountBatchSize).
+		Find(&users).Error; err != nil {
+		return err
+	}
+
+	for i := range users {
+		if err := p.Purge(&users[i]); err != nil {
+			log.Printf("Error purge user %d: %v", users[i].ID, err)
+		}
+	}
+
+	return nil
+}
+
+// Purge deletes the account of user now.
+func (p *AccountPurger) Purge(user *datamodel.UserModel) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	purged := false
+	err := p.db.Transaction(func(tx *gorm.DB) error {
+		// the deletion may be cancelled after the user is loaded
+		var locked datamodel.UserModel
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "icon_url").
+			Where("id = ? AND deletion_scheduled_at IS NOT NULL", user.ID).
+			First(&locked).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		// the avatar is removed before the user is anonymized, the purge is retried
+		// by the next run if it fails
+		if err := p.removeAvatar(ctx, locked.IconUrl); err != nil {
+			return err
+		}
+
+		steps := []func(tx *gorm.DB, userId uint) error{
+			leaveWorkspaces,
+			purgePrivateQueries,
+			purgePrivateDashboards,
+			purgeFavorites,
+			purgeFollows,
+			purgeUserData,
+			anonymizeUser,
+		}
+		for _, step := range steps {
+			if err := step(tx, user.ID); err != nil {
+				return err
+			}
+		}
+
+		purged = true
+		return tx.Create(&datamodel.AuditLogModel{
+			ActorID:    user.ID,
+			Action:     datamodel.AuditUserDelete,
+			TargetType: datamodel.AuditTargetUser,
+			TargetID:   user.ID,
+			CreatedAt:  time.Now(),
+		}).Error
+	})
+	if err != nil || !purged {
+		return err
+	}
+
+	// the access tokens are rejected before the sessions are deleted
+	if err := base.RevokeUserSessions(ctx, p.db, p.revocations, user.ID); err != nil {
+		return err
+	}
+	return p.db.Where("user_id = ?", user.ID).Delete(&datamodel.UserSessionModel{}).Error
+}
+
+// removeAvatar removes the uploaded avatar from s3, the avatar of oauth provider is
+// not stored in s3.
+func (p *AccountPurger) removeAvatar(ctx context.Context, iconUrl string) error {
+	if p.s3Client == nil || len(iconUrl) == 0 ||
+		strings.HasPrefix(iconUrl, "http://") || strings.HasPrefix(iconUrl, "https://") {
+		return nil
+	}
+
+	if err := p.s3Client.Remove(ctx, avatarBucket, iconUrl); err != nil {
+		return fmt.Errorf("remove avatar %s: %w", iconUrl, err)
+	}
+	return nil
+}
+
+// leaveWorkspaces removes the user from workspaces. The workspace left by its last
+// owner is owned by the earliest member, and the workspace without members is
+// deleted with its contents moved back to their creators.
+func leaveWorkspaces(tx *gorm.DB, userId uint) error {
+	var members []datamodel.WorkspaceMemberModel
+	if err := tx.Where("user_id = ?", userId).Find(&members).Error; err != nil {
+		return err
+	}
+
+	for _, member := range members {
+		if err := tx.Delete(&member).Error; err != nil {
+			return err
+		}
+
+		var next datamodel.WorkspaceMemberModel
+		err := tx.Where("workspace_id = ?", member.WorkspaceID).Order("id ASC").First(&next).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if err := deleteWorkspace(tx, member.WorkspaceID); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		var owners int64
+		if err := tx.Model(&datamodel.WorkspaceMemberModel{}).
+			Where("workspace_id = ? AND role = ?", member.WorkspaceID, datamodel.WorkspaceRoleOwner).
+			Count(&owners).Error; err != nil {
+			return err
+		}
+		if owners == 0 {
+			if err := tx.Model(&next).Update("role", datamodel.WorkspaceRoleOwner).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := base.RefreshWorkspaceStatistics(tx, member.WorkspaceID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteWorkspace deletes the workspace and moves its contents back to their creators.
+func deleteWorkspace(tx *gorm.DB, workspaceId uint) error {
+	if err := tx.Unscoped().Model(&datamodel.QueryModel{}).Where("workspace_id = ?", workspaceId).
+		Update("workspace_id", 0).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Unscoped().Model(&datamodel.DashboardModel{}).Where("workspace_id = ?", workspaceId).
+		Update("workspace_id", 0).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("workspace_id = ?", workspaceId).Delete(&datamodel.WorkspaceStatistics{}).Error; err != nil {
+		return err
+	}
+
+	return tx.Where("id = ?", workspaceId).Delete(&datamodel.WorkspaceModel{}).Error
+}
+
+// purgePrivateQueries deletes the private, unsaved and trashed personal queries of
+// user with their charts, permissions, comments and favorites. The queries of
+// workspaces are kept for the other members.
+func purgePrivateQueries(tx *gorm.DB, userId uint) error {
+	var ids []uint
+	if err := tx.Unscoped().Model(&datamodel.QueryModel{}).
+		Where("user_id = ? AND workspace_id = 0 AND (is_privacy = TRUE OR unsaved = TRUE OR deleted_at IS NOT NULL)", userId).
+		Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+
+	return base.PurgeQueries(tx, ids...)
+}
+
+// purgePrivateDashboards deletes the private and trashed personal dashboards of user
+// with their panels, permissions, comments, favorites and share tokens.
+func purgePrivateDashboards(tx *gorm.DB, userId uint) error {
+	var ids []uint
+	if err := tx.Unscoped().Model(&datamodel.DashboardModel{}).
+		Where("user_id = ? AND workspace_id = 0 AND (is_privacy = TRUE OR deleted_at IS NOT NULL)", userId).
+		Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+
+	return base.PurgeDashboards(tx, ids...)
+}
+
+// purgeFavorites deletes the favorites of user, withdraws the stars of the contents
+// and refreshes the statistics of their owners.
+func purgeFavorites(tx *gorm.DB, userId uint) error {
+	var owners []uint
+	var queryIds []uint
+	if err := tx.Model(&datamodel.UserQueryFavorites{}).
+		Where("user_id = ? AND stared = TRUE", userId).
+		Pluck("query_id", &queryIds).Error; err != nil {
+		return err
+	}
+
+	for _, id := range queryIds {
+		var query datamodel.QueryModel
+		if err := tx.Select("id", "user_id").Where("id = ?", id).First(&query).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			return err
+		}
+
+		if err := withdrawStar(tx, &datamodel.QueryModel{}, id); err != nil {
+			return err
+		}
+		owners = append(owners, query.UserID)
+	}
+
+	var dashboardIds []uint
+	if err := tx.Model(&datamodel.UserDashboardFavorites{}).
+		Where("user_id = ? AND stared = TRUE", userId).
+		Pluck("dashboard_id", &dashboardIds).Error; err != nil {
+		return err
+	}
+
+	for _, id := range dashboardIds {
+		var dashboard datamodel.DashboardModel
+		if err := tx.Select("id", "user_id").Where("id = ?", id).First(&dashboard).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			return err
+		}
+
+		if err := withdrawStar(tx, &datamodel.DashboardModel{}, id); err != nil {
+			return err
+		}
+		owners = append(owners, dashboard.UserID)
+	}
+
+	if err := tx.Where("user_id = ?", userId).Delete(&datamodel.UserQueryFavorites{}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("user_id = ?", userId).Delete(&datamodel.UserDashboardFavorites{}).Error; err != nil {
+		return err
+	}
+
+	return base.RefreshContentStatistics(tx, owners...)
+}
+
+// withdrawStar decreases the stars of content.
+func withdrawStar(tx *gorm.DB, model any, id uint) error {
+	return tx.Model(model).Where("id = ? AND stars > 0", id).
+		UpdateColumn("stars", gorm.Expr("stars - 1")).Error
+}
+
+// purgeFollows deletes the follows of and to user and refreshes the statistics of
+// the other users.
+func purgeFollows(tx *gorm.DB, userId uint) error {
+	var follows []datamodel.UserFollowModel
+	if err := tx.Where("follower_id = ? OR followee_id = ?", userId, userId).Find(&follows).Error; err != nil {
+		return err
+	}
+	if len(follows) == 0 {
+		return nil
+	}
+
+	if err := tx.Where("follower_id = ? OR followee_id = ?", userId, userId).Delete(&datamodel.UserFollowModel{}).Error; err != nil {
+		return err
+	}
+
+	others := make([]uint, 0, len(follows))
+	for _, follow := range follows {
+		if follow.FollowerID == userId {
+			others = append(others, follow.FolloweeID)
+		} else {
+			others = append(others, follow.FollowerID)
+		}
+	}
+	return base.RefreshFollowStatistics(tx, others...)
+}
+
+// purgeUserData deletes the credentials, settings and personal records of user.
+func purgeUserData(tx *gorm.DB, userId uint) error {
+	webhooks := tx.Model(&datamodel.WebhookModel{}).Select("id").Where("user_id = ?", userId)
+	if err := tx.Where("webhook_id IN (?)", webhooks).Delete(&datamodel.WebhookDeliveryModel{}).Error; err != nil {
+		return err
+	}
+
+	models := []any{
+		&datamodel.ApiKeyModel{},
+		&datamodel.UserTwoFactorModel{},
+		&datamodel.UserRecoveryCodeModel{},
+		&datamodel.ShareTokenModel{},
+		&datamodel.WebhookModel{},
+		&datamodel.AlertModel{},
+		&datamodel.NotificationModel{},
+		&datamodel.CommentMentionModel{},
+		&datamodel.ContentPermissionModel{},
+		&datamodel.UserStatistics{},
+	}
+	for _, model := range models {
+		if err := tx.Where("user_id = ?", userId).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// anonymizeUser clears the personal information of user and marks it deleted, so
+// the username, email and oauth identity can be used by new accounts.
+func anonymizeUser(tx *gorm.DB, userId uint) error {
+	return tx.Model(&datamodel.UserModel{}).Where("id = ?", userId).UpdateColumns(map[string]any{
+		"uid":                      "",
+		"encrypted_password":       "",
+		"username":                 fmt.Sprintf("deleted-user-%d", userId),
+		"email":                    "",
+		"bio":                      "",
+		"icon_url":                 "",
+		"twitter":                  "",
+		"github":                   "",
+		"github_uid":               "",
+		"telgram":                  "",
+		"discord":                  "",
+		"location":                 "",
+		"confirmed_at":             nil,
+		"notification_preferences": nil,
+		"user_sign_logs":           datamodel.UserSignLogs{},
+		"deletion_scheduled_at":    nil,
+		"deleted_at":               time.Now(),
+	}).Error
+}
@@ -0,0 +1,328 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+
+	"infra-3.xyz/hyperdot-node/internal/apis/base"
+	"infra-3.xyz/hyperdot-node/internal/dataengine"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+	"infra-3.xyz/hyperdot-node/internal/mailer"
+	"infra-3.xyz/hyperdot-node/internal/utils"
+)
+
+const (
+	// alertBatchSize is the most alerts evaluated in a run.
+	alertBatchSize = 100
+	// alertTimeout is the timeout of executing the query of alert.
+	alertTimeout = 5 * time.Minute
+)
+
+// ReduceAlertValue reduces the column of rows to the value compared by alert, the
+// count of rows is 0 and the sum of rows is 0 if there is no row.
+func ReduceAlertValue(rows []map[string]interface{}, column string, reduce string) (float64, error) {
+	if reduce == datamodel.AlertReduceCount {
+		return float64(len(rows)), nil
+	}
+
+	if len(rows) == 0 {
+		if reduce == datamodel.AlertReduceSum {
+			return 0, nil
+		}
+		return 0, errors.New("query returns no rows")
+	}
+
+	var result float64
+	for i, row := range rows {
+		value, err := toAlertValue(row, column)
+		if err != nil {
+			return 0, err
+		}
+
+		switch {
+		case i == 0:
+			result = value
+		case reduce == datamodel.AlertReduceMin && value < result:
+			result = value
+		case reduce == datamodel.AlertReduceMax && value > result:
+			result = value
+		case reduce == datamodel.AlertReduceSum:
+			result += value
+		}
+
+		if reduce == datamodel.AlertReduceFirst || len(reduce) == 0 {
+			break
+		}
+	}
+
+	return result, nil
+}
+
+// toAlertValue converts the column of row to number.
+func toAlertValue(row map[string]interface{}, column string) (float64, error) {
+	value, ok := row[column]
+	if !ok {
+		return 0, fmt.Errorf("column %s not found", column)
+	}
+
+	switch v := value.(type) {
+	case int:
+		return float64(v), nil
+	case int32:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
+	case float32:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, fmt.Errorf("column %s is not a number: %s", column, v)
+		}
+		return f, nil
+	case fmt.Stringer:
+		f, err := strconv.ParseFloat(v.String(), 64)
+		if err != nil {
+			return 0, fmt.Errorf("column %s is not a number: %s", column, v)
+		}
+		return f, nil
+	case nil:
+		return 0, fmt.Errorf("column %s is null", column)
+	default:
+		return 0, fmt.Errorf("column %s is not a number: %v", column, v)
+	}
+}
+
+// CompareAlertValue reports whether the value meets the condition of alert.
+func CompareAlertValue(value float64, operator string, threshold float64) bool {
+	switch operator {
+	case datamodel.AlertOperatorGT:
+		return value > threshold
+	case datamodel.AlertOperatorGTE:
+		return value >= threshold
+	case datamodel.AlertOperatorLT:
+		return value < threshold
+	case datamodel.AlertOperatorLTE:
+		return value <= threshold
+	case datamodel.AlertOperatorEQ:
+		return value == threshold
+	case datamodel.AlertOperatorNE:
+		return value != threshold
+	}
+	return false
+}
+
+// AlertEvaluator is a job to execute the queries of due alerts and evaluate their
+// conditions. The users are notified only when the alerts are triggered or resolved,
+// or when the queries start to fail.
+type AlertEvaluator struct {
+	db      *gorm.DB
+	engines map[string]dataengine.QueryEngine
+	mailer  mailer.Mailer
+}
+
+// NewAlertEvaluator creates a new AlertEvaluator.
+func NewAlertEvaluator(db *gorm.DB, engines map[string]dataengine.QueryEngine, mailer mailer.Mailer) *AlertEvaluator {
+	return &AlertEvaluator{
+		db:      db,
+		engines: engines,
+		mailer:  mailer,
+	}
+}
+
+// Do evaluates the alerts due now.
+func (e *AlertEvaluator) Do() error {
+	// the alerts of trashed queries are paused until the queries are restored
+	var alerts []datamodel.AlertModel
+	if err := e.db.Where("enabled = ? AND next_run_at <= ?", true, time.Now()).
+		Where("query_id IN (?)", e.db.Model(&datamodel.QueryModel{}).Select("id")).
+		Order("next_run_at ASC").
+		Limit(alertBatchSize).
+		Find(&alerts).Error; err != nil {
+		return err
+	}
+
+	for i := range alerts {
+		claimed, err := e.claim(&alerts[i])
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			continue
+		}
+
+		if err := e.evaluate(&alerts[i]); err != nil {
+			log.Printf("Error save alert %d: %v", alerts[i].ID, err)
+		}
+	}
+
+	return nil
+}
+
+// claim schedules the next run of alert so that it is not evaluated by other nodes
+// at the same time, it returns false if the alert has been claimed.
+func (e *AlertEvaluator) claim(alert *datamodel.AlertModel) (bool, error) {
+	next := time.Now().Add(time.Duration(alert.Interval) * time.Minute)
+	result := e.db.Model(&datamodel.AlertModel{}).
+		Where("id = ? AND next_run_at = ?", alert.ID, alert.NextRunAt).
+		Update("next_run_at", next)
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// evaluate executes the query of alert, updates its state and notifies the user.
+func (e *AlertEvaluator) evaluate(alert *datamodel.AlertModel) error {
+	now := time.Now()
+	updates := map[string]any{
+		"last_run_at": now,
+	}
+
+	value, err := e.run(alert)
+	if err != nil {
+		updates["last_error"] = truncateError(err)
+		if err := e.db.Model(alert).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		// the user is notified once until the query succeeds again
+		if len(alert.LastError) == 0 {
+			e.notify(alert, datamodel.NotificationQueryFailed, datamodel.WebhookQueryFailed, map[string]any{
+				"error": err.Error(),
+			})
+		}
+		return nil
+	}
+
+	state := alert.NextState(CompareAlertValue(value, alert.Operator, alert.Threshold))
+	updates["state"] = state
+	updates["last_value"] = value
+	updates["last_error"] = ""
+	if state == datamodel.AlertStateTriggered && alert.State != datamodel.AlertStateTriggered {
+		updates["triggered_at"] = now
+	}
+	if err := e.db.Model(alert).Updates(updates).Error; err != nil {
+		return err
+	}
+
+	data := map[string]any{
+		"state":     state,
+		"value":     value,
+		"operator":  alert.Operator,
+		"threshold": alert.Threshold,
+	}
+	switch {
+	case state == datamodel.AlertStateTriggered && alert.State != datamodel.AlertStateTriggered:
+		e.notify(alert, datamodel.NotificationAlert, datamodel.WebhookAlertTriggered, data)
+	case state == datamodel.AlertStateResolved:
+		e.notify(alert, datamodel.NotificationAlert, datamodel.WebhookAlertResolved, data)
+	}
+
+	return nil
+}
+
+// run executes the query of alert and reduces the result, the user must still be
+// able to view the query.
+func (e *AlertEvaluator) run(alert *datamodel.AlertModel) (float64, error) {
+	var query datamodel.QueryModel
+	if err := e.db.Where("id = ?", alert.QueryID).First(&query).Error; err != nil {
+		return 0, err
+	}
+
+	access, err := base.GetContentAccess(e.db, alert.UserID, base.QueryContent(&query))
+	if err != nil {
+		return 0, err
+	}
+	if access < base.ContentAccessView {
+		return 0, fmt.Errorf("query %d is not accessible", query.ID)
+	}
+
+	sql, err := utils.RenderSafeTemplate(query.Query, alert.StringParams())
+	if err != nil {
+		return 0, err
+	}
+
+	engine, ok := e.engines[query.QueryEngine]
+	if !ok {
+		return 0, fmt.Errorf("the %s query engine unsupported now", query.QueryEngine)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), alertTimeout)
+	defer cancel()
+
+	result, err := dataengine.Execute(ctx, engine, sql)
+	if err != nil {
+		return 0, err
+	}
+
+	return ReduceAlertValue(result.Rows, alert.Column, alert.Reduce)
+}
+
+// notify sends the event of alert by its channels.
+func (e *AlertEvaluator) notify(alert *datamodel.AlertModel, notificationType string, event string, data map[string]any) {
+	data["alert_id"] = alert.ID
+	data["name"] = alert.Name
+	data["query_id"] = alert.QueryID
+
+	if alert.Channels.Has(datamodel.AlertChannelInApp) {
+		base.Notify(e.db, datamodel.NotificationModel{
+			UserID:     alert.UserID,
+			Type:       notificationType,
+			TargetType: datamodel.ContentTypeQuery,
+			TargetID:   alert.QueryID,
+			Data:       datamodel.JSON(data),
+		})
+	}
+
+	if alert.Channels.Has(datamodel.AlertChannelWebhook) {
+		base.FireWebhooks(e.db, alert.UserID, event, data)
+	}
+
+	if alert.Channels.Has(datamodel.AlertChannelEmail) {
+		if err := e.sendEmail(alert, event, data); err != nil {
+			log.Printf("Error send email of alert %d: %v", alert.ID, err)
+		}
+	}
+}
+
+// sendEmail mails the event of alert to the verified email of user.
+func (e *AlertEvaluator) sendEmail(alert *datamodel.AlertModel, event string, data map[string]any) error {
+	var user datamodel.UserModel
+	if err := e.db.Select("id", "email", "confirmed_at").Where("id = ?", alert.UserID).First(&user).Error; err != nil {
+		return err
+	}
+
+	if user.ConfirmedAt == nil || len(user.Email) == 0 {
+		return nil
+	}
+
+	msg := mailer.Message{To: user.Email}
+	switch event {
+	case datamodel.WebhookQueryFailed:
+		msg.Subject = fmt.Sprintf("[Hyperdot] The query of alert %s failed", alert.Name)
+		msg.Body = fmt.Sprintf("The query %d of alert %s failed: %s", alert.QueryID, alert.Name, data["error"])
+	case datamodel.WebhookAlertTriggered:
+		msg.Subject = fmt.Sprintf("[Hyperdot] Alert %s triggered", alert.Name)
+		msg.Body = fmt.Sprintf("The alert %s is triggered, %s of %s is %v which is %s %v.",
+			alert.Name, alert.Reduce, alert.Column, data["value"], alert.Operator, alert.Threshold)
+	default:
+		msg.Subject = fmt.Sprintf("[Hyperdot] Alert %s resolved", alert.Name)
+		msg.Body = fmt.Sprintf("The alert %s is resolved, %s of %s is %v.", alert.Name, alert.Reduce, alert.Column, data["value"])
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	return e.mailer.Send(ctx, &msg)
+}
@@ -1,20 +1,47 @@
 package jobs
 
 import (
+	"errors"
 	"log"
 	"sync/atomic"
 
+	"gorm.io/gorm"
+
+	"infra-3.xyz/hyperdot-node/internal/cache"
+	"infra-3.xyz/hyperdot-node/internal/clients"
+	"infra-3.xyz/hyperdot-node/internal/dataengine"
+	"infra-3.xyz/hyperdot-node/internal/mailer"
 	"infra-3.xyz/hyperdot-node/internal/store"
 
 	"github.com/jasonlvhit/gocron"
 	"infra-3.xyz/hyperdot-node/internal/common"
 )
 
+// ErrJobRunning is returned when the job is triggered while it is running.
+var ErrJobRunning = errors.New("job is running")
+
 // JobManager is controller of all jobs and can timing execute jobs
 type JobManager struct {
 	total          *atomic.Uint64
 	cfg            common.Config
 	bigquerySyncer *BigQuerySyncer
+	syncing        *atomic.Bool
+
+	webhookDeliverer *WebhookDeliverer
+	delivering       *atomic.Bool
+	pruning          *atomic.Bool
+
+	alertEvaluator *AlertEvaluator
+	evaluating     *atomic.Bool
+
+	accountPurger *AccountPurger
+	purging       *atomic.Bool
+
+	trashPurger  *TrashPurger
+	purgingTrash *atomic.Bool
+
+	statisticsReconciler *StatisticsReconciler
+	reconciling          *atomic.Bool
 }
 
 // NewJobManager creates a new JobManager
@@ -22,29 +49,217 @@ func NewJobManager(cfg *common.Config) *JobManager {
 	total := atomic.Uint64{}
 	total.Store(0)
 	return &JobManager{
-		cfg:   *cfg,
-		total: &total,
+		cfg:          *cfg,
+		total:        &total,
+		syncing:      &atomic.Bool{},
+		delivering:   &atomic.Bool{},
+		pruning:      &atomic.Bool{},
+		evaluating:   &atomic.Bool{},
+		purging:      &atomic.Bool{},
+		purgingTrash: &atomic.Bool{},
+		reconciling:  &atomic.Bool{},
 	}
 }
 
 // Init initializes the job manager
 // It starts theses jobs
 //  1. bigquery syncer
-func (j *JobManager) Init(boltStore *store.BoltStore) (err error) {
+//  2. webhook deliverer, it also prunes the delivery log
+//  3. alert evaluator
+//  4. account purger
+//  5. trash purger
+//  6. statistics reconciler
+func (j *JobManager) Init(boltStore *store.BoltStore, db *gorm.DB, engines map[string]dataengine.QueryEngine, s3Client *clients.SimpleS3Cliet) (err error) {
 	if j.bigquerySyncer, err = NewBigQuerySyncer(&j.cfg, boltStore); err != nil {
 		return
 	}
+	mail, err := mailer.New(&j.cfg.Mail)
+	if err != nil {
+		return err
+	}
+	j.webhookDeliverer = NewWebhookDeliverer(db, NewWebhookClient(j.cfg.Webhook.AllowLoopback), j.cfg.Webhook.DeliveryRetention())
+	j.alertEvaluator = NewAlertEvaluator(db, engines, mail)
+	j.accountPurger = NewAccountPurger(db, s3Client, cache.NewSessionRevocationList(&j.cfg.Redis))
+	j.trashPurger = NewTrashPurger(db, j.cfg.Trash.Retention())
+	j.statisticsReconciler = NewStatisticsReconciler(db)
 
 	err = gocron.Every(1).Day().From(gocron.NextTick()).Do(func() {
-		if err := j.bigquerySyncer.Do(); err != nil {
+		if err := j.SyncBigQuery(); err != nil {
 			log.Printf("Error fetching bigquery engine chaindata: %v", err)
 			return
 		}
 	})
+	if err != nil {
+		return
+	}
+
+	if err = gocron.Every(5).Seconds().Do(j.deliverWebhooks); err != nil {
+		return
+	}
+
+	if err = gocron.Every(1).Hour().Do(j.pruneWebhookDeliveries); err != nil {
+		return
+	}
+
+	if err = gocron.Every(1).Minute().Do(j.evaluateAlerts); err != nil {
+		return
+	}
+
+	if err = gocron.Every(1).Hour().Do(j.purgeAccounts); err != nil {
+		return
+	}
 
+	if err = gocron.Every(1).Hour().Do(j.purgeTrash); err != nil {
+		return
+	}
+
+	err = gocron.Every(1).Day().Do(j.reconcileStatistics)
 	return err
 }
 
+// deliverWebhooks posts the pending webhook deliveries, it is skipped if the last
+// run is not finished.
+func (j *JobManager) deliverWebhooks() {
+	if !j.delivering.CompareAndSwap(false, true) {
+		return
+	}
+	defer j.delivering.Store(false)
+
+	if err := j.webhookDeliverer.Do(); err != nil {
+		log.Printf("Error delivering webhooks: %v", err)
+	}
+}
+
+// pruneWebhookDeliveries deletes the finished webhook deliveries whose retention is
+// over, it is skipped if the last run is not finished.
+func (j *JobManager) pruneWebhookDeliveries() {
+	if !j.pruning.CompareAndSwap(false, true) {
+		return
+	}
+	defer j.pruning.Store(false)
+
+	if _, err := j.webhookDeliverer.Prune(); err != nil {
+		log.Printf("Error pruning webhook deliveries: %v", err)
+	}
+}
+
+// evaluateAlerts evaluates the due alerts, it is skipped if the last run is not
+// finished.
+func (j *JobManager) evaluateAlerts() {
+	if !j.evaluating.CompareAndSwap(false, true) {
+		return
+	}
+	defer j.evaluating.Store(false)
+
+	if err := j.alertEvaluator.Do(); err != nil {
+		log.Printf("Error evaluating alerts: %v", err)
+	}
+}
+
+// purgeAccounts purges the accounts whose deletion grace period is over, it is
+// skipped if the last run is not finished.
+func (j *JobManager) purgeAccounts() {
+	if !j.purging.CompareAndSwap(false, true) {
+		return
+	}
+	defer j.purging.Store(false)
+
+	if err := j.accountPurger.Do(); err != nil {
+		log.Printf("Error purging accounts: %v", err)
+	}
+}
+
+// purgeTrash purges the queries and dashboards whose trash retention is over, it
+// is skipped if the last run is not finished.
+func (j *JobManager) purgeTrash() {
+	if !j.purgingTrash.CompareAndSwap(false, true) {
+		return
+	}
+	defer j.purgingTrash.Store(false)
+
+	if err := j.trashPurger.Do(); err != nil {
+		log.Printf("Error purging trash: %v", err)
+	}
+}
+
+// reconcileStatistics recounts the statistics of users, it is skipped if the last
+// run is not finished.
+func (j *JobManager) reconcileStatistics() {
+	if !j.reconciling.CompareAndSwap(false, true) {
+		return
+	}
+	defer j.reconciling.Store(false)
+
+	j.doReconcileStatistics()
+}
+
+// doReconcileStatistics runs the statistics reconciler and logs the result.
+func (j *JobManager) doReconcileStatistics() {
+	fixed, err := j.statisticsReconciler.Do()
+	if err != nil {
+		log.Printf("Error reconciling user statistics: %v", err)
+		return
+	}
+	if fixed > 0 {
+		log.Printf("Fixed the statistics of %d users", fixed)
+	}
+}
+
+// TriggerStatisticsReconcile starts to recount the statistics of users in background,
+// it returns ErrJobRunning if the reconcile is running.
+func (j *JobManager) TriggerStatisticsReconcile() error {
+	if j.statisticsReconciler == nil {
+		return errors.New("job manager is not initialized")
+	}
+
+	if !j.reconciling.CompareAndSwap(false, true) {
+		return ErrJobRunning
+	}
+
+	go func() {
+		defer j.reconciling.Store(false)
+		j.doReconcileStatistics()
+	}()
+
+	return nil
+}
+
+// SyncBigQuery syncs the bigquery engine chaindata now, it returns ErrJobRunning
+// if the sync is running.
+func (j *JobManager) SyncBigQuery() error {
+	if j.bigquerySyncer == nil {
+		return errors.New("job manager is not initialized")
+	}
+
+	if !j.syncing.CompareAndSwap(false, true) {
+		return ErrJobRunning
+	}
+	defer j.syncing.Store(false)
+
+	return j.bigquerySyncer.Do()
+}
+
+// TriggerBigQuerySync starts to sync the bigquery engine chaindata in background,
+// it returns ErrJobRunning if the sync is running.
+func (j *JobManager) TriggerBigQuerySync() error {
+	if j.bigquerySyncer == nil {
+		return errors.New("job manager is not initialized")
+	}
+
+	if !j.syncing.CompareAndSwap(false, true) {
+		return ErrJobRunning
+	}
+
+	go func() {
+		defer j.syncing.Store(false)
+		if err := j.bigquerySyncer.Do(); err != nil {
+			log.Printf("Error fetching bigquery engine chaindata: %v", err)
+		}
+	}()
+
+	return nil
+}
+
 // Start starts the job manager
 func (j *JobManager) Start() <-chan bool {
 	return gocron.Start()
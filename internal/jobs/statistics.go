@@ -0,0 +1,88 @@
+package jobs
+
+import (
+	"gorm.io/gorm"
+
+	"infra-3.xyz/hyperdot-node/internal/apis/base"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+)
+
+// statisticsBatchSize is the most users reconciled in a transaction.
+const statisticsBatchSize = 100
+
+// StatisticsReconciler is a job to recount the statistics of users from the queries,
+// dashboards, favorites and follows, it fixes the statistics drifted by the changes
+// made before they were maintained transactionally or out of the apis.
+type StatisticsReconciler struct {
+	db *gorm.DB
+}
+
+// NewStatisticsReconciler creates a new StatisticsReconciler.
+func NewStatisticsReconciler(db *gorm.DB) *StatisticsReconciler {
+	return &StatisticsReconciler{
+		db: db,
+	}
+}
+
+// Do recounts the statistics of all users, it returns the number of users whose
+// statistics are fixed.
+func (r *StatisticsReconciler) Do() (int, error) {
+	fixed := 0
+	lastId := uint(0)
+	for {
+		var ids []uint
+		if err := r.db.Model(&datamodel.UserModel{}).
+			Where("id > ?", lastId).
+			Order("id ASC").
+			Limit(statisticsBatchSize).
+			Pluck("id", &ids).Error; err != nil {
+			return fixed, err
+		}
+		if len(ids) == 0 {
+			return fixed, nil
+		}
+		lastId = ids[len(ids)-1]
+
+		err := r.db.Transaction(func(tx *gorm.DB) error {
+			before, err := loadStatistics(tx, ids)
+			if err != nil {
+				return err
+			}
+
+			if err := base.RefreshUserStatistics(tx, ids...); err != nil {
+				return err
+			}
+
+			after, err := loadStatistics(tx, ids)
+			if err != nil {
+				return err
+			}
+
+			for id, statistics := range after {
+				if before[id] != statistics {
+					fixed++
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return fixed, err
+		}
+	}
+}
+
+// loadStatistics loads the statistics of users by user id, the ids of statistics are
+// cleared to compare the counts only.
+func loadStatistics(tx *gorm.DB, userIds []uint) (map[uint]datamodel.UserStatistics, error) {
+	var statistics []datamodel.UserStatistics
+	if err := tx.Where("user_id IN ?", userIds).Find(&statistics).Error; err != nil {
+		return nil, err
+	}
+
+	loaded := make(map[uint]datamodel.UserStatistics, len(statistics))
+	for _, s := range statistics {
+		s.ID = 0
+		loaded[s.UserId] = s
+	}
+	return loaded, nil
+}
@@ -0,0 +1,65 @@
+package jobs
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"infra-3.xyz/hyperdot-node/internal/apis/base"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+)
+
+// trashBatchSize is the most queries or dashboards purged in a transaction.
+const trashBatchSize = 100
+
+// TrashPurger is a job to permanently delete the queries and dashboards which are
+// in trash longer than the retention period.
+type TrashPurger struct {
+	db        *gorm.DB
+	retention time.Duration
+}
+
+// NewTrashPurger creates a new TrashPurger.
+func NewTrashPurger(db *gorm.DB, retention time.Duration) *TrashPurger {
+	return &TrashPurger{
+		db:        db,
+		retention: retention,
+	}
+}
+
+// Do purges the queries and dashboards trashed before the retention period.
+func (p *TrashPurger) Do() error {
+	before := time.Now().Add(-p.retention)
+	if err := p.purge(&datamodel.QueryModel{}, before, base.PurgeQueries); err != nil {
+		return err
+	}
+
+	return p.purge(&datamodel.DashboardModel{}, before, base.PurgeDashboards)
+}
+
+// purge deletes the trashed rows of model in batches, the rows are locked so that
+// they can not be restored while purging.
+func (p *TrashPurger) purge(model any, before time.Time, purge func(tx *gorm.DB, ids ...uint) error) error {
+	for {
+		var ids []uint
+		err := p.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Unscoped().Model(model).Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+				Order("id ASC").
+				Limit(trashBatchSize).
+				Pluck("id", &ids).Error; err != nil {
+				return err
+			}
+
+			return purge(tx, ids...)
+		})
+		if err != nil {
+			return err
+		}
+
+		if len(ids) < trashBatchSize {
+			return nil
+		}
+	}
+}
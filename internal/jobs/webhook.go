@@ -0,0 +1,233 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"gorm.io/gorm"
+
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+	"infra-3.xyz/hyperdot-node/internal/utils"
+)
+
+const (
+	// webhookMaxAttempts is the number of attempts before the delivery fails.
+	webhookMaxAttempts = 6
+	// webhookRetryBase is the delay before the first retry, it doubles on every retry.
+	webhookRetryBase = 30 * time.Second
+	// webhookLease is how long a delivery is claimed by a node while it is posted.
+	webhookLease = time.Minute
+	// webhookBatchSize is the most deliveries posted in a run.
+	webhookBatchSize = 100
+	// webhookTimeout is the timeout of posting a delivery.
+	webhookTimeout = 10 * time.Second
+	// webhookMaxError is the most bytes of error kept in delivery log.
+	webhookMaxError = 1024
+)
+
+// WebhookRetryDelay returns the delay before the next attempt after the failed attempts.
+func WebhookRetryDelay(attempts int) time.Duration {
+	delay := webhookRetryBase
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+	}
+	return delay
+}
+
+// NewWebhookClient creates the http client posting webhooks. The addresses are checked
+// when connecting, after the host is resolved, so the webhooks can not reach the internal
+// services by the urls or the dns records pointing to them. The redirects are not
+// followed, and the proxy from environment is not used as the proxy address would be
+// checked instead of the webhook.
+func NewWebhookClient(allowLoopback bool) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: webhookTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+
+			ip := net.ParseIP(host)
+			if ip == nil {
+				return fmt.Errorf("invalid address %s", address)
+			}
+			return utils.CheckWebhookIP(ip, allowLoopback)
+		},
+	}
+
+	return &http.Client{
+		Timeout: webhookTimeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: webhookTimeout,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// WebhookDeliverer is a job to post the pending webhook deliveries, the failed
+// deliveries are retried with exponential backoff. The finished deliveries are
+// pruned after the retention period.
+type WebhookDeliverer struct {
+	db        *gorm.DB
+	client    *http.Client
+	retention time.Duration
+}
+
+// NewWebhookDeliverer creates a new WebhookDeliverer, the client is the one created by
+// NewWebhookClient without loopback if it is nil.
+func NewWebhookDeliverer(db *gorm.DB, client *http.Client, retention time.Duration) *WebhookDeliverer {
+	if client == nil {
+		client = NewWebhookClient(false)
+	}
+
+	return &WebhookDeliverer{
+		db:        db,
+		client:    client,
+		retention: retention,
+	}
+}
+
+// Prune deletes the finished deliveries created before the retention period, it
+// returns the number of deleted deliveries.
+func (d *WebhookDeliverer) Prune() (int64, error) {
+	result := d.db.Where("status <> ? AND created_at < ?", datamodel.WebhookDeliveryPending, time.Now().Add(-d.retention)).
+		Delete(&datamodel.WebhookDeliveryModel{})
+	return result.RowsAffected, result.Error
+}
+
+// Do posts the deliveries due now.
+func (d *WebhookDeliverer) Do() error {
+	var deliveries []datamodel.WebhookDeliveryModel
+	if err := d.db.Where("status = ? AND next_attempt_at <= ?", datamodel.WebhookDeliveryPending, time.Now()).
+		Order("next_attempt_at ASC").
+		Limit(webhookBatchSize).
+		Find(&deliveries).Error; err != nil {
+		return err
+	}
+
+	for i := range deliveries {
+		claimed, err := d.claim(&deliveries[i])
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			continue
+		}
+
+		if err := d.deliver(&deliveries[i]); err != nil {
+			log.Printf("Error save webhook delivery %d: %v", deliveries[i].ID, err)
+		}
+	}
+
+	return nil
+}
+
+// claim leases the delivery so that it is not posted by other nodes at the same time,
+// it returns false if the delivery has been claimed.
+func (d *WebhookDeliverer) claim(delivery *datamodel.WebhookDeliveryModel) (bool, error) {
+	lease := time.Now().Add(webhookLease)
+	result := d.db.Model(&datamodel.WebhookDeliveryModel{}).
+		Where("id = ? AND status = ? AND next_attempt_at = ?", delivery.ID, datamodel.WebhookDeliveryPending, delivery.NextAttemptAt).
+		Update("next_attempt_at", lease)
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// deliver posts the delivery and saves the result.
+func (d *WebhookDeliverer) deliver(delivery *datamodel.WebhookDeliveryModel) error {
+	var webhook datamodel.WebhookModel
+	err := d.db.Where("id = ?", delivery.WebhookID).First(&webhook).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	now := time.Now()
+	updates := map[string]any{
+		"attempts": delivery.Attempts + 1,
+	}
+	if err != nil || !webhook.Enabled {
+		// the deliveries of disabled webhooks are not retried
+		updates["status"] = datamodel.WebhookDeliveryFailed
+		updates["error"] = "webhook is disabled"
+		updates["next_attempt_at"] = nil
+		return d.db.Model(delivery).Updates(updates).Error
+	}
+
+	code, err := d.post(&webhook, delivery)
+	updates["response_code"] = code
+	switch {
+	case err == nil:
+		updates["status"] = datamodel.WebhookDeliverySuccess
+		updates["error"] = ""
+		updates["delivered_at"] = now
+		updates["next_attempt_at"] = nil
+	case delivery.Attempts+1 >= webhookMaxAttempts:
+		updates["status"] = datamodel.WebhookDeliveryFailed
+		updates["error"] = truncateError(err)
+		updates["next_attempt_at"] = nil
+	default:
+		updates["error"] = truncateError(err)
+		updates["next_attempt_at"] = now.Add(WebhookRetryDelay(delivery.Attempts + 1))
+	}
+
+	return d.db.Model(delivery).Updates(updates).Error
+}
+
+// post posts the signed payload to webhook, the response other than 2xx is an error.
+func (d *WebhookDeliverer) post(webhook *datamodel.WebhookModel, delivery *datamodel.WebhookDeliveryModel) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+
+	payload := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "hyperdot-webhook")
+	req.Header.Set("X-Hyperdot-Event", delivery.Event)
+	req.Header.Set("X-Hyperdot-Delivery", strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set("X-Hyperdot-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-Hyperdot-Signature", utils.SignWebhookPayload(webhook.Secret, timestamp, payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+func truncateError(err error) string {
+	text := err.Error()
+	if len(text) > webhookMaxError {
+		return text[:webhookMaxError]
+	}
+	return text
+}
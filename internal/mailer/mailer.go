@@ -0,0 +1,37 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"infra-3.xyz/hyperdot-node/internal/common"
+)
+
+// Message is a plain text mail.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends mails to users.
+type Mailer interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// New creates a mailer by config. The mails are sent by the smtp server, or kept in
+// memory if it is enabled for development. It returns an error if neither is configured,
+// as the email verification, password reset and alerts depend on mails.
+func New(cfg *common.MailConfig) (Mailer, error) {
+	if len(cfg.Host) > 0 {
+		return NewSMTPMailer(cfg), nil
+	}
+
+	if !cfg.Memory {
+		return nil, errors.New("mail.host is required, or enable mail.memory to keep the mails in memory for development")
+	}
+
+	log.Printf("No smtp server configured, the mails are kept in memory")
+	return NewMemoryMailer(), nil
+}
@@ -0,0 +1,45 @@
+package mailer
+
+import (
+	"context"
+	"sync"
+)
+
+// maxMemoryMessages is the most mails kept by MemoryMailer, the oldest are dropped.
+const maxMemoryMessages = 1000
+
+// MemoryMailer keeps the latest mails in memory, it is used in tests and development.
+type MemoryMailer struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+// NewMemoryMailer creates a new MemoryMailer.
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+// Send keeps the mail.
+func (m *MemoryMailer) Send(ctx context.Context, msg *Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.messages) >= maxMemoryMessages {
+		m.messages = append(m.messages[:0], m.messages[len(m.messages)-maxMemoryMessages+1:]...)
+	}
+	m.messages = append(m.messages, *msg)
+	return nil
+}
+
+// Messages returns the mails sent to the recipient.
+func (m *MemoryMailer) Messages(to string) []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var res []Message
+	for _, msg := range m.messages {
+		if msg.To == to {
+			res = append(res, msg)
+		}
+	}
+	return res
+}
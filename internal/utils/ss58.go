@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// SS58GenericPrefix is the address prefix of generic substrate networks.
+const SS58GenericPrefix uint16 = 42
+
+var ss58Pre = []byte("SS58PRE")
+
+func base58Encode(data []byte) string {
+	n := new(big.Int).SetBytes(data)
+	radix := big.NewInt(58)
+	mod := new(big.Int)
+
+	var out []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, radix, mod)
+		out = append(out, base58Alphabet[mod.Int64()])
+	}
+	for _, b := range data {
+		if b != 0 {
+			break
+		}
+		out = append(out, base58Alphabet[0])
+	}
+
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
+
+func base58Decode(s string) ([]byte, error) {
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	for _, c := range []byte(s) {
+		i := bytes.IndexByte([]byte(base58Alphabet), c)
+		if i < 0 {
+			return nil, fmt.Errorf("invalid base58 character %q", c)
+		}
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(i)))
+	}
+
+	var zeros int
+	for zeros < len(s) && s[zeros] == base58Alphabet[0] {
+		zeros++
+	}
+
+	return append(make([]byte, zeros), n.Bytes()...), nil
+}
+
+func ss58Checksum(data []byte) []byte {
+	sum := blake2b.Sum512(append(append([]byte{}, ss58Pre...), data...))
+	return sum[:2]
+}
+
+func ss58PrefixBytes(prefix uint16) []byte {
+	if prefix < 64 {
+		return []byte{byte(prefix)}
+	}
+
+	// the two bytes prefix of https://docs.substrate.io/reference/address-formats/
+	return []byte{
+		byte((prefix&0xfc)>>2) | 0x40,
+		byte(prefix>>8) | byte(prefix&0x03)<<6,
+	}
+}
+
+// EncodeSS58 encodes the 32 bytes public key to SS58 address of the network prefix.
+func EncodeSS58(prefix uint16, publicKey []byte) string {
+	data := append(ss58PrefixBytes(prefix), publicKey...)
+	return base58Encode(append(data, ss58Checksum(data)...))
+}
+
+// DecodeSS58 decodes the SS58 address of an account, it returns the network
+// prefix and the 32 bytes public key.
+func DecodeSS58(address string) (uint16, []byte, error) {
+	data, err := base58Decode(address)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if len(data) < 1 {
+		return 0, nil, errors.New("empty address")
+	}
+
+	var (
+		prefix    uint16
+		prefixLen int
+	)
+	switch {
+	case data[0] < 64:
+		prefix, prefixLen = uint16(data[0]), 1
+	case data[0] < 128 && len(data) > 1:
+		lower := (data[0] << 2) | (data[1] >> 6)
+		upper := data[1] & 0x3f
+		prefix, prefixLen = uint16(lower)|uint16(upper)<<8, 2
+	default:
+		return 0, nil, errors.New("invalid address prefix")
+	}
+
+	if len(data) != prefixLen+32+2 {
+		return 0, nil, errors.New("invalid address length")
+	}
+
+	body := data[:prefixLen+32]
+	if !bytes.Equal(ss58Checksum(body), data[prefixLen+32:]) {
+		return 0, nil, errors.New("invalid address checksum")
+	}
+
+	return prefix, body[prefixLen:], nil
+}
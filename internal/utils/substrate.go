@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+
+	"github.com/ChainSafe/go-schnorrkel"
+)
+
+const (
+	sigTypeEd25519 = 0x00
+	sigTypeSr25519 = 0x01
+)
+
+// the wallets such as polkadot.js wrap the raw message before signing it
+var (
+	wrappedPrefix = []byte("<Bytes>")
+	wrappedSuffix = []byte("</Bytes>")
+)
+
+// ErrInvalidSignature is returned when the signature does not match the message.
+var ErrInvalidSignature = errors.New("invalid signature")
+
+func verifySr25519(publicKey []byte, message []byte, signature []byte) bool {
+	var key [32]byte
+	copy(key[:], publicKey)
+	pub, err := schnorrkel.NewPublicKey(key)
+	if err != nil {
+		return false
+	}
+
+	var sigBytes [64]byte
+	copy(sigBytes[:], signature)
+	sig := new(schnorrkel.Signature)
+	if err := sig.Decode(sigBytes); err != nil {
+		return false
+	}
+
+	ok, err := pub.Verify(sig, schnorrkel.NewSigningContext([]byte("substrate"), message))
+	return err == nil && ok
+}
+
+func verifyEd25519(publicKey []byte, message []byte, signature []byte) bool {
+	return ed25519.Verify(publicKey, message, signature)
+}
+
+// VerifySubstrateSignature verifies the sr25519 or ed25519 signature of message signed
+// by substrate account. The signature is either the raw 64 bytes, or the 65 bytes
+// MultiSignature whose first byte is the signature type. The message wrapped by
+// <Bytes></Bytes> is accepted too.
+func VerifySubstrateSignature(publicKey []byte, message []byte, signature []byte) error {
+	if len(publicKey) != 32 {
+		return fmt.Errorf("invalid public key length %d", len(publicKey))
+	}
+
+	verifiers := []func([]byte, []byte, []byte) bool{verifySr25519, verifyEd25519}
+	switch len(signature) {
+	case 64:
+	case 65:
+		switch signature[0] {
+		case sigTypeEd25519:
+			verifiers = verifiers[1:]
+		case sigTypeSr25519:
+			verifiers = verifiers[:1]
+		default:
+			return fmt.Errorf("unsupported signature type %d", signature[0])
+		}
+		signature = signature[1:]
+	default:
+		return fmt.Errorf("invalid signature length %d", len(signature))
+	}
+
+	messages := [][]byte{message}
+	if !bytes.HasPrefix(message, wrappedPrefix) {
+		messages = append(messages, bytes.Join([][]byte{wrappedPrefix, message, wrappedSuffix}, nil))
+	}
+
+	for _, verify := range verifiers {
+		for _, msg := range messages {
+			if verify(publicKey, msg, signature) {
+				return nil
+			}
+		}
+	}
+
+	return ErrInvalidSignature
+}
@@ -0,0 +1,87 @@
+package utils_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	"github.com/ChainSafe/go-schnorrkel"
+
+	"infra-3.xyz/hyperdot-node/internal/utils"
+)
+
+func TestSS58(t *testing.T) {
+	alice, _ := hex.DecodeString("d43593c715fdd31c61141abd04a99fd6822c8558854ccde39a5684e7a56da27d")
+	cases := []struct {
+		prefix  uint16
+		address string
+	}{
+		{prefix: 42, address: "5GrwvaEF5zXb26Fz9rcQpDWS57CtERHpNehXCPcNoHGKutQY"},
+		{prefix: 0, address: "15oF4uVJwmo4TdGW7VfQxNLavjCXviqxT9S1MgbjMNHr6Sp5"},
+		{prefix: 2, address: "HNZata7iMYWmk5RvZRTiAsSDhV8366zq2YGb3tLH5Upf74F"},
+	}
+
+	for _, c := range cases {
+		if address := utils.EncodeSS58(c.prefix, alice); address != c.address {
+			t.Fatalf("prefix %d: invalid address %s", c.prefix, address)
+		}
+
+		prefix, publicKey, err := utils.DecodeSS58(c.address)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if prefix != c.prefix || hex.EncodeToString(publicKey) != hex.EncodeToString(alice) {
+			t.Fatalf("invalid decoded address %d %x", prefix, publicKey)
+		}
+	}
+
+	// two bytes prefix
+	address := utils.EncodeSS58(1284, alice)
+	if prefix, _, err := utils.DecodeSS58(address); err != nil || prefix != 1284 {
+		t.Fatalf("invalid two bytes prefix %d: %v", prefix, err)
+	}
+
+	if _, _, err := utils.DecodeSS58("5GrwvaEF5zXb26Fz9rcQpDWS57CtERHpNehXCPcNoHGKutQZ"); err == nil {
+		t.Fatal("invalid checksum should be rejected")
+	}
+}
+
+func TestVerifySubstrateSignature(t *testing.T) {
+	message := []byte("sign in to hyperdot")
+
+	// sr25519
+	secret, public, err := schnorrkel.GenerateKeypair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := secret.Sign(schnorrkel.NewSigningContext([]byte("substrate"), []byte("<Bytes>sign in to hyperdot</Bytes>")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srPublic := public.Encode()
+	srSig := sig.Encode()
+
+	if err := utils.VerifySubstrateSignature(srPublic[:], message, srSig[:]); err != nil {
+		t.Fatal(err)
+	}
+	if err := utils.VerifySubstrateSignature(srPublic[:], message, append([]byte{1}, srSig[:]...)); err != nil {
+		t.Fatal(err)
+	}
+	if err := utils.VerifySubstrateSignature(srPublic[:], []byte("other"), srSig[:]); err == nil {
+		t.Fatal("signature of other message should be rejected")
+	}
+
+	// ed25519
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edSig := ed25519.Sign(edPrivate, message)
+	if err := utils.VerifySubstrateSignature(edPublic, message, edSig); err != nil {
+		t.Fatal(err)
+	}
+	if err := utils.VerifySubstrateSignature(edPublic, message, append([]byte{1}, edSig...)); err == nil {
+		t.Fatal("ed25519 signature typed as sr25519 should be rejected")
+	}
+}
@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var templatePlaceholder = regexp.MustCompile(`{{\s*([A-Za-z_][A-Za-z0-9_]*)\s*}}`)
+
+// TemplatePlaceholders returns the distinct placeholder names in text,
+// e.g. "chain_id" for "{{chain_id}}" or "{{ chain_id }}".
+func TemplatePlaceholders(text string) []string {
+	var (
+		names []string
+		seen  = make(map[string]struct{})
+	)
+	for _, match := range templatePlaceholder.FindAllStringSubmatch(text, -1) {
+		if _, ok := seen[match[1]]; ok {
+			continue
+		}
+		seen[match[1]] = struct{}{}
+		names = append(names, match[1])
+	}
+
+	return names
+}
+
+// RenderTemplate replaces the placeholders in text by params.
+// The placeholders not in params are kept as is.
+func RenderTemplate(text string, params map[string]string) string {
+	if len(params) == 0 || !strings.Contains(text, "{{") {
+		return text
+	}
+
+	return templatePlaceholder.ReplaceAllStringFunc(text, func(s string) string {
+		name := templatePlaceholder.FindStringSubmatch(s)[1]
+		if v, ok := params[name]; ok {
+			return v
+		}
+		return s
+	})
+}
+
+var templateSafeValue = regexp.MustCompile(`^[A-Za-z0-9_.:\-]*$`)
+
+// IsSafeTemplateValue reports whether the value can be rendered into a query
+// without quoting, i.e. it is a number, an identifier, a date or an address.
+// The "--" is rejected as it starts a sql comment.
+func IsSafeTemplateValue(value string) bool {
+	return templateSafeValue.MatchString(value) && !strings.Contains(value, "--")
+}
+
+// RenderSafeTemplate fills the placeholders such as {{chain_id}} in query by params,
+// the values must be safe to be rendered into sql and all placeholders are required.
+func RenderSafeTemplate(query string, params map[string]string) (string, error) {
+	for name, value := range params {
+		if !IsSafeTemplateValue(value) {
+			return "", fmt.Errorf("invalid value of parameter %s", name)
+		}
+	}
+
+	for _, name := range TemplatePlaceholders(query) {
+		if _, ok := params[name]; !ok {
+			return "", fmt.Errorf("parameter %s is required", name)
+		}
+	}
+
+	return RenderTemplate(query, params), nil
+}
@@ -0,0 +1,63 @@
+package utils_test
+
+import (
+	"testing"
+
+	"infra-3.xyz/hyperdot-node/internal/utils"
+)
+
+func TestTemplatePlaceholders(t *testing.T) {
+	names := utils.TemplatePlaceholders("select * from t where chain_id = {{chain_id}} and relay = '{{ relay }}' or {{chain_id}}")
+	if len(names) != 2 || names[0] != "chain_id" || names[1] != "relay" {
+		t.Fatalf("invalid placeholders: %v", names)
+	}
+
+	if names := utils.TemplatePlaceholders("no placeholder {{}}"); len(names) != 0 {
+		t.Fatalf("invalid placeholders: %v", names)
+	}
+}
+
+func TestRenderTemplate(t *testing.T) {
+	text := utils.RenderTemplate("chain {{chain_id}} on {{ relay }} by {{unknown}}", map[string]string{
+		"chain_id": "2004",
+		"relay":    "polkadot",
+	})
+
+	if text != "chain 2004 on polkadot by {{unknown}}" {
+		t.Fatalf("invalid render: %s", text)
+	}
+
+	if text := utils.RenderTemplate("{{chain_id}}", nil); text != "{{chain_id}}" {
+		t.Fatalf("invalid render: %s", text)
+	}
+}
+
+func TestIsSafeTemplateValue(t *testing.T) {
+	for _, v := range []string{"2004", "polkadot", "2023-01-01", "0x1a2b", "15oF4uVJwmo4TdGW7VfQxNLavjCXviqxT9S1MgbjMNHr6Sp5", "1.5"} {
+		if !utils.IsSafeTemplateValue(v) {
+			t.Fatalf("%s should be safe", v)
+		}
+	}
+
+	for _, v := range []string{"1 or 1=1", "'a'", "a;drop table t", "a--", "a)", "a\nb"} {
+		if utils.IsSafeTemplateValue(v) {
+			t.Fatalf("%q should be unsafe", v)
+		}
+	}
+}
+
+func TestRenderSafeTemplate(t *testing.T) {
+	query := "select * from t where chain_id = {{chain_id}}"
+	text, err := utils.RenderSafeTemplate(query, map[string]string{"chain_id": "2004"})
+	if err != nil || text != "select * from t where chain_id = 2004" {
+		t.Fatalf("invalid render: %s, %v", text, err)
+	}
+
+	if _, err := utils.RenderSafeTemplate(query, nil); err == nil {
+		t.Fatal("the missing parameter should be rejected")
+	}
+
+	if _, err := utils.RenderSafeTemplate(query, map[string]string{"chain_id": "1 or 1=1"}); err == nil {
+		t.Fatal("the unsafe parameter should be rejected")
+	}
+}
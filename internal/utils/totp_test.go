@@ -0,0 +1,64 @@
+package utils_test
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"infra-3.xyz/hyperdot-node/internal/utils"
+)
+
+func TestTOTPCode(t *testing.T) {
+	// the SHA1 test vectors of RFC 6238 truncated to 6 digits
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+	vectors := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1111111111: "050471",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+
+	for at, expected := range vectors {
+		code, err := utils.TOTPCode(secret, utils.TOTPStep(time.Unix(at, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != expected {
+			t.Fatalf("invalid code at %d: %s != %s", at, code, expected)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	step := utils.TOTPStep(now)
+	code, err := utils.TOTPCode(secret, step-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	matched, ok := utils.ValidateTOTP(secret, code, now)
+	if !ok || matched != step-1 {
+		t.Fatal("the code of previous step should be accepted")
+	}
+
+	if _, ok := utils.ValidateTOTP(secret, code, now.Add(time.Minute*5)); ok {
+		t.Fatal("the expired code should be rejected")
+	}
+
+	if _, ok := utils.ValidateTOTP(secret, "12345", now); ok {
+		t.Fatal("the short code should be rejected")
+	}
+
+	uri := utils.TOTPProvisioningURI("hyperdot", "alice", secret)
+	if !strings.HasPrefix(uri, "otpauth://totp/hyperdot:alice?") || !strings.Contains(uri, "secret="+secret) {
+		t.Fatalf("invalid provisioning uri %s", uri)
+	}
+}
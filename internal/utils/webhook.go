@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strconv"
+)
+
+// nonPublicNetworks are the reserved networks not covered by the methods of net.IP.
+var nonPublicNetworks = []*net.IPNet{
+	mustParseCIDR("0.0.0.0/8"),
+	mustParseCIDR("100.64.0.0/10"),
+	mustParseCIDR("198.18.0.0/15"),
+}
+
+func mustParseCIDR(cidr string) *net.IPNet {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(err)
+	}
+	return network
+}
+
+// CheckWebhookIP checks the webhooks can be posted to the ip, the loopback, private,
+// link-local (e.g. the cloud metadata service), multicast and unspecified addresses
+// are rejected so that the webhooks can not reach the internal services. The loopback
+// addresses are allowed only if allowLoopback is true, which is for tests.
+func CheckWebhookIP(ip net.IP, allowLoopback bool) error {
+	if ip.IsLoopback() {
+		if allowLoopback {
+			return nil
+		}
+		return fmt.Errorf("ip %s is not public", ip)
+	}
+
+	if ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("ip %s is not public", ip)
+	}
+
+	for _, network := range nonPublicNetworks {
+		if network.Contains(ip) {
+			return fmt.Errorf("ip %s is not public", ip)
+		}
+	}
+
+	return nil
+}
+
+// SignWebhookPayload signs the payload sent at timestamp with HMAC-SHA256 of secret, the
+// signed message is "<timestamp>.<payload>" so that the timestamp can not be replaced.
+func SignWebhookPayload(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature reports whether the signature is signed by secret.
+func VerifyWebhookSignature(secret string, timestamp int64, payload []byte, signature string) bool {
+	return hmac.Equal([]byte(SignWebhookPayload(secret, timestamp, payload)), []byte(signature))
+}
@@ -0,0 +1,52 @@
+package utils_test
+
+import (
+	"net"
+	"testing"
+
+	"infra-3.xyz/hyperdot-node/internal/utils"
+)
+
+func TestSignWebhookPayload(t *testing.T) {
+	payload := []byte(`{"event":"ping"}`)
+
+	// printf '1700000000.{"event":"ping"}' | openssl dgst -sha256 -hmac secret
+	signature := utils.SignWebhookPayload("secret", 1700000000, payload)
+	if signature != "sha256=4d39bd2442f073b6bc62e95d0297ce25475582a17389ab860abdc778fe1d9f77" {
+		t.Fatalf("invalid signature %s", signature)
+	}
+
+	if !utils.VerifyWebhookSignature("secret", 1700000000, payload, signature) {
+		t.Fatal("the signature should be verified")
+	}
+	if utils.VerifyWebhookSignature("secret", 1700000001, payload, signature) {
+		t.Fatal("the signature of another timestamp should be rejected")
+	}
+	if utils.VerifyWebhookSignature("other", 1700000000, payload, signature) {
+		t.Fatal("the signature of another secret should be rejected")
+	}
+}
+
+func TestCheckWebhookIP(t *testing.T) {
+	for _, ip := range []string{
+		"127.0.0.1", "::1", "10.0.0.1", "172.16.0.1", "192.168.1.1", "169.254.169.254",
+		"fe80::1", "fd00:ec2::254", "0.0.0.0", "::", "100.64.0.1", "224.0.0.1", "::ffff:127.0.0.1",
+	} {
+		if err := utils.CheckWebhookIP(net.ParseIP(ip), false); err == nil {
+			t.Fatalf("ip %s should be rejected", ip)
+		}
+	}
+
+	for _, ip := range []string{"8.8.8.8", "140.82.112.3", "2606:4700::1111"} {
+		if err := utils.CheckWebhookIP(net.ParseIP(ip), false); err != nil {
+			t.Fatalf("ip %s should be allowed: %v", ip, err)
+		}
+	}
+
+	if err := utils.CheckWebhookIP(net.ParseIP("127.0.0.1"), true); err != nil {
+		t.Fatalf("loopback should be allowed: %v", err)
+	}
+	if err := utils.CheckWebhookIP(net.ParseIP("10.0.0.1"), true); err == nil {
+		t.Fatal("private ip should be rejected even if loopback is allowed")
+	}
+}
@@ -0,0 +1,244 @@
+package tests
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+
+	"infra-3.xyz/hyperdot-node/internal/apis/base"
+	"infra-3.xyz/hyperdot-node/internal/apis/service/user"
+	"infra-3.xyz/hyperdot-node/internal/cache"
+	"infra-3.xyz/hyperdot-node/internal/clients"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+	"infra-3.xyz/hyperdot-node/internal/jobs"
+	"infra-3.xyz/hyperdot-node/internal/mailer"
+	"infra-3.xyz/hyperdot-node/internal/utils"
+)
+
+func TestAccountExport(t *testing.T) {
+	cfg := initialSystemConfig()
+	db, err := initDB(cfg)
+	assert.Nil(t, err)
+
+	router := NewServiceEngine(user.New(cfg, db, nil, nil, mailer.NewMemoryMailer()))
+	owner, ownerToken := createRoleUser(t, db, datamodel.RoleUser)
+
+	query := datamodel.QueryModel{UserID: owner.ID, Name: "exported query", IsPrivacy: true}
+	assert.Nil(t, db.Create(&query).Error)
+	assert.Nil(t, db.Create(&datamodel.ChartModel{QueryID: query.ID, UserID: owner.ID, Name: "exported chart"}).Error)
+	board := datamodel.DashboardModel{UserID: owner.ID, Name: "exported dashboard"}
+	assert.Nil(t, db.Create(&board).Error)
+	assert.Nil(t, db.Create(&datamodel.DashboardPanelModel{UserID: owner.ID, DashboardID: board.ID, Name: "exported panel"}).Error)
+
+	w := serve(router, "GET", "/apis/v1/user/export", ownerToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+
+	archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	assert.Nil(t, err)
+	files := make(map[string]*zip.File)
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+	for _, name := range []string{"profile.json", "queries.json", "charts.json", "dashboards.json", "favorites.json"} {
+		assert.Contains(t, files, name)
+	}
+
+	read := func(name string, v any) {
+		r, err := files[name].Open()
+		assert.Nil(t, err)
+		defer r.Close()
+		assert.Nil(t, json.NewDecoder(r).Decode(v))
+	}
+
+	var profile map[string]any
+	read("profile.json", &profile)
+	assert.Equal(t, owner.Username, profile["username"])
+	assert.NotContains(t, profile, "encrypted_password")
+
+	var queries []datamodel.QueryModel
+	read("queries.json", &queries)
+	assert.Len(t, queries, 1)
+	assert.Equal(t, query.ID, queries[0].ID)
+
+	var dashboards []datamodel.DashboardModel
+	read("dashboards.json", &dashboards)
+	assert.Len(t, dashboards, 1)
+	assert.Len(t, dashboards[0].Panels, 1)
+}
+
+func TestAccountDeletion(t *testing.T) {
+	cfg := initialSystemConfig()
+	db, err := initDB(cfg)
+	assert.Nil(t, err)
+
+	router := NewServiceEngine(user.New(cfg, db, nil, nil, mailer.NewMemoryMailer()))
+	purger := jobs.NewAccountPurger(db, nil, cache.NewSessionRevocationList(&cfg.Redis))
+	owner, ownerToken := createRoleUser(t, db, datamodel.RoleUser)
+	other, _ := createRoleUser(t, db, datamodel.RoleUser)
+
+	private := datamodel.QueryModel{UserID: owner.ID, Name: "private query", IsPrivacy: true}
+	assert.Nil(t, db.Create(&private).Error)
+	public := datamodel.QueryModel{UserID: owner.ID, Name: "public query"}
+	assert.Nil(t, db.Create(&public).Error)
+	privateBoard := datamodel.DashboardModel{UserID: owner.ID, Name: "private dashboard", IsPrivacy: true}
+	assert.Nil(t, db.Create(&privateBoard).Error)
+
+	// the owner starred a query of other user
+	starred := datamodel.QueryModel{UserID: other.ID, Name: "starred query", Stars: 1}
+	assert.Nil(t, db.Create(&starred).Error)
+	assert.Nil(t, db.Create(&datamodel.UserQueryFavorites{UserID: owner.ID, QueryID: starred.ID, Stared: true}).Error)
+
+	// the sole owner of a workspace with other members must transfer it first
+	workspace := datamodel.WorkspaceModel{UserID: owner.ID, Name: "team"}
+	assert.Nil(t, db.Create(&workspace).Error)
+	assert.Nil(t, db.Create(&datamodel.WorkspaceMemberModel{WorkspaceID: workspace.ID, UserID: owner.ID, Role: datamodel.WorkspaceRoleOwner}).Error)
+	assert.Nil(t, db.Create(&datamodel.WorkspaceMemberModel{WorkspaceID: workspace.ID, UserID: other.ID, Role: datamodel.WorkspaceRoleViewer}).Error)
+
+	request := user.RequestDeleteAccount{Username: owner.Username, Password: "wrong"}
+	w := serve(router, "POST", "/apis/v1/user/deletion", ownerToken, request)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	request.Password = "test"
+	w = serve(router, "POST", "/apis/v1/user/deletion", ownerToken, request)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	assert.Nil(t, db.Model(&datamodel.WorkspaceMemberModel{}).
+		Where("workspace_id = ? AND user_id = ?", workspace.ID, other.ID).
+		Update("role", datamodel.WorkspaceRoleOwner).Error)
+	w = serve(router, "POST", "/apis/v1/user/deletion", ownerToken, request)
+	assert.Equal(t, http.StatusOK, w.Code)
+	scheduled := user.ResponseAccountDeletion{}
+	assert.Nil(t, MarshalResponseBody(w.Body, &scheduled))
+	assert.NotNil(t, scheduled.Data.DeletionScheduledAt)
+	assert.True(t, scheduled.Data.DeletionScheduledAt.After(time.Now().Add(13*24*time.Hour)))
+
+	// the account is kept during the grace period and the deletion can be cancelled
+	assert.Nil(t, purger.Do())
+	assert.Nil(t, db.Where("id = ?", owner.ID).First(&datamodel.UserModel{}).Error)
+	w = serve(router, "DELETE", "/apis/v1/user/deletion", ownerToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = serve(router, "DELETE", "/apis/v1/user/deletion", ownerToken, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = serve(router, "POST", "/apis/v1/user/deletion", ownerToken, request)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Nil(t, db.Model(&datamodel.UserModel{}).Where("id = ?", owner.ID).
+		Update("deletion_scheduled_at", time.Now().Add(-time.Minute)).Error)
+	assert.Nil(t, purger.Do())
+
+	// the user is anonymized and soft deleted
+	err = db.Where("id = ?", owner.ID).First(&datamodel.UserModel{}).Error
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+	var deleted datamodel.UserModel
+	assert.Nil(t, db.Unscoped().Where("id = ?", owner.ID).First(&deleted).Error)
+	assert.True(t, deleted.DeletedAt.Valid)
+	assert.Empty(t, deleted.Email)
+	assert.Empty(t, deleted.EncryptedPassword)
+	assert.NotEqual(t, owner.Username, deleted.Username)
+
+	// the private contents are purged and the public ones are kept
+	err = db.Where("id = ?", private.ID).First(&datamodel.QueryModel{}).Error
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+	err = db.Where("id = ?", privateBoard.ID).First(&datamodel.DashboardModel{}).Error
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+	assert.Nil(t, db.Where("id = ?", public.ID).First(&datamodel.QueryModel{}).Error)
+
+	// the stars are withdrawn and the user left the workspace
+	assert.Nil(t, db.Where("id = ?", starred.ID).First(&starred).Error)
+	assert.Equal(t, uint(0), starred.Stars)
+	var members int64
+	assert.Nil(t, db.Model(&datamodel.WorkspaceMemberModel{}).Where("workspace_id = ?", workspace.ID).Count(&members).Error)
+	assert.Equal(t, int64(1), members)
+
+	// the deleted user can not login
+	w = serve(router, "POST", "/apis/v1/user/auth/login", "", user.RequestLogin{
+		UserId: owner.Username, Password: "test", Provider: user.PasswordProvider,
+	})
+	login := base.BaseResponse{}
+	assert.Nil(t, MarshalResponseBody(w.Body, &login))
+	assert.False(t, login.Success)
+}
+
+func TestAccountDeletionReauthentication(t *testing.T) {
+	cfg := initialSystemConfig()
+	db, err := initDB(cfg)
+	assert.Nil(t, err)
+
+	router := NewServiceEngine(user.New(cfg, db, nil, nil, mailer.NewMemoryMailer()))
+	owner, _ := createRoleUser(t, db, datamodel.RoleUser)
+	assert.Nil(t, db.Model(owner).Updates(map[string]any{"provider": user.GithubProvider, "encrypted_password": ""}).Error)
+
+	sessionToken := func(createdAt time.Time) string {
+		session := datamodel.UserSessionModel{UserID: owner.ID, ExpiresAt: time.Now().Add(time.Hour), CreatedAt: createdAt}
+		assert.Nil(t, db.Create(&session).Error)
+		claims := owner.ToClaims()
+		claims.SessionID = session.ID
+		token, err := base.GenerateJwtToken(claims, base.TokenDefaultExpireTime())
+		assert.Nil(t, err)
+		return token
+	}
+
+	// the user without password must login recently
+	request := user.RequestDeleteAccount{Username: owner.Username}
+	w := serve(router, "POST", "/apis/v1/user/deletion", sessionToken(time.Now().Add(-time.Hour)), request)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	recentToken := sessionToken(time.Now())
+	w = serve(router, "POST", "/apis/v1/user/deletion", recentToken, request)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = serve(router, "DELETE", "/apis/v1/user/deletion", recentToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// or provide the two-factor code if it is enabled
+	secret, err := utils.GenerateTOTPSecret()
+	assert.Nil(t, err)
+	now := time.Now()
+	assert.Nil(t, db.Create(&datamodel.UserTwoFactorModel{UserID: owner.ID, Secret: secret, EnabledAt: &now}).Error)
+	w = serve(router, "POST", "/apis/v1/user/deletion", recentToken, request)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	request.Code, err = utils.TOTPCode(secret, utils.TOTPStep(time.Now()))
+	assert.Nil(t, err)
+	w = serve(router, "POST", "/apis/v1/user/deletion", recentToken, request)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestAccountPurgeAvatar(t *testing.T) {
+	cfg := initialSystemConfig()
+	db, err := initDB(cfg)
+	assert.Nil(t, err)
+
+	// the s3 server denies to remove the avatar
+	s3 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><Error><Code>AccessDenied</Code><Message>Access Denied</Message></Error>`))
+	}))
+	defer s3.Close()
+
+	revocations := cache.NewSessionRevocationList(&cfg.Redis)
+	owner, _ := createRoleUser(t, db, datamodel.RoleUser)
+	assert.Nil(t, db.Model(owner).Updates(map[string]any{
+		"icon_url":              "avatars/owner.png",
+		"deletion_scheduled_at": time.Now().Add(-time.Minute),
+	}).Error)
+
+	// the user is not anonymized until the avatar is removed
+	s3Client := clients.NewSimpleS3Client(strings.TrimPrefix(s3.URL, "http://"), "hyperdot", "hyperdot", false)
+	assert.NotNil(t, jobs.NewAccountPurger(db, s3Client, revocations).Purge(owner))
+	var kept datamodel.UserModel
+	assert.Nil(t, db.Where("id = ?", owner.ID).First(&kept).Error)
+	assert.Equal(t, "avatars/owner.png", kept.IconUrl)
+
+	assert.Nil(t, jobs.NewAccountPurger(db, nil, revocations).Purge(owner))
+	err = db.Where("id = ?", owner.ID).First(&datamodel.UserModel{}).Error
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+}
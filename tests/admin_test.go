@@ -0,0 +1,103 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"infra-3.xyz/hyperdot-node/internal/apis/service/admin"
+	"infra-3.xyz/hyperdot-node/internal/apis/service/user"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+	"infra-3.xyz/hyperdot-node/internal/mailer"
+)
+
+func TestAdminRoles(t *testing.T) {
+	cfg := initialSystemConfig()
+	db, err := initDB(cfg)
+	assert.Nil(t, err)
+
+	router := NewServiceEngine(admin.New(cfg, db, nil))
+	adminUser, adminToken := createRoleUser(t, db, datamodel.RoleAdmin)
+	_, moderatorToken := createRoleUser(t, db, datamodel.RoleModerator)
+	normalUser, normalToken := createRoleUser(t, db, datamodel.RoleUser)
+
+	// the admin apis require admin role
+	w := serve(router, "GET", "/apis/v1/admin/users?keyword="+normalUser.Username, normalToken, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = serve(router, "GET", "/apis/v1/admin/users?keyword="+normalUser.Username, moderatorToken, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = serve(router, "GET", "/apis/v1/admin/users?keyword="+normalUser.Username, adminToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	users := admin.ResponseListUsers{}
+	assert.Nil(t, MarshalResponseBody(w.Body, &users))
+	assert.Equal(t, int64(1), users.Data.Total)
+	assert.Equal(t, normalUser.ID, users.Data.Users[0].ID)
+
+	// the moderators unpublish public contents
+	query := datamodel.QueryModel{UserID: normalUser.ID, Name: "spam", Query: "select 1", QueryEngine: "bigquery"}
+	assert.Nil(t, db.Create(&query).Error)
+
+	path := fmt.Sprintf("/apis/v1/admin/query/%d/unpublish", query.ID)
+	w = serve(router, "POST", path, normalToken, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = serve(router, "POST", path, moderatorToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Nil(t, db.First(&query, query.ID).Error)
+	assert.True(t, query.IsPrivacy)
+
+	// admin can not manage itself
+	w = serve(router, "POST", fmt.Sprintf("/apis/v1/admin/users/%d/disable", adminUser.ID), adminToken, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = serve(router, "PUT", fmt.Sprintf("/apis/v1/admin/users/%d/role", normalUser.ID), adminToken, admin.RequestUpdateRole{
+		Role: "root",
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = serve(router, "PUT", fmt.Sprintf("/apis/v1/admin/users/%d/role", normalUser.ID), adminToken, admin.RequestUpdateRole{
+		Role: datamodel.RoleModerator,
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	updated := admin.ResponseUser{}
+	assert.Nil(t, MarshalResponseBody(w.Body, &updated))
+	assert.Equal(t, datamodel.RoleModerator, updated.Data.Role)
+}
+
+func TestAdminDisableUser(t *testing.T) {
+	cfg := initialSystemConfig()
+	db, err := initDB(cfg)
+	assert.Nil(t, err)
+
+	router := NewServiceEngine(admin.New(cfg, db, nil))
+	userRouter := NewServiceEngine(user.New(cfg, db, nil, nil, mailer.NewMemoryMailer()))
+	_, adminToken := createRoleUser(t, db, datamodel.RoleAdmin)
+	normalUser, _ := createRoleUser(t, db, datamodel.RoleUser)
+
+	loginRequest := user.RequestLogin{UserId: normalUser.Username, Password: "test", Provider: user.PasswordProvider}
+	w := serve(userRouter, "POST", "/apis/v1/user/auth/login", "", loginRequest)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	tokens := responseLogin{}
+	assert.Nil(t, MarshalResponseBody(w.Body, &tokens))
+
+	w = serve(router, "POST", fmt.Sprintf("/apis/v1/admin/users/%d/disable", normalUser.ID), adminToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// the disabled user can not login, and the sessions are revoked
+	w = serve(userRouter, "POST", "/apis/v1/user/auth/login", "", loginRequest)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = serve(userRouter, "GET", "/apis/v1/user", tokens.Data.Token, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = serve(userRouter, "POST", "/apis/v1/user/auth/refresh", "", user.RequestRefreshToken{RefreshToken: tokens.Data.RefreshToken})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = serve(router, "POST", fmt.Sprintf("/apis/v1/admin/users/%d/enable", normalUser.ID), adminToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = serve(userRouter, "POST", "/apis/v1/user/auth/login", "", loginRequest)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
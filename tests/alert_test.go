@@ -0,0 +1,172 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"infra-3.xyz/hyperdot-node/internal/apis/service/alert"
+	"infra-3.xyz/hyperdot-node/internal/apis/service/notification"
+	"infra-3.xyz/hyperdot-node/internal/dataengine"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+	"infra-3.xyz/hyperdot-node/internal/jobs"
+	"infra-3.xyz/hyperdot-node/internal/mailer"
+)
+
+// valueEngine is a query engine returning a row with the value column.
+type valueEngine struct {
+	value   atomic.Int64
+	queries atomic.Int32
+}
+
+func (e *valueEngine) Run(ctx context.Context, query string) (dataengine.RowIterator, error) {
+	e.queries.Add(1)
+	return &valueRows{rows: []map[string]interface{}{{"value": e.value.Load()}}}, nil
+}
+
+type valueRows struct {
+	rows []map[string]interface{}
+}
+
+func (r *valueRows) Schema() []*dataengine.FieldSchema {
+	return nil
+}
+
+func (r *valueRows) Next() (map[string]interface{}, error) {
+	if len(r.rows) == 0 {
+		return nil, dataengine.IterDone
+	}
+	row := r.rows[0]
+	r.rows = r.rows[1:]
+	return row, nil
+}
+
+func (r *valueRows) TotalRows() uint64 {
+	return uint64(len(r.rows))
+}
+
+func TestAlerts(t *testing.T) {
+	cfg := initialSystemConfig()
+	db, err := initDB(cfg)
+	assert.Nil(t, err)
+
+	engine := &valueEngine{}
+	engine.value.Store(5)
+	evaluator := jobs.NewAlertEvaluator(db, map[string]dataengine.QueryEngine{"fake": engine}, mailer.NewMemoryMailer())
+	alertRouter := NewServiceEngine(alert.New(db))
+	notificationRouter := NewServiceEngine(notification.New(db))
+	owner, ownerToken := createRoleUser(t, db, datamodel.RoleUser)
+	_, otherToken := createRoleUser(t, db, datamodel.RoleUser)
+
+	query := datamodel.QueryModel{UserID: owner.ID, Name: "alert query", Query: "select {{n}} as value", QueryEngine: "fake", IsPrivacy: true}
+	assert.Nil(t, db.Create(&query).Error)
+
+	// the params must fill the placeholders and the private query is not accessible by others
+	request := alert.RequestCreateAlert{
+		QueryID: query.ID, Name: "too large", Column: "value", Operator: datamodel.AlertOperatorGT, Threshold: 10,
+	}
+	w := serve(alertRouter, "POST", "/apis/v1/alert", ownerToken, request)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	request.Params = map[string]string{"n": "1"}
+	w = serve(alertRouter, "POST", "/apis/v1/alert", otherToken, request)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	request.Operator = "between"
+	w = serve(alertRouter, "POST", "/apis/v1/alert", ownerToken, request)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	request.Operator = datamodel.AlertOperatorGT
+	request.Interval = 1
+	w = serve(alertRouter, "POST", "/apis/v1/alert", ownerToken, request)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	request.Interval = 0
+
+	w = serve(alertRouter, "POST", "/apis/v1/alert", ownerToken, request)
+	assert.Equal(t, http.StatusOK, w.Code)
+	created := alert.ResponseAlert{}
+	assert.Nil(t, MarshalResponseBody(w.Body, &created))
+	assert.Equal(t, datamodel.AlertStateOK, created.Data.State)
+	assert.Equal(t, datamodel.AlertReduceFirst, created.Data.Reduce)
+	assert.Equal(t, uint(60), created.Data.Interval)
+	assert.True(t, created.Data.Channels.Has(datamodel.AlertChannelInApp))
+
+	alertPath := fmt.Sprintf("/apis/v1/alert/%d", created.Data.ID)
+	w = serve(alertRouter, "GET", alertPath, otherToken, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	evaluate := func() datamodel.AlertModel {
+		// make the alert due again
+		assert.Nil(t, db.Model(&datamodel.AlertModel{}).Where("id = ?", created.Data.ID).
+			Update("next_run_at", time.Now().Add(-time.Second)).Error)
+		assert.Nil(t, evaluator.Do())
+
+		var model datamodel.AlertModel
+		assert.Nil(t, db.Where("id = ?", created.Data.ID).First(&model).Error)
+		return model
+	}
+	unread := func() int64 {
+		var count int64
+		assert.Nil(t, db.Model(&datamodel.NotificationModel{}).
+			Where("user_id = ? AND type = ? AND read_at IS NULL", owner.ID, datamodel.NotificationAlert).
+			Count(&count).Error)
+		return count
+	}
+
+	model := evaluate()
+	assert.Equal(t, datamodel.AlertStateOK, model.State)
+	assert.NotNil(t, model.LastValue)
+	assert.Equal(t, float64(5), *model.LastValue)
+	assert.Equal(t, int64(0), unread())
+
+	// the claimed alert is not evaluated again before its next run
+	queries := engine.queries.Load()
+	assert.Nil(t, evaluator.Do())
+	assert.Equal(t, queries, engine.queries.Load())
+
+	// the user is notified once when the alert is triggered
+	engine.value.Store(20)
+	model = evaluate()
+	assert.Equal(t, datamodel.AlertStateTriggered, model.State)
+	assert.NotNil(t, model.TriggeredAt)
+	assert.Equal(t, int64(1), unread())
+	model = evaluate()
+	assert.Equal(t, datamodel.AlertStateTriggered, model.State)
+	assert.Equal(t, int64(1), unread())
+
+	// and when it is resolved
+	engine.value.Store(3)
+	model = evaluate()
+	assert.Equal(t, datamodel.AlertStateResolved, model.State)
+	assert.Equal(t, int64(2), unread())
+	model = evaluate()
+	assert.Equal(t, datamodel.AlertStateOK, model.State)
+	assert.Equal(t, int64(2), unread())
+
+	w = serve(notificationRouter, "GET", "/apis/v1/user/notifications/unread", ownerToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// the disabled alert is not evaluated
+	disabled := false
+	w = serve(alertRouter, "PUT", alertPath, ownerToken, alert.RequestUpdateAlert{Enabled: &disabled})
+	assert.Equal(t, http.StatusOK, w.Code)
+	queries = engine.queries.Load()
+	evaluate()
+	assert.Equal(t, queries, engine.queries.Load())
+
+	w = serve(alertRouter, "GET", fmt.Sprintf("/apis/v1/alert?query_id=%d", query.ID), ownerToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	list := alert.ResponseListAlerts{}
+	assert.Nil(t, MarshalResponseBody(w.Body, &list))
+	assert.Len(t, list.Data, 1)
+	assert.False(t, list.Data[0].Enabled)
+
+	w = serve(alertRouter, "DELETE", alertPath, otherToken, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = serve(alertRouter, "DELETE", alertPath, ownerToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = serve(alertRouter, "GET", alertPath, ownerToken, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"infra-3.xyz/hyperdot-node/internal/apis/base"
+	"infra-3.xyz/hyperdot-node/internal/apis/service/apikey"
+)
+
+func serveApiKey(method string, path string, key string) int {
+	router := apiserver.GetEngine()
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, nil)
+	req.Header.Add(base.ApiKeyHeader, key)
+	router.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestApiKey(t *testing.T) {
+	router := apiserver.GetEngine()
+
+	w := httptest.NewRecorder()
+	req, _ := MakeTokenRequest("POST", "/apis/v1/apikey", apikey.RequestCreateApiKey{
+		Name:   "dashboard reader",
+		Scopes: []string{base.ScopeDashboardRead},
+	})
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	created := apikey.ResponseCreateApiKey{}
+	if err := MarshalResponseBody(w.Body, &created); err != nil {
+		t.Fatal(err)
+	}
+	assert.NotEmpty(t, created.Data.Key)
+	assert.Equal(t, created.Data.Prefix, created.Data.Key[:len(created.Data.Prefix)])
+
+	// the key can only access the apis of its scopes
+	assert.Equal(t, 200, serveApiKey("GET", "/apis/v1/dashboard", created.Data.Key))
+	assert.Equal(t, http.StatusForbidden, serveApiKey("POST", "/apis/v1/query/run", created.Data.Key))
+	assert.Equal(t, http.StatusForbidden, serveApiKey("GET", "/apis/v1/user", created.Data.Key))
+	assert.Equal(t, http.StatusForbidden, serveApiKey("GET", "/apis/v1/apikey", created.Data.Key))
+	assert.Equal(t, http.StatusUnauthorized, serveApiKey("GET", "/apis/v1/dashboard", "hdk_invalid"))
+
+	w = httptest.NewRecorder()
+	req, _ = MakeTokenRequest("GET", "/apis/v1/apikey", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	keys := apikey.ResponseListApiKeys{}
+	if err := MarshalResponseBody(w.Body, &keys); err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, key := range keys.Data {
+		found = found || key.ID == created.Data.ID
+	}
+	assert.True(t, found)
+
+	// revoke
+	w = httptest.NewRecorder()
+	req, _ = MakeTokenRequest("DELETE", fmt.Sprintf("/apis/v1/apikey/%d", created.Data.ID), nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	assert.Equal(t, http.StatusUnauthorized, serveApiKey("GET", "/apis/v1/dashboard", created.Data.Key))
+}
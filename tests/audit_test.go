@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"infra-3.xyz/hyperdot-node/internal/apis/base"
+	"infra-3.xyz/hyperdot-node/internal/apis/service/admin"
+	"infra-3.xyz/hyperdot-node/internal/apis/service/dashboard"
+	"infra-3.xyz/hyperdot-node/internal/apis/service/user"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+	"infra-3.xyz/hyperdot-node/internal/mailer"
+)
+
+func TestAuditLog(t *testing.T) {
+	cfg := initialSystemConfig()
+	db, err := initDB(cfg)
+	assert.Nil(t, err)
+
+	userRouter := NewServiceEngine(user.New(cfg, db, nil, nil, mailer.NewMemoryMailer()))
+	dashboardRouter := NewServiceEngine(dashboard.New(db, nil))
+	adminRouter := NewServiceEngine(admin.New(cfg, db, nil))
+	normalUser, normalToken := createRoleUser(t, db, datamodel.RoleUser)
+	_, adminToken := createRoleUser(t, db, datamodel.RoleAdmin)
+
+	// the failed and succeeded logins are recorded with the sign logs
+	w := serve(userRouter, "POST", "/apis/v1/user/auth/login", "", user.RequestLogin{
+		UserId:   normalUser.Username,
+		Password: "wrong",
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = serve(userRouter, "POST", "/apis/v1/user/auth/login", "", user.RequestLogin{
+		UserId:   normalUser.Username,
+		Password: "test",
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var current datamodel.UserModel
+	assert.Nil(t, db.First(&current, normalUser.ID).Error)
+	assert.Equal(t, uint(1), current.SignInCount)
+	assert.Equal(t, 1, len(current.Logs))
+
+	w = serve(userRouter, "GET", "/apis/v1/user/activity?target_type=user", normalToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	activity := base.ResponseAuditLogs{}
+	assert.Nil(t, MarshalResponseBody(w.Body, &activity))
+	assert.Equal(t, int64(2), activity.Data.Total)
+	assert.Equal(t, datamodel.AuditUserLogin, activity.Data.Logs[0].Action)
+	assert.Equal(t, datamodel.AuditUserLoginFailed, activity.Data.Logs[1].Action)
+
+	// the content changes are recorded with the changed fields
+	w = serve(dashboardRouter, "POST", "/apis/v1/dashboard", normalToken, datamodel.DashboardModel{
+		Name:      "audited",
+		IsPrivacy: true,
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	created := dashboard.Response{}
+	assert.Nil(t, MarshalResponseBody(w.Body, &created))
+	audited := created.Data
+	audited.Name = "renamed"
+	w = serve(dashboardRouter, "PUT", "/apis/v1/dashboard", normalToken, audited)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = serve(dashboardRouter, "DELETE", fmt.Sprintf("/apis/v1/dashboard/%d", audited.ID), normalToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// only the admins query all audit logs
+	path := fmt.Sprintf("/apis/v1/admin/audit?target_type=dashboard&target_id=%d", audited.ID)
+	w = serve(adminRouter, "GET", path, normalToken, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = serve(adminRouter, "GET", path, adminToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	logs := base.ResponseAuditLogs{}
+	assert.Nil(t, MarshalResponseBody(w.Body, &logs))
+	assert.Equal(t, int64(3), logs.Data.Total)
+	assert.Equal(t, datamodel.AuditDashboardDelete, logs.Data.Logs[0].Action)
+	assert.Equal(t, normalUser.ID, logs.Data.Logs[0].ActorID)
+
+	update := logs.Data.Logs[1]
+	assert.Equal(t, datamodel.AuditDashboardUpdate, update.Action)
+	assert.Equal(t, datamodel.JSON{"name": "audited"}, update.Before)
+	assert.Equal(t, datamodel.JSON{"name": "renamed"}, update.After)
+	assert.Equal(t, datamodel.AuditDashboardCreate, logs.Data.Logs[2].Action)
+
+	w = serve(adminRouter, "GET", "/apis/v1/admin/audit?from=yesterday", adminToken, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,37 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGuestAccess(t *testing.T) {
+	router := apiserver.GetEngine()
+
+	// browse public content as guest
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/apis/v1/dashboard", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/apis/v1/query", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	// current user requires login
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/apis/v1/user", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// invalid token is rejected even if guest allowed
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/apis/v1/dashboard", nil)
+	req.Header.Add("Authorization", "invalid")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"infra-3.xyz/hyperdot-node/internal/apis/service/comment"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+)
+
+func TestComments(t *testing.T) {
+	cfg := initialSystemConfig()
+	db, err := initDB(cfg)
+	assert.Nil(t, err)
+
+	commentRouter := NewServiceEngine(comment.New(db))
+	author, authorToken := createRoleUser(t, db, datamodel.RoleUser)
+	owner, ownerToken := createRoleUser(t, db, datamodel.RoleUser)
+	outsider, _ := createRoleUser(t, db, datamodel.RoleUser)
+
+	query := datamodel.QueryModel{UserID: owner.ID, Name: "public query"}
+	assert.Nil(t, db.Create(&query).Error)
+	private := datamodel.QueryModel{UserID: owner.ID, Name: "private query", IsPrivacy: true}
+	assert.Nil(t, db.Create(&private).Error)
+
+	commentsPath := fmt.Sprintf("/apis/v1/query/%d/comments", query.ID)
+	w := serve(commentRouter, "POST", commentsPath, authorToken, comment.RequestCreateComment{Body: "  "})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = serve(commentRouter, "POST", fmt.Sprintf("/apis/v1/query/%d/comments", private.ID), authorToken,
+		comment.RequestCreateComment{Body: "hello"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// the mentioned owner is saved, the author and email addresses are not
+	body := fmt.Sprintf("@%s why is it slow? cc @%s, mail %s", owner.Username, author.Username, outsider.Email)
+	w = serve(commentRouter, "POST", commentsPath, authorToken, comment.RequestCreateComment{Body: body})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	root := comment.ResponseComment{}
+	assert.Nil(t, MarshalResponseBody(w.Body, &root))
+	assert.Equal(t, author.Username, root.Data.Username)
+	assert.Equal(t, 1, len(root.Data.Mentions))
+	assert.Equal(t, owner.ID, root.Data.Mentions[0].UserID)
+
+	// the reply to a reply is added to the same thread
+	w = serve(commentRouter, "POST", commentsPath, ownerToken, comment.RequestCreateComment{Body: "the index is missing", ParentID: root.Data.ID})
+	assert.Equal(t, http.StatusOK, w.Code)
+	reply := comment.ResponseComment{}
+	assert.Nil(t, MarshalResponseBody(w.Body, &reply))
+
+	w = serve(commentRouter, "POST", commentsPath, authorToken, comment.RequestCreateComment{Body: "thanks", ParentID: reply.Data.ID})
+	assert.Equal(t, http.StatusOK, w.Code)
+	nested := comment.ResponseComment{}
+	assert.Nil(t, MarshalResponseBody(w.Body, &nested))
+	assert.Equal(t, root.Data.ID, nested.Data.ParentID)
+
+	w = serve(commentRouter, "GET", commentsPath, "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	list := comment.ResponseListComments{}
+	assert.Nil(t, MarshalResponseBody(w.Body, &list))
+	assert.Equal(t, int64(1), list.Data.Total)
+	assert.Equal(t, int64(2), list.Data.Comments[0].Replies)
+
+	// only the author can edit the comment
+	editPath := fmt.Sprintf("/apis/v1/comment/%d", root.Data.ID)
+	w = serve(commentRouter, "PUT", editPath, ownerToken, comment.RequestUpdateComment{Body: "edited"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = serve(commentRouter, "PUT", editPath, authorToken, comment.RequestUpdateComment{Body: "why is it slow?"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	edited := comment.ResponseComment{}
+	assert.Nil(t, MarshalResponseBody(w.Body, &edited))
+	assert.NotNil(t, edited.Data.EditedAt)
+	assert.Equal(t, 0, len(edited.Data.Mentions))
+
+	// the owner of query deletes the thread, it is kept as a placeholder for replies
+	w = serve(commentRouter, "DELETE", editPath, ownerToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = serve(commentRouter, "GET", fmt.Sprintf("/apis/v1/comment/%d/replies", root.Data.ID), "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	replies := comment.ResponseListComments{}
+	assert.Nil(t, MarshalResponseBody(w.Body, &replies))
+	assert.Equal(t, int64(2), replies.Data.Total)
+
+	var placeholder datamodel.CommentModel
+	assert.Nil(t, db.Where("id = ?", root.Data.ID).First(&placeholder).Error)
+	assert.True(t, placeholder.IsDeleted())
+	assert.Empty(t, placeholder.Body)
+
+	// the placeholder is removed with the last reply
+	for _, id := range []uint{reply.Data.ID, nested.Data.ID} {
+		w = serve(commentRouter, "DELETE", fmt.Sprintf("/apis/v1/comment/%d", id), ownerToken, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+
+	var count int64
+	assert.Nil(t, db.Model(&datamodel.CommentModel{}).Where("target_type = ? AND target_id = ?", datamodel.CommentTargetQuery, query.ID).Count(&count).Error)
+	assert.Equal(t, int64(0), count)
+}
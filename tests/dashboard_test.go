@@ -1,10 +1,13 @@
 package tests
 
 import (
+	"fmt"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"infra-3.xyz/hyperdot-node/internal/apis/service/dashboard"
+	"infra-3.xyz/hyperdot-node/internal/apis/service/query"
 	"infra-3.xyz/hyperdot-node/internal/datamodel"
 )
 
@@ -25,3 +28,129 @@ func TestDashboardCreate(t *testing.T) {
 	router.ServeHTTP(w, req)
 	assert.Equal(t, 200, w.Code)
 }
+
+func TestDashboardDuplicateTemplate(t *testing.T) {
+	router := apiserver.GetEngine()
+
+	// create a query used by template panel
+	w := httptest.NewRecorder()
+	req, _ := MakeTokenRequest("POST", "/apis/v1/query", datamodel.QueryModel{
+		Name:        "transfers of {{chain_id}}",
+		Query:       "select * from `bigquery-public-data.crypto_polkadot.transfers{{chain_id}}` limit 2",
+		QueryEngine: "bigquery",
+	})
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	queryResponse := query.Response{}
+	if err := MarshalResponseBody(w.Body, &queryResponse); err != nil {
+		t.Fatal(err)
+	}
+
+	// create template dashboard
+	w = httptest.NewRecorder()
+	req, _ = MakeTokenRequest("POST", "/apis/v1/dashboard", datamodel.DashboardModel{
+		Name:           "parachain {{chain_id}}",
+		IsTemplate:     true,
+		TemplateParams: datamodel.JSON{"chain_id": "0"},
+	})
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	dashboardResponse := dashboard.Response{}
+	if err := MarshalResponseBody(w.Body, &dashboardResponse); err != nil {
+		t.Fatal(err)
+	}
+
+	template := dashboardResponse.Data
+	template.Panels = []datamodel.DashboardPanelModel{
+		{Name: "transfers", Type: 1, QueryID: queryResponse.Data.ID},
+	}
+	w = httptest.NewRecorder()
+	req, _ = MakeTokenRequest("PUT", "/apis/v1/dashboard", template)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	// instantiate template
+	w = httptest.NewRecorder()
+	req, _ = MakeTokenRequest("POST", fmt.Sprintf("/apis/v1/dashboard/%d/duplicate", template.ID), dashboard.RequestDuplicateDashboard{
+		Params: map[string]string{"chain_id": "2004"},
+	})
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	duplicated := dashboard.Response{}
+	if err := MarshalResponseBody(w.Body, &duplicated); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NotEqual(t, template.ID, duplicated.Data.ID)
+	assert.Equal(t, "parachain 2004", duplicated.Data.Name)
+	assert.False(t, duplicated.Data.IsTemplate)
+	assert.Equal(t, 1, len(duplicated.Data.Panels))
+	assert.NotEqual(t, queryResponse.Data.ID, duplicated.Data.Panels[0].QueryID)
+
+	// the cloned query is rendered
+	w = httptest.NewRecorder()
+	req, _ = MakeTokenRequest("GET", fmt.Sprintf("/apis/v1/query/%d", duplicated.Data.Panels[0].QueryID), nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	clonedQuery := query.Response{}
+	if err := MarshalResponseBody(w.Body, &clonedQuery); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "transfers of 2004", clonedQuery.Data.Name)
+}
+
+func TestDashboardExportImport(t *testing.T) {
+	router := apiserver.GetEngine()
+
+	w := httptest.NewRecorder()
+	req, _ := MakeTokenRequest("POST", "/apis/v1/dashboard", datamodel.DashboardModel{
+		Name: "export",
+	})
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	created := dashboard.Response{}
+	if err := MarshalResponseBody(w.Body, &created); err != nil {
+		t.Fatal(err)
+	}
+
+	// export
+	w = httptest.NewRecorder()
+	req, _ = MakeTokenRequest("GET", fmt.Sprintf("/apis/v1/dashboard/%d/export", created.Data.ID), nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	bundle := dashboard.DashboardBundle{}
+	if err := MarshalResponseBody(w.Body, &bundle); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, dashboard.BundleVersion, bundle.Version)
+	assert.Equal(t, "export", bundle.Dashboard.Name)
+
+	// import
+	w = httptest.NewRecorder()
+	req, _ = MakeTokenRequest("POST", "/apis/v1/dashboard/import", bundle)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	imported := dashboard.Response{}
+	if err := MarshalResponseBody(w.Body, &imported); err != nil {
+		t.Fatal(err)
+	}
+	assert.NotEqual(t, created.Data.ID, imported.Data.ID)
+	assert.Equal(t, "export", imported.Data.Name)
+
+	// import with unsupported engine
+	bundle.Queries = []datamodel.QueryModel{
+		{ID: 1, Name: "q", Query: "select 1", QueryEngine: "unknown"},
+	}
+	w = httptest.NewRecorder()
+	req, _ = MakeTokenRequest("POST", "/apis/v1/dashboard/import", bundle)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 400, w.Code)
+	assert.Contains(t, w.Body.String(), "unknown query engine unsupported")
+}
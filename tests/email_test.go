@@ -0,0 +1,137 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"infra-3.xyz/hyperdot-node/internal/apis/service/user"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+	"infra-3.xyz/hyperdot-node/internal/mailer"
+)
+
+var mailTokenRegexp = regexp.MustCompile(`token=(\S+)`)
+
+// lastMailToken returns the token in the last mail sent to email.
+func lastMailToken(t *testing.T, mail *mailer.MemoryMailer, email string) string {
+	messages := mail.Messages(email)
+	if !assert.NotEmpty(t, messages) {
+		t.FailNow()
+	}
+
+	match := mailTokenRegexp.FindStringSubmatch(messages[len(messages)-1].Body)
+	if !assert.Len(t, match, 2) {
+		t.FailNow()
+	}
+
+	token, err := url.QueryUnescape(match[1])
+	assert.Nil(t, err)
+	return token
+}
+
+func TestEmailVerificationAndPasswordReset(t *testing.T) {
+	cfg := initialSystemConfig()
+	db, err := initDB(cfg)
+	assert.Nil(t, err)
+
+	mail := mailer.NewMemoryMailer()
+	router := NewServiceEngine(user.New(cfg, db, nil, nil, mail))
+
+	name := fmt.Sprintf("mail-%d", time.Now().UnixNano())
+	email := name + "@email.com"
+	w := serve(router, "POST", "/apis/v1/user/auth/createAccount", "", user.RequestCreateAccount{
+		Username: name,
+		Email:    email,
+		Password: "password",
+	})
+	assert.Equal(t, 200, w.Code)
+	verifyToken := lastMailToken(t, mail, email)
+
+	w = serve(router, "POST", "/apis/v1/user/auth/login", "", user.RequestLogin{
+		UserId:   name,
+		Password: "password",
+	})
+	assert.Equal(t, 200, w.Code)
+	var login responseLogin
+	assert.Nil(t, MarshalResponseBody(w.Body, &login))
+
+	// unverified user can not publish public dashboard
+	w = serve(apiserver.GetEngine(), "POST", "/apis/v1/dashboard", login.Data.Token, datamodel.DashboardModel{
+		Name: "public dashboard",
+	})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = serve(apiserver.GetEngine(), "POST", "/apis/v1/dashboard", login.Data.Token, datamodel.DashboardModel{
+		Name:      "private dashboard",
+		IsPrivacy: true,
+	})
+	assert.Equal(t, 200, w.Code)
+
+	// the token of password reset can not verify email
+	w = serve(router, "POST", "/apis/v1/user/auth/forgotPassword", "", user.RequestForgotPassword{Email: email})
+	assert.Equal(t, 200, w.Code)
+	resetToken := lastMailToken(t, mail, email)
+
+	w = serve(router, "POST", "/apis/v1/user/auth/verifyEmail", "", user.RequestVerifyEmail{Token: resetToken})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// the action token is not an access token
+	w = serve(router, "GET", "/apis/v1/user", verifyToken, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = serve(router, "POST", "/apis/v1/user/auth/verifyEmail", "", user.RequestVerifyEmail{Token: verifyToken})
+	assert.Equal(t, 200, w.Code)
+
+	w = serve(apiserver.GetEngine(), "POST", "/apis/v1/dashboard", login.Data.Token, datamodel.DashboardModel{
+		Name: "public dashboard",
+	})
+	assert.Equal(t, 200, w.Code)
+
+	// reset password once
+	w = serve(router, "POST", "/apis/v1/user/auth/resetPassword", "", user.RequestResetPassword{
+		Token:       resetToken,
+		NewPassword: "new-password",
+	})
+	assert.Equal(t, 200, w.Code)
+
+	w = serve(router, "POST", "/apis/v1/user/auth/resetPassword", "", user.RequestResetPassword{
+		Token:       resetToken,
+		NewPassword: "other-password",
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// the sessions are logged out after reset
+	w = serve(router, "GET", "/apis/v1/user", login.Data.Token, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = serve(router, "POST", "/apis/v1/user/auth/login", "", user.RequestLogin{
+		UserId:   name,
+		Password: "new-password",
+	})
+	assert.Equal(t, 200, w.Code)
+
+	// change email after the new email is verified
+	assert.Nil(t, MarshalResponseBody(w.Body, &login))
+	newEmail := "new-" + email
+	w = serve(router, "PUT", "/apis/v1/user/email", login.Data.Token, user.RequestUpdateEmail{NewEmail: newEmail})
+	assert.Equal(t, 200, w.Code)
+
+	var current datamodel.UserModel
+	assert.Nil(t, db.Where("username = ?", name).First(&current).Error)
+	assert.Equal(t, email, current.Email)
+
+	w = serve(router, "POST", "/apis/v1/user/auth/verifyEmail", "", user.RequestVerifyEmail{Token: lastMailToken(t, mail, newEmail)})
+	assert.Equal(t, 200, w.Code)
+	assert.Nil(t, db.Where("username = ?", name).First(&current).Error)
+	assert.Equal(t, newEmail, current.Email)
+
+	// the old link can not change the email back
+	w = serve(router, "POST", "/apis/v1/user/auth/verifyEmail", "", user.RequestVerifyEmail{Token: verifyToken})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Nil(t, db.Where("username = ?", name).First(&current).Error)
+	assert.Equal(t, newEmail, current.Email)
+}
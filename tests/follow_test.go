@@ -0,0 +1,97 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"infra-3.xyz/hyperdot-node/internal/apis/service/follow"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+)
+
+func TestFollowFeed(t *testing.T) {
+	cfg := initialSystemConfig()
+	db, err := initDB(cfg)
+	assert.Nil(t, err)
+
+	followRouter := NewServiceEngine(follow.New(db))
+	follower, followerToken := createRoleUser(t, db, datamodel.RoleUser)
+	author, _ := createRoleUser(t, db, datamodel.RoleUser)
+
+	followPath := fmt.Sprintf("/apis/v1/user/%d/follow", author.ID)
+	w := serve(followRouter, "POST", fmt.Sprintf("/apis/v1/user/%d/follow", follower.ID), followerToken, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = serve(followRouter, "POST", followPath, "", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// following twice is idempotent
+	for i := 0; i < 2; i++ {
+		w = serve(followRouter, "POST", followPath, followerToken, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+
+	followed := follow.ResponseFollow{}
+	assert.Nil(t, MarshalResponseBody(w.Body, &followed))
+	assert.True(t, followed.Data.Followed)
+	assert.Equal(t, uint(1), followed.Data.Followers)
+
+	var statistics datamodel.UserStatistics
+	assert.Nil(t, db.Where("user_id = ?", follower.ID).First(&statistics).Error)
+	assert.Equal(t, uint(1), statistics.Following)
+
+	w = serve(followRouter, "GET", fmt.Sprintf("/apis/v1/user/%d/followers", author.ID), "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	followers := follow.ResponseListFollowUsers{}
+	assert.Nil(t, MarshalResponseBody(w.Body, &followers))
+	assert.Equal(t, int64(1), followers.Data.Total)
+	assert.Equal(t, follower.ID, followers.Data.Users[0].UserID)
+
+	// the public contents of followed users are listed in feed, the private are not
+	now := time.Now()
+	query := datamodel.QueryModel{UserID: author.ID, Name: "public query", CreatedAt: now.Add(-time.Hour)}
+	assert.Nil(t, db.Create(&query).Error)
+	private := datamodel.QueryModel{UserID: author.ID, Name: "private query", IsPrivacy: true}
+	assert.Nil(t, db.Create(&private).Error)
+	dashboard := datamodel.DashboardModel{UserID: author.ID, Name: "public dashboard", CreatedAt: now.Add(-time.Minute)}
+	assert.Nil(t, db.Create(&dashboard).Error)
+	fork := datamodel.DashboardModel{UserID: author.ID, Name: "fork", ForkedFromID: dashboard.ID, CreatedAt: now}
+	assert.Nil(t, db.Create(&fork).Error)
+
+	w = serve(followRouter, "GET", "/apis/v1/user/feed?limit=2", followerToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	feed := follow.ResponseFeed{}
+	assert.Nil(t, MarshalResponseBody(w.Body, &feed))
+	assert.Equal(t, 2, len(feed.Data.Items))
+	assert.Equal(t, "fork", feed.Data.Items[0].Type)
+	assert.Equal(t, dashboard.ID, feed.Data.Items[0].ForkedFromID)
+	assert.Equal(t, "dashboard", feed.Data.Items[1].Type)
+	assert.Equal(t, author.Username, feed.Data.Items[1].Username)
+	assert.NotEmpty(t, feed.Data.NextCursor)
+
+	w = serve(followRouter, "GET", "/apis/v1/user/feed?limit=2&cursor="+feed.Data.NextCursor, followerToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	next := follow.ResponseFeed{}
+	assert.Nil(t, MarshalResponseBody(w.Body, &next))
+	assert.Equal(t, 1, len(next.Data.Items))
+	assert.Equal(t, query.ID, next.Data.Items[0].ID)
+	assert.Empty(t, next.Data.NextCursor)
+
+	w = serve(followRouter, "GET", "/apis/v1/user/feed?cursor=invalid", followerToken, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// the feed is empty after unfollowing
+	w = serve(followRouter, "DELETE", followPath, followerToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = serve(followRouter, "GET", "/apis/v1/user/feed", followerToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	empty := follow.ResponseFeed{}
+	assert.Nil(t, MarshalResponseBody(w.Body, &empty))
+	assert.Equal(t, 0, len(empty.Data.Items))
+}
@@ -0,0 +1,223 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"infra-3.xyz/hyperdot-node/internal/apis/service/user"
+	"infra-3.xyz/hyperdot-node/internal/common"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+	"infra-3.xyz/hyperdot-node/internal/mailer"
+	"infra-3.xyz/hyperdot-node/internal/utils"
+)
+
+// newFakeGithub starts a fake github oauth server of user octocat.
+func newFakeGithub(t *testing.T, id int64, email string) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"access_token": "token", "token_type": "bearer"})
+	})
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"id": id, "login": "octocat", "avatar_url": "https://avatars.example/583231"})
+	})
+	mux.HandleFunc("/user/emails", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]map[string]any{{"email": email, "primary": true, "verified": true}})
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// githubConfig returns the config of github provider served by the fake server.
+func githubConfig(server *httptest.Server) *common.Config {
+	cfg := initialSystemConfig()
+	cfg.Github.Enable = true
+	cfg.Github.ClientId = "client"
+	cfg.Github.RedirectUrl = "http://localhost/apis/v1/user/auth/github/callback"
+	cfg.Github.AuthUrl = server.URL + "/login/oauth/authorize"
+	cfg.Github.TokenUrl = server.URL + "/login/oauth/access_token"
+	cfg.Github.ApiUrl = server.URL
+	return cfg
+}
+
+// githubAuthorize starts the github authorization and returns the state.
+func githubAuthorize(t *testing.T, router *gin.Engine) string {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/apis/v1/user/auth/github", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusFound, w.Code)
+
+	location, err := url.Parse(w.Header().Get("Location"))
+	assert.Nil(t, err)
+	state := location.Query().Get("state")
+	assert.NotEmpty(t, state)
+	return state
+}
+
+// githubCallback serves the github callback with the cookies.
+func githubCallback(router *gin.Engine, state string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/apis/v1/user/auth/github/callback?code=code&state="+state, nil)
+	req.AddCookie(&http.Cookie{Name: "github_oauth_state", Value: state})
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestGithubLogin(t *testing.T) {
+	// link to the account of same confirmed email
+	email := "octocat@email.com"
+	server := newFakeGithub(t, 583231, email)
+	cfg := githubConfig(server)
+
+	db, err := initDB(cfg)
+	assert.Nil(t, err)
+
+	var existing datamodel.UserModel
+	err = db.Where(datamodel.UserModel{UserBasic: datamodel.UserBasic{Email: email}}).
+		Attrs(datamodel.UserModel{UserBasic: datamodel.UserBasic{Provider: "password", Username: "octocat-password"}}).
+		FirstOrCreate(&existing).Error
+	assert.Nil(t, err)
+	assert.Nil(t, db.Model(&existing).Update("confirmed_at", time.Now()).Error)
+
+	router := NewServiceEngine(user.New(cfg, db, nil, nil, mailer.NewMemoryMailer()))
+	state := githubAuthorize(t, router)
+
+	// the state must match the cookie
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/apis/v1/user/auth/github/callback?code=code&state=forged", nil)
+	req.AddCookie(&http.Cookie{Name: "github_oauth_state", Value: state})
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = githubCallback(router, state)
+	assert.Equal(t, 200, w.Code)
+
+	var resp responseLogin
+	assert.Nil(t, MarshalResponseBody(w.Body, &resp))
+	assert.NotEmpty(t, resp.Data.Token)
+
+	var linked datamodel.UserModel
+	assert.Nil(t, db.Where("id = ?", existing.ID).First(&linked).Error)
+	assert.Equal(t, "octocat", linked.Github)
+	assert.Equal(t, "583231", linked.GithubUID)
+	if len(existing.IconUrl) == 0 {
+		assert.Equal(t, "https://avatars.example/583231", linked.IconUrl)
+	}
+}
+
+func TestGithubLinkUnconfirmedEmail(t *testing.T) {
+	cfg := initialSystemConfig()
+	db, err := initDB(cfg)
+	assert.Nil(t, err)
+
+	// the user has not confirmed the email, anyone could register it
+	existing, token := createRoleUser(t, db, datamodel.RoleUser)
+	githubId := time.Now().UnixNano()
+	server := newFakeGithub(t, githubId, existing.Email)
+	cfg = githubConfig(server)
+	router := NewServiceEngine(user.New(cfg, db, nil, nil, mailer.NewMemoryMailer()))
+
+	w := githubCallback(router, githubAuthorize(t, router))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	var count int64
+	assert.Nil(t, db.Model(&datamodel.UserModel{}).Where("github_uid = ?", strconv.FormatInt(githubId, 10)).Count(&count).Error)
+	assert.Equal(t, int64(0), count)
+
+	// the signed in user links the github account explicitly
+	w = serve(router, "POST", "/apis/v1/user/auth/github/link", "", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = serve(router, "POST", "/apis/v1/user/auth/github/link", token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var link user.ResponseGithubLink
+	assert.Nil(t, MarshalResponseBody(w.Body, &link))
+	location, err := url.Parse(link.Data.Url)
+	assert.Nil(t, err)
+	state := location.Query().Get("state")
+
+	var linkCookie *http.Cookie
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == "github_link" {
+			linkCookie = cookie
+		}
+	}
+	assert.NotNil(t, linkCookie)
+
+	// the link cookie is bound to its state
+	w = githubCallback(router, githubAuthorize(t, router), linkCookie)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = githubCallback(router, state, linkCookie)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var linked datamodel.UserModel
+	assert.Nil(t, db.Where("id = ?", existing.ID).First(&linked).Error)
+	assert.Equal(t, strconv.FormatInt(githubId, 10), linked.GithubUID)
+
+	// then the user logins by the github account
+	w = githubCallback(router, githubAuthorize(t, router))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp responseLogin
+	assert.Nil(t, MarshalResponseBody(w.Body, &resp))
+	assert.NotEmpty(t, resp.Data.Token)
+}
+
+func TestGithubLoginTwoFactor(t *testing.T) {
+	cfg := initialSystemConfig()
+	db, err := initDB(cfg)
+	assert.Nil(t, err)
+
+	existing, _ := createRoleUser(t, db, datamodel.RoleUser)
+	now := time.Now()
+	assert.Nil(t, db.Model(existing).Update("confirmed_at", now).Error)
+	secret, err := utils.GenerateTOTPSecret()
+	assert.Nil(t, err)
+	assert.Nil(t, db.Create(&datamodel.UserTwoFactorModel{
+		UserID:    existing.ID,
+		Secret:    secret,
+		EnabledAt: &now,
+	}).Error)
+
+	server := newFakeGithub(t, time.Now().UnixNano(), existing.Email)
+	cfg = githubConfig(server)
+	router := NewServiceEngine(user.New(cfg, db, nil, nil, mailer.NewMemoryMailer()))
+
+	// the github login requires the second factor as the password login
+	w := githubCallback(router, githubAuthorize(t, router))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var pending responseLogin
+	assert.Nil(t, MarshalResponseBody(w.Body, &pending))
+	assert.True(t, pending.Data.TwoFactorRequired)
+	assert.NotEmpty(t, pending.Data.TwoFactorToken)
+	assert.Empty(t, pending.Data.Token)
+
+	var sessions int64
+	assert.Nil(t, db.Model(&datamodel.UserSessionModel{}).Where("user_id = ?", existing.ID).Count(&sessions).Error)
+	assert.Equal(t, int64(0), sessions)
+
+	// the pending token is passed to the login page
+	cfg.Github.LoginRedirectUrl = "http://localhost/login"
+	router = NewServiceEngine(user.New(cfg, db, nil, nil, mailer.NewMemoryMailer()))
+	w = githubCallback(router, githubAuthorize(t, router))
+	assert.Equal(t, http.StatusFound, w.Code)
+
+	location, err := url.Parse(w.Header().Get("Location"))
+	assert.Nil(t, err)
+	assert.NotEmpty(t, location.Query().Get("two_factor_token"))
+	assert.Nil(t, db.Model(&datamodel.UserSessionModel{}).Where("user_id = ?", existing.ID).Count(&sessions).Error)
+	assert.Equal(t, int64(0), sessions)
+}
@@ -0,0 +1,35 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"infra-3.xyz/hyperdot-node/internal/apis/service/user"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+	"infra-3.xyz/hyperdot-node/internal/mailer"
+)
+
+func TestLoginLockout(t *testing.T) {
+	cfg := initialSystemConfig()
+	db, err := initDB(cfg)
+	assert.Nil(t, err)
+
+	userRouter := NewServiceEngine(user.New(cfg, db, nil, nil, mailer.NewMemoryMailer()))
+	account, _ := createRoleUser(t, db, datamodel.RoleUser)
+
+	wrong := user.RequestLogin{UserId: account.Username, Password: "wrong"}
+	for i := 0; i < 5; i++ {
+		w := serve(userRouter, "POST", "/apis/v1/user/auth/login", "", wrong)
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+
+	// the correct password is rejected during the lockout
+	w := serve(userRouter, "POST", "/apis/v1/user/auth/login", "", user.RequestLogin{
+		UserId:   account.Username,
+		Password: "test",
+	})
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+}
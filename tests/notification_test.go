@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"infra-3.xyz/hyperdot-node/internal/apis/service/comment"
+	"infra-3.xyz/hyperdot-node/internal/apis/service/dashboard"
+	"infra-3.xyz/hyperdot-node/internal/apis/service/notification"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+)
+
+func TestNotifications(t *testing.T) {
+	cfg := initialSystemConfig()
+	db, err := initDB(cfg)
+	assert.Nil(t, err)
+
+	dashboardRouter := NewServiceEngine(dashboard.New(db, nil))
+	commentRouter := NewServiceEngine(comment.New(db))
+	notificationRouter := NewServiceEngine(notification.New(db))
+	owner, ownerToken := createRoleUser(t, db, datamodel.RoleUser)
+	actor, actorToken := createRoleUser(t, db, datamodel.RoleUser)
+
+	board := datamodel.DashboardModel{UserID: owner.ID, Name: "public dashboard"}
+	assert.Nil(t, db.Create(&board).Error)
+
+	// favoriting twice notifies once, and the owner is not notified of own actions
+	favorite := datamodel.UserDashboardFavorites{DashboardID: board.ID, DashboardUserID: owner.ID}
+	for _, path := range []string{"favorite", "unfavorite", "favorite"} {
+		w := serve(dashboardRouter, "PUT", "/apis/v1/dashboard/"+path, actorToken, favorite)
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+	w := serve(dashboardRouter, "PUT", "/apis/v1/dashboard/favorite", ownerToken, favorite)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// the mentioned owner gets a mention instead of a comment notification
+	w = serve(commentRouter, "POST", fmt.Sprintf("/apis/v1/dashboard/%d/comments", board.ID), actorToken,
+		comment.RequestCreateComment{Body: "nice work @" + owner.Username})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = serve(notificationRouter, "GET", "/apis/v1/user/notifications", ownerToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	list := notification.ResponseListNotifications{}
+	assert.Nil(t, MarshalResponseBody(w.Body, &list))
+	assert.Equal(t, int64(2), list.Data.Total)
+	assert.Equal(t, int64(2), list.Data.Unread)
+	assert.Equal(t, datamodel.NotificationMention, list.Data.Notifications[0].Type)
+	assert.Equal(t, datamodel.NotificationFavorite, list.Data.Notifications[1].Type)
+	assert.Equal(t, actor.Username, list.Data.Notifications[1].ActorUsername)
+	assert.Equal(t, board.ID, list.Data.Notifications[1].TargetID)
+
+	// the notifications of others can not be marked
+	w = serve(notificationRouter, "PUT", "/apis/v1/user/notifications/read", actorToken,
+		notification.RequestMarkRead{IDs: []uint{list.Data.Notifications[0].ID}})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = serve(notificationRouter, "PUT", "/apis/v1/user/notifications/read", ownerToken,
+		notification.RequestMarkRead{IDs: []uint{list.Data.Notifications[0].ID}})
+	assert.Equal(t, http.StatusOK, w.Code)
+	unread := notification.ResponseUnread{}
+	assert.Nil(t, MarshalResponseBody(w.Body, &unread))
+	assert.Equal(t, int64(1), unread.Data.Unread)
+
+	w = serve(notificationRouter, "GET", "/apis/v1/user/notifications?unread=true", ownerToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	unreadList := notification.ResponseListNotifications{}
+	assert.Nil(t, MarshalResponseBody(w.Body, &unreadList))
+	assert.Equal(t, int64(1), unreadList.Data.Total)
+	assert.Equal(t, datamodel.NotificationFavorite, unreadList.Data.Notifications[0].Type)
+
+	// the disabled types are not notified
+	w = serve(notificationRouter, "PUT", "/apis/v1/user/notifications/preferences", ownerToken,
+		notification.RequestUpdatePreferences{Preferences: map[string]bool{"unknown": false}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = serve(notificationRouter, "PUT", "/apis/v1/user/notifications/preferences", ownerToken,
+		notification.RequestUpdatePreferences{Preferences: map[string]bool{datamodel.NotificationComment: false}})
+	assert.Equal(t, http.StatusOK, w.Code)
+	preferences := notification.ResponsePreferences{}
+	assert.Nil(t, MarshalResponseBody(w.Body, &preferences))
+	assert.False(t, preferences.Data[datamodel.NotificationComment])
+	assert.True(t, preferences.Data[datamodel.NotificationFork])
+
+	w = serve(commentRouter, "POST", fmt.Sprintf("/apis/v1/dashboard/%d/comments", board.ID), actorToken,
+		comment.RequestCreateComment{Body: "another comment"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = serve(notificationRouter, "PUT", "/apis/v1/user/notifications/read", ownerToken, notification.RequestMarkRead{All: true})
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = serve(notificationRouter, "GET", "/apis/v1/user/notifications/unread", ownerToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	unread = notification.ResponseUnread{}
+	assert.Nil(t, MarshalResponseBody(w.Body, &unread))
+	assert.Equal(t, int64(0), unread.Data.Unread)
+
+	var count int64
+	assert.Nil(t, db.Model(&datamodel.NotificationModel{}).Where("user_id = ?", owner.ID).Count(&count).Error)
+	assert.Equal(t, int64(2), count)
+}
@@ -0,0 +1,181 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"infra-3.xyz/hyperdot-node/internal/apis/service/dashboard"
+	"infra-3.xyz/hyperdot-node/internal/apis/service/permission"
+	"infra-3.xyz/hyperdot-node/internal/apis/service/query"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+)
+
+func TestContentPermission(t *testing.T) {
+	cfg := initialSystemConfig()
+	db, err := initDB(cfg)
+	assert.Nil(t, err)
+
+	dashboardRouter := NewServiceEngine(dashboard.New(db, nil))
+	permissionRouter := NewServiceEngine(permission.New(db))
+	owner, ownerToken := createRoleUser(t, db, datamodel.RoleUser)
+	grantee, granteeToken := createRoleUser(t, db, datamodel.RoleUser)
+	other, otherToken := createRoleUser(t, db, datamodel.RoleUser)
+
+	private := datamodel.DashboardModel{UserID: owner.ID, Name: "private", IsPrivacy: true}
+	assert.Nil(t, db.Create(&private).Error)
+
+	dashboardPath := fmt.Sprintf("/apis/v1/dashboard/%d", private.ID)
+	permissionsPath := dashboardPath + "/permissions"
+
+	// the private dashboard is not readable by other users
+	w := serve(dashboardRouter, "GET", dashboardPath, "", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = serve(dashboardRouter, "GET", dashboardPath, granteeToken, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = serve(permissionRouter, "POST", permissionsPath, granteeToken, permission.RequestGrantPermission{
+		UserID:     grantee.ID,
+		Permission: datamodel.PermissionViewer,
+	})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = serve(permissionRouter, "POST", permissionsPath, ownerToken, permission.RequestGrantPermission{
+		UserID:     owner.ID,
+		Permission: datamodel.PermissionViewer,
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// the viewers read but can not update
+	w = serve(permissionRouter, "POST", permissionsPath, ownerToken, permission.RequestGrantPermission{
+		Username:   grantee.Username,
+		Permission: datamodel.PermissionViewer,
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = serve(dashboardRouter, "GET", dashboardPath, granteeToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = serve(dashboardRouter, "GET", dashboardPath, otherToken, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	private.Description = "shared"
+	w = serve(dashboardRouter, "PUT", "/apis/v1/dashboard", granteeToken, private)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// the editors update but can not publish, delete or share
+	w = serve(permissionRouter, "POST", permissionsPath, ownerToken, permission.RequestGrantPermission{
+		UserID:     grantee.ID,
+		Permission: datamodel.PermissionEditor,
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = serve(dashboardRouter, "PUT", "/apis/v1/dashboard", granteeToken, private)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Nil(t, db.First(&private, private.ID).Error)
+	assert.Equal(t, "shared", private.Description)
+	assert.Equal(t, owner.ID, private.UserID)
+
+	private.IsPrivacy = false
+	w = serve(dashboardRouter, "PUT", "/apis/v1/dashboard", granteeToken, private)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	private.IsPrivacy = true
+
+	w = serve(permissionRouter, "POST", permissionsPath, granteeToken, permission.RequestGrantPermission{
+		UserID:     other.ID,
+		Permission: datamodel.PermissionViewer,
+	})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = serve(dashboardRouter, "DELETE", dashboardPath, granteeToken, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = serve(permissionRouter, "GET", permissionsPath, granteeToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	permissions := permission.ResponseListPermissions{}
+	assert.Nil(t, MarshalResponseBody(w.Body, &permissions))
+	assert.Equal(t, 1, len(permissions.Data))
+	assert.Equal(t, datamodel.PermissionEditor, permissions.Data[0].Permission)
+
+	// the revoked user can not read any more
+	w = serve(permissionRouter, "DELETE", fmt.Sprintf("%s/%d", permissionsPath, grantee.ID), ownerToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = serve(dashboardRouter, "GET", dashboardPath, granteeToken, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = serve(dashboardRouter, "DELETE", dashboardPath, ownerToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestContentEditorReferences(t *testing.T) {
+	cfg := initialSystemConfig()
+	db, err := initDB(cfg)
+	assert.Nil(t, err)
+
+	dashboardRouter := NewServiceEngine(dashboard.New(db, nil))
+	queryRouter := NewServiceEngine(query.New(nil, cfg, db, nil))
+	owner, _ := createRoleUser(t, db, datamodel.RoleUser)
+	editor, editorToken := createRoleUser(t, db, datamodel.RoleUser)
+	other, _ := createRoleUser(t, db, datamodel.RoleUser)
+
+	shared := datamodel.QueryModel{UserID: owner.ID, Name: "shared", Query: "select 1", QueryEngine: "bigquery", IsPrivacy: true}
+	assert.Nil(t, db.Create(&shared).Error)
+	private := datamodel.QueryModel{UserID: other.ID, Name: "private", Query: "select 2", QueryEngine: "bigquery", IsPrivacy: true}
+	assert.Nil(t, db.Create(&private).Error)
+	privateChart := datamodel.ChartModel{QueryID: private.ID, UserID: other.ID, Name: "private"}
+	assert.Nil(t, db.Create(&privateChart).Error)
+
+	board := datamodel.DashboardModel{UserID: owner.ID, Name: "board", IsPrivacy: true}
+	assert.Nil(t, db.Create(&board).Error)
+	otherPanel := datamodel.DashboardPanelModel{UserID: other.ID, Name: "other"}
+	assert.Nil(t, db.Create(&otherPanel).Error)
+
+	for _, content := range []struct {
+		contentType string
+		id          uint
+	}{{datamodel.ContentTypeQuery, shared.ID}, {datamodel.ContentTypeDashboard, board.ID}} {
+		assert.Nil(t, db.Create(&datamodel.ContentPermissionModel{
+			ContentType: content.contentType,
+			ContentID:   content.id,
+			UserID:      editor.ID,
+			Permission:  datamodel.PermissionEditor,
+		}).Error)
+	}
+
+	// the charts of other queries are created as new charts
+	shared.Charts = []datamodel.ChartModel{{ID: privateChart.ID, Name: "taken"}}
+	w := serve(queryRouter, "PUT", "/apis/v1/query", editorToken, shared)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	assert.Nil(t, db.First(&privateChart, privateChart.ID).Error)
+	assert.Equal(t, private.ID, privateChart.QueryID)
+	assert.Equal(t, "private", privateChart.Name)
+
+	var charts []datamodel.ChartModel
+	assert.Nil(t, db.Where("query_id = ?", shared.ID).Find(&charts).Error)
+	assert.Equal(t, 1, len(charts))
+	assert.NotEqual(t, privateChart.ID, charts[0].ID)
+
+	// the panels of other dashboards are created as new panels
+	board.Panels = []datamodel.DashboardPanelModel{{ID: otherPanel.ID, Name: "moved", QueryID: shared.ID}}
+	w = serve(dashboardRouter, "PUT", "/apis/v1/dashboard", editorToken, board)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	assert.Nil(t, db.First(&otherPanel, otherPanel.ID).Error)
+	assert.Equal(t, uint(0), otherPanel.DashboardID)
+	assert.Equal(t, "other", otherPanel.Name)
+
+	var panels []datamodel.DashboardPanelModel
+	assert.Nil(t, db.Where("dashboard_id = ?", board.ID).Find(&panels).Error)
+	assert.Equal(t, 1, len(panels))
+	assert.NotEqual(t, otherPanel.ID, panels[0].ID)
+
+	// the panels can not reference the queries and charts the editor can not view
+	board.Panels = append(panels, datamodel.DashboardPanelModel{Name: "private", QueryID: private.ID, ChartID: privateChart.ID})
+	w = serve(dashboardRouter, "PUT", "/apis/v1/dashboard", editorToken, board)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	board.Panels = append(panels, datamodel.DashboardPanelModel{Name: "mismatched", QueryID: shared.ID, ChartID: privateChart.ID})
+	w = serve(dashboardRouter, "PUT", "/apis/v1/dashboard", editorToken, board)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
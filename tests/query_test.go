@@ -1,6 +1,7 @@
 package tests
 
 import (
+	"encoding/csv"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -98,3 +99,70 @@ func TestCRUDQuery(t *testing.T) {
 	router.ServeHTTP(w, req)
 	assert.Equal(t, http.StatusNotFound, w.Code)
 }
+
+func TestQueryResults(t *testing.T) {
+	router := apiserver.GetEngine()
+	w := httptest.NewRecorder()
+
+	req, _ := MakeTokenRequest("POST", "/apis/v1/query", datamodel.QueryModel{
+		Name:        "results",
+		Query:       "select * from `bigquery-public-data.crypto_polkadot.AAA_tableschema` limit {{limit}}",
+		QueryEngine: "bigquery",
+	})
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	created := query.Response{}
+	if err := MarshalResponseBody(w.Body, &created); err != nil {
+		t.Fatal(err)
+	}
+	path := fmt.Sprintf("/apis/v1/query/%d/results", created.Data.ID)
+
+	// the parameters must be given and safe
+	for _, q := range []string{"", "?params[limit]=1%20or%201=1"} {
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", path+q, nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	}
+
+	// the guests only read the cached results
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", path+"?params[limit]=2&max_age=0", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = MakeTokenRequest("GET", path+"?params[limit]=2&max_age=0", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	executed := query.ResponseQueryResult{}
+	if err := MarshalResponseBody(w.Body, &executed); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, len(executed.Data.Rows))
+	assert.Equal(t, "2", executed.Data.Params["limit"])
+
+	// the cached result is served, even to guests asking for a fresh one
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", path+"?params[limit]=2&max_age=0", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	cached := query.ResponseQueryResult{}
+	if err := MarshalResponseBody(w.Body, &cached); err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, executed.Data.UpdatedAt.Equal(cached.Data.UpdatedAt))
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", path+"?params[limit]=2&format=csv", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+
+	records, err := csv.NewReader(w.Body).ReadAll()
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(records))
+}
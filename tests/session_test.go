@@ -0,0 +1,144 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"infra-3.xyz/hyperdot-node/internal/apis/service/user"
+	"infra-3.xyz/hyperdot-node/internal/mailer"
+)
+
+type responseLogin struct {
+	Success bool               `json:"success"`
+	Data    user.ResponseLogin `json:"data"`
+}
+
+func login(t *testing.T) user.ResponseLogin {
+	router := apiserver.GetEngine()
+	w := httptest.NewRecorder()
+	req, _ := MakeTokenRequest("POST", "/apis/v1/user/auth/login", user.RequestLogin{
+		UserId:   "test",
+		Password: "test",
+		Provider: "password",
+	})
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	var resp responseLogin
+	assert.Nil(t, MarshalResponseBody(w.Body, &resp))
+	assert.True(t, resp.Success)
+	assert.NotEmpty(t, resp.Data.RefreshToken)
+	return resp.Data
+}
+
+func refresh(refreshToken string) (*httptest.ResponseRecorder, responseLogin) {
+	router := apiserver.GetEngine()
+	w := httptest.NewRecorder()
+	req, _ := MakeTokenRequest("POST", "/apis/v1/user/auth/refresh", user.RequestRefreshToken{
+		RefreshToken: refreshToken,
+	})
+	router.ServeHTTP(w, req)
+
+	var resp responseLogin
+	_ = MarshalResponseBody(w.Body, &resp)
+	return w, resp
+}
+
+func getCurrentUser(token string) int {
+	router := apiserver.GetEngine()
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/apis/v1/user", nil)
+	req.Header.Add("Authorization", token)
+	router.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestSessionRefreshRotation(t *testing.T) {
+	tokens := login(t)
+	assert.Equal(t, 200, getCurrentUser(tokens.Token))
+
+	w, rotated := refresh(tokens.RefreshToken)
+	assert.Equal(t, 200, w.Code)
+	assert.NotEqual(t, tokens.RefreshToken, rotated.Data.RefreshToken)
+	assert.Equal(t, 200, getCurrentUser(rotated.Data.Token))
+
+	// reusing the rotated refresh token revokes the whole session
+	w, _ = refresh(tokens.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w, _ = refresh(rotated.Data.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, http.StatusUnauthorized, getCurrentUser(rotated.Data.Token))
+}
+
+func TestSessionLogout(t *testing.T) {
+	router := apiserver.GetEngine()
+	current := login(t)
+	other := login(t)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/apis/v1/user/auth/logout", nil)
+	req.Header.Add("Authorization", current.Token)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	assert.Equal(t, http.StatusUnauthorized, getCurrentUser(current.Token))
+	assert.Equal(t, 200, getCurrentUser(other.Token))
+
+	w, _ = refresh(current.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// logout all sessions
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/apis/v1/user/auth/logoutAll", nil)
+	req.Header.Add("Authorization", other.Token)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	assert.Equal(t, http.StatusUnauthorized, getCurrentUser(other.Token))
+	w, _ = refresh(other.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestSessionPasswordChange(t *testing.T) {
+	cfg := initialSystemConfig()
+	db, err := initDB(cfg)
+	assert.Nil(t, err)
+
+	router := NewServiceEngine(user.New(cfg, db, nil, nil, mailer.NewMemoryMailer()))
+	name := fmt.Sprintf("session-%d", time.Now().UnixNano())
+	w := serve(router, "POST", "/apis/v1/user/auth/createAccount", "", user.RequestCreateAccount{
+		Username: name,
+		Email:    name + "@email.com",
+		Password: "password",
+	})
+	assert.Equal(t, 200, w.Code)
+
+	sessions := make([]responseLogin, 2)
+	for i := range sessions {
+		w = serve(router, "POST", "/apis/v1/user/auth/login", "", user.RequestLogin{
+			UserId:   name,
+			Password: "password",
+		})
+		assert.Equal(t, 200, w.Code)
+		assert.Nil(t, MarshalResponseBody(w.Body, &sessions[i]))
+	}
+
+	// the other sessions are logged out after the password changes
+	w = serve(router, "PUT", "/apis/v1/user/password", sessions[0].Data.Token, user.RequestUpdatePassword{
+		CurrentPassword: "password",
+		NewPassword:     "new-password",
+	})
+	assert.Equal(t, 200, w.Code)
+
+	w = serve(router, "GET", "/apis/v1/user", sessions[0].Data.Token, nil)
+	assert.Equal(t, 200, w.Code)
+	w = serve(router, "GET", "/apis/v1/user", sessions[1].Data.Token, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = serve(router, "POST", "/apis/v1/user/auth/refresh", "", user.RequestRefreshToken{RefreshToken: sessions[1].Data.RefreshToken})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
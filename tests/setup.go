@@ -9,14 +9,20 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"os"
 	"strings"
+	"testing"
+	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"infra-3.xyz/hyperdot-node/internal/apis/base"
+	"infra-3.xyz/hyperdot-node/internal/apis/service/user"
 	"infra-3.xyz/hyperdot-node/internal/dataengine"
 	"infra-3.xyz/hyperdot-node/internal/datamodel"
 	"infra-3.xyz/hyperdot-node/internal/utils"
@@ -72,8 +78,8 @@ func initRedisClient(cfg *common.Config) (*redis.Client, error) {
 	return redisClient, nil
 }
 
-func initJobs(jobManager *jobs.JobManager, store *store.BoltStore) error {
-	if err := jobManager.Init(store); err != nil {
+func initJobs(jobManager *jobs.JobManager, store *store.BoltStore, db *gorm.DB, engines map[string]dataengine.QueryEngine, s3Client *clients.SimpleS3Cliet) error {
+	if err := jobManager.Init(store, db, engines, s3Client); err != nil {
 		return err
 	}
 
@@ -135,6 +141,78 @@ func initDB(cfg *common.Config) (*gorm.DB, error) {
 		return nil, err
 	}
 
+	if err := db.AutoMigrate(&datamodel.ShareTokenModel{}); err != nil {
+		return nil, err
+	}
+
+	if err := db.AutoMigrate(&datamodel.UserSessionModel{}); err != nil {
+		return nil, err
+	}
+
+	if err := db.AutoMigrate(&datamodel.ApiKeyModel{}); err != nil {
+		return nil, err
+	}
+
+	if err := db.AutoMigrate(&datamodel.WorkspaceModel{}); err != nil {
+		return nil, err
+	}
+
+	if err := db.AutoMigrate(&datamodel.WorkspaceMemberModel{}); err != nil {
+		return nil, err
+	}
+
+	if err := db.AutoMigrate(&datamodel.WorkspaceStatistics{}); err != nil {
+		return nil, err
+	}
+
+	if err := db.AutoMigrate(&datamodel.ContentPermissionModel{}); err != nil {
+		return nil, err
+	}
+
+	if err := db.AutoMigrate(&datamodel.AuditLogModel{}); err != nil {
+		return nil, err
+	}
+
+	if err := db.AutoMigrate(&datamodel.UserTwoFactorModel{}); err != nil {
+		return nil, err
+	}
+
+	if err := db.AutoMigrate(&datamodel.UserRecoveryCodeModel{}); err != nil {
+		return nil, err
+	}
+
+	if err := db.AutoMigrate(&datamodel.UserFollowModel{}); err != nil {
+		return nil, err
+	}
+
+	if err := db.AutoMigrate(&datamodel.CommentModel{}); err != nil {
+		return nil, err
+	}
+
+	if err := db.AutoMigrate(&datamodel.CommentMentionModel{}); err != nil {
+		return nil, err
+	}
+
+	if err := db.AutoMigrate(&datamodel.NotificationModel{}); err != nil {
+		return nil, err
+	}
+
+	if err := db.AutoMigrate(&datamodel.WebhookModel{}); err != nil {
+		return nil, err
+	}
+
+	if err := db.AutoMigrate(&datamodel.WebhookDeliveryModel{}); err != nil {
+		return nil, err
+	}
+
+	if err := db.AutoMigrate(&datamodel.AlertModel{}); err != nil {
+		return nil, err
+	}
+
+	if err := datamodel.MigrateZeroDeletedAt(db, &datamodel.UserModel{}, &datamodel.DashboardModel{}); err != nil {
+		return nil, err
+	}
+
 	if err := datamodel.HackAutoMigrate(db); err != nil {
 		return nil, err
 	}
@@ -166,12 +244,15 @@ func createTestUser(db *gorm.DB) error {
 	if err != nil {
 		return err
 	}
+	// the test user publishes public contents which requires verified email
+	confirmedAt := time.Now()
 	user := &datamodel.UserModel{
 		UserBasic: datamodel.UserBasic{
 			Provider:          "password",
 			Username:          "test",
 			Email:             "test",
 			EncryptedPassword: password,
+			ConfirmedAt:       &confirmedAt,
 		},
 	}
 
@@ -228,6 +309,59 @@ func MakeTokenRequest(method string, path string, body any) (*http.Request, erro
 	return req, nil
 }
 
+// serve serves the request with the token on router, the request is sent by guest if
+// the token is empty.
+func serve(router *gin.Engine, method string, path string, token string, body any) *httptest.ResponseRecorder {
+	req, _ := MakeTokenRequest(method, path, body)
+	req.Header.Del("Authorization")
+	if len(token) > 0 {
+		req.Header.Add("Authorization", token)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// createRoleUser creates a password user of role and returns it with a token.
+func createRoleUser(t *testing.T, db *gorm.DB, role string) (*datamodel.UserModel, string) {
+	password, err := utils.GeneratePassword("test")
+	assert.Nil(t, err)
+
+	name := fmt.Sprintf("%s-%d", role, time.Now().UnixNano())
+	model := &datamodel.UserModel{
+		UserBasic: datamodel.UserBasic{
+			Provider:          user.PasswordProvider,
+			Username:          name,
+			Email:             name + "@email.com",
+			EncryptedPassword: password,
+		},
+		Role: role,
+	}
+	assert.Nil(t, db.Create(model).Error)
+
+	token, err := base.GenerateJwtToken(model.ToClaims(), base.TokenDefaultExpireTime())
+	assert.Nil(t, err)
+	return model, token
+}
+
+// NewServiceEngine builds a gin engine of the service routes, it tests the service
+// created by the dependencies different from api server, e.g. a fake oauth server.
+func NewServiceEngine(svc apis.Router) *gin.Engine {
+	engine := gin.New()
+	versionUrl := fmt.Sprintf("%s/%s", apis.BASE, apis.CURRENT_VERSION)
+	router := engine.Group(versionUrl)
+	for _, table := range svc.RouteTables() {
+		handlers, err := apis.BuildHandlers(versionUrl, &table)
+		if err != nil {
+			log.Fatal(err)
+		}
+		router.Handle(table.Method, table.Path, handlers...)
+	}
+
+	return engine
+}
+
 func MarshalResponseBody(body *bytes.Buffer, to interface{}) error {
 	if err := json.Unmarshal(body.Bytes(), to); err != nil {
 		return err
@@ -262,12 +396,6 @@ func SetupApiServer() *apis.ApiServer {
 		log.Fatalf("Error initial redis client: %v", err)
 	}
 
-	jobManager := jobs.NewJobManager(cfg)
-
-	if err := initJobs(jobManager, boltStore); err != nil {
-		log.Fatalf("Error initial jobs: %v", err)
-	}
-
 	db, err := initDB(cfg)
 	if err != nil {
 		log.Fatalf("Error initial database: %v", err)
@@ -283,11 +411,17 @@ func SetupApiServer() *apis.ApiServer {
 		log.Fatalf("Error initial s3 client: %v", err)
 	}
 
+	jobManager := jobs.NewJobManager(cfg)
+
+	if err := initJobs(jobManager, boltStore, db, engines, s3Client); err != nil {
+		log.Fatalf("Error initial jobs: %v", err)
+	}
+
 	if err := createTestUser(db); err != nil {
 		log.Fatalf("Error creating test user: %v", err)
 	}
 
-	apiserver, err := apis.NewApiServer(boltStore, cfg, db, engines, s3Client)
+	apiserver, err := apis.NewApiServer(boltStore, cfg, db, engines, s3Client, jobManager)
 	if err != nil {
 		log.Fatalf("Error creating apiserver: %v", err)
 	}
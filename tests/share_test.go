@@ -0,0 +1,102 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"infra-3.xyz/hyperdot-node/internal/apis/service/dashboard"
+	"infra-3.xyz/hyperdot-node/internal/apis/service/share"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+	"infra-3.xyz/hyperdot-node/internal/utils"
+)
+
+func TestShareDashboard(t *testing.T) {
+	router := apiserver.GetEngine()
+	db, err := initDB(initialSystemConfig())
+	assert.Nil(t, err)
+
+	w := httptest.NewRecorder()
+	req, _ := MakeTokenRequest("POST", "/apis/v1/dashboard", datamodel.DashboardModel{
+		Name:      "shared",
+		IsPrivacy: true,
+	})
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	created := dashboard.Response{}
+	if err := MarshalResponseBody(w.Body, &created); err != nil {
+		t.Fatal(err)
+	}
+
+	// create share token
+	w = httptest.NewRecorder()
+	req, _ = MakeTokenRequest("POST", "/apis/v1/share", share.RequestCreateShare{
+		DashboardID: created.Data.ID,
+		ExpiresIn:   3600,
+	})
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	shared := share.ResponseShare{}
+	if err := MarshalResponseBody(w.Body, &shared); err != nil {
+		t.Fatal(err)
+	}
+	assert.NotEmpty(t, shared.Data.Token)
+
+	// guest access
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/apis/v1/share/public/"+shared.Data.Token, nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "frame-ancestors *", w.Header().Get("Content-Security-Policy"))
+
+	content := share.ResponseShared{}
+	if err := MarshalResponseBody(w.Body, &content); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, created.Data.ID, content.Data.Dashboard.ID)
+
+	// only the hash of token is stored
+	var stored datamodel.ShareTokenModel
+	assert.Nil(t, db.Where("id = ?", shared.Data.ID).First(&stored).Error)
+	assert.Equal(t, utils.HashToken(shared.Data.Token), stored.TokenHash)
+
+	// the sql of referenced queries is not shared and the query is never executed by token
+	query := datamodel.QueryModel{UserID: created.Data.UserID, Name: "shared query", Query: "select 1", QueryEngine: "bigquery", IsPrivacy: true}
+	assert.Nil(t, db.Create(&query).Error)
+	assert.Nil(t, db.Create(&datamodel.DashboardPanelModel{UserID: created.Data.UserID, DashboardID: created.Data.ID, QueryID: query.ID}).Error)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/apis/v1/share/public/"+shared.Data.Token, nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+	assert.Nil(t, MarshalResponseBody(w.Body, &content))
+	assert.Len(t, content.Data.Queries, 1)
+	assert.Empty(t, content.Data.Queries[0].Query)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", fmt.Sprintf("/apis/v1/share/public/%s/results/%d", shared.Data.Token, query.ID), nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// the trashed query is not shared any more
+	assert.Nil(t, db.Delete(&query).Error)
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", fmt.Sprintf("/apis/v1/share/public/%s/results/%d", shared.Data.Token, query.ID), nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// revoke
+	w = httptest.NewRecorder()
+	req, _ = MakeTokenRequest("DELETE", fmt.Sprintf("/apis/v1/share/%d", shared.Data.ID), nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/apis/v1/share/public/"+shared.Data.Token, nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+
+	"infra-3.xyz/hyperdot-node/internal/apis/service/dashboard"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+	"infra-3.xyz/hyperdot-node/internal/jobs"
+)
+
+func loadUserStatistics(t *testing.T, db *gorm.DB, userId uint) datamodel.UserStatistics {
+	var statistics datamodel.UserStatistics
+	assert.Nil(t, db.Where("user_id = ?", userId).First(&statistics).Error)
+	return statistics
+}
+
+func TestUserStatistics(t *testing.T) {
+	cfg := initialSystemConfig()
+	db, err := initDB(cfg)
+	assert.Nil(t, err)
+
+	router := NewServiceEngine(dashboard.New(db, nil))
+	owner, ownerToken := createRoleUser(t, db, datamodel.RoleUser)
+	other, otherToken := createRoleUser(t, db, datamodel.RoleUser)
+
+	w := serve(router, "POST", "/apis/v1/dashboard", ownerToken, datamodel.DashboardModel{Name: "counted dashboard"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	created := dashboard.Response{}
+	assert.Nil(t, MarshalResponseBody(w.Body, &created))
+	assert.Equal(t, uint(1), loadUserStatistics(t, db, owner.ID).Dashboards)
+
+	// the star is counted to the owner whatever the dashboard_user_id of request is
+	favorite := datamodel.UserDashboardFavorites{DashboardID: created.Data.ID, DashboardUserID: other.ID}
+	w = serve(router, "PUT", "/apis/v1/dashboard/favorite", otherToken, favorite)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = serve(router, "PUT", "/apis/v1/dashboard/favorite", otherToken, favorite)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, uint(1), loadUserStatistics(t, db, owner.ID).Stars)
+
+	// unfavoriting twice does not underflow the stars
+	for i := 0; i < 2; i++ {
+		w = serve(router, "PUT", "/apis/v1/dashboard/unfavorite", otherToken, favorite)
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+	assert.Equal(t, uint(0), loadUserStatistics(t, db, owner.ID).Stars)
+
+	w = serve(router, "PUT", "/apis/v1/dashboard/favorite", otherToken, favorite)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// the trashed dashboard and its stars are not counted until it is restored
+	boardPath := fmt.Sprintf("/apis/v1/dashboard/%d", created.Data.ID)
+	w = serve(router, "DELETE", boardPath, ownerToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	statistics := loadUserStatistics(t, db, owner.ID)
+	assert.Equal(t, uint(0), statistics.Dashboards)
+	assert.Equal(t, uint(0), statistics.Stars)
+
+	w = serve(router, "POST", boardPath+"/restore", ownerToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	statistics = loadUserStatistics(t, db, owner.ID)
+	assert.Equal(t, uint(1), statistics.Dashboards)
+	assert.Equal(t, uint(1), statistics.Stars)
+
+	// the drifted statistics are fixed by the reconciler
+	assert.Nil(t, db.Model(&datamodel.UserStatistics{}).Where("user_id = ?", owner.ID).
+		Updates(map[string]any{"dashboards": 7, "stars": 9, "queries": 3}).Error)
+	fixed, err := jobs.NewStatisticsReconciler(db).Do()
+	assert.Nil(t, err)
+	assert.GreaterOrEqual(t, fixed, 1)
+	statistics = loadUserStatistics(t, db, owner.ID)
+	assert.Equal(t, uint(1), statistics.Dashboards)
+	assert.Equal(t, uint(1), statistics.Stars)
+	assert.Equal(t, uint(0), statistics.Queries)
+}
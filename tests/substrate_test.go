@@ -0,0 +1,69 @@
+package tests
+
+import (
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ChainSafe/go-schnorrkel"
+	"github.com/stretchr/testify/assert"
+	"infra-3.xyz/hyperdot-node/internal/apis/service/user"
+	"infra-3.xyz/hyperdot-node/internal/utils"
+)
+
+type responseSubstrateNonce struct {
+	Success bool                            `json:"success"`
+	Data    user.ResponseSubstrateNonceData `json:"data"`
+}
+
+func TestSubstrateLogin(t *testing.T) {
+	router := apiserver.GetEngine()
+	secret, public, err := schnorrkel.GenerateKeypair()
+	assert.Nil(t, err)
+	publicKey := public.Encode()
+	address := utils.EncodeSS58(0, publicKey[:])
+
+	w := httptest.NewRecorder()
+	req, _ := MakeTokenRequest("POST", "/apis/v1/user/auth/substrate/nonce", user.RequestSubstrateNonce{
+		Address: address,
+	})
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	var nonce responseSubstrateNonce
+	assert.Nil(t, MarshalResponseBody(w.Body, &nonce))
+	assert.Equal(t, uint16(0), nonce.Data.Prefix)
+
+	sig, err := secret.Sign(schnorrkel.NewSigningContext([]byte("substrate"), []byte(nonce.Data.Message)))
+	assert.Nil(t, err)
+	sigBytes := sig.Encode()
+	request := user.RequestLogin{
+		Provider:  "substrate",
+		Address:   address,
+		Signature: "0x" + hex.EncodeToString(sigBytes[:]),
+	}
+
+	w = httptest.NewRecorder()
+	req, _ = MakeTokenRequest("POST", "/apis/v1/user/auth/login", request)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	var resp responseLogin
+	assert.Nil(t, MarshalResponseBody(w.Body, &resp))
+	assert.NotEmpty(t, resp.Data.Token)
+
+	// the nonce can not be replayed
+	w = httptest.NewRecorder()
+	req, _ = MakeTokenRequest("POST", "/apis/v1/user/auth/login", request)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// invalid address
+	w = httptest.NewRecorder()
+	req, _ = MakeTokenRequest("POST", "/apis/v1/user/auth/substrate/nonce", user.RequestSubstrateNonce{
+		Address: address + "x",
+	})
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,185 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+
+	"infra-3.xyz/hyperdot-node/internal/apis/service/dashboard"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+	"infra-3.xyz/hyperdot-node/internal/jobs"
+	"infra-3.xyz/hyperdot-node/internal/utils"
+)
+
+func TestDashboardTrash(t *testing.T) {
+	cfg := initialSystemConfig()
+	db, err := initDB(cfg)
+	assert.Nil(t, err)
+
+	router := NewServiceEngine(dashboard.New(db, nil))
+	purger := jobs.NewTrashPurger(db, cfg.Trash.Retention())
+	owner, ownerToken := createRoleUser(t, db, datamodel.RoleUser)
+	_, otherToken := createRoleUser(t, db, datamodel.RoleUser)
+
+	board := datamodel.DashboardModel{UserID: owner.ID, Name: "trashed dashboard"}
+	assert.Nil(t, db.Create(&board).Error)
+	panel := datamodel.DashboardPanelModel{UserID: owner.ID, DashboardID: board.ID, Name: "kept panel"}
+	assert.Nil(t, db.Create(&panel).Error)
+
+	boardPath := fmt.Sprintf("/apis/v1/dashboard/%d", board.ID)
+	w := serve(router, "DELETE", boardPath, ownerToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = serve(router, "GET", boardPath, ownerToken, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// the trashed dashboard is listed to its owner only
+	list := dashboard.ResponseTrash{}
+	w = serve(router, "GET", "/apis/v1/dashboard/trash", ownerToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Nil(t, MarshalResponseBody(w.Body, &list))
+	assert.Equal(t, int64(1), list.Data.Total)
+	assert.Equal(t, board.ID, list.Data.Dashboards[0].ID)
+	w = serve(router, "GET", "/apis/v1/dashboard/trash", otherToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Nil(t, MarshalResponseBody(w.Body, &list))
+	assert.Equal(t, int64(0), list.Data.Total)
+
+	// the dashboard is restored with its panels
+	w = serve(router, "POST", boardPath+"/restore", otherToken, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = serve(router, "POST", boardPath+"/restore", ownerToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = serve(router, "POST", boardPath+"/restore", ownerToken, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = serve(router, "GET", boardPath, ownerToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Nil(t, db.Where("id = ?", panel.ID).First(&datamodel.DashboardPanelModel{}).Error)
+
+	// the dashboard is kept in trash during the retention period
+	w = serve(router, "DELETE", boardPath, ownerToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Nil(t, purger.Do())
+	assert.Nil(t, db.Unscoped().Where("id = ?", board.ID).First(&datamodel.DashboardModel{}).Error)
+
+	assert.Nil(t, db.Unscoped().Model(&datamodel.DashboardModel{}).Where("id = ?", board.ID).
+		Update("deleted_at", time.Now().Add(-cfg.Trash.Retention()-time.Minute)).Error)
+	assert.Nil(t, purger.Do())
+	err = db.Unscoped().Where("id = ?", board.ID).First(&datamodel.DashboardModel{}).Error
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+	err = db.Where("id = ?", panel.ID).First(&datamodel.DashboardPanelModel{}).Error
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+}
+
+func TestQueryTrashPurge(t *testing.T) {
+	cfg := initialSystemConfig()
+	db, err := initDB(cfg)
+	assert.Nil(t, err)
+
+	purger := jobs.NewTrashPurger(db, time.Hour)
+	owner, _ := createRoleUser(t, db, datamodel.RoleUser)
+
+	trashed := datamodel.QueryModel{UserID: owner.ID, Name: "trashed query"}
+	assert.Nil(t, db.Create(&trashed).Error)
+	chart := datamodel.ChartModel{QueryID: trashed.ID, UserID: owner.ID, Name: "trashed chart"}
+	assert.Nil(t, db.Create(&chart).Error)
+	kept := datamodel.QueryModel{UserID: owner.ID, Name: "kept query"}
+	assert.Nil(t, db.Create(&kept).Error)
+	panel := datamodel.DashboardPanelModel{UserID: owner.ID, Name: "kept panel", QueryID: trashed.ID, ChartID: chart.ID}
+	assert.Nil(t, db.Create(&panel).Error)
+	token := datamodel.ShareTokenModel{UserID: owner.ID, ChartID: chart.ID, TokenHash: utils.HashToken("trashed chart")}
+	assert.Nil(t, db.Create(&token).Error)
+
+	assert.Nil(t, db.Delete(&trashed).Error)
+	assert.Nil(t, db.Delete(&kept).Error)
+	assert.Nil(t, db.Unscoped().Model(&trashed).Update("deleted_at", time.Now().Add(-2*time.Hour)).Error)
+
+	assert.Nil(t, purger.Do())
+	err = db.Unscoped().Where("id = ?", trashed.ID).First(&datamodel.QueryModel{}).Error
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+	err = db.Where("id = ?", chart.ID).First(&datamodel.ChartModel{}).Error
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+	assert.Nil(t, db.Unscoped().Where("id = ?", kept.ID).First(&datamodel.QueryModel{}).Error)
+
+	// the panels are kept without the references, and the chart share tokens are deleted
+	assert.Nil(t, db.Where("id = ?", panel.ID).First(&panel).Error)
+	assert.Equal(t, uint(0), panel.QueryID)
+	assert.Equal(t, uint(0), panel.ChartID)
+	err = db.Where("id = ?", token.ID).First(&datamodel.ShareTokenModel{}).Error
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+}
+
+func TestDashboardUpdateCanNotTrash(t *testing.T) {
+	cfg := initialSystemConfig()
+	db, err := initDB(cfg)
+	assert.Nil(t, err)
+
+	router := NewServiceEngine(dashboard.New(db, nil))
+	owner, _ := createRoleUser(t, db, datamodel.RoleUser)
+	editor, editorToken := createRoleUser(t, db, datamodel.RoleUser)
+
+	board := datamodel.DashboardModel{UserID: owner.ID, Name: "shared dashboard"}
+	assert.Nil(t, db.Create(&board).Error)
+	assert.Nil(t, db.Create(&datamodel.ContentPermissionModel{
+		ContentType: datamodel.ContentTypeDashboard, ContentID: board.ID, UserID: editor.ID,
+		Permission: datamodel.PermissionEditor, GrantedBy: owner.ID,
+	}).Error)
+
+	// the deleted_at of update body is ignored, the editor can not trash the dashboard
+	update := board
+	update.Name = "renamed dashboard"
+	update.DeletedAt = gorm.DeletedAt{Time: time.Now().Add(-365 * 24 * time.Hour), Valid: true}
+	w := serve(router, "PUT", "/apis/v1/dashboard", editorToken, update)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var updated datamodel.DashboardModel
+	assert.Nil(t, db.Where("id = ?", board.ID).First(&updated).Error)
+	assert.Equal(t, "renamed dashboard", updated.Name)
+	assert.False(t, updated.DeletedAt.Valid)
+}
+
+func TestDashboardExportTrashedQuery(t *testing.T) {
+	cfg := initialSystemConfig()
+	db, err := initDB(cfg)
+	assert.Nil(t, err)
+
+	router := NewServiceEngine(dashboard.New(db, nil))
+	owner, ownerToken := createRoleUser(t, db, datamodel.RoleUser)
+
+	trashed := datamodel.QueryModel{UserID: owner.ID, Name: "trashed query", Query: "SELECT 1"}
+	assert.Nil(t, db.Create(&trashed).Error)
+	chart := datamodel.ChartModel{QueryID: trashed.ID, UserID: owner.ID, Name: "trashed chart"}
+	assert.Nil(t, db.Create(&chart).Error)
+	assert.Nil(t, db.Delete(&trashed).Error)
+
+	board := datamodel.DashboardModel{UserID: owner.ID, Name: "exported dashboard"}
+	assert.Nil(t, db.Create(&board).Error)
+	panel := datamodel.DashboardPanelModel{UserID: owner.ID, DashboardID: board.ID, Name: "panel", QueryID: trashed.ID, ChartID: chart.ID}
+	assert.Nil(t, db.Create(&panel).Error)
+
+	// the panel of trashed query is exported without the references
+	w := serve(router, "GET", fmt.Sprintf("/apis/v1/dashboard/%d/export", board.ID), ownerToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var bundle dashboard.DashboardBundle
+	assert.Nil(t, MarshalResponseBody(w.Body, &bundle))
+	assert.Equal(t, 0, len(bundle.Queries))
+	assert.Equal(t, 0, len(bundle.Charts))
+	assert.Equal(t, 1, len(bundle.Dashboard.Panels))
+	assert.Equal(t, uint(0), bundle.Dashboard.Panels[0].QueryID)
+	assert.Equal(t, uint(0), bundle.Dashboard.Panels[0].ChartID)
+
+	// the imported dashboard is never trashed by the bundle
+	bundle.Dashboard.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	w = serve(router, "POST", "/apis/v1/dashboard/import", ownerToken, bundle)
+	assert.Equal(t, http.StatusOK, w.Code)
+	imported := dashboard.Response{}
+	assert.Nil(t, MarshalResponseBody(w.Body, &imported))
+
+	var stored datamodel.DashboardModel
+	assert.Nil(t, db.Where("id = ?", imported.Data.ID).First(&stored).Error)
+	assert.False(t, stored.DeletedAt.Valid)
+}
@@ -0,0 +1,111 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"infra-3.xyz/hyperdot-node/internal/apis/service/user"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+	"infra-3.xyz/hyperdot-node/internal/mailer"
+	"infra-3.xyz/hyperdot-node/internal/utils"
+)
+
+func TestTwoFactorLogin(t *testing.T) {
+	cfg := initialSystemConfig()
+	db, err := initDB(cfg)
+	assert.Nil(t, err)
+
+	userRouter := NewServiceEngine(user.New(cfg, db, nil, nil, mailer.NewMemoryMailer()))
+	account, token := createRoleUser(t, db, datamodel.RoleUser)
+	login := user.RequestLogin{UserId: account.Username, Password: "test"}
+
+	// the secret is pending until a code of it is verified
+	w := serve(userRouter, "POST", "/apis/v1/user/2fa/enroll", token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	enroll := user.ResponseTwoFactorEnroll{}
+	assert.Nil(t, MarshalResponseBody(w.Body, &enroll))
+	assert.Contains(t, enroll.Data.URI, "otpauth://totp/")
+
+	w = serve(userRouter, "POST", "/apis/v1/user/2fa/verify", token, user.RequestTwoFactorCode{Code: "000000"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	step := utils.TOTPStep(time.Now())
+	code, err := utils.TOTPCode(enroll.Data.Secret, step)
+	assert.Nil(t, err)
+	w = serve(userRouter, "POST", "/apis/v1/user/2fa/verify", token, user.RequestTwoFactorCode{Code: code})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	recovery := user.ResponseRecoveryCodes{}
+	assert.Nil(t, MarshalResponseBody(w.Body, &recovery))
+	assert.Equal(t, 10, len(recovery.Data))
+
+	// the password login responds a pending token instead of the session tokens
+	w = serve(userRouter, "POST", "/apis/v1/user/auth/login", "", login)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	pending := struct {
+		Data user.ResponseLogin `json:"data"`
+	}{}
+	assert.Nil(t, MarshalResponseBody(w.Body, &pending))
+	assert.True(t, pending.Data.TwoFactorRequired)
+	assert.Empty(t, pending.Data.Token)
+
+	// the used code can not be replayed
+	w = serve(userRouter, "POST", "/apis/v1/user/auth/2fa/login", "", user.RequestTwoFactorLogin{
+		Token: pending.Data.TwoFactorToken,
+		Code:  code,
+	})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	next, err := utils.TOTPCode(enroll.Data.Secret, step+1)
+	assert.Nil(t, err)
+	w = serve(userRouter, "POST", "/apis/v1/user/auth/2fa/login", "", user.RequestTwoFactorLogin{
+		Token: pending.Data.TwoFactorToken,
+		Code:  next,
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	logined := struct {
+		Data user.ResponseLogin `json:"data"`
+	}{}
+	assert.Nil(t, MarshalResponseBody(w.Body, &logined))
+	assert.NotEmpty(t, logined.Data.Token)
+
+	// the recovery code logins once
+	w = serve(userRouter, "POST", "/apis/v1/user/auth/2fa/login", "", user.RequestTwoFactorLogin{
+		Token: pending.Data.TwoFactorToken,
+		Code:  recovery.Data[0],
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = serve(userRouter, "POST", "/apis/v1/user/auth/2fa/login", "", user.RequestTwoFactorLogin{
+		Token: pending.Data.TwoFactorToken,
+		Code:  recovery.Data[0],
+	})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// disabling requires the password and the second factor
+	w = serve(userRouter, "POST", "/apis/v1/user/2fa/disable", token, user.RequestDisableTwoFactor{
+		Password: "wrong",
+		Code:     recovery.Data[1],
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = serve(userRouter, "POST", "/apis/v1/user/2fa/disable", token, user.RequestDisableTwoFactor{
+		Password: "test",
+		Code:     recovery.Data[1],
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = serve(userRouter, "POST", "/apis/v1/user/auth/login", "", login)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	direct := struct {
+		Data user.ResponseLogin `json:"data"`
+	}{}
+	assert.Nil(t, MarshalResponseBody(w.Body, &direct))
+	assert.NotEmpty(t, direct.Data.Token)
+	assert.False(t, direct.Data.TwoFactorRequired)
+}
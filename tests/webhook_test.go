@@ -0,0 +1,161 @@
+package tests
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"infra-3.xyz/hyperdot-node/internal/apis/service/dashboard"
+	"infra-3.xyz/hyperdot-node/internal/apis/service/webhook"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+	"infra-3.xyz/hyperdot-node/internal/jobs"
+	"infra-3.xyz/hyperdot-node/internal/utils"
+)
+
+func TestWebhooks(t *testing.T) {
+	cfg := initialSystemConfig()
+	cfg.Webhook.AllowLoopback = true
+	db, err := initDB(cfg)
+	assert.Nil(t, err)
+
+	var (
+		secret   string
+		verified atomic.Int32
+		status   atomic.Int32
+	)
+	status.Store(http.StatusOK)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp, _ := strconv.ParseInt(r.Header.Get("X-Hyperdot-Timestamp"), 10, 64)
+		if utils.VerifyWebhookSignature(secret, timestamp, body, r.Header.Get("X-Hyperdot-Signature")) {
+			verified.Add(1)
+		}
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer server.Close()
+
+	webhookRouter := NewServiceEngine(webhook.New(cfg, db))
+	dashboardRouter := NewServiceEngine(dashboard.New(db, nil))
+	deliverer := jobs.NewWebhookDeliverer(db, jobs.NewWebhookClient(true), time.Hour)
+	owner, ownerToken := createRoleUser(t, db, datamodel.RoleUser)
+	_, actorToken := createRoleUser(t, db, datamodel.RoleUser)
+
+	w := serve(webhookRouter, "POST", "/apis/v1/webhook", ownerToken, webhook.RequestCreateWebhook{
+		Name: "local", URL: "ftp://localhost", Events: []string{datamodel.WebhookFavoriteAdded},
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = serve(webhookRouter, "POST", "/apis/v1/webhook", ownerToken, webhook.RequestCreateWebhook{
+		Name: "local", URL: server.URL, Events: []string{"unknown"},
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = serve(webhookRouter, "POST", "/apis/v1/webhook", ownerToken, webhook.RequestCreateWebhook{
+		Name: "metadata", URL: "http://169.254.169.254/latest/meta-data", Events: []string{datamodel.WebhookFavoriteAdded},
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = serve(webhookRouter, "POST", "/apis/v1/webhook", ownerToken, webhook.RequestCreateWebhook{
+		Name: "local", URL: server.URL, Events: []string{datamodel.WebhookFavoriteAdded},
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+	created := webhook.ResponseWebhook{}
+	assert.Nil(t, MarshalResponseBody(w.Body, &created))
+	assert.NotEmpty(t, created.Data.Secret)
+	assert.True(t, created.Data.Enabled)
+	secret = created.Data.Secret
+
+	// the favorite of others is posted with signature
+	board := datamodel.DashboardModel{UserID: owner.ID, Name: "public dashboard"}
+	assert.Nil(t, db.Create(&board).Error)
+	w = serve(dashboardRouter, "PUT", "/apis/v1/dashboard/favorite", actorToken,
+		datamodel.UserDashboardFavorites{DashboardID: board.ID, DashboardUserID: owner.ID})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	assert.Nil(t, deliverer.Do())
+	assert.Equal(t, int32(1), verified.Load())
+
+	deliveriesPath := fmt.Sprintf("/apis/v1/webhook/%d/deliveries", created.Data.ID)
+	w = serve(webhookRouter, "GET", deliveriesPath, ownerToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	deliveries := webhook.ResponseListDeliveries{}
+	assert.Nil(t, MarshalResponseBody(w.Body, &deliveries))
+	assert.Equal(t, int64(1), deliveries.Data.Total)
+	assert.Equal(t, datamodel.WebhookDeliverySuccess, deliveries.Data.Deliveries[0].Status)
+	assert.Equal(t, datamodel.WebhookFavoriteAdded, deliveries.Data.Deliveries[0].Event)
+
+	// the failed delivery is retried with backoff until the attempts are used up
+	status.Store(http.StatusInternalServerError)
+	w = serve(webhookRouter, "POST", fmt.Sprintf("/apis/v1/webhook/%d/ping", created.Data.ID), ownerToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Nil(t, deliverer.Do())
+
+	var ping datamodel.WebhookDeliveryModel
+	assert.Nil(t, db.Where("webhook_id = ? AND event = ?", created.Data.ID, datamodel.WebhookPing).First(&ping).Error)
+	assert.Equal(t, datamodel.WebhookDeliveryPending, ping.Status)
+	assert.Equal(t, 1, ping.Attempts)
+	assert.Equal(t, http.StatusInternalServerError, ping.ResponseCode)
+	assert.True(t, ping.NextAttemptAt.After(time.Now().Add(jobs.WebhookRetryDelay(1)-time.Second)))
+
+	for i := 1; i < 6; i++ {
+		assert.Nil(t, db.Model(&ping).Update("next_attempt_at", time.Now().Add(-time.Second)).Error)
+		assert.Nil(t, deliverer.Do())
+	}
+	assert.Nil(t, db.Where("id = ?", ping.ID).First(&ping).Error)
+	assert.Equal(t, datamodel.WebhookDeliveryFailed, ping.Status)
+	assert.Equal(t, 6, ping.Attempts)
+	assert.Equal(t, int32(7), verified.Load())
+
+	// the finished deliveries are pruned after the retention
+	assert.Nil(t, db.Model(&datamodel.WebhookDeliveryModel{}).Where("webhook_id = ?", created.Data.ID).
+		Update("created_at", time.Now().Add(-2*time.Hour)).Error)
+	pruned, err := deliverer.Prune()
+	assert.Nil(t, err)
+	assert.True(t, pruned >= 2)
+
+	// the webhooks of others can not be accessed
+	w = serve(webhookRouter, "GET", deliveriesPath, actorToken, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = serve(webhookRouter, "DELETE", fmt.Sprintf("/apis/v1/webhook/%d", created.Data.ID), ownerToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var count int64
+	assert.Nil(t, db.Model(&datamodel.WebhookDeliveryModel{}).Where("webhook_id = ?", created.Data.ID).Count(&count).Error)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestWebhookClient(t *testing.T) {
+	var posted atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posted.Add(1)
+		http.Redirect(w, r, "http://169.254.169.254/latest/meta-data", http.StatusFound)
+	}))
+	defer server.Close()
+
+	// the loopback addresses are rejected by default
+	_, err := jobs.NewWebhookClient(false).Post(server.URL, "application/json", nil)
+	assert.NotNil(t, err)
+	assert.Equal(t, int32(0), posted.Load())
+
+	// the redirects are not followed
+	resp, err := jobs.NewWebhookClient(true).Post(server.URL, "application/json", nil)
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, int32(1), posted.Load())
+
+	// the webhook urls of non-public ip are rejected unless loopback is allowed for tests
+	cfg := initialSystemConfig()
+	db, err := initDB(cfg)
+	assert.Nil(t, err)
+	_, token := createRoleUser(t, db, datamodel.RoleUser)
+	w := serve(NewServiceEngine(webhook.New(cfg, db)), "POST", "/apis/v1/webhook", token, webhook.RequestCreateWebhook{
+		Name: "local", URL: server.URL, Events: []string{datamodel.WebhookFavoriteAdded},
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,131 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"infra-3.xyz/hyperdot-node/internal/apis/service/dashboard"
+	"infra-3.xyz/hyperdot-node/internal/apis/service/workspace"
+	"infra-3.xyz/hyperdot-node/internal/datamodel"
+)
+
+func TestWorkspace(t *testing.T) {
+	cfg := initialSystemConfig()
+	db, err := initDB(cfg)
+	assert.Nil(t, err)
+
+	wsRouter := NewServiceEngine(workspace.New(db))
+	dashboardRouter := NewServiceEngine(dashboard.New(db, nil))
+	owner, ownerToken := createRoleUser(t, db, datamodel.RoleUser)
+	editor, editorToken := createRoleUser(t, db, datamodel.RoleUser)
+	viewer, viewerToken := createRoleUser(t, db, datamodel.RoleUser)
+	_, outsiderToken := createRoleUser(t, db, datamodel.RoleUser)
+
+	w := serve(wsRouter, "POST", "/apis/v1/workspace", ownerToken, workspace.RequestWorkspace{Name: "team"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	created := workspace.ResponseWorkspace{}
+	assert.Nil(t, MarshalResponseBody(w.Body, &created))
+	assert.Equal(t, datamodel.WorkspaceRoleOwner, created.Data.Role)
+	wsPath := fmt.Sprintf("/apis/v1/workspace/%d", created.Data.ID)
+
+	// only the owners manage members
+	w = serve(wsRouter, "POST", wsPath+"/members", ownerToken, workspace.RequestAddMember{
+		Username: editor.Username,
+		Role:     datamodel.WorkspaceRoleEditor,
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = serve(wsRouter, "POST", wsPath+"/members", editorToken, workspace.RequestAddMember{
+		UserID: viewer.ID,
+		Role:   datamodel.WorkspaceRoleViewer,
+	})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = serve(wsRouter, "POST", wsPath+"/members", ownerToken, workspace.RequestAddMember{
+		UserID: viewer.ID,
+		Role:   datamodel.WorkspaceRoleViewer,
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = serve(wsRouter, "GET", wsPath+"/members", outsiderToken, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = serve(wsRouter, "GET", wsPath+"/members", viewerToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	members := workspace.ResponseListMembers{}
+	assert.Nil(t, MarshalResponseBody(w.Body, &members))
+	assert.Equal(t, 3, len(members.Data))
+
+	// the editors create contents of workspace, the viewers can not
+	newDashboard := datamodel.DashboardModel{Name: "team dashboard", IsPrivacy: true, WorkspaceID: created.Data.ID}
+	w = serve(dashboardRouter, "POST", "/apis/v1/dashboard", viewerToken, newDashboard)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = serve(dashboardRouter, "POST", "/apis/v1/dashboard", editorToken, newDashboard)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	dashboardResponse := dashboard.Response{}
+	assert.Nil(t, MarshalResponseBody(w.Body, &dashboardResponse))
+	teamDashboard := dashboardResponse.Data
+
+	// the private contents are listed by members
+	listPath := fmt.Sprintf("/apis/v1/dashboard?workspace_id=%d", created.Data.ID)
+	w = serve(dashboardRouter, "GET", listPath, outsiderToken, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = serve(dashboardRouter, "GET", listPath, viewerToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	list := struct {
+		Data struct {
+			Dashboards []map[string]interface{} `json:"dashboards"`
+			Total      uint                     `json:"total"`
+		} `json:"data"`
+	}{}
+	assert.Nil(t, MarshalResponseBody(w.Body, &list))
+	assert.Equal(t, uint(1), list.Data.Total)
+
+	// the editors and owners update the contents created by other members
+	teamDashboard.Description = "updated"
+	w = serve(dashboardRouter, "PUT", "/apis/v1/dashboard", viewerToken, teamDashboard)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = serve(dashboardRouter, "PUT", "/apis/v1/dashboard", ownerToken, teamDashboard)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Nil(t, db.First(&teamDashboard, teamDashboard.ID).Error)
+	assert.Equal(t, editor.ID, teamDashboard.UserID)
+	assert.Equal(t, "updated", teamDashboard.Description)
+
+	w = serve(wsRouter, "GET", wsPath+"/statistics", viewerToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	statistics := workspace.ResponseStatistics{}
+	assert.Nil(t, MarshalResponseBody(w.Body, &statistics))
+	assert.Equal(t, uint(3), statistics.Data.Members)
+	assert.Equal(t, uint(1), statistics.Data.Dashboards)
+
+	// the last owner can not leave
+	w = serve(wsRouter, "DELETE", fmt.Sprintf("%s/members/%d", wsPath, owner.ID), ownerToken, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// the members leave by themselves
+	w = serve(wsRouter, "DELETE", fmt.Sprintf("%s/members/%d", wsPath, viewer.ID), viewerToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	dashboardPath := fmt.Sprintf("/apis/v1/dashboard/%d", teamDashboard.ID)
+	w = serve(dashboardRouter, "DELETE", dashboardPath, viewerToken, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = serve(dashboardRouter, "DELETE", dashboardPath, ownerToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var stored datamodel.WorkspaceStatistics
+	assert.Nil(t, db.Where("workspace_id = ?", created.Data.ID).First(&stored).Error)
+	assert.Equal(t, uint(2), stored.Members)
+	assert.Equal(t, uint(0), stored.Dashboards)
+
+	w = serve(wsRouter, "DELETE", wsPath, editorToken, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = serve(wsRouter, "DELETE", wsPath, ownerToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = serve(wsRouter, "GET", wsPath, ownerToken, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}